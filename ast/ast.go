@@ -7,6 +7,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/goccy/go-yaml/token"
 )
@@ -185,6 +186,8 @@ type Node interface {
 	SetPath(string)
 	// MarshalYAML
 	MarshalYAML() ([]byte, error)
+	// MarshalJSON encodes node to the JSON representation of the AST having the type, the position, the children and the comments.
+	MarshalJSON() ([]byte, error)
 	// already read length
 	readLen() int
 	// append read length
@@ -294,6 +297,10 @@ func checkLineBreak(t *token.Token) bool {
 				prev = prev.Prev
 			}
 		}
+		if isKeepBlockScalarContent(prev) {
+			// the trailing empty lines are written by the block scalar as its content.
+			return false
+		}
 		lineDiff := t.Position.Line - prev.Position.Line - 1
 		if lineDiff > 0 {
 			if prev.Type == token.StringType {
@@ -322,6 +329,15 @@ func checkLineBreak(t *token.Token) bool {
 	return false
 }
 
+// isKeepBlockScalarContent returns whether tk is the content of the block scalar with the keep chomping indicator `+`.
+func isKeepBlockScalarContent(tk *token.Token) bool {
+	header := tk.Prev
+	if header == nil || (header.Type != token.LiteralType && header.Type != token.FoldedType) {
+		return false
+	}
+	return strings.Contains(header.Value, "+")
+}
+
 // Null create node for null value
 func Null(tk *token.Token) *NullNode {
 	return &NullNode{
@@ -548,6 +564,25 @@ type DocumentNode struct {
 	Start *token.Token // position of DocumentHeader ( `---` )
 	End   *token.Token // position of DocumentEnd ( `...` )
 	Body  Node
+	// Directives is the directives written before the document ( e.g. %YAML and %TAG ).
+	// They are also parsed as the documents having the DirectiveNode as the body, so they are not written by String.
+	Directives []*DirectiveNode
+	// HeadComment is the comment written before the directives or the document header ( e.g. the license header ).
+	HeadComment *CommentGroupNode
+	// FootComment is the comment written after the document end marker.
+	FootComment *CommentGroupNode
+}
+
+// Version returns the YAML version declared by the %YAML directive of the document ( e.g. "1.1" ).
+// It returns an empty string if the version is not declared.
+func (d *DocumentNode) Version() string {
+	for _, directive := range d.Directives {
+		if directive.Name == nil || directive.Name.String() != "YAML" || len(directive.Values) == 0 {
+			continue
+		}
+		return directive.Values[0].String()
+	}
+	return ""
 }
 
 // Read implements (io.Reader).Read
@@ -573,13 +608,21 @@ func (d *DocumentNode) AddColumn(col int) {
 // String document to text
 func (d *DocumentNode) String() string {
 	doc := []string{}
+	if d.HeadComment != nil {
+		doc = append(doc, d.HeadComment.String())
+	}
 	if d.Start != nil {
 		doc = append(doc, d.Start.Value)
 	}
-	doc = append(doc, d.Body.String())
+	if d.Body != nil {
+		doc = append(doc, d.Body.String())
+	}
 	if d.End != nil {
 		doc = append(doc, d.End.Value)
 	}
+	if d.FootComment != nil {
+		doc = append(doc, d.FootComment.String())
+	}
 	return strings.Join(doc, "\n")
 }
 
@@ -814,7 +857,7 @@ func (n *StringNode) String() string {
 	if strings.Contains(n.Value, lbc) {
 		// This block assumes that the line breaks in this inside scalar content and the Outside scalar content are the same.
 		// It works mostly, but inconsistencies occur if line break characters are mixed.
-		header := token.LiteralBlockHeader(n.Value)
+		header := literalBlockHeader(n.Value)
 		space := strings.Repeat(" ", n.Token.Position.Column-1)
 		values := []string{}
 		for _, v := range strings.Split(n.Value, lbc) {
@@ -831,6 +874,22 @@ func (n *StringNode) String() string {
 	return n.Value
 }
 
+// literalBlockHeader returns the header of the literal block scalar of value indented by 2 spaces.
+// The indentation indicator is added if the first non-empty line starts with the space,
+// because the indentation can't be detected from the line.
+func literalBlockHeader(value string) string {
+	header := token.LiteralBlockHeader(value)
+	for _, line := range strings.Split(value, token.DetectLineBreakCharacter(value)) {
+		if strings.HasPrefix(line, " ") {
+			return header[:1] + "2" + header[1:]
+		}
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+	return header
+}
+
 func (n *StringNode) stringWithoutComment() string {
 	switch n.Token.Type {
 	case token.SingleQuoteType:
@@ -845,7 +904,7 @@ func (n *StringNode) stringWithoutComment() string {
 	if strings.Contains(n.Value, lbc) {
 		// This block assumes that the line breaks in this inside scalar content and the Outside scalar content are the same.
 		// It works mostly, but inconsistencies occur if line break characters are mixed.
-		header := token.LiteralBlockHeader(n.Value)
+		header := literalBlockHeader(n.Value)
 		space := strings.Repeat(" ", n.Token.Position.Column-1)
 		values := []string{}
 		for _, v := range strings.Split(n.Value, lbc) {
@@ -889,7 +948,37 @@ func (n *LiteralNode) AddColumn(col int) {
 	n.Start.AddColumn(col)
 	if n.Value != nil {
 		n.Value.AddColumn(col)
+		// the content of the block scalar is rendered from the origin as it is, so reindent it.
+		if tk := n.Value.GetToken(); tk != nil {
+			tk.Origin = reindent(tk.Origin, col)
+		}
+	}
+}
+
+// reindent adds col spaces to the indentation of each non-empty line of text.
+// The lines of the white spaces are also reindented, because they may be the content of the block scalar.
+// If col is negative, removes up to -col leading spaces instead.
+func reindent(text string, col int) string {
+	if col == 0 {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.TrimRight(line, "\r\n") == "" {
+			continue
+		}
+		if col > 0 {
+			lines[i] = strings.Repeat(" ", col) + line
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > -col {
+			lines[i] = line[-col:]
+		} else {
+			lines[i] = trimmed
+		}
 	}
+	return strings.Join(lines, "")
 }
 
 // GetValue returns string value
@@ -901,6 +990,10 @@ func (n *LiteralNode) GetValue() interface{} {
 func (n *LiteralNode) String() string {
 	origin := n.Value.GetToken().Origin
 	lit := strings.TrimRight(strings.TrimRight(origin, " "), "\n")
+	if strings.Contains(n.Start.Value, "+") {
+		// the trailing empty lines are the content of the block scalar with the keep chomping indicator.
+		lit = strings.TrimSuffix(strings.TrimRight(origin, " "), "\n")
+	}
 	if n.Comment != nil {
 		return fmt.Sprintf("%s %s\n%s", n.Start.Value, n.Comment.String(), lit)
 	}
@@ -1206,6 +1299,59 @@ func (n *MappingNode) SetIsFlowStyle(isFlow bool) {
 	}
 }
 
+// DuplicateKeys returns the values whose keys are already defined by the preceding values in the mapping, in the order of the source.
+// The keys are the same if they have the same text and are decoded to the same type, like `a` and `"a"`, but not `1` and `"1"`.
+// The parser keeps such values only if parser.AllowDuplicateMapKey is specified, so linters and migration tools can report or fix them.
+func (n *MappingNode) DuplicateKeys() []*MappingValueNode {
+	type keyID struct {
+		text string
+		typ  NodeType
+	}
+	defined := map[keyID]struct{}{}
+	var duplicates []*MappingValueNode
+	for _, value := range n.Values {
+		if value.Key == nil {
+			continue
+		}
+		text, typ := mapKeyIdentity(value.Key)
+		id := keyID{text: text, typ: typ}
+		if _, exists := defined[id]; exists {
+			duplicates = append(duplicates, value)
+			continue
+		}
+		defined[id] = struct{}{}
+	}
+	return duplicates
+}
+
+// mapKeyIdentity returns the text and the type of key compared to find the duplicate keys.
+func mapKeyIdentity(key Node) (string, NodeType) {
+	switch k := key.(type) {
+	case *MappingKeyNode:
+		if k.Value != nil {
+			return mapKeyIdentity(k.Value)
+		}
+	case *TagNode:
+		if k.Value != nil {
+			return mapKeyIdentity(k.Value)
+		}
+	case *AnchorNode:
+		if k.Value != nil {
+			return mapKeyIdentity(k.Value)
+		}
+	case *AliasNode:
+		return "*" + k.Value.GetToken().Value, AliasType
+	case *LiteralNode:
+		return k.Value.Value, StringType
+	case *MappingNode, *SequenceNode:
+		return k.String(), k.Type()
+	}
+	if tk := key.GetToken(); tk != nil {
+		return tk.Value, key.Type()
+	}
+	return key.String(), key.Type()
+}
+
 // Read implements (io.Reader).Read
 func (n *MappingNode) Read(p []byte) (int, error) {
 	return readNode(p, n)
@@ -1231,6 +1377,11 @@ func (n *MappingNode) AddColumn(col int) {
 func (n *MappingNode) flowStyleString(commentMode bool) string {
 	values := []string{}
 	for _, value := range n.Values {
+		if _, ok := value.Key.(*MappingKeyNode); ok {
+			// the explicit key and the value are written in the same line in the flow style.
+			values = append(values, fmt.Sprintf("%s: %s", value.Key.String(), value.Value.String()))
+			continue
+		}
 		values = append(values, strings.TrimLeft(value.String(), " "))
 	}
 	mapText := fmt.Sprintf("{%s}", strings.Join(values, ", "))
@@ -1421,11 +1572,18 @@ func (n *MappingValueNode) toString() string {
 	if checkLineBreak(n.Key.GetToken()) {
 		space = fmt.Sprintf("%s%s", "\n", space)
 	}
+	if _, ok := n.Key.(*MappingKeyNode); ok {
+		return n.explicitKeyString(space)
+	}
 	keyIndentLevel := n.Key.GetToken().Position.IndentLevel
 	valueIndentLevel := n.Value.GetToken().Position.IndentLevel
 	keyComment := n.Key.GetComment()
 	if _, ok := n.Value.(ScalarNode); ok {
 		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
+	} else if m, ok := n.Value.(*MappingNode); ok && m.IsFlowStyle && !checkLineBreak(m.Start) {
+		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
+	} else if s, ok := n.Value.(*SequenceNode); ok && s.IsFlowStyle && !checkLineBreak(s.Start) {
+		return fmt.Sprintf("%s%s: %s", space, n.Key.String(), n.Value.String())
 	} else if keyIndentLevel < valueIndentLevel {
 		if keyComment != nil {
 			return fmt.Sprintf(
@@ -1466,6 +1624,31 @@ func (n *MappingValueNode) toString() string {
 	return fmt.Sprintf("%s%s:\n%s", space, n.Key.String(), n.Value.String())
 }
 
+// explicitKeyString writes the key with the explicit key indicator '?' and the value on the next line.
+// The null value without the text, like the value of the entry of !!set, is omitted.
+func (n *MappingValueNode) explicitKeyString(space string) string {
+	indent := strings.TrimPrefix(space, "\n")
+	value := n.Value.String()
+	switch v := n.Value.(type) {
+	case *NullNode:
+		if v.Token.Origin == "" && v.Comment == nil {
+			return fmt.Sprintf("%s%s", space, n.Key.String())
+		}
+		return fmt.Sprintf("%s%s\n%s: %s", space, n.Key.String(), indent, value)
+	case ScalarNode, *AnchorNode, *AliasNode:
+		return fmt.Sprintf("%s%s\n%s: %s", space, n.Key.String(), indent, value)
+	case *MappingNode:
+		if v.IsFlowStyle || len(v.Values) == 0 {
+			return fmt.Sprintf("%s%s\n%s: %s", space, n.Key.String(), indent, value)
+		}
+	case *SequenceNode:
+		if v.IsFlowStyle || len(v.Values) == 0 {
+			return fmt.Sprintf("%s%s\n%s: %s", space, n.Key.String(), indent, value)
+		}
+	}
+	return fmt.Sprintf("%s%s\n%s:\n%s", space, n.Key.String(), indent, value)
+}
+
 // MapRange implements MapNode protocol
 func (n *MappingValueNode) MapRange() *MapNodeIter {
 	return &MapNodeIter{
@@ -1511,12 +1694,18 @@ func (m *ArrayNodeIter) Len() int {
 // SequenceNode type of sequence node
 type SequenceNode struct {
 	*BaseNode
-	Start             *token.Token
-	End               *token.Token
-	IsFlowStyle       bool
+	Start       *token.Token
+	End         *token.Token
+	IsFlowStyle bool
+	// IsExpandedStyle reports whether block mappings and sequences in the entries start on the line after the dash.
+	// In that case, the columns of the entries are used as they are.
+	IsExpandedStyle   bool
 	Values            []Node
 	ValueHeadComments []*CommentGroupNode
-	FootComment       *CommentGroupNode
+	// ValueBlankLines reports whether each value is separated from the previous value by the blank line.
+	// The blank line is written before the head comment of the value.
+	ValueBlankLines []bool
+	FootComment     *CommentGroupNode
 }
 
 // Replace replace value node.
@@ -1537,6 +1726,11 @@ func (n *SequenceNode) Replace(idx int, value Node) error {
 func (n *SequenceNode) Merge(target *SequenceNode) {
 	column := n.Start.Position.Column - target.Start.Position.Column
 	target.AddColumn(column)
+	if len(n.ValueBlankLines) == len(n.Values) && len(target.ValueBlankLines) == len(target.Values) {
+		n.ValueBlankLines = append(n.ValueBlankLines, target.ValueBlankLines...)
+	} else if len(n.ValueBlankLines) == len(n.Values) {
+		n.ValueBlankLines = append(n.ValueBlankLines, make([]bool, len(target.Values))...)
+	}
 	n.Values = append(n.Values, target.Values...)
 	if len(target.ValueHeadComments) == 0 {
 		n.ValueHeadComments = append(n.ValueHeadComments, make([]*CommentGroupNode, len(target.Values))...)
@@ -1587,7 +1781,11 @@ func (n *SequenceNode) flowStyleString() string {
 	for _, value := range n.Values {
 		values = append(values, value.String())
 	}
-	return fmt.Sprintf("[%s]", strings.Join(values, ", "))
+	seqText := fmt.Sprintf("[%s]", strings.Join(values, ", "))
+	if n.Comment != nil {
+		return addCommentString(seqText, n.Comment)
+	}
+	return seqText
 }
 
 func (n *SequenceNode) blockStyleString() string {
@@ -1604,9 +1802,30 @@ func (n *SequenceNode) blockStyleString() string {
 			valueStr = valueStr[1:]
 			newLinePrefix = "\n"
 		}
+		blankLine := idx > 0 && len(n.ValueBlankLines) == len(n.Values) && n.ValueBlankLines[idx]
+		if blankLine {
+			newLinePrefix = "\n"
+		}
+		if len(n.ValueHeadComments) == len(n.Values) && n.ValueHeadComments[idx] != nil {
+			comment := n.ValueHeadComments[idx].StringWithSpace(n.Start.Position.Column - 1)
+			if blankLine {
+				// the blank line before the parsed comment is also written by the comment.
+				comment = strings.TrimPrefix(comment, "\n")
+			}
+			values = append(values, fmt.Sprintf("%s%s", newLinePrefix, comment))
+			newLinePrefix = ""
+		}
+		if n.IsExpandedStyle && isBlockCollection(value) {
+			values = append(values, fmt.Sprintf("%s%s-\n%s", newLinePrefix, space, valueStr))
+			continue
+		}
 		splittedValues := strings.Split(valueStr, "\n")
 		trimmedFirstValue := strings.TrimLeft(splittedValues[0], " ")
 		diffLength := len(splittedValues[0]) - len(trimmedFirstValue)
+		if tag, ok := value.(*TagNode); ok && isBlockCollection(tag.Value) {
+			// the block collection is written from the next line of the tag.
+			diffLength = len(splittedValues[1]) - len(strings.TrimLeft(splittedValues[1], " "))
+		}
 		if len(splittedValues) > 1 && value.Type() == StringType || value.Type() == LiteralType {
 			// If multi-line string, the space characters for indent have already been added, so delete them.
 			prefix := space + "  "
@@ -1625,10 +1844,6 @@ func (n *SequenceNode) blockStyleString() string {
 			newValues = append(newValues, fmt.Sprintf("%s  %s", space, trimmed))
 		}
 		newValue := strings.Join(newValues, "\n")
-		if len(n.ValueHeadComments) == len(n.Values) && n.ValueHeadComments[idx] != nil {
-			values = append(values, fmt.Sprintf("%s%s", newLinePrefix, n.ValueHeadComments[idx].StringWithSpace(n.Start.Position.Column-1)))
-			newLinePrefix = ""
-		}
 		values = append(values, fmt.Sprintf("%s%s- %s", newLinePrefix, space, newValue))
 	}
 	if n.FootComment != nil {
@@ -1637,6 +1852,16 @@ func (n *SequenceNode) blockStyleString() string {
 	return strings.Join(values, "\n")
 }
 
+func isBlockCollection(node Node) bool {
+	switch n := node.(type) {
+	case *MappingNode:
+		return !n.IsFlowStyle && len(n.Values) != 0
+	case *SequenceNode:
+		return !n.IsFlowStyle && len(n.Values) != 0
+	}
+	return false
+}
+
 // String sequence to text
 func (n *SequenceNode) String() string {
 	if n.IsFlowStyle || len(n.Values) == 0 {
@@ -1916,6 +2141,10 @@ func (n *TagNode) AddColumn(col int) {
 
 // String tag to text
 func (n *TagNode) String() string {
+	if isBlockCollection(n.Value) {
+		// the block collection starts on the next line of the tag.
+		return fmt.Sprintf("%s\n%s", n.Start.Value, strings.TrimLeft(n.Value.String(), "\n"))
+	}
 	return fmt.Sprintf("%s %s", n.Start.Value, n.Value.String())
 }
 
@@ -2075,7 +2304,13 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Value)
 	case *DocumentNode:
 		walkComment(v, n.BaseNode)
+		if n.HeadComment != nil {
+			Walk(v, n.HeadComment)
+		}
 		Walk(v, n.Body)
+		if n.FootComment != nil {
+			Walk(v, n.FootComment)
+		}
 	case *MappingNode:
 		walkComment(v, n.BaseNode)
 		for _, value := range n.Values {
@@ -2221,6 +2456,92 @@ func FilterFile(typ NodeType, file *File) []Node {
 	return results
 }
 
+// Stats is the number of nodes in the documents.
+// Comments are not counted.
+type Stats struct {
+	// Documents is the number of documents.
+	Documents int
+	// Nodes is the number of all nodes except documents.
+	Nodes int
+	// Scalars is the number of scalar nodes including mapping keys.
+	Scalars int
+	// Mappings is the number of mapping nodes.
+	Mappings int
+	// Sequences is the number of sequence nodes.
+	Sequences int
+	// Anchors is the number of anchor nodes.
+	Anchors int
+	// Aliases is the number of alias nodes.
+	Aliases int
+}
+
+// Add adds the counts of s2 to s.
+func (s *Stats) Add(s2 Stats) {
+	s.Documents += s2.Documents
+	s.Nodes += s2.Nodes
+	s.Scalars += s2.Scalars
+	s.Mappings += s2.Mappings
+	s.Sequences += s2.Sequences
+	s.Anchors += s2.Anchors
+	s.Aliases += s2.Aliases
+}
+
+// CollectStats returns the number of nodes in node and its child nodes.
+func CollectStats(node Node) Stats {
+	walker := &statsWalker{}
+	if node != nil {
+		Walk(walker, node)
+	}
+	return walker.stats
+}
+
+// Stats returns the number of nodes in all documents.
+func (f *File) Stats() Stats {
+	var stats Stats
+	for _, doc := range f.Docs {
+		stats.Add(CollectStats(doc))
+	}
+	return stats
+}
+
+type statsWalker struct {
+	stats Stats
+}
+
+func (v *statsWalker) Visit(n Node) Visitor {
+	switch n := n.(type) {
+	case nil, *CommentNode, *CommentGroupNode:
+		return nil
+	case *DocumentNode:
+		v.stats.Documents++
+		return v
+	case *MappingNode:
+		v.stats.Mappings++
+	case *SequenceNode:
+		v.stats.Sequences++
+	case *AnchorNode:
+		v.stats.Anchors++
+		v.stats.Nodes++
+		// the anchor name is not counted as a scalar
+		Walk(v, n.Value)
+		return nil
+	case *AliasNode:
+		v.stats.Aliases++
+		v.stats.Nodes++
+		return nil
+	case *DirectiveNode:
+		v.stats.Nodes++
+		return nil
+	case ScalarNode:
+		v.stats.Scalars++
+		v.stats.Nodes++
+		// the body of the block scalar is a part of the node
+		return nil
+	}
+	v.stats.Nodes++
+	return v
+}
+
 type ErrInvalidMergeType struct {
 	dst Node
 	src Node
@@ -2259,3 +2580,107 @@ func Merge(dst Node, src Node) error {
 	}
 	return err
 }
+
+// NodeRange returns the start and end positions of the text of node including the child nodes,
+// from the first token to the next of the last character of the last token.
+// Offset is the 1-based character offset like the positions of the tokens,
+// and ByteOffset is the 0-based byte offset, so src[start.ByteOffset:end.ByteOffset] is the text of node
+// if it's parsed from src. The DisplayColumn of the end position isn't set.
+// The zero values are returned if node doesn't have the tokens with the positions.
+func NodeRange(node Node) (token.Position, token.Position) {
+	tokens := rangeTokens(node)
+	if len(tokens) == 0 {
+		return token.Position{}, token.Position{}
+	}
+	first, last := tokens[0], tokens[0]
+	for _, tk := range tokens[1:] {
+		if comparePosition(tk.Position, first.Position) < 0 {
+			first = tk
+		}
+		if comparePosition(tk.Position, last.Position) > 0 {
+			last = tk
+		}
+	}
+	return *first.Position, tokenEndPosition(last)
+}
+
+// rangeTokens returns the tokens of node and child nodes except comments.
+func rangeTokens(node Node) []*token.Token {
+	var tokens []*token.Token
+	add := func(tks ...*token.Token) {
+		for _, tk := range tks {
+			if tk != nil && tk.Position != nil {
+				tokens = append(tokens, tk)
+			}
+		}
+	}
+	switch n := node.(type) {
+	case *CommentNode:
+		add(n.Token)
+		return tokens
+	case *CommentGroupNode:
+		for _, comment := range n.Comments {
+			add(comment.Token)
+		}
+		return tokens
+	}
+	Walk(rangeTokenCollector(func(node Node) {
+		switch n := node.(type) {
+		case *DocumentNode:
+			add(n.Start, n.End)
+		case *MappingNode:
+			add(n.Start, n.End)
+		case *SequenceNode:
+			add(n.Start, n.End)
+		default:
+			add(node.GetToken())
+		}
+	}), node)
+	return tokens
+}
+
+type rangeTokenCollector func(Node)
+
+func (f rangeTokenCollector) Visit(node Node) Visitor {
+	switch node.(type) {
+	case nil, *CommentNode, *CommentGroupNode:
+		return nil
+	}
+	f(node)
+	return f
+}
+
+func comparePosition(a, b *token.Position) int {
+	if a.Line != b.Line {
+		return a.Line - b.Line
+	}
+	return a.Column - b.Column
+}
+
+// tokenEndPosition returns the position of the next of the last character of tk.
+func tokenEndPosition(tk *token.Token) token.Position {
+	pos := *tk.Position
+	pos.DisplayColumn = 0
+	text := strings.TrimRight(tk.Origin, " \t\r\n")
+	if pos.Column > 0 {
+		// the origin may contain the preceding spaces of the token.
+		text = strings.TrimLeft(text, " \t\r\n")
+	} else {
+		// the block scalar content starts after the indent of the line.
+		text = strings.TrimLeft(text, " \t")
+	}
+	// the column and offset of the token are counted by character.
+	pos.Offset += utf8.RuneCountInString(text)
+	pos.ByteOffset += len(text)
+	if idx := strings.LastIndexByte(text, '\n'); idx >= 0 {
+		pos.Line += strings.Count(text, "\n")
+		pos.Column = utf8.RuneCountInString(text[idx:])
+		return pos
+	}
+	if pos.Column == 0 {
+		// block scalar content starts at the beginning of the line.
+		pos.Column = 1
+	}
+	pos.Column += utf8.RuneCountInString(text)
+	return pos
+}
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/goccy/go-yaml/token"
@@ -34,3 +35,52 @@ func TestReadNode(t *testing.T) {
 		}
 	})
 }
+
+func TestBuilder(t *testing.T) {
+	node := NewMapping(
+		KV("name", NewString("app")),
+		KV("version", NewInteger(3)),
+		KV("ratio", NewFloat(1)),
+		KV("quoted", NewString("a: b")),
+		KV("db", NewMapping(
+			KV("host", NewString("localhost")),
+			KV("options", NewMapping(KV("tls", NewBool(true)))),
+			KV("ports", NewSequence(NewInteger(5432), NewInteger(5433))),
+		)),
+		KV("items", NewSequence(
+			NewMapping(KV("a", NewInteger(1)), KV("b", nil)),
+			NewSequence(NewString("x"), NewString("y")),
+		)),
+		KV("flow", NewFlowSequence(NewInteger(1), NewString("a,b"), NewString(""))),
+		KV("flowMap", NewFlowMapping(KV("k", NewString("v")))),
+	)
+	node.Values[0].SetComment(NewComment(" application", " settings"))
+	node.Values[1].Value.SetComment(NewComment(" schema version"))
+	node.Values[4].Value.(*MappingNode).Values[1].SetComment(NewComment(" connection options"))
+
+	expected := `
+# application
+# settings
+name: app
+version: 3 # schema version
+ratio: 1.0
+quoted: "a: b"
+db:
+  host: localhost
+  # connection options
+  options:
+    tls: true
+  ports:
+  - 5432
+  - 5433
+items:
+- a: 1
+  b: null
+- - x
+  - "y"
+flow: [1, "a,b", ""]
+flowMap: {k: v}`
+	if got := NewDocument(node).String(); got != strings.TrimPrefix(expected, "\n") {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
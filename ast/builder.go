@@ -0,0 +1,143 @@
+package ast
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// builderIndent is the number of columns used to indent nested mappings created by the builder functions.
+const builderIndent = 2
+
+func builderPosition() *token.Position {
+	return &token.Position{Line: 1, Column: 1}
+}
+
+// NewString create node for string value.
+// The value is double-quoted if it cannot be written as a plain scalar.
+func NewString(v string) *StringNode {
+	if token.IsNeedQuoted(v) {
+		return String(token.DoubleQuote(v, strconv.Quote(v), builderPosition()))
+	}
+	return String(token.New(v, v, builderPosition()))
+}
+
+// NewInteger create node for integer value.
+func NewInteger(v int64) *IntegerNode {
+	value := strconv.FormatInt(v, 10)
+	return Integer(token.New(value, value, builderPosition()))
+}
+
+// NewFloat create node for float value.
+// Infinity and NaN are written as .inf, -.inf and .nan.
+func NewFloat(v float64) Node {
+	switch {
+	case math.IsInf(v, 1):
+		return Infinity(token.New(".inf", ".inf", builderPosition()))
+	case math.IsInf(v, -1):
+		return Infinity(token.New("-.inf", "-.inf", builderPosition()))
+	case math.IsNaN(v):
+		return Nan(token.New(".nan", ".nan", builderPosition()))
+	}
+	value := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(value, ".eE") {
+		value += ".0"
+	}
+	return Float(token.New(value, value, builderPosition()))
+}
+
+// NewBool create node for boolean value.
+func NewBool(v bool) *BoolNode {
+	value := strconv.FormatBool(v)
+	return Bool(token.New(value, value, builderPosition()))
+}
+
+// NewNull create node for null value.
+func NewNull() *NullNode {
+	return Null(token.New("null", "null", builderPosition()))
+}
+
+// NewComment create comment group node from comment texts.
+// Each text becomes one comment line, and the leading '#' is added automatically.
+func NewComment(texts ...string) *CommentGroupNode {
+	tks := make([]*token.Token, 0, len(texts))
+	for idx, text := range texts {
+		tks = append(tks, token.Comment(text, "#"+text, &token.Position{Line: idx + 1, Column: 1}))
+	}
+	return CommentGroup(tks)
+}
+
+// KV create mapping value node for key and value.
+// If value is nil, it is written as null.
+func KV(key string, value Node) *MappingValueNode {
+	if value == nil {
+		value = NewNull()
+	}
+	if _, ok := value.(MapNode); ok {
+		value.AddColumn(builderIndent)
+	}
+	return MappingValue(token.New("", "", builderPosition()), NewString(key), value)
+}
+
+// NewMapping create block style mapping node from mapping values.
+// Mapping values are usually created by KV.
+func NewMapping(values ...*MappingValueNode) *MappingNode {
+	return Mapping(token.New("", "", builderPosition()), false, values...)
+}
+
+// NewFlowMapping create flow style mapping node from mapping values.
+func NewFlowMapping(values ...*MappingValueNode) *MappingNode {
+	for _, value := range values {
+		quoteFlowString(value.Key)
+		quoteFlowString(value.Value)
+	}
+	return Mapping(token.New("{", "{", builderPosition()), true, values...)
+}
+
+// NewSequence create block style sequence node from values.
+// If a value is nil, it is written as null.
+func NewSequence(values ...Node) *SequenceNode {
+	return newSequence(false, values)
+}
+
+// NewFlowSequence create flow style sequence node from values.
+func NewFlowSequence(values ...Node) *SequenceNode {
+	return newSequence(true, values)
+}
+
+func newSequence(isFlowStyle bool, values []Node) *SequenceNode {
+	tk := token.New("-", "-", builderPosition())
+	if isFlowStyle {
+		tk = token.New("[", "[", builderPosition())
+	}
+	node := Sequence(tk, isFlowStyle)
+	for _, value := range values {
+		if value == nil {
+			value = NewNull()
+		}
+		if isFlowStyle {
+			quoteFlowString(value)
+		}
+		node.Values = append(node.Values, value)
+	}
+	return node
+}
+
+// quoteFlowString makes plain string node double-quoted if it contains the flow indicators.
+func quoteFlowString(node Node) {
+	s, ok := node.(*StringNode)
+	if !ok || s.Token.Type != token.StringType {
+		return
+	}
+	if !strings.ContainsAny(s.Value, "[]{},") {
+		return
+	}
+	s.Token = token.DoubleQuote(s.Value, strconv.Quote(s.Value), s.Token.Position)
+}
+
+// NewDocument create document node that has body.
+func NewDocument(body Node) *DocumentNode {
+	return Document(nil, body)
+}
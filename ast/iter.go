@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package ast
+
+import "iter"
+
+// Items returns the iterator over the index and the value of the elements.
+//
+//	for idx, value := range seq.Items() {
+//		...
+//	}
+func (n *SequenceNode) Items() iter.Seq2[int, Node] {
+	return func(yield func(int, Node) bool) {
+		for idx, value := range n.Values {
+			if !yield(idx, value) {
+				return
+			}
+		}
+	}
+}
+
+// Items returns the iterator over the key and the value of the mapping values.
+// The merge keys are not expanded, like MapRange.
+func (n *MappingNode) Items() iter.Seq2[MapKeyNode, Node] {
+	return func(yield func(MapKeyNode, Node) bool) {
+		for _, value := range n.Values {
+			if !yield(value.Key, value.Value) {
+				return
+			}
+		}
+	}
+}
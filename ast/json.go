@@ -0,0 +1,142 @@
+//go:build !yaml_lean
+
+package ast
+
+import (
+	"encoding/json"
+
+	"github.com/goccy/go-yaml/token"
+)
+
+// jsonNode is the JSON representation of the node written by MarshalJSON.
+// The scalar has the text of the value in Value, and the other nodes have the child nodes
+// in Key, Value, Values and Body, which are written by MarshalJSON of the child nodes.
+// ValueHeadComments and ValueBlankLines are written only for the sequence having them.
+type jsonNode struct {
+	Type              string              `json:"type"`
+	Path              string              `json:"path,omitempty"`
+	Start             *jsonPosition       `json:"start,omitempty"`
+	End               *jsonPosition       `json:"end,omitempty"`
+	Tag               string              `json:"tag,omitempty"`
+	Name              string              `json:"name,omitempty"`
+	Header            string              `json:"header,omitempty"`
+	Flow              bool                `json:"flow,omitempty"`
+	Key               Node                `json:"key,omitempty"`
+	Value             any                 `json:"value,omitempty"`
+	Values            []Node              `json:"values,omitempty"`
+	ValueHeadComments []*CommentGroupNode `json:"valueHeadComments,omitempty"`
+	ValueBlankLines   []bool              `json:"valueBlankLines,omitempty"`
+	Directives        []*DirectiveNode    `json:"directives,omitempty"`
+	Body              Node                `json:"body,omitempty"`
+	Comments          []*CommentNode      `json:"comments,omitempty"`
+	HeadComment       *CommentGroupNode   `json:"headComment,omitempty"`
+	Comment           *CommentGroupNode   `json:"comment,omitempty"`
+	FootComment       *CommentGroupNode   `json:"footComment,omitempty"`
+}
+
+// jsonPosition is the JSON representation of token.Position.
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+func newJSONPosition(pos token.Position) *jsonPosition {
+	if pos.Line == 0 {
+		// the node built without the tokens doesn't have the position.
+		return nil
+	}
+	return &jsonPosition{Line: pos.Line, Column: pos.Column, Offset: pos.Offset}
+}
+
+// marshalNodeJSON encodes node to the JSON object having the type, the path, the range, the children and the comments of node.
+func marshalNodeJSON(node Node) ([]byte, error) {
+	start, end := NodeRange(node)
+	v := &jsonNode{
+		Type:  node.Type().String(),
+		Path:  node.GetPath(),
+		Start: newJSONPosition(start),
+		End:   newJSONPosition(end),
+	}
+	v.Comment = node.GetComment()
+	switch n := node.(type) {
+	case *DocumentNode:
+		v.Directives = n.Directives
+		v.Body = n.Body
+		v.HeadComment = n.HeadComment
+		v.FootComment = n.FootComment
+	case *StringNode:
+		v.Value = n.Value
+	case *LiteralNode:
+		v.Header = n.Start.Value
+		v.Value = n.Value.Value
+	case *MappingNode:
+		v.Flow = n.IsFlowStyle
+		for _, value := range n.Values {
+			v.Values = append(v.Values, value)
+		}
+		v.FootComment = n.FootComment
+	case *MappingKeyNode:
+		v.Value = n.Value
+	case *MappingValueNode:
+		v.Key = n.Key
+		v.Value = n.Value
+		v.FootComment = n.FootComment
+	case *SequenceNode:
+		v.Flow = n.IsFlowStyle
+		v.Values = n.Values
+		if hasValueHeadComment(n) {
+			v.ValueHeadComments = n.ValueHeadComments
+		}
+		if hasValueBlankLine(n) {
+			v.ValueBlankLines = n.ValueBlankLines
+		}
+		v.FootComment = n.FootComment
+	case *AnchorNode:
+		v.Name = n.Name.GetToken().Value
+		v.Value = n.Value
+	case *AliasNode:
+		v.Name = n.Value.GetToken().Value
+	case *DirectiveNode:
+		v.Name = n.Name.GetToken().Value
+		v.Values = n.Values
+	case *TagNode:
+		v.Tag = n.Start.Value
+		v.Value = n.Value
+	case *CommentNode:
+		v.Value = n.Token.Value
+	case *CommentGroupNode:
+		v.Comments = n.Comments
+	default:
+		// the other scalars have the text of the value in the token.
+		if tk := node.GetToken(); tk != nil {
+			v.Value = tk.Value
+		}
+	}
+	return json.Marshal(v)
+}
+
+func hasValueHeadComment(n *SequenceNode) bool {
+	for _, comment := range n.ValueHeadComments {
+		if comment != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasValueBlankLine(n *SequenceNode) bool {
+	for _, blankLine := range n.ValueBlankLines {
+		if blankLine {
+			return true
+		}
+	}
+	return false
+}
+
+func marshalFileJSON(f *File) ([]byte, error) {
+	return json.Marshal(struct {
+		Name string          `json:"name,omitempty"`
+		Docs []*DocumentNode `json:"docs"`
+	}{Name: f.Name, Docs: f.Docs})
+}
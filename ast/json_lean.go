@@ -0,0 +1,17 @@
+//go:build yaml_lean
+
+package ast
+
+import "errors"
+
+// errJSONNotSupported is returned by MarshalJSON in the build with the yaml_lean tag,
+// which doesn't depend on encoding/json.
+var errJSONNotSupported = errors.New("the JSON representation of the AST is not supported in the yaml_lean build")
+
+func marshalNodeJSON(Node) ([]byte, error) {
+	return nil, errJSONNotSupported
+}
+
+func marshalFileJSON(*File) ([]byte, error) {
+	return nil, errJSONNotSupported
+}
@@ -0,0 +1,142 @@
+//go:build !yaml_lean
+
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	src := `# head
+a: &x 1 # line
+b: *x
+c: !!str |
+  text
+d: [1, {e: f}]
+`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type node struct {
+		Type  string `json:"type"`
+		Path  string `json:"path"`
+		Start *struct {
+			Line   int `json:"line"`
+			Column int `json:"column"`
+		} `json:"start"`
+		Tag     string          `json:"tag"`
+		Name    string          `json:"name"`
+		Header  string          `json:"header"`
+		Flow    bool            `json:"flow"`
+		Key     *node           `json:"key"`
+		Value   json.RawMessage `json:"value"`
+		Values  []*node         `json:"values"`
+		Body    *node           `json:"body"`
+		Comment *struct {
+			Comments []*node `json:"comments"`
+		} `json:"comment"`
+	}
+	var file struct {
+		Docs []*node `json:"docs"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Docs) != 1 || file.Docs[0].Type != "Document" {
+		t.Fatalf("unexpected documents: %s", b)
+	}
+	body := file.Docs[0].Body
+	if body.Type != "Mapping" || body.Path != "$" || len(body.Values) != 4 {
+		t.Fatalf("unexpected body: %s", b)
+	}
+	child := func(t *testing.T, raw json.RawMessage) *node {
+		t.Helper()
+		var n node
+		if err := json.Unmarshal(raw, &n); err != nil {
+			t.Fatal(err)
+		}
+		return &n
+	}
+	text := func(t *testing.T, raw json.RawMessage) string {
+		t.Helper()
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	t.Run("anchor and comments", func(t *testing.T) {
+		a := body.Values[0]
+		if a.Comment == nil || len(a.Comment.Comments) != 1 || text(t, a.Comment.Comments[0].Value) != " head" {
+			t.Fatalf("unexpected head comment: %s", b)
+		}
+		if a.Key.Type != "String" || text(t, a.Key.Value) != "a" || a.Key.Path != "$.a" {
+			t.Fatalf("unexpected key: %+v", a.Key)
+		}
+		if a.Key.Start == nil || a.Key.Start.Line != 2 || a.Key.Start.Column != 1 {
+			t.Fatalf("unexpected key position: %+v", a.Key.Start)
+		}
+		anchor := child(t, a.Value)
+		if anchor.Type != "Anchor" || anchor.Name != "x" {
+			t.Fatalf("unexpected anchor: %+v", anchor)
+		}
+		value := child(t, anchor.Value)
+		if value.Type != "Integer" || text(t, value.Value) != "1" {
+			t.Fatalf("unexpected anchor value: %+v", value)
+		}
+		if value.Comment == nil || len(value.Comment.Comments) != 1 || text(t, value.Comment.Comments[0].Value) != " line" {
+			t.Fatalf("unexpected line comment: %s", b)
+		}
+	})
+	t.Run("alias", func(t *testing.T) {
+		alias := child(t, body.Values[1].Value)
+		if alias.Type != "Alias" || alias.Name != "x" {
+			t.Fatalf("unexpected alias: %+v", alias)
+		}
+	})
+	t.Run("tag and literal", func(t *testing.T) {
+		tag := child(t, body.Values[2].Value)
+		if tag.Type != "Tag" || tag.Tag != "!!str" {
+			t.Fatalf("unexpected tag: %+v", tag)
+		}
+		literal := child(t, tag.Value)
+		if literal.Type != "Literal" || literal.Header != "|" || text(t, literal.Value) != "text\n" {
+			t.Fatalf("unexpected literal: %+v", literal)
+		}
+	})
+	t.Run("flow collections", func(t *testing.T) {
+		seq := child(t, body.Values[3].Value)
+		if seq.Type != "Sequence" || !seq.Flow || len(seq.Values) != 2 {
+			t.Fatalf("unexpected sequence: %+v", seq)
+		}
+		if seq.Values[0].Path != "$.d[0]" || text(t, seq.Values[0].Value) != "1" {
+			t.Fatalf("unexpected sequence value: %+v", seq.Values[0])
+		}
+		mapping := seq.Values[1]
+		if mapping.Type != "Mapping" || !mapping.Flow || len(mapping.Values) != 1 {
+			t.Fatalf("unexpected mapping: %+v", mapping)
+		}
+		if value := child(t, mapping.Values[0].Value); value.Path != "$.d[1].e" || text(t, value.Value) != "f" {
+			t.Fatalf("unexpected mapping value: %+v", value)
+		}
+	})
+	t.Run("node without tokens", func(t *testing.T) {
+		b, err := json.Marshal(ast.Null(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != `{"type":"Null"}` {
+			t.Fatalf("unexpected json: %s", b)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package ast
+
+// MarshalJSON encodes the file to the JSON object having the name and the documents.
+func (f *File) MarshalJSON() ([]byte, error) { return marshalFileJSON(f) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *DocumentNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *NullNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *IntegerNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *FloatNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *StringNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *LiteralNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *MergeKeyNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *BoolNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *InfinityNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *NanNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *MappingNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *MappingKeyNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *MappingValueNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *SequenceNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *AnchorNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *AliasNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *DirectiveNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *TagNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *CommentNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
+
+// MarshalJSON encodes the node to the JSON representation of the AST.
+func (n *CommentGroupNode) MarshalJSON() ([]byte, error) { return marshalNodeJSON(n) }
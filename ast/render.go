@@ -0,0 +1,110 @@
+package ast
+
+import (
+	"errors"
+	"strings"
+)
+
+// RenderOptions is the options of Render.
+type RenderOptions struct {
+	// Indent is the number of the spaces added to the lines of the shallowest level.
+	Indent int
+	// PreserveComments writes the comments of node and its children.
+	PreserveComments bool
+}
+
+// Render writes node as the text indented by opts.Indent instead of the column of node in the original document,
+// so the text can be injected into another document at the different level.
+// If the first line of node is written after the key like the header of the block scalar ( e.g. `|` ),
+// it's written as it is and the following lines are indented.
+// All the lines are shifted by the same width, so the relative indentation of the children
+// and the content of the literal and folded block scalars are kept as they are.
+// node is not modified.
+func Render(node Node, opts RenderOptions) ([]byte, error) {
+	if node == nil {
+		return nil, errors.New("cannot render nil node")
+	}
+	if opts.Indent < 0 {
+		return nil, errors.New("indent must not be negative")
+	}
+	if !opts.PreserveComments {
+		restore := detachComments(node)
+		defer restore()
+	}
+	lines := strings.Split(node.String(), "\n")
+	start := 0
+	if pos, _ := NodeRange(node); pos.Column > 0 && indentWidth(lines[0]) != pos.Column-1 {
+		// the first line is written after the key or the sequence entry like the header of the block scalar,
+		// so only the following lines are indented.
+		start = 1
+	}
+	base := -1
+	for _, line := range lines[start:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := indentWidth(line); base < 0 || n < base {
+			base = n
+		}
+	}
+	if base < 0 {
+		base = 0
+	}
+	prefix := strings.Repeat(" ", opts.Indent)
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if len(line) <= base && strings.TrimSpace(line) == "" {
+			// the empty lines are written without the indentation.
+			lines[i] = ""
+			continue
+		}
+		lines[i] = prefix + line[base:]
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func indentWidth(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// detachComments removes the comments of node and its children, and returns the function to restore them.
+func detachComments(node Node) func() {
+	var restores []func()
+	Walk(visitorFunc(func(n Node) {
+		if comment := n.GetComment(); comment != nil {
+			_ = n.SetComment(nil)
+			restores = append(restores, func() { _ = n.SetComment(comment) })
+		}
+		switch n := n.(type) {
+		case *MappingNode:
+			if foot := n.FootComment; foot != nil {
+				n.FootComment = nil
+				restores = append(restores, func() { n.FootComment = foot })
+			}
+		case *MappingValueNode:
+			if foot := n.FootComment; foot != nil {
+				n.FootComment = nil
+				restores = append(restores, func() { n.FootComment = foot })
+			}
+		case *SequenceNode:
+			if foot, heads := n.FootComment, n.ValueHeadComments; foot != nil || heads != nil {
+				n.FootComment, n.ValueHeadComments = nil, nil
+				restores = append(restores, func() { n.FootComment, n.ValueHeadComments = foot, heads })
+			}
+		}
+	}), node)
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
+type visitorFunc func(Node)
+
+func (f visitorFunc) Visit(node Node) Visitor {
+	if node != nil {
+		f(node)
+	}
+	return f
+}
@@ -0,0 +1,104 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestRender(t *testing.T) {
+	src := `root:
+  spec:
+    # head comment
+    name: web # line
+    script: |
+      echo a
+        indented
+
+      echo b
+    list:
+      - a
+      - b: 1
+        c: 2
+`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := f.Docs[0].Body.(*ast.MappingNode).Values[0].Value.(*ast.MappingNode)
+	spec := root.Values[0].Value.(*ast.MappingNode)
+	tests := []struct {
+		name     string
+		node     ast.Node
+		opts     ast.RenderOptions
+		expected string
+	}{
+		{
+			name: "preserve comments",
+			node: spec,
+			opts: ast.RenderOptions{Indent: 2, PreserveComments: true},
+			expected: `  # head comment
+  name: web # line
+  script: |
+    echo a
+      indented
+
+    echo b
+  list:
+    - a
+    - b: 1
+      c: 2`,
+		},
+		{
+			name: "remove comments",
+			node: spec,
+			opts: ast.RenderOptions{},
+			expected: `name: web
+script: |
+  echo a
+    indented
+
+  echo b
+list:
+  - a
+  - b: 1
+    c: 2`,
+		},
+		{
+			name: "literal",
+			node: spec.Values[1].Value,
+			opts: ast.RenderOptions{Indent: 4},
+			expected: `|
+    echo a
+      indented
+
+    echo b`,
+		},
+		{
+			name: "sequence",
+			node: spec.Values[2].Value,
+			opts: ast.RenderOptions{Indent: 1},
+			expected: ` - a
+ - b: 1
+   c: 2`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ast.Render(test.node, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", test.expected, got)
+			}
+		})
+	}
+	if f.String() != src {
+		t.Fatalf("the node is modified:\n%s", f.String())
+	}
+	if _, err := ast.Render(nil, ast.RenderOptions{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,159 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnterLeaveVisitor has the callbacks invoked for each node encountered by Walker.
+// Enter is called with the parent node and the path of the node ( e.g. $.a.b[0] ) before the children are visited.
+// If Enter returns false, the children of the node are skipped and Leave isn't called for the node.
+// Otherwise, Leave is called after all the children are visited.
+type EnterLeaveVisitor interface {
+	Enter(node, parent Node, path string) bool
+	Leave(node Node)
+}
+
+// Walker traverses an AST in depth-first order like Walk, calling Enter and Leave of the visitor
+// and tracking the ancestors of the visited node.
+// The path of the mapping value is the path of the key, and the path of the node wrapping the value,
+// like the tag, the anchor and the mapping key, is the same as the parent.
+type Walker struct {
+	visitor   EnterLeaveVisitor
+	ancestors []Node
+}
+
+// NewWalker creates a Walker calling the callbacks of v.
+func NewWalker(v EnterLeaveVisitor) *Walker {
+	return &Walker{visitor: v}
+}
+
+// Ancestors returns the ancestors of the node being visited, from the root to the parent.
+// The returned slice must not be modified, and it's valid only until the callback returns.
+func (w *Walker) Ancestors() []Node {
+	return w.ancestors
+}
+
+// Walk traverses node and its children; node must not be nil.
+func (w *Walker) Walk(node Node) {
+	w.ancestors = w.ancestors[:0]
+	w.walk(node, nil, "$")
+}
+
+func (w *Walker) walk(node, parent Node, path string) {
+	if node == nil {
+		return
+	}
+	if !w.visitor.Enter(node, parent, path) {
+		return
+	}
+	w.ancestors = append(w.ancestors, node)
+
+	switch n := node.(type) {
+	case *CommentNode:
+	case *NullNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *IntegerNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *FloatNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *StringNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *MergeKeyNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *BoolNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *InfinityNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *NanNode:
+		w.walkComment(n, n.BaseNode, path)
+	case *LiteralNode:
+		w.walkComment(n, n.BaseNode, path)
+		w.walk(n.Value, n, path)
+	case *DirectiveNode:
+		w.walkComment(n, n.BaseNode, path)
+		w.walk(n.Name, n, path)
+		for _, value := range n.Values {
+			w.walk(value, n, path)
+		}
+	case *TagNode:
+		w.walkComment(n, n.BaseNode, path)
+		w.walk(n.Value, n, path)
+	case *DocumentNode:
+		w.walkComment(n, n.BaseNode, path)
+		if n.HeadComment != nil {
+			w.walk(n.HeadComment, n, path)
+		}
+		w.walk(n.Body, n, path)
+		if n.FootComment != nil {
+			w.walk(n.FootComment, n, path)
+		}
+	case *MappingNode:
+		w.walkComment(n, n.BaseNode, path)
+		for _, value := range n.Values {
+			w.walk(value, n, path+"."+walkerKeyPath(value.Key))
+		}
+	case *MappingKeyNode:
+		w.walkComment(n, n.BaseNode, path)
+		w.walk(n.Value, n, path)
+	case *MappingValueNode:
+		w.walkComment(n, n.BaseNode, path)
+		w.walk(n.Key, n, path)
+		w.walk(n.Value, n, path)
+	case *SequenceNode:
+		w.walkComment(n, n.BaseNode, path)
+		for idx, value := range n.Values {
+			w.walk(value, n, fmt.Sprintf("%s[%d]", path, idx))
+		}
+	case *AnchorNode:
+		w.walkComment(n, n.BaseNode, path)
+		w.walk(n.Name, n, path)
+		w.walk(n.Value, n, path)
+	case *AliasNode:
+		w.walkComment(n, n.BaseNode, path)
+		w.walk(n.Value, n, path)
+	}
+
+	w.ancestors = w.ancestors[:len(w.ancestors)-1]
+	w.visitor.Leave(node)
+}
+
+func (w *Walker) walkComment(node Node, base *BaseNode, path string) {
+	if base == nil {
+		return
+	}
+	if base.Comment == nil {
+		return
+	}
+	w.walk(base.Comment, node, path)
+}
+
+// walkerKeyPath returns the path element of the key, quoting it like the parser
+// if it contains the special characters of the path.
+func walkerKeyPath(key Node) string {
+	for {
+		switch n := key.(type) {
+		case *MappingKeyNode:
+			key = n.Value
+			continue
+		case *TagNode:
+			key = n.Value
+			continue
+		case *AnchorNode:
+			key = n.Value
+			continue
+		case *AliasNode:
+			key = n.Value
+			continue
+		}
+		break
+	}
+	if key == nil || key.GetToken() == nil {
+		return ""
+	}
+	text := key.GetToken().Value
+	if strings.ContainsAny(text, "$*.[]") {
+		return "'" + text + "'"
+	}
+	return text
+}
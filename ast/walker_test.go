@@ -0,0 +1,82 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+type recordingVisitor struct {
+	walker *ast.Walker
+	events []string
+	skip   string
+}
+
+func (v *recordingVisitor) Enter(node, parent ast.Node, path string) bool {
+	if _, ok := node.(*ast.StringNode); ok {
+		isKey := false
+		if mv, ok := parent.(*ast.MappingValueNode); ok {
+			isKey = mv.Key == node
+		}
+		v.events = append(v.events, fmt.Sprintf("%s %s key=%t depth=%d", path, node.GetToken().Value, isKey, len(v.walker.Ancestors())))
+	}
+	return path != v.skip
+}
+
+func (v *recordingVisitor) Leave(node ast.Node) {
+	if _, ok := node.(*ast.MappingNode); ok {
+		v.events = append(v.events, "leave mapping")
+	}
+}
+
+func TestWalker(t *testing.T) {
+	src := `
+a:
+  b: x
+  c.d:
+    - y
+    - !!str z
+e: w
+`
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("path and parent", func(t *testing.T) {
+		v := &recordingVisitor{}
+		v.walker = ast.NewWalker(v)
+		v.walker.Walk(f.Docs[0])
+		expected := []string{
+			"$.a a key=true depth=3",
+			"$.a.b b key=true depth=5",
+			"$.a.b x key=false depth=5",
+			"$.a.'c.d' c.d key=true depth=5",
+			"$.a.'c.d'[0] y key=false depth=6",
+			"$.a.'c.d'[1] z key=false depth=7",
+			"leave mapping",
+			"$.e e key=true depth=3",
+			"$.e w key=false depth=3",
+			"leave mapping",
+		}
+		if !reflect.DeepEqual(v.events, expected) {
+			t.Fatalf("unexpected events:\nexpected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(v.events, "\n"))
+		}
+	})
+	t.Run("skip children", func(t *testing.T) {
+		v := &recordingVisitor{skip: "$.a"}
+		v.walker = ast.NewWalker(v)
+		v.walker.Walk(f.Docs[0])
+		expected := []string{
+			"$.e e key=true depth=3",
+			"$.e w key=false depth=3",
+			"leave mapping",
+		}
+		if !reflect.DeepEqual(v.events, expected) {
+			t.Fatalf("unexpected events:\nexpected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(v.events, "\n"))
+		}
+	})
+}
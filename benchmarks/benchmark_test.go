@@ -1,6 +1,7 @@
 package benchmarks
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/goccy/go-yaml"
@@ -50,3 +51,68 @@ elements:
 		}
 	})
 }
+
+func BenchmarkGenericShapes(b *testing.B) {
+	const src = `---
+labels:
+  app: web
+  tier: frontend
+  version: 1.2.3
+  replicas: 3
+args:
+  - --port=8080
+  - --verbose
+  - "true"
+  - 10
+`
+	b.Run("map[string]string and []string", func(b *testing.B) {
+		var v struct {
+			Labels map[string]string `yaml:"labels"`
+			Args   []string          `yaml:"args"`
+		}
+		for i := 0; i < b.N; i++ {
+			if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("map[string]any and []any", func(b *testing.B) {
+		var v struct {
+			Labels map[string]interface{} `yaml:"labels"`
+			Args   []interface{}          `yaml:"args"`
+		}
+		for i := 0; i < b.N; i++ {
+			if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSkipOrigins(b *testing.B) {
+	src := []byte(strings.Repeat(`- name: foo # comment
+  tags: [a, b, c]
+  meta: {x: 1, y: 2}
+  items:
+    - id: 1
+      value: "hello"
+    - id: 2
+      value: world
+`, 200))
+	b.Run("default", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v interface{}
+			if err := yaml.Unmarshal(src, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("SkipOrigins", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var v interface{}
+			if err := yaml.UnmarshalWithOptions(src, &v, yaml.SkipOrigins()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package yaml
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// canonicalText returns the text of the document in the canonical form.
+// Every node has the explicit tag, every scalar is double quoted,
+// every collection uses the flow style and the keys of the mapping are sorted.
+// The tags are taken from the types of the encoded nodes.
+func (e *Encoder) canonicalText(node ast.Node) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	e.writeCanonicalNode(&b, node, "", 0)
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (e *Encoder) writeCanonicalNode(b *strings.Builder, node ast.Node, tag string, level int) {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		b.WriteString("&" + n.Name.GetToken().Value + " ")
+		e.writeCanonicalNode(b, n.Value, tag, level)
+	case *ast.AliasNode:
+		b.WriteString("*" + n.Value.GetToken().Value)
+	case *ast.TagNode:
+		e.writeCanonicalNode(b, n.Value, n.Start.Value, level)
+	case *ast.MappingKeyNode:
+		e.writeCanonicalNode(b, n.Value, tag, level)
+	case *ast.MappingNode:
+		e.writeCanonicalMapping(b, n.Values, tag, level)
+	case *ast.MappingValueNode:
+		e.writeCanonicalMapping(b, []*ast.MappingValueNode{n}, tag, level)
+	case *ast.SequenceNode:
+		e.writeCanonicalSequence(b, n.Values, tag, level)
+	default:
+		defaultTag, value := canonicalScalar(node)
+		if tag == "" {
+			tag = defaultTag
+		}
+		b.WriteString(tag + " " + strconv.Quote(value))
+	}
+}
+
+func (e *Encoder) writeCanonicalMapping(b *strings.Builder, values []*ast.MappingValueNode, tag string, level int) {
+	if tag == "" {
+		tag = "!!map"
+	}
+	if len(values) == 0 {
+		b.WriteString(tag + " {}")
+		return
+	}
+	space := strings.Repeat(" ", e.indent*(level+1))
+	entries := make([]*canonicalEntry, 0, len(values))
+	for idx, value := range values {
+		var key, val strings.Builder
+		e.writeCanonicalNode(&key, value.Key, "", level+1)
+		e.writeCanonicalNode(&val, value.Value, "", level+1)
+		entries = append(entries, &canonicalEntry{
+			index:       idx,
+			key:         key.String(),
+			value:       val.String(),
+			anchorNames: canonicalAnchorNames(value),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	b.WriteString(tag + " {\n")
+	for _, entry := range orderCanonicalEntries(entries) {
+		fmt.Fprintf(b, "%s? %s\n%s: %s,\n", space, entry.key, space, entry.value)
+	}
+	b.WriteString(strings.Repeat(" ", e.indent*level) + "}")
+}
+
+// canonicalEntry is the entry of the mapping written in the canonical form.
+type canonicalEntry struct {
+	index       int
+	key         string
+	value       string
+	anchorNames map[string]struct{}
+}
+
+// sharesAnchorName reports whether the entries define or refer to the same anchor name.
+func (c *canonicalEntry) sharesAnchorName(other *canonicalEntry) bool {
+	for name := range c.anchorNames {
+		if _, exists := other.anchorNames[name]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// orderCanonicalEntries returns the entries sorted by the keys, except that the entries defining or referring to
+// the same anchor name keep the original order, so every alias is written after the anchor it refers to.
+func orderCanonicalEntries(entries []*canonicalEntry) []*canonicalEntry {
+	var constrained bool
+	for _, entry := range entries {
+		if len(entry.anchorNames) != 0 {
+			constrained = true
+			break
+		}
+	}
+	if !constrained {
+		return entries
+	}
+	ordered := make([]*canonicalEntry, 0, len(entries))
+	written := make([]bool, len(entries))
+	for len(ordered) < len(entries) {
+		for i, entry := range entries {
+			if written[i] || !canonicalEntryReady(entries, written, entry) {
+				continue
+			}
+			written[i] = true
+			ordered = append(ordered, entry)
+			break
+		}
+	}
+	return ordered
+}
+
+// canonicalEntryReady reports whether all the entries preceding entry in the original order
+// and sharing an anchor name with it are already written.
+func canonicalEntryReady(entries []*canonicalEntry, written []bool, entry *canonicalEntry) bool {
+	if len(entry.anchorNames) == 0 {
+		return true
+	}
+	for i, other := range entries {
+		if written[i] || other.index >= entry.index {
+			continue
+		}
+		if entry.sharesAnchorName(other) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalAnchorNames returns the names of the anchors and the aliases in node.
+func canonicalAnchorNames(node ast.Node) map[string]struct{} {
+	var names map[string]struct{}
+	ast.Walk(canonicalAnchorNameVisitor(func(name string) {
+		if names == nil {
+			names = map[string]struct{}{}
+		}
+		names[name] = struct{}{}
+	}), node)
+	return names
+}
+
+type canonicalAnchorNameVisitor func(string)
+
+func (f canonicalAnchorNameVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		f(n.Name.GetToken().Value)
+	case *ast.AliasNode:
+		f(n.Value.GetToken().Value)
+	}
+	return f
+}
+
+func (e *Encoder) writeCanonicalSequence(b *strings.Builder, values []ast.Node, tag string, level int) {
+	if tag == "" {
+		tag = "!!seq"
+	}
+	if len(values) == 0 {
+		b.WriteString(tag + " []")
+		return
+	}
+	space := strings.Repeat(" ", e.indent*(level+1))
+	b.WriteString(tag + " [\n")
+	for _, value := range values {
+		b.WriteString(space)
+		e.writeCanonicalNode(b, value, "", level+1)
+		b.WriteString(",\n")
+	}
+	b.WriteString(strings.Repeat(" ", e.indent*level) + "]")
+}
+
+// canonicalScalar returns the default tag and the text of the scalar node.
+func canonicalScalar(node ast.Node) (string, string) {
+	switch n := node.(type) {
+	case nil, *ast.NullNode:
+		return "!!null", ""
+	case *ast.BoolNode:
+		return "!!bool", strconv.FormatBool(n.Value)
+	case *ast.IntegerNode:
+		return "!!int", fmt.Sprint(n.Value)
+	case *ast.FloatNode:
+		// take the text of the token, because the value isn't set for the text like 1e+300.
+		return "!!float", n.GetToken().Value
+	case *ast.InfinityNode:
+		if n.Value < 0 {
+			return "!!float", "-.inf"
+		}
+		return "!!float", ".inf"
+	case *ast.NanNode:
+		return "!!float", ".nan"
+	case *ast.MergeKeyNode:
+		return "!!merge", "<<"
+	case *ast.StringNode:
+		return "!!str", canonicalString(n)
+	case *ast.LiteralNode:
+		return "!!str", n.Value.Value
+	}
+	return "!!str", node.String()
+}
+
+// canonicalString returns the value of the string node.
+// The string nodes created by the encoder keep the quotes in the value, so they are removed here.
+func canonicalString(n *ast.StringNode) string {
+	value := n.Value
+	if n.Token.Type != token.StringType || len(value) < 2 {
+		return value
+	}
+	switch value[0] {
+	case '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	case '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
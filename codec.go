@@ -0,0 +1,186 @@
+package yaml
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// Options is the set of the options used by NewMarshaler and NewUnmarshaler.
+//
+// The options are applied in the order, so when the same option is specified more than once
+// or the options setting the same setting are combined ( e.g. KubernetesStyle and Indent ),
+// the option specified later takes precedence. The options adding the settings,
+// like CustomMarshaler and ReferenceReaders, are merged.
+// The combinations which can't take effect together ( e.g. JSON and UseLiteralStyleIfMultiline )
+// are reported by Validate.
+type Options struct {
+	Encode []EncodeOption
+	Decode []DecodeOption
+}
+
+// Validate applies the options and reports the error returned by the options
+// and the conflicting combinations of the options as ErrConflictingOptions.
+func (o Options) Validate() error {
+	if err := validateEncodeOptions(o.Encode); err != nil {
+		return err
+	}
+	if err := validateDecodeOptions(o.Decode); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateEncodeOptions(opts []EncodeOption) error {
+	e := NewEncoder(io.Discard)
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return err
+		}
+	}
+	if e.indent < 1 {
+		return fmt.Errorf("%w: Indent must be positive but %d", ErrInvalidOption, e.indent)
+	}
+	if e.foldedStyleWidth < 0 {
+		return fmt.Errorf("%w: UseFoldedStyleIfLong must not be negative but %d", ErrInvalidOption, e.foldedStyleWidth)
+	}
+	switch {
+	case e.isJSONStyle && e.isCanonical:
+		return ErrOptionConflict("JSON", "Canonical")
+	case e.isJSONStyle && !e.isFlowStyle:
+		return ErrOptionConflict("JSON", "Flow(false)")
+	case e.isJSONStyle && e.singleQuote:
+		return ErrOptionConflict("JSON", "UseSingleQuote")
+	case e.isJSONStyle && e.useLiteralStyleIfMultiline:
+		return ErrOptionConflict("JSON", "UseLiteralStyleIfMultiline")
+	case e.isJSONStyle && e.foldedStyleWidth > 0:
+		return ErrOptionConflict("JSON", "UseFoldedStyleIfLong")
+	case e.isJSONStyle && e.allowCycles:
+		return ErrOptionConflict("JSON", "AllowCycles")
+	case e.isCanonical && e.singleQuote:
+		return ErrOptionConflict("Canonical", "UseSingleQuote")
+	case e.isCanonical && e.useLiteralStyleIfMultiline:
+		return ErrOptionConflict("Canonical", "UseLiteralStyleIfMultiline")
+	case e.isCanonical && e.foldedStyleWidth > 0:
+		return ErrOptionConflict("Canonical", "UseFoldedStyleIfLong")
+	case e.isFlowStyle && e.useLiteralStyleIfMultiline:
+		return ErrOptionConflict("Flow", "UseLiteralStyleIfMultiline")
+	case e.isFlowStyle && e.foldedStyleWidth > 0:
+		return ErrOptionConflict("Flow", "UseFoldedStyleIfLong")
+	}
+	return nil
+}
+
+func validateDecodeOptions(opts []DecodeOption) error {
+	d := NewDecoder(bytes.NewReader(nil))
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return err
+		}
+	}
+	if d.maxDocumentBytes < 0 {
+		return fmt.Errorf("%w: MaxDocumentBytes must not be negative but %d", ErrInvalidOption, d.maxDocumentBytes)
+	}
+	return nil
+}
+
+// OptionsMarshaler is the reusable marshaler having the validated EncodeOptions.
+// It's safe for concurrent use if the options are.
+type OptionsMarshaler struct {
+	opts []EncodeOption
+}
+
+// NewMarshaler validates opts.Encode and returns the marshaler encoding the values with them.
+func NewMarshaler(opts Options) (*OptionsMarshaler, error) {
+	if err := validateEncodeOptions(opts.Encode); err != nil {
+		return nil, err
+	}
+	return &OptionsMarshaler{opts: append([]EncodeOption{}, opts.Encode...)}, nil
+}
+
+// Marshal serializes v with the options of the marshaler.
+// The per-call opts are applied after the options of the marshaler, so they override the defaults,
+// and the combined options are validated again.
+func (m *OptionsMarshaler) Marshal(v interface{}, opts ...EncodeOption) ([]byte, error) {
+	return m.MarshalContext(context.Background(), v, opts...)
+}
+
+// MarshalContext serializes v with context.Context and the options of the marshaler.
+func (m *OptionsMarshaler) MarshalContext(ctx context.Context, v interface{}, opts ...EncodeOption) ([]byte, error) {
+	merged, err := m.merge(opts)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalContext(ctx, v, merged...)
+}
+
+// NewEncoder returns a new encoder writing to w with the options of the marshaler and opts.
+func (m *OptionsMarshaler) NewEncoder(w io.Writer, opts ...EncodeOption) (*Encoder, error) {
+	merged, err := m.merge(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncoder(w, merged...), nil
+}
+
+func (m *OptionsMarshaler) merge(opts []EncodeOption) ([]EncodeOption, error) {
+	if len(opts) == 0 {
+		return m.opts, nil
+	}
+	merged := append(append([]EncodeOption{}, m.opts...), opts...)
+	if err := validateEncodeOptions(merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// OptionsUnmarshaler is the reusable unmarshaler having the validated DecodeOptions.
+// It's safe for concurrent use if the options are.
+type OptionsUnmarshaler struct {
+	opts []DecodeOption
+}
+
+// NewUnmarshaler validates opts.Decode and returns the unmarshaler decoding the documents with them.
+func NewUnmarshaler(opts Options) (*OptionsUnmarshaler, error) {
+	if err := validateDecodeOptions(opts.Decode); err != nil {
+		return nil, err
+	}
+	return &OptionsUnmarshaler{opts: append([]DecodeOption{}, opts.Decode...)}, nil
+}
+
+// Unmarshal decodes the first document in data into v with the options of the unmarshaler.
+// The per-call opts are applied after the options of the unmarshaler, so they override the defaults,
+// and the combined options are validated again.
+func (u *OptionsUnmarshaler) Unmarshal(data []byte, v interface{}, opts ...DecodeOption) error {
+	return u.UnmarshalContext(context.Background(), data, v, opts...)
+}
+
+// UnmarshalContext decodes with context.Context and the options of the unmarshaler.
+func (u *OptionsUnmarshaler) UnmarshalContext(ctx context.Context, data []byte, v interface{}, opts ...DecodeOption) error {
+	merged, err := u.merge(opts)
+	if err != nil {
+		return err
+	}
+	return UnmarshalContext(ctx, data, v, merged...)
+}
+
+// NewDecoder returns a new decoder reading from r with the options of the unmarshaler and opts.
+func (u *OptionsUnmarshaler) NewDecoder(r io.Reader, opts ...DecodeOption) (*Decoder, error) {
+	merged, err := u.merge(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewDecoder(r, merged...), nil
+}
+
+func (u *OptionsUnmarshaler) merge(opts []DecodeOption) ([]DecodeOption, error) {
+	if len(opts) == 0 {
+		return u.opts, nil
+	}
+	merged := append(append([]DecodeOption{}, u.opts...), opts...)
+	if err := validateDecodeOptions(merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
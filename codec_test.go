@@ -0,0 +1,130 @@
+package yaml_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts yaml.Options
+		err  error
+	}{
+		{name: "empty"},
+		{
+			name: "override",
+			opts: yaml.Options{Encode: []yaml.EncodeOption{yaml.KubernetesStyle(), yaml.Indent(4), yaml.Indent(2)}},
+		},
+		{
+			name: "json and literal",
+			opts: yaml.Options{Encode: []yaml.EncodeOption{yaml.JSON(), yaml.UseLiteralStyleIfMultiline(true)}},
+			err:  yaml.ErrConflictingOptions,
+		},
+		{
+			name: "literal disabled later",
+			opts: yaml.Options{Encode: []yaml.EncodeOption{yaml.UseLiteralStyleIfMultiline(true), yaml.JSON(), yaml.UseLiteralStyleIfMultiline(false)}},
+		},
+		{
+			name: "json and block style",
+			opts: yaml.Options{Encode: []yaml.EncodeOption{yaml.JSON(), yaml.Flow(false)}},
+			err:  yaml.ErrConflictingOptions,
+		},
+		{
+			name: "json and cycles",
+			opts: yaml.Options{Encode: []yaml.EncodeOption{yaml.AllowCycles(true), yaml.JSON()}},
+			err:  yaml.ErrConflictingOptions,
+		},
+		{
+			name: "canonical and single quote",
+			opts: yaml.Options{Encode: []yaml.EncodeOption{yaml.UseSingleQuote(true), yaml.Canonical()}},
+			err:  yaml.ErrConflictingOptions,
+		},
+		{
+			name: "invalid indent",
+			opts: yaml.Options{Encode: []yaml.EncodeOption{yaml.Indent(0)}},
+			err:  yaml.ErrInvalidOption,
+		},
+		{
+			name: "invalid max document bytes",
+			opts: yaml.Options{Decode: []yaml.DecodeOption{yaml.MaxDocumentBytes(-1)}},
+			err:  yaml.ErrInvalidOption,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opts.Validate()
+			if test.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v but got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestNewMarshaler(t *testing.T) {
+	if _, err := yaml.NewMarshaler(yaml.Options{Encode: []yaml.EncodeOption{yaml.JSON(), yaml.UseSingleQuote(true)}}); !errors.Is(err, yaml.ErrConflictingOptions) {
+		t.Fatalf("expected conflicting options error but got %v", err)
+	}
+	m, err := yaml.NewMarshaler(yaml.Options{Encode: []yaml.EncodeOption{yaml.Indent(4), yaml.UseLiteralStyleIfMultiline(true)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := map[string]interface{}{"a": map[string]string{"b": "x\ny"}}
+	got, err := m.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `a:
+    b: |-
+      x
+      y
+`
+	if string(got) != expected {
+		t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+	got, err = m.Marshal(v, yaml.Indent(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `a:
+  b: |-
+    x
+    y
+`
+	if string(got) != expected {
+		t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+	if _, err := m.Marshal(v, yaml.JSON()); !errors.Is(err, yaml.ErrConflictingOptions) {
+		t.Fatalf("expected conflicting options error but got %v", err)
+	}
+}
+
+func TestNewUnmarshaler(t *testing.T) {
+	if _, err := yaml.NewUnmarshaler(yaml.Options{Decode: []yaml.DecodeOption{yaml.MaxDocumentBytes(-1)}}); !errors.Is(err, yaml.ErrInvalidOption) {
+		t.Fatalf("expected invalid option error but got %v", err)
+	}
+	u, err := yaml.NewUnmarshaler(yaml.Options{Decode: []yaml.DecodeOption{yaml.Strict()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		A int `yaml:"a"`
+	}
+	if err := u.Unmarshal([]byte("a: 1\nb: 2\n"), &v); err == nil {
+		t.Fatal("expected unknown field error")
+	}
+	if err := u.Unmarshal([]byte("a: 1\n"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1 {
+		t.Fatalf("unexpected value: %d", v.A)
+	}
+}
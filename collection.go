@@ -0,0 +1,266 @@
+package yaml
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Set is the set of the values of T, which is encoded as the `!!set` mapping having only the keys like
+//
+//	!!set
+//	? a
+//	? b
+//
+// It's decoded from the `!!set` mapping or the other mapping by the keys.
+type Set[T comparable] map[T]struct{}
+
+// NewSet creates the Set having values.
+func NewSet[T comparable](values ...T) Set[T] {
+	s := make(Set[T], len(values))
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+	return s
+}
+
+// Has returns whether the set has v.
+func (s Set[T]) Has(v T) bool {
+	_, exists := s[v]
+	return exists
+}
+
+func (Set[T]) yamlSet() {}
+
+// setMarker is implemented only by Set to find it by the type.
+type setMarker interface {
+	yamlSet()
+}
+
+var setMarkerType = reflect.TypeOf((*setMarker)(nil)).Elem()
+
+func isSetType(typ reflect.Type) bool {
+	return typ.Implements(setMarkerType)
+}
+
+var mapItemType = reflect.TypeOf(MapItem{})
+
+// isCollectionTagType returns whether the field of typ can be written as the collection of tag.
+// The keys of the map or the values of the slice are written as the set,
+// and the map, MapSlice or []MapItem is written as the ordered map or the pairs.
+func isCollectionTagType(tag token.ReservedTagKeyword, typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice, reflect.Array:
+		return tag == token.SetTag || typ.Elem() == mapItemType
+	}
+	return false
+}
+
+func isPairsTag(tag token.ReservedTagKeyword) bool {
+	return tag == token.OrderedMapTag || tag == token.PairsTag
+}
+
+// collectionTag returns the reserved tag of node like `!!set` and `!!omap` following the anchor and the alias.
+func (d *Decoder) collectionTag(node ast.Node) token.ReservedTagKeyword {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		return d.collectionTag(n.Value)
+	case *ast.AliasNode:
+		if anchor, _ := d.anchorOf(n); anchor != nil && anchor != node {
+			return d.collectionTag(anchor)
+		}
+	case *ast.TagNode:
+		return token.ReservedTagKeyword(n.Start.Value)
+	}
+	return ""
+}
+
+// decodePairsMap decodes the `!!omap` or `!!pairs` sequence of the single pair mappings into the map.
+func (d *Decoder) decodePairsMap(ctx context.Context, dst reflect.Value, src ast.Node) error {
+	arrayNode, err := d.getArrayNode(src)
+	if err != nil {
+		return err
+	}
+	if arrayNode == nil {
+		return nil
+	}
+	mapValue := reflect.MakeMap(dst.Type())
+	keyMap := map[string]struct{}{}
+	iter := arrayNode.ArrayRange()
+	for iter.Next() {
+		pair := iter.Value()
+		pairValue := reflect.New(dst.Type()).Elem()
+		if err := d.decodeMap(ctx, pairValue, pair); err != nil {
+			return err
+		}
+		pairIter := pairValue.MapRange()
+		for pairIter.Next() {
+			if err := d.validateDuplicateKey(keyMap, pairIter.Key().Interface(), pair); err != nil {
+				return err
+			}
+			mapValue.SetMapIndex(pairIter.Key(), pairIter.Value())
+		}
+	}
+	dst.Set(mapValue)
+	return nil
+}
+
+// decodePairsMapSlice decodes the `!!omap` or `!!pairs` sequence of the single pair mappings into the MapSlice.
+// The duplicate keys are kept for `!!pairs`.
+func (d *Decoder) decodePairsMapSlice(ctx context.Context, dst *MapSlice, src ast.Node, tag token.ReservedTagKeyword) error {
+	arrayNode, err := d.getArrayNode(src)
+	if err != nil {
+		return err
+	}
+	if arrayNode == nil {
+		return nil
+	}
+	mapSlice := MapSlice{}
+	keyMap := map[string]struct{}{}
+	iter := arrayNode.ArrayRange()
+	for iter.Next() {
+		pair := iter.Value()
+		var m MapSlice
+		if err := d.decodeMapSlice(ctx, &m, pair); err != nil {
+			return err
+		}
+		for _, item := range m {
+			if tag == token.OrderedMapTag {
+				if err := d.validateDuplicateKey(keyMap, item.Key, pair); err != nil {
+					return err
+				}
+			}
+			mapSlice = append(mapSlice, item)
+		}
+	}
+	*dst = mapSlice
+	return nil
+}
+
+// decodeSetSlice decodes the keys of the `!!set` mapping into the slice.
+func (d *Decoder) decodeSetSlice(ctx context.Context, dst reflect.Value, src ast.Node) error {
+	mapNode, err := d.getMapNode(src, false)
+	if err != nil {
+		return err
+	}
+	elemType := dst.Type().Elem()
+	sliceValue := reflect.MakeSlice(dst.Type(), 0, 0)
+	iter := mapNode.MapRange()
+	for iter.Next() {
+		var key ast.Node = iter.Key()
+		if explicitKey, ok := key.(*ast.MappingKeyNode); ok {
+			key = explicitKey.Value
+		}
+		v, err := d.createDecodedNewValue(ctx, elemType, reflect.Value{}, key)
+		if err != nil {
+			return err
+		}
+		sliceValue = reflect.Append(sliceValue, v)
+	}
+	dst.Set(sliceValue)
+	return nil
+}
+
+// encodeTaggedCollection encodes v as the collection of tag, which is `!!set`, `!!omap` or `!!pairs`.
+func (e *Encoder) encodeTaggedCollection(ctx context.Context, tag token.ReservedTagKeyword, v reflect.Value, column int) (ast.Node, error) {
+	value := v
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return e.encodeValue(ctx, v, column)
+		}
+		value = value.Elem()
+	}
+	if (value.Kind() == reflect.Map || value.Kind() == reflect.Slice) && value.IsNil() {
+		return e.encodeValue(ctx, v, column)
+	}
+	var (
+		node ast.Node
+		err  error
+	)
+	if tag == token.SetTag {
+		node, err = e.encodeSet(ctx, value, column)
+	} else {
+		node, err = e.encodePairs(ctx, value, column)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tagNode := ast.Tag(token.Tag(string(tag), string(tag), e.pos(column)))
+	tagNode.Value = node
+	return tagNode, nil
+}
+
+// encodeSet encodes the keys of the map or the values of the slice as the keys of the mapping without the values.
+// In block style, the keys are written with the explicit key indicator `?`.
+func (e *Encoder) encodeSet(ctx context.Context, value reflect.Value, column int) (*ast.MappingNode, error) {
+	var keys []mapKey
+	if value.Kind() == reflect.Map {
+		sorted, err := e.sortedMapKeys(value)
+		if err != nil {
+			return nil, err
+		}
+		keys = sorted
+	} else {
+		isPtrMarshaler := isPtrTextMarshalerKeyType(value.Type().Elem())
+		for i := 0; i < value.Len(); i++ {
+			text, err := e.mapKeyString(value.Index(i), isPtrMarshaler)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, mapKey{value: value.Index(i), text: text})
+		}
+	}
+	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
+	for _, key := range keys {
+		keyNode, err := e.encodeMapKey(ctx, key.value, key.text, column)
+		if err != nil {
+			return nil, err
+		}
+		if e.isFlowStyle {
+			null := ast.Null(token.New("null", "null", e.pos(column)))
+			node.Values = append(node.Values, ast.MappingValue(nil, keyNode, null))
+			continue
+		}
+		if _, ok := keyNode.(*ast.MappingKeyNode); !ok {
+			explicitKey := ast.MappingKey(token.New("?", "?", e.pos(column)))
+			keyNode.AddColumn(2)
+			explicitKey.Value = keyNode
+			keyNode = explicitKey
+		}
+		// the null without the text isn't written after the explicit key.
+		null := ast.Null(token.New("null", "", e.pos(column)))
+		node.Values = append(node.Values, ast.MappingValue(nil, keyNode, null))
+	}
+	return node, nil
+}
+
+// encodePairs encodes the map, MapSlice or []MapItem as the sequence of the single pair mappings.
+// The keys of the map are sorted.
+func (e *Encoder) encodePairs(ctx context.Context, value reflect.Value, column int) (ast.Node, error) {
+	var items MapSlice
+	if value.Kind() == reflect.Map {
+		keys, err := e.sortedMapKeys(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			items = append(items, MapItem{Key: key.value.Interface(), Value: value.MapIndex(key.value).Interface()})
+		}
+	} else {
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, value.Index(i).Interface().(MapItem))
+		}
+	}
+	pairs := make([]MapSlice, 0, len(items))
+	for _, item := range items {
+		pairs = append(pairs, MapSlice{item})
+	}
+	return e.encodeValue(ctx, reflect.ValueOf(pairs), column)
+}
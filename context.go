@@ -1,6 +1,10 @@
 package yaml
 
-import "context"
+import (
+	"context"
+
+	"github.com/goccy/go-yaml/ast"
+)
 
 type ctxMergeKey struct{}
 
@@ -15,3 +19,44 @@ func isMerge(ctx context.Context) bool {
 	}
 	return v
 }
+
+type ctxSequenceElementKey struct{}
+
+// sequenceElement is the element of the sequence currently being decoded.
+type sequenceElement struct {
+	index int
+	node  ast.Node
+}
+
+func withSequenceElement(ctx context.Context, index int, node ast.Node) context.Context {
+	return context.WithValue(ctx, ctxSequenceElementKey{}, &sequenceElement{index: index, node: node})
+}
+
+func sequenceElementFromContext(ctx context.Context) *sequenceElement {
+	v, _ := ctx.Value(ctxSequenceElementKey{}).(*sequenceElement)
+	return v
+}
+
+type ctxTimeLayoutKey struct{}
+
+func withTimeLayout(ctx context.Context, layout string) context.Context {
+	return context.WithValue(ctx, ctxTimeLayoutKey{}, layout)
+}
+
+// timeLayoutFromContext returns the layout specified by the layout option of the struct field currently being decoded.
+func timeLayoutFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(ctxTimeLayoutKey{}).(string)
+	return v
+}
+
+type ctxRuneKey struct{}
+
+func withRune(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxRuneKey{}, true)
+}
+
+// isRuneFromContext returns whether the struct field currently being decoded has the rune option.
+func isRuneFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(ctxRuneKey{}).(bool)
+	return v
+}
@@ -7,62 +7,107 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"io/fs"
 	"math"
+	"math/big"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/lexer"
 	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/resolver"
 	"github.com/goccy/go-yaml/token"
 )
 
 // Decoder reads and decodes YAML values from an input stream.
 type Decoder struct {
-	reader               io.Reader
-	referenceReaders     []io.Reader
-	anchorNodeMap        map[string]ast.Node
-	aliasValueMap        map[*ast.AliasNode]any
-	anchorValueMap       map[string]reflect.Value
-	customUnmarshalerMap map[reflect.Type]func(interface{}, []byte) error
-	toCommentMap         CommentMap
-	opts                 []DecodeOption
-	referenceFiles       []string
-	referenceDirs        []string
-	isRecursiveDir       bool
-	isResolvedReference  bool
-	validator            StructValidator
-	disallowUnknownField bool
-	allowDuplicateMapKey bool
-	useOrderedMap        bool
-	useJSONUnmarshaler   bool
-	parsedFile           *ast.File
-	streamIndex          int
-	decodeDepth          int
+	reader                     io.Reader
+	referenceReaders           []io.Reader
+	anchorNodeMap              map[string]ast.Node
+	aliasValueMap              map[*ast.AliasNode]any
+	aliasAnchorMap             map[*ast.AliasNode]ast.Node
+	anchorValueMap             map[ast.Node]reflect.Value
+	customUnmarshalerMap       map[reflect.Type]func(interface{}, []byte) error
+	customNodeUnmarshalerMap   map[reflect.Type]func(interface{}, ast.Node) error
+	toCommentMap               CommentMap
+	toSourceMap                SourceMap
+	opts                       []DecodeOption
+	referenceFiles             []string
+	referenceDirs              []string
+	referenceFS                []referenceFS
+	isRecursiveDir             bool
+	isResolvedReference        bool
+	validator                  StructValidator
+	disallowUnknownField       bool
+	allowDuplicateMapKey       bool
+	duplicateKeyCallback       func(path string, firstTk, dupTk *token.Token)
+	typeSelectors              []*typeSelector
+	disallowMergeKeyOverride   bool
+	disallowAnchorRedefinition bool
+	allErrors                  bool
+	subNode                    *subNodeDecoding
+	continueOnError            bool
+	useProtoJSON               bool
+	caseInsensitiveKeys        bool
+	honorJSONTagOptions        bool
+	maxDocumentBytes           int64
+	disallowNULByte            bool
+	inputEncoding              parser.Encoding
+	useOrderedMap              bool
+	useNumber                  bool
+	strictNumberConversion     bool
+	nullDoesNotOverwrite       bool
+	scalarExpansion            func(string, string) (string, error)
+	includeLoader              IncludeLoader
+	useJSONUnmarshaler         bool
+	useBinaryUnmarshaler       bool
+	tracer                     Tracer
+	redactor                   *Redactor
+	redactedValues             []string
+	errorPrinter               *ErrorPrinter
+	plainScalarResolver        func(string) (string, bool)
+	respectVersionDirective    bool
+	isVersionResolver          bool
+	decodeRune                 bool
+	skipOrigins                bool
+	skippedOrigins             []*skippedOrigins
+	parsedFile                 *ast.File
+	documentRanges             []*documentRange
+	directiveComments          map[*ast.DocumentNode][]*ast.CommentGroupNode
+	lastDocumentRange          *documentRange
+	stats                      ast.Stats
+	streamIndex                int
+	decodeDepth                int
 }
 
 // NewDecoder returns a new decoder that reads from r.
 func NewDecoder(r io.Reader, opts ...DecodeOption) *Decoder {
 	return &Decoder{
-		reader:               r,
-		anchorNodeMap:        map[string]ast.Node{},
-		aliasValueMap:        make(map[*ast.AliasNode]any),
-		anchorValueMap:       map[string]reflect.Value{},
-		customUnmarshalerMap: map[reflect.Type]func(interface{}, []byte) error{},
-		opts:                 opts,
-		referenceReaders:     []io.Reader{},
-		referenceFiles:       []string{},
-		referenceDirs:        []string{},
-		isRecursiveDir:       false,
-		isResolvedReference:  false,
-		disallowUnknownField: false,
-		allowDuplicateMapKey: false,
-		useOrderedMap:        false,
+		reader:                   r,
+		anchorNodeMap:            map[string]ast.Node{},
+		aliasValueMap:            make(map[*ast.AliasNode]any),
+		aliasAnchorMap:           map[*ast.AliasNode]ast.Node{},
+		anchorValueMap:           map[ast.Node]reflect.Value{},
+		customUnmarshalerMap:     map[reflect.Type]func(interface{}, []byte) error{},
+		customNodeUnmarshalerMap: map[reflect.Type]func(interface{}, ast.Node) error{},
+		opts:                     opts,
+		referenceReaders:         []io.Reader{},
+		referenceFiles:           []string{},
+		referenceDirs:            []string{},
+		isRecursiveDir:           false,
+		isResolvedReference:      false,
+		disallowUnknownField:     false,
+		allowDuplicateMapKey:     false,
+		useOrderedMap:            false,
 	}
 }
 
@@ -279,6 +324,13 @@ func (d *Decoder) addSequenceNodeCommentToMap(node *ast.SequenceNode) {
 			}
 		}
 	}
+	if len(node.ValueBlankLines) == len(node.Values) {
+		for idx, blankLine := range node.ValueBlankLines {
+			if blankLine {
+				d.addCommentToMap(node.Values[idx].GetPath(), BlankLine())
+			}
+		}
+	}
 	firstElemHeadComment := node.GetComment()
 	if firstElemHeadComment != nil {
 		texts := make([]string, 0, len(firstElemHeadComment.Comments))
@@ -325,6 +377,32 @@ func (d *Decoder) addFootCommentToMap(node ast.Node) {
 	}
 }
 
+// addDocumentCommentToMap adds the head comment and the foot comment of doc to the root path.
+// The head comments of the directives of doc are added before the head comment of doc.
+func (d *Decoder) addDocumentCommentToMap(doc *ast.DocumentNode) {
+	if d.toCommentMap == nil {
+		return
+	}
+	commentTexts := func(groups ...*ast.CommentGroupNode) []string {
+		var texts []string
+		for _, group := range groups {
+			if group == nil {
+				continue
+			}
+			for _, comment := range group.Comments {
+				texts = append(texts, comment.Token.Value)
+			}
+		}
+		return texts
+	}
+	if texts := commentTexts(append(d.directiveComments[doc], doc.HeadComment)...); len(texts) != 0 {
+		d.addCommentToMap("$", HeadComment(texts...))
+	}
+	if texts := commentTexts(doc.FootComment); len(texts) != 0 {
+		d.addCommentToMap("$", FootComment(texts...))
+	}
+}
+
 func (d *Decoder) addCommentToMap(path string, comment *Comment) {
 	for _, c := range d.toCommentMap[path] {
 		if c.Position == comment.Position {
@@ -346,14 +424,31 @@ func (d *Decoder) nodeToValue(node ast.Node) (any, error) {
 	}
 
 	d.setPathToCommentMap(node)
+	if d.plainScalarResolver != nil {
+		if v, resolved, err := d.resolvePlainScalar(node); resolved {
+			return v, err
+		}
+	}
 	switch n := node.(type) {
 	case *ast.NullNode:
 		return nil, nil
 	case *ast.StringNode:
+		if d.useNumber && n.Token.Type == token.StringType {
+			// the integers out of the range of uint64 are tokenized as plain strings.
+			if _, err := Number(n.Value).BigInt(); err == nil {
+				return Number(n.Value), nil
+			}
+		}
 		return n.GetValue(), nil
 	case *ast.IntegerNode:
+		if d.useNumber {
+			return Number(n.Token.Value), nil
+		}
 		return n.GetValue(), nil
 	case *ast.FloatNode:
+		if d.useNumber {
+			return Number(n.Token.Value), nil
+		}
 		return n.GetValue(), nil
 	case *ast.BoolNode:
 		return n.GetValue(), nil
@@ -374,15 +469,14 @@ func (d *Decoder) nodeToValue(node ast.Node) (any, error) {
 		}
 		switch token.ReservedTagKeyword(n.Start.Value) {
 		case token.TimestampTag:
-			t, _ := d.castToTime(n.Value)
+			t, _ := d.castToTime(context.Background(), n.Value)
 			return t, nil
 		case token.IntegerTag:
 			v, err := d.nodeToValue(n.Value)
 			if err != nil {
 				return nil, err
 			}
-			i, _ := strconv.Atoi(fmt.Sprint(v))
-			return i, nil
+			return parseInteger(fmt.Sprint(v), n.Value.GetToken())
 		case token.FloatTag:
 			v, err := d.nodeToValue(n.Value)
 			if err != nil {
@@ -456,7 +550,7 @@ func (d *Decoder) nodeToValue(node ast.Node) (any, error) {
 		d.aliasValueMap[n] = nil
 
 		aliasName := n.Value.GetToken().Value
-		node, exists := d.anchorNodeMap[aliasName]
+		node, exists := d.anchorOf(n)
 		if !exists {
 			return nil, errors.ErrSyntax(fmt.Sprintf("could not find alias %q", aliasName), n.Value.GetToken())
 		}
@@ -542,6 +636,88 @@ func (d *Decoder) nodeToValue(node ast.Node) (any, error) {
 	return nil, nil
 }
 
+// resolvePlainScalar resolves the value of the plain ( not quoted ) scalar node by PlainScalarResolver.
+// The second return value reports whether the resolver decided the tag for the node.
+func (d *Decoder) resolvePlainScalar(node ast.Node) (any, bool, error) {
+	if _, ok := node.(ast.ScalarNode); !ok {
+		return nil, false, nil
+	}
+	switch node.Type() {
+	case ast.LiteralType, ast.MergeKeyType, ast.AnchorType, ast.AliasType, ast.TagType:
+		return nil, false, nil
+	}
+	tk := node.GetToken()
+	if tk == nil {
+		return nil, false, nil
+	}
+	switch tk.Type {
+	case token.SingleQuoteType, token.DoubleQuoteType:
+		return nil, false, nil
+	}
+	tag, ok := d.plainScalarResolver(tk.Value)
+	if !ok {
+		return nil, false, nil
+	}
+	value := tk.Value
+	switch token.ReservedTagKeyword(tag) {
+	case token.StringTag:
+		return value, true, nil
+	case token.NullTag:
+		return nil, true, nil
+	case token.BooleanTag:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			// YAML 1.1 booleans
+			switch strings.ToLower(value) {
+			case "y", "yes", "on":
+				return true, true, nil
+			case "n", "no", "off":
+				return false, true, nil
+			}
+			return nil, true, errors.ErrSyntax(fmt.Sprintf("cannot convert %q to boolean", value), tk)
+		}
+		return b, true, nil
+	case token.IntegerTag:
+		i, err := parseInteger(value, tk)
+		return i, true, err
+	case token.FloatTag:
+		switch strings.ToLower(value) {
+		case ".inf", "+.inf":
+			return math.Inf(0), true, nil
+		case "-.inf":
+			return math.Inf(-1), true, nil
+		case ".nan":
+			return math.NaN(), true, nil
+		}
+		f, err := strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64)
+		if err != nil {
+			return nil, true, errors.ErrSyntax(fmt.Sprintf("cannot convert %q to float", value), tk)
+		}
+		return f, true, nil
+	case token.TimestampTag:
+		for _, format := range allowedTimestampFormats {
+			if t, err := time.Parse(format, value); err == nil {
+				return t, true, nil
+			}
+		}
+		return nil, true, errors.ErrSyntax(fmt.Sprintf("cannot convert %q to timestamp", value), tk)
+	}
+	return nil, true, errors.ErrSyntax(fmt.Sprintf("unsupported tag %q for plain scalar", tag), tk)
+}
+
+// parseInteger parses the value of the integer as int64, or uint64 if it's larger than math.MaxInt64.
+func parseInteger(value string, tk *token.Token) (any, error) {
+	text := strings.ReplaceAll(value, "_", "")
+	i, err := strconv.ParseInt(text, 0, 64)
+	if err != nil {
+		if u, err := strconv.ParseUint(strings.TrimPrefix(text, "+"), 0, 64); err == nil {
+			return u, nil
+		}
+		return nil, errors.ErrSyntax(fmt.Sprintf("cannot convert %q to integer", value), tk)
+	}
+	return i, nil
+}
+
 func (d *Decoder) resolveAlias(node ast.Node) (ast.Node, error) {
 	d.stepIn()
 	defer d.stepOut()
@@ -601,10 +777,9 @@ func (d *Decoder) resolveAlias(node ast.Node) (ast.Node, error) {
 			n.Values[idx] = value
 		}
 	case *ast.AliasNode:
-		aliasName := n.Value.GetToken().Value
-		node := d.anchorNodeMap[aliasName]
+		node, _ := d.anchorOf(n)
 		if node == nil {
-			return nil, fmt.Errorf("cannot find anchor by alias name %s", aliasName)
+			return nil, fmt.Errorf("cannot find anchor by alias name %s", n.Value.GetToken().Value)
 		}
 		return d.resolveAlias(node)
 	}
@@ -626,12 +801,16 @@ func (d *Decoder) getMapNode(node ast.Node, isMerge bool) (ast.MapNode, error) {
 		d.anchorNodeMap[anchorName] = n.Value
 		return d.getMapNode(n.Value, isMerge)
 	case *ast.AliasNode:
-		aliasName := n.Value.GetToken().Value
-		node := d.anchorNodeMap[aliasName]
+		node, _ := d.anchorOf(n)
 		if node == nil {
-			return nil, fmt.Errorf("cannot find anchor by alias name %s", aliasName)
+			return nil, fmt.Errorf("cannot find anchor by alias name %s", n.Value.GetToken().Value)
 		}
 		return d.getMapNode(node, isMerge)
+	case *ast.TagNode:
+		switch token.ReservedTagKeyword(n.Start.Value) {
+		case token.MappingTag, token.SetTag:
+			return d.getMapNode(n.Value, isMerge)
+		}
 	case *ast.SequenceNode:
 		if !isMerge {
 			return nil, errors.ErrUnexpectedNodeType(node.Type(), ast.MappingType, node.GetToken())
@@ -649,6 +828,23 @@ func (d *Decoder) getMapNode(node ast.Node, isMerge bool) (ast.MapNode, error) {
 	return nil, errors.ErrUnexpectedNodeType(node.Type(), ast.MappingType, node.GetToken())
 }
 
+// isNullNode returns whether node is null or tagged null.
+// The null scalar is not regarded as null if PlainScalarResolver resolves it to the other tag.
+func (d *Decoder) isNullNode(node ast.Node) bool {
+	if tag, ok := node.(*ast.TagNode); ok && token.ReservedTagKeyword(tag.Start.Value) == token.NullTag {
+		return true
+	}
+	if node.Type() != ast.NullType {
+		return false
+	}
+	if d.plainScalarResolver != nil {
+		if v, resolved, err := d.resolvePlainScalar(node); resolved && (err != nil || v != nil) {
+			return false
+		}
+	}
+	return true
+}
+
 func (d *Decoder) getArrayNode(node ast.Node) (ast.ArrayNode, error) {
 	d.stepIn()
 	defer d.stepOut()
@@ -659,7 +855,16 @@ func (d *Decoder) getArrayNode(node ast.Node) (ast.ArrayNode, error) {
 	if _, ok := node.(*ast.NullNode); ok {
 		return nil, nil
 	}
+	if tag, ok := node.(*ast.TagNode); ok {
+		switch token.ReservedTagKeyword(tag.Start.Value) {
+		case token.SequenceTag, token.OrderedMapTag, token.PairsTag:
+			return d.getArrayNode(tag.Value)
+		}
+	}
 	if anchor, ok := node.(*ast.AnchorNode); ok {
+		if _, ok := anchor.Value.(*ast.TagNode); ok {
+			return d.getArrayNode(anchor.Value)
+		}
 		arrayNode, ok := anchor.Value.(ast.ArrayNode)
 		if ok {
 			return arrayNode, nil
@@ -668,10 +873,12 @@ func (d *Decoder) getArrayNode(node ast.Node) (ast.ArrayNode, error) {
 		return nil, errors.ErrUnexpectedNodeType(anchor.Value.Type(), ast.SequenceType, node.GetToken())
 	}
 	if alias, ok := node.(*ast.AliasNode); ok {
-		aliasName := alias.Value.GetToken().Value
-		node := d.anchorNodeMap[aliasName]
+		node, _ := d.anchorOf(alias)
 		if node == nil {
-			return nil, fmt.Errorf("cannot find anchor by alias name %s", aliasName)
+			return nil, fmt.Errorf("cannot find anchor by alias name %s", alias.Value.GetToken().Value)
+		}
+		if _, ok := node.(*ast.TagNode); ok {
+			return d.getArrayNode(node)
 		}
 		arrayNode, ok := node.(ast.ArrayNode)
 		if ok {
@@ -729,7 +936,11 @@ func (d *Decoder) deleteStructKeys(structType reflect.Type, unknownFields map[st
 	if structType.Kind() == reflect.Ptr {
 		structType = structType.Elem()
 	}
-	structFieldMap, err := structFieldMap(structType)
+	if structType.Kind() != reflect.Struct {
+		// the inline map doesn't declare the keys.
+		return nil
+	}
+	structFieldMap, err := structFieldMap(structType, d.honorJSONTagOptions)
 	if err != nil {
 		return err
 	}
@@ -748,12 +959,207 @@ func (d *Decoder) deleteStructKeys(structType reflect.Type, unknownFields map[st
 		if structField.IsInline {
 			_ = d.deleteStructKeys(field.Type, unknownFields)
 		} else {
-			delete(unknownFields, structField.RenderName)
+			d.deleteStructKey(unknownFields, structField)
+		}
+	}
+	return nil
+}
+
+// inlineKeyToNodeMap returns the keys passed to the inline fields, which don't match the fields not inline.
+func (d *Decoder) inlineKeyToNodeMap(structFieldMap StructFieldMap, keyToNodeMap map[string]ast.Node) map[string]ast.Node {
+	inlineKeyToNodeMap := make(map[string]ast.Node, len(keyToNodeMap))
+	for k, v := range keyToNodeMap {
+		inlineKeyToNodeMap[k] = v
+	}
+	for _, structField := range structFieldMap {
+		if !structField.IsInline {
+			d.deleteStructKey(inlineKeyToNodeMap, structField)
+		}
+	}
+	return inlineKeyToNodeMap
+}
+
+// validateInlineConflict returns an error if the key matches both the field of the struct and the field of the inline struct,
+// because the field of the inline struct is left empty.
+func (d *Decoder) validateInlineConflict(structType reflect.Type, structFieldMap StructFieldMap, keyToNodeMap, inlineKeyToNodeMap, keyNodes map[string]ast.Node) error {
+	claimedKeys := map[string]ast.Node{}
+	for k, v := range keyToNodeMap {
+		if _, exists := inlineKeyToNodeMap[k]; !exists {
+			claimedKeys[k] = v
+		}
+	}
+	if len(claimedKeys) == 0 {
+		return nil
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if isIgnoredStructField(field) {
+			continue
+		}
+		structField := structFieldMap[field.Name]
+		if !structField.IsInline || structField.IsAutoAlias || isInlineMapType(field.Type) {
+			continue
+		}
+		remainingKeys := make(map[string]ast.Node, len(claimedKeys))
+		for k, v := range claimedKeys {
+			remainingKeys[k] = v
+		}
+		if err := d.deleteStructKeys(field.Type, remainingKeys); err != nil {
+			return err
+		}
+		var conflicts []string
+		for k := range claimedKeys {
+			if _, exists := remainingKeys[k]; !exists {
+				conflicts = append(conflicts, k)
+			}
+		}
+		if len(conflicts) == 0 {
+			continue
 		}
+		sort.Slice(conflicts, func(i, j int) bool {
+			return nodePositionLess(keyNodes[conflicts[i]], keyNodes[conflicts[j]])
+		})
+		key := conflicts[0]
+		return errors.ErrSyntax(
+			fmt.Sprintf(`key "%s" matches both a field of %s and a field of the inline %s.%s`, key, structType.Name(), structType.Name(), field.Name),
+			keyNodes[key].GetToken(),
+		)
 	}
 	return nil
 }
 
+// inlineMapKeys assigns the keys which don't match any field to the inline maps by the field name.
+// If the struct has several inline maps, each key is assigned to the first map in the field order which can decode the value,
+// and the last map receives the keys no other map can decode.
+func (d *Decoder) inlineMapKeys(ctx context.Context, structType reflect.Type, structFieldMap StructFieldMap, inlineKeyToNodeMap map[string]ast.Node) map[string]map[string]ast.Node {
+	var mapFields []reflect.StructField
+	restKeys := make(map[string]ast.Node, len(inlineKeyToNodeMap))
+	for k, v := range inlineKeyToNodeMap {
+		restKeys[k] = v
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if isIgnoredStructField(field) {
+			continue
+		}
+		structField := structFieldMap[field.Name]
+		if !structField.IsInline || structField.IsAutoAlias {
+			continue
+		}
+		if isInlineMapType(field.Type) {
+			mapFields = append(mapFields, field)
+			continue
+		}
+		_ = d.deleteStructKeys(field.Type, restKeys)
+	}
+	if len(mapFields) == 0 {
+		return nil
+	}
+	inlineMapKeys := make(map[string]map[string]ast.Node, len(mapFields))
+	for _, field := range mapFields {
+		inlineMapKeys[field.Name] = map[string]ast.Node{}
+	}
+	for _, k := range sortedKeysByPosition(restKeys) {
+		v := restKeys[k]
+		assigned := mapFields[len(mapFields)-1]
+		for _, field := range mapFields[:len(mapFields)-1] {
+			typ := field.Type
+			if typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			if _, err := d.createDecodedNewValue(ctx, typ.Elem(), reflect.Value{}, v); err == nil {
+				assigned = field
+				break
+			}
+		}
+		inlineMapKeys[assigned.Name][k] = v
+	}
+	return inlineMapKeys
+}
+
+// isInlineMapType returns whether the type of the inline field is a map or a pointer to a map.
+func isInlineMapType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Map
+}
+
+// sortedKeysByPosition returns the keys of keyToNodeMap in the order of the nodes in the document.
+func sortedKeysByPosition(keyToNodeMap map[string]ast.Node) []string {
+	keys := make([]string, 0, len(keyToNodeMap))
+	for k := range keyToNodeMap {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := keyToNodeMap[keys[i]], keyToNodeMap[keys[j]]
+		if nodePositionLess(ki, kj) || nodePositionLess(kj, ki) {
+			return nodePositionLess(ki, kj)
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// lookupStructKey returns the node of the key matching the name of the struct field.
+// If CaseInsensitiveKeys is specified or the field follows encoding/json by HonorJSONTagOptions,
+// and there is no exact match, the key equal to the name under case-folding is used,
+// and the last one in the document wins if there are several keys.
+func (d *Decoder) lookupStructKey(keyToNodeMap map[string]ast.Node, structField *StructField) (ast.Node, bool) {
+	name := structField.RenderName
+	if node, exists := keyToNodeMap[name]; exists {
+		return node, true
+	}
+	if d.useProtoJSON && structField.protoJSONName != "" {
+		// protojson accepts both the name in the proto file and the lowerCamelCase name.
+		if node, exists := keyToNodeMap[structField.protoJSONName]; exists {
+			return node, true
+		}
+	}
+	if !d.isCaseInsensitiveField(structField) {
+		return nil, false
+	}
+	var found ast.Node
+	for key, node := range keyToNodeMap {
+		if !strings.EqualFold(key, name) {
+			continue
+		}
+		if found == nil || nodePositionLess(found, node) {
+			found = node
+		}
+	}
+	return found, found != nil
+}
+
+// deleteStructKey deletes the keys matching the name of the struct field from the unknown fields.
+func (d *Decoder) deleteStructKey(unknownFields map[string]ast.Node, structField *StructField) {
+	name := structField.RenderName
+	delete(unknownFields, name)
+	if d.useProtoJSON && structField.protoJSONName != "" {
+		delete(unknownFields, structField.protoJSONName)
+	}
+	if !d.isCaseInsensitiveField(structField) {
+		return
+	}
+	for key := range unknownFields {
+		if strings.EqualFold(key, name) {
+			delete(unknownFields, key)
+		}
+	}
+}
+
+func (d *Decoder) isCaseInsensitiveField(structField *StructField) bool {
+	return d.caseInsensitiveKeys || structField.IsJSON
+}
+
+func nodePositionLess(a, b ast.Node) bool {
+	at, bt := a.GetToken(), b.GetToken()
+	if at == nil || bt == nil {
+		return bt != nil
+	}
+	return positionLess(at.Position, bt.Position)
+}
+
 func (d *Decoder) lastNode(node ast.Node) ast.Node {
 	switch n := node.(type) {
 	case *ast.MappingNode:
@@ -770,18 +1176,191 @@ func (d *Decoder) lastNode(node ast.Node) ast.Node {
 	return node
 }
 
+// unmarshalableDocument returns the self-contained document of node passed to BytesUnmarshaler.
+// The aliases in node are replaced with the copies of the anchored values,
+// so the returned document can be parsed without the rest of the source.
 func (d *Decoder) unmarshalableDocument(node ast.Node) ([]byte, error) {
-	var err error
-	node, err = d.resolveAlias(node)
+	column := 1
+	if _, isAlias := node.(*ast.AliasNode); !isAlias {
+		if len(ast.Filter(ast.AliasType, node)) == 0 {
+			// node is self-contained, so it's not necessary to parse it again.
+			return d.nodeDocument(node), nil
+		}
+		if tk := startToken(node); tk != nil && tk.Position != nil {
+			column = tk.Position.Column
+		}
+	}
+	node, err := d.expandedNode(node, column, false)
 	if err != nil {
 		return nil, err
 	}
+	return d.nodeDocument(node), nil
+}
+
+// nodeDocument returns the text of node ending with the line break of the last block scalar.
+func (d *Decoder) nodeDocument(node ast.Node) []byte {
 	doc := node.String()
 	last := d.lastNode(node)
 	if last != nil && last.Type() == ast.LiteralType {
 		doc += "\n"
 	}
-	return []byte(doc), nil
+	return []byte(doc)
+}
+
+// standaloneDocument returns the canonical standalone document of just node passed to the unmarshaler specified by CustomUnmarshaler.
+// Unlike unmarshalableDocument, the document starts at the first column regardless of the position of node,
+// like the value in the nested sequence, and doesn't have the anchor and the comments of node.
+func (d *Decoder) standaloneDocument(node ast.Node) ([]byte, error) {
+	node, err := d.expandedNode(unanchoredNode(node), 1, false)
+	if err != nil {
+		return nil, err
+	}
+	if tag, ok := node.(*ast.TagNode); ok {
+		tag.Value = unanchoredNode(tag.Value)
+	}
+	doc, err := ast.Render(node, ast.RenderOptions{})
+	if err != nil {
+		return nil, err
+	}
+	last := d.lastNode(node)
+	if last != nil && last.Type() == ast.LiteralType {
+		doc = append(doc, '\n')
+	}
+	return doc, nil
+}
+
+// unanchoredNode returns the value of node without the anchor.
+func unanchoredNode(node ast.Node) ast.Node {
+	for {
+		anchor, ok := node.(*ast.AnchorNode)
+		if !ok {
+			return node
+		}
+		node = anchor.Value
+	}
+}
+
+// expandedNode returns the copy of node placed at column in which the aliases are expanded.
+// If flow is true, the collections in the copy use the flow style.
+// The original node is not modified.
+func (d *Decoder) expandedNode(node ast.Node, column int, flow bool) (ast.Node, error) {
+	d.stepIn()
+	defer d.stepOut()
+	if d.isExceededMaxDepth() {
+		return nil, ErrExceededMaxDepth
+	}
+
+	if alias, ok := node.(*ast.AliasNode); ok {
+		anchor, _ := d.anchorOf(alias)
+		if anchor == nil {
+			return nil, fmt.Errorf("cannot find anchor by alias name %s", alias.Value.GetToken().Value)
+		}
+		node = anchor
+	}
+	src := node.String()
+	if last := d.lastNode(node); last != nil && last.Type() == ast.LiteralType {
+		src += "\n"
+	}
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var copied ast.Node
+	for _, doc := range f.Docs {
+		if doc.Body != nil {
+			copied = doc.Body
+			break
+		}
+	}
+	if copied == nil {
+		return node, nil
+	}
+	copied, err = d.expandAliases(copied, map[string]ast.Node{}, flow)
+	if err != nil {
+		return nil, err
+	}
+	if flow {
+		copied = flowStyleNode(copied)
+	}
+	if tk := startToken(copied); tk != nil && tk.Position != nil {
+		copied.AddColumn(column - tk.Position.Column)
+	}
+	return copied, nil
+}
+
+// expandAliases replaces the aliases in node with the copies of the anchored values.
+// anchors keeps the anchors defined in the copied document, which take precedence over the anchors of the decoder.
+func (d *Decoder) expandAliases(node ast.Node, anchors map[string]ast.Node, flow bool) (ast.Node, error) {
+	expand := func(value ast.Node, column int, flow bool) (ast.Node, error) {
+		alias, ok := value.(*ast.AliasNode)
+		if !ok {
+			return d.expandAliases(value, anchors, flow)
+		}
+		if anchor, exists := anchors[alias.Value.GetToken().Value]; exists {
+			return d.expandedNode(anchor, column, flow)
+		}
+		return d.expandedNode(alias, column, flow)
+	}
+	var err error
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			if _, err := d.expandAliases(value, anchors, flow || n.IsFlowStyle); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.MappingValueNode:
+		column := n.Key.GetToken().Position.Column + DefaultIndentSpaces
+		if n.Value, err = expand(n.Value, column, flow); err != nil {
+			return nil, err
+		}
+	case *ast.SequenceNode:
+		column := n.Start.Position.Column + DefaultIndentSpaces
+		for idx, value := range n.Values {
+			if n.Values[idx], err = expand(value, column, flow || n.IsFlowStyle); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.TagNode:
+		if n.Value, err = expand(n.Value, n.Start.Position.Column, flow); err != nil {
+			return nil, err
+		}
+	case *ast.AnchorNode:
+		if n.Value, err = expand(n.Value, n.Start.Position.Column, flow); err != nil {
+			return nil, err
+		}
+		anchors[n.Name.GetToken().Value] = n.Value
+	case *ast.AliasNode:
+		return expand(n, n.Start.Position.Column, flow)
+	}
+	return node, nil
+}
+
+// flowStyleNode makes the collections in node use the flow style.
+// Block scalars cannot appear in the flow style, so they are replaced with double quoted strings.
+func flowStyleNode(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		n.IsFlowStyle = true
+		for _, value := range n.Values {
+			flowStyleNode(value)
+		}
+	case *ast.MappingValueNode:
+		n.Value = flowStyleNode(n.Value)
+	case *ast.SequenceNode:
+		n.IsFlowStyle = true
+		for idx, value := range n.Values {
+			n.Values[idx] = flowStyleNode(value)
+		}
+	case *ast.TagNode:
+		n.Value = flowStyleNode(n.Value)
+	case *ast.AnchorNode:
+		n.Value = flowStyleNode(n.Value)
+	case *ast.LiteralNode:
+		value := strconv.Quote(n.Value.Value)
+		return ast.String(token.New(value, value, n.Start.Position))
+	}
+	return node
 }
 
 func (d *Decoder) unmarshalableText(node ast.Node) ([]byte, bool, error) {
@@ -801,12 +1380,57 @@ func (d *Decoder) unmarshalableText(node ast.Node) ([]byte, bool, error) {
 	default:
 		scalar, ok := n.(ast.ScalarNode)
 		if ok {
+			if d.plainScalarResolver != nil {
+				if v, resolved, err := d.resolvePlainScalar(n); resolved {
+					if err != nil {
+						return nil, false, err
+					}
+					return []byte(fmt.Sprint(v)), true, nil
+				}
+			}
 			return []byte(fmt.Sprint(scalar.GetValue())), true, nil
 		}
 	}
 	return nil, false, nil
 }
 
+// unmarshalableBinary returns the bytes decoded from the base64 string of the node,
+// which is written with or without the !!binary tag.
+func (d *Decoder) unmarshalableBinary(dst reflect.Value, node ast.Node) ([]byte, error) {
+	var err error
+	node, err = d.resolveAlias(node)
+	if err != nil {
+		return nil, err
+	}
+	if node.Type() == ast.AnchorType {
+		node = node.(*ast.AnchorNode).Value
+	}
+	if tag, ok := node.(*ast.TagNode); ok && tag.Start.Value == string(token.BinaryTag) {
+		node = tag.Value
+	}
+	var text string
+	switch n := node.(type) {
+	case *ast.StringNode:
+		text = n.Value
+	case *ast.LiteralNode:
+		text = n.Value.Value
+	case ast.ScalarNode:
+		// the base64 string like 1234 is parsed as the number.
+		text = n.GetToken().Value
+	default:
+		v, err := d.nodeToValue(node)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.ErrTypeMismatch(dst.Type(), reflect.TypeOf(v), node.GetToken())
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	if err != nil {
+		return nil, errors.ErrSyntax(fmt.Sprintf("cannot decode %q as base64: %s", text, err), node.GetToken())
+	}
+	return b, nil
+}
+
 type jsonUnmarshaler interface {
 	UnmarshalJSON([]byte) error
 }
@@ -815,6 +1439,9 @@ func (d *Decoder) existsTypeInCustomUnmarshalerMap(t reflect.Type) bool {
 	if _, exists := d.customUnmarshalerMap[t]; exists {
 		return true
 	}
+	if _, exists := d.customNodeUnmarshalerMap[t]; exists {
+		return true
+	}
 
 	globalCustomUnmarshalerMu.Lock()
 	defer globalCustomUnmarshalerMu.Unlock()
@@ -844,6 +1471,10 @@ func (d *Decoder) canDecodeByUnmarshaler(dst reflect.Value) bool {
 	}
 	iface := ptrValue.Interface()
 	switch iface.(type) {
+	case UnmarshalerFrom:
+		return true
+	case AnchorAwareUnmarshaler:
+		return true
 	case BytesUnmarshalerContext:
 		return true
 	case BytesUnmarshaler:
@@ -852,22 +1483,49 @@ func (d *Decoder) canDecodeByUnmarshaler(dst reflect.Value) bool {
 		return true
 	case InterfaceUnmarshaler:
 		return true
-	case *time.Time:
+	case NodeUnmarshalerContext:
+		return true
+	case NodeUnmarshaler:
+		return true
+	case *time.Time:
 		return true
 	case *time.Duration:
 		return true
 	case encoding.TextUnmarshaler:
 		return true
 	case jsonUnmarshaler:
-		return d.useJSONUnmarshaler
+		if d.useJSONUnmarshaler {
+			return true
+		}
 	}
-	return false
+	if _, ok := iface.(encoding.BinaryUnmarshaler); ok && d.useBinaryUnmarshaler {
+		return true
+	}
+	return isTimeStructType(dst.Type())
+}
+
+// anchorNameOf returns the name of the anchor referred by the alias node or defined by the anchor node,
+// and the node of the value without the anchor.
+func anchorNameOf(node ast.Node) (string, ast.Node) {
+	switch n := node.(type) {
+	case *ast.AliasNode:
+		return n.Value.GetToken().Value, n
+	case *ast.AnchorNode:
+		return n.Name.GetToken().Value, n.Value
+	}
+	return "", node
 }
 
 func (d *Decoder) decodeByUnmarshaler(ctx context.Context, dst reflect.Value, src ast.Node) error {
 	ptrValue := dst.Addr()
+	if unmarshaler, exists := d.customNodeUnmarshalerMap[ptrValue.Type()]; exists {
+		if err := unmarshaler(ptrValue.Interface(), src); err != nil {
+			return err
+		}
+		return nil
+	}
 	if unmarshaler, exists := d.unmarshalerFromCustomUnmarshalerMap(ptrValue.Type()); exists {
-		b, err := d.unmarshalableDocument(src)
+		b, err := d.standaloneDocument(src)
 		if err != nil {
 			return err
 		}
@@ -878,6 +1536,27 @@ func (d *Decoder) decodeByUnmarshaler(ctx context.Context, dst reflect.Value, sr
 	}
 	iface := ptrValue.Interface()
 
+	if unmarshaler, ok := iface.(UnmarshalerFrom); ok {
+		sub := *d
+		sub.subNode = &subNodeDecoding{node: src}
+		if err := unmarshaler.UnmarshalYAMLFrom(&sub); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if unmarshaler, ok := iface.(AnchorAwareUnmarshaler); ok {
+		name, value := anchorNameOf(src)
+		b, err := d.unmarshalableDocument(value)
+		if err != nil {
+			return err
+		}
+		if err := unmarshaler.UnmarshalYAMLWithAnchor(name, b); err != nil {
+			return err
+		}
+		return nil
+	}
+
 	if unmarshaler, ok := iface.(BytesUnmarshalerContext); ok {
 		b, err := d.unmarshalableDocument(src)
 		if err != nil {
@@ -932,8 +1611,22 @@ func (d *Decoder) decodeByUnmarshaler(ctx context.Context, dst reflect.Value, sr
 		return nil
 	}
 
-	if _, ok := iface.(*time.Time); ok {
-		return d.decodeTime(ctx, dst, src)
+	if unmarshaler, ok := iface.(NodeUnmarshalerContext); ok {
+		if err := unmarshaler.UnmarshalYAML(ctx, src); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if unmarshaler, ok := iface.(NodeUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalYAML(src); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if tv, ok := timeValue(dst); ok {
+		return d.decodeTime(ctx, tv, src)
 	}
 
 	if _, ok := iface.(*time.Duration); ok {
@@ -955,16 +1648,17 @@ func (d *Decoder) decodeByUnmarshaler(ctx context.Context, dst reflect.Value, sr
 
 	if d.useJSONUnmarshaler {
 		if unmarshaler, ok := iface.(jsonUnmarshaler); ok {
-			b, err := d.unmarshalableDocument(src)
-			if err != nil {
-				return err
-			}
-			jsonBytes, err := YAMLToJSON(b)
+			return d.decodeByJSONUnmarshaler(unmarshaler, src)
+		}
+	}
+
+	if d.useBinaryUnmarshaler {
+		if unmarshaler, ok := iface.(encoding.BinaryUnmarshaler); ok {
+			b, err := d.unmarshalableBinary(dst, src)
 			if err != nil {
 				return err
 			}
-			jsonBytes = bytes.TrimRight(jsonBytes, "\n")
-			if err := unmarshaler.UnmarshalJSON(jsonBytes); err != nil {
+			if err := unmarshaler.UnmarshalBinary(b); err != nil {
 				return err
 			}
 			return nil
@@ -974,22 +1668,49 @@ func (d *Decoder) decodeByUnmarshaler(ctx context.Context, dst reflect.Value, sr
 	return errors.New("does not implemented Unmarshaler")
 }
 
+// decodeByJSONUnmarshaler decodes src by UnmarshalJSON with the JSON text converted from src.
+func (d *Decoder) decodeByJSONUnmarshaler(unmarshaler jsonUnmarshaler, src ast.Node) error {
+	b, err := d.unmarshalableDocument(src)
+	if err != nil {
+		return err
+	}
+	jsonBytes, err := YAMLToJSON(b)
+	if err != nil {
+		return err
+	}
+	jsonBytes = bytes.TrimRight(jsonBytes, "\n")
+	if err := unmarshaler.UnmarshalJSON(jsonBytes); err != nil {
+		return err
+	}
+	return nil
+}
+
 var (
 	astNodeType = reflect.TypeOf((*ast.Node)(nil)).Elem()
 )
 
 func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.Node) error {
+	if d.tracer != nil {
+		defer d.traceNodeDecoded(src, dst.Type(), time.Now())
+	}
 	d.stepIn()
 	defer d.stepOut()
 	if d.isExceededMaxDepth() {
 		return ErrExceededMaxDepth
 	}
 
-	if src.Type() == ast.AnchorType {
-		anchorName := src.(*ast.AnchorNode).Name.GetToken().Value
-		if _, exists := d.anchorValueMap[anchorName]; !exists {
-			d.anchorValueMap[anchorName] = dst
+	if anchor, ok := src.(*ast.AnchorNode); ok {
+		// the anchor redefined later overrides the previous one for the following aliases.
+		anchorName := anchor.Name.GetToken().Value
+		d.anchorNodeMap[anchorName] = anchor.Value
+		d.anchorValueMap[anchor.Value] = dst
+	}
+	if setter, ok := presentValue(dst); ok {
+		value := setter.setPresent(d.isNullNode(src))
+		if d.isNullNode(src) {
+			return nil
 		}
+		return d.decodeValue(ctx, value, src)
 	}
 	if d.canDecodeByUnmarshaler(dst) {
 		if err := d.decodeByUnmarshaler(ctx, dst, src); err != nil {
@@ -997,17 +1718,26 @@ func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.No
 		}
 		return nil
 	}
+	if d.useProtoJSON {
+		if ok, err := d.decodeProtoJSON(ctx, dst, src); ok {
+			return err
+		}
+	}
 	valueType := dst.Type()
 	switch valueType.Kind() {
 	case reflect.Ptr:
-		if dst.IsNil() {
+		if dst.IsNil() && !dst.CanSet() {
 			return nil
 		}
-		if src.Type() == ast.NullType {
+		if d.isNullNode(src) {
 			// set nil value to pointer
 			dst.Set(reflect.Zero(valueType))
 			return nil
 		}
+		if !dst.IsNil() {
+			// decode into the value pointed to like encoding/json.
+			return d.decodeValue(ctx, dst.Elem(), src)
+		}
 		v := d.createDecodableValue(dst.Type())
 		if err := d.decodeValue(ctx, v, src); err != nil {
 			return err
@@ -1017,17 +1747,40 @@ func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.No
 			return err
 		}
 		dst.Set(castedValue)
+		return nil
 	case reflect.Interface:
 		if dst.Type() == astNodeType {
 			dst.Set(reflect.ValueOf(src))
 			return nil
 		}
+		selectedType, err := d.selectType(valueType, src)
+		if err != nil {
+			return err
+		}
+		if selectedType != nil {
+			v, err := d.createDecodedNewValue(ctx, selectedType, reflect.Value{}, src)
+			if err != nil {
+				return err
+			}
+			dst.Set(v)
+			return nil
+		}
+		if !dst.IsNil() && !d.isNullNode(src) {
+			// decode into the value pointed to by the interface like encoding/json,
+			// so that the unmarshaler implemented with the pointer receiver is used.
+			if elem := dst.Elem(); elem.Kind() == reflect.Ptr && !elem.IsNil() {
+				return d.decodeValue(ctx, elem.Elem(), src)
+			}
+		}
 		srcVal, err := d.nodeToValue(src)
 		if err != nil {
 			return err
 		}
 		v := reflect.ValueOf(srcVal)
 		if v.IsValid() {
+			if !v.Type().AssignableTo(valueType) {
+				return errors.ErrTypeMismatch(valueType, v.Type(), src.GetToken())
+			}
 			dst.Set(v)
 		}
 	case reflect.Map:
@@ -1038,6 +1791,19 @@ func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.No
 		if mapSlice, ok := dst.Addr().Interface().(*MapSlice); ok {
 			return d.decodeMapSlice(ctx, mapSlice, src)
 		}
+		if d.isRuneDecoding(ctx) && valueType.Elem().Kind() == reflect.Int32 {
+			if runes, ok, err := d.nodeToRunes(src); ok {
+				if err != nil {
+					return err
+				}
+				slice := reflect.MakeSlice(valueType, len(runes), len(runes))
+				for i, r := range runes {
+					slice.Index(i).SetInt(int64(r))
+				}
+				dst.Set(slice)
+				return nil
+			}
+		}
 		return d.decodeSlice(ctx, dst, src)
 	case reflect.Struct:
 		if mapItem, ok := dst.Addr().Interface().(*MapItem); ok {
@@ -1061,12 +1827,30 @@ func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.No
 				return nil
 			}
 		case float64:
+			if d.strictNumberConversion && vv != math.Trunc(vv) {
+				return errors.ErrPrecision(valueType, fmt.Sprint(v), src.GetToken())
+			}
 			if vv <= math.MaxInt64 && !dst.OverflowInt(int64(vv)) {
 				dst.SetInt(int64(vv))
 				return nil
 			}
-		case string: // handle scientific notation
+		case string:
+			if d.isRuneDecoding(ctx) && valueType.Kind() == reflect.Int32 {
+				runes := []rune(vv)
+				if len(runes) != 1 {
+					return errors.ErrSyntax(
+						fmt.Sprintf("cannot decode %q into rune: it must be a single character", vv),
+						src.GetToken(),
+					)
+				}
+				dst.SetInt(int64(runes[0]))
+				return nil
+			}
+			// handle scientific notation
 			if i, err := strconv.ParseFloat(vv, 64); err == nil {
+				if d.strictNumberConversion && i != math.Trunc(i) {
+					return errors.ErrPrecision(valueType, vv, src.GetToken())
+				}
 				if 0 <= i && i <= math.MaxUint64 && !dst.OverflowInt(int64(i)) {
 					dst.SetInt(int64(i))
 					return nil
@@ -1095,12 +1879,18 @@ func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.No
 				return nil
 			}
 		case float64:
+			if d.strictNumberConversion && vv != math.Trunc(vv) {
+				return errors.ErrPrecision(valueType, fmt.Sprint(v), src.GetToken())
+			}
 			if 0 <= vv && vv <= math.MaxUint64 && !dst.OverflowUint(uint64(vv)) {
 				dst.SetUint(uint64(vv))
 				return nil
 			}
 		case string: // handle scientific notation
 			if i, err := strconv.ParseFloat(vv, 64); err == nil {
+				if d.strictNumberConversion && i != math.Trunc(i) {
+					return errors.ErrPrecision(valueType, vv, src.GetToken())
+				}
 				if 0 <= i && i <= math.MaxUint64 && !dst.OverflowUint(uint64(i)) {
 					dst.SetUint(uint64(i))
 					return nil
@@ -1120,6 +1910,11 @@ func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.No
 	}
 	v := reflect.ValueOf(srcVal)
 	if v.IsValid() {
+		if d.strictNumberConversion {
+			if err := d.validateFloatConversion(v, dst.Type(), src); err != nil {
+				return err
+			}
+		}
 		convertedValue, err := d.convertValue(v, dst.Type(), src)
 		if err != nil {
 			return err
@@ -1129,6 +1924,145 @@ func (d *Decoder) decodeValue(ctx context.Context, dst reflect.Value, src ast.No
 	return nil
 }
 
+// validateFloatConversion returns an error if the number v is not represented exactly by the float type typ.
+// The float64 value is regarded as exact in float32 if it has the same shortest decimal representation.
+func (d *Decoder) validateFloatConversion(v reflect.Value, typ reflect.Type, src ast.Node) error {
+	if typ.Kind() != reflect.Float32 && typ.Kind() != reflect.Float64 {
+		return nil
+	}
+	var f *big.Float
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = new(big.Float).SetInt64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = new(big.Float).SetUint64(v.Uint())
+	case reflect.String:
+		// handle scientific notation
+		num, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return nil
+		}
+		return d.validateFloatConversion(reflect.ValueOf(num), typ, src)
+	case reflect.Float64:
+		if typ.Kind() != reflect.Float32 {
+			return nil
+		}
+		num := v.Float()
+		if math.IsInf(num, 0) || math.IsNaN(num) {
+			return nil
+		}
+		if math.Abs(num) > math.MaxFloat32 {
+			return errors.ErrOverflow(typ, fmt.Sprint(num), src.GetToken())
+		}
+		text := strconv.FormatFloat(float64(float32(num)), 'g', -1, 32)
+		if f, _ := strconv.ParseFloat(text, 64); f != num {
+			return errors.ErrPrecision(typ, fmt.Sprint(num), src.GetToken())
+		}
+		return nil
+	default:
+		return nil
+	}
+	accuracy := big.Exact
+	if typ.Kind() == reflect.Float32 {
+		_, accuracy = f.Float32()
+	} else {
+		_, accuracy = f.Float64()
+	}
+	if accuracy != big.Exact {
+		return errors.ErrPrecision(typ, fmt.Sprint(v.Interface()), src.GetToken())
+	}
+	return nil
+}
+
+// isRuneDecoding returns whether the string scalars are decoded into the rune values
+// by DecodeRune or the rune option of the struct field.
+func (d *Decoder) isRuneDecoding(ctx context.Context) bool {
+	return d.decodeRune || isRuneFromContext(ctx)
+}
+
+// nodeToRunes converts the string scalar node to []rune.
+// The second return value reports whether the node is a string scalar.
+func (d *Decoder) nodeToRunes(src ast.Node) ([]rune, bool, error) {
+	switch src.Type() {
+	case ast.StringType, ast.LiteralType, ast.AliasType, ast.AnchorType, ast.TagType:
+	default:
+		return nil, false, nil
+	}
+	v, err := d.nodeToValue(src)
+	if err != nil {
+		return nil, true, err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, false, nil
+	}
+	return []rune(s), true, nil
+}
+
+// typeSelector selects the type to decode the mapping into the interface by the value of the key.
+type typeSelector struct {
+	key   string
+	types map[string]reflect.Type
+}
+
+// selectType returns the type of the value of the key in the mapping specified by TypeSelector,
+// which implements the interface type iface.
+// If no selector is applied to src, nil is returned.
+func (d *Decoder) selectType(iface reflect.Type, src ast.Node) (reflect.Type, error) {
+	if iface.NumMethod() == 0 || len(d.typeSelectors) == 0 || d.isNullNode(src) {
+		return nil, nil
+	}
+	mapNode, err := d.getMapNode(src, false)
+	if err != nil {
+		return nil, nil
+	}
+	for _, selector := range d.typeSelectors {
+		if !selector.isAppliedTo(iface) {
+			continue
+		}
+		iter := mapNode.MapRange()
+		for iter.Next() {
+			key, err := d.nodeToValue(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			if key != selector.key {
+				continue
+			}
+			value, err := d.nodeToValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			name, ok := value.(string)
+			if !ok {
+				return nil, errors.ErrTypeMismatch(reflect.TypeOf(""), reflect.TypeOf(value), iter.Value().GetToken())
+			}
+			typ, exists := selector.types[name]
+			if !exists {
+				return nil, errors.ErrSyntax(fmt.Sprintf("unknown %s %q for %s", selector.key, name, iface), iter.Value().GetToken())
+			}
+			if !typ.Implements(iface) {
+				typ = reflect.PtrTo(typ)
+			}
+			if !typ.Implements(iface) {
+				return nil, errors.ErrTypeMismatch(iface, typ.Elem(), iter.Value().GetToken())
+			}
+			return typ, nil
+		}
+	}
+	return nil, nil
+}
+
+// isAppliedTo returns whether any of the types or the pointers to them implements iface.
+func (s *typeSelector) isAppliedTo(iface reflect.Type) bool {
+	for _, typ := range s.types {
+		if typ.Implements(iface) || reflect.PtrTo(typ).Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *Decoder) createDecodableValue(typ reflect.Type) reflect.Value {
 	for {
 		if typ.Kind() == reflect.Ptr {
@@ -1165,22 +2099,21 @@ func (d *Decoder) castToAssignableValue(value reflect.Value, target reflect.Type
 func (d *Decoder) createDecodedNewValue(
 	ctx context.Context, typ reflect.Type, defaultVal reflect.Value, node ast.Node,
 ) (reflect.Value, error) {
-	if node.Type() == ast.AliasType {
-		aliasName := node.(*ast.AliasNode).Value.GetToken().Value
-		value := d.anchorValueMap[aliasName]
+	if alias, ok := node.(*ast.AliasNode); ok {
+		anchor, exists := d.anchorOf(alias)
+		value := d.anchorValueMap[anchor]
 		if value.IsValid() {
 			v, err := d.castToAssignableValue(value, typ, node)
 			if err == nil {
 				return v, nil
 			}
 		}
-		anchor, exists := d.anchorNodeMap[aliasName]
 		if exists {
 			node = anchor
 		}
 	}
 	var newValue reflect.Value
-	if node.Type() == ast.NullType {
+	if d.isNullNode(node) {
 		newValue = reflect.New(typ).Elem()
 	} else {
 		newValue = d.createDecodableValue(typ)
@@ -1191,9 +2124,18 @@ func (d *Decoder) createDecodedNewValue(
 	if defaultVal.IsValid() && defaultVal.Type().AssignableTo(newValue.Type()) {
 		newValue.Set(defaultVal)
 	}
-	if node.Type() != ast.NullType {
+	if _, ok := presentValue(newValue); ok || !d.isNullNode(node) {
+		// Present is decoded from the null value to report that the key is present.
 		if err := d.decodeValue(ctx, newValue, node); err != nil {
-			return reflect.Value{}, err
+			if !d.isSkippedElementError(err) {
+				return reflect.Value{}, err
+			}
+			// the value is decoded except the elements skipped by ContinueOnError.
+			v, castErr := d.castToAssignableValue(newValue, typ, node)
+			if castErr != nil {
+				return reflect.Value{}, castErr
+			}
+			return v, err
 		}
 	}
 	return d.castToAssignableValue(newValue, typ, node)
@@ -1212,6 +2154,7 @@ func (d *Decoder) keyToNodeMap(node ast.Node, ignoreMergeKey bool, getKeyOrValue
 	}
 	keyMap := map[string]struct{}{}
 	keyToNodeMap := map[string]ast.Node{}
+	var mergeMaps []map[string]ast.Node
 	mapIter := mapNode.MapRange()
 	for mapIter.Next() {
 		keyNode := mapIter.Key()
@@ -1219,16 +2162,11 @@ func (d *Decoder) keyToNodeMap(node ast.Node, ignoreMergeKey bool, getKeyOrValue
 			if ignoreMergeKey {
 				continue
 			}
-			mergeMap, err := d.keyToNodeMap(mapIter.Value(), ignoreMergeKey, getKeyOrValueNode)
+			maps, err := d.mergeKeyToNodeMaps(mapIter.Value(), getKeyOrValueNode)
 			if err != nil {
 				return nil, err
 			}
-			for k, v := range mergeMap {
-				if err := d.validateDuplicateKey(keyMap, k, v); err != nil {
-					return nil, err
-				}
-				keyToNodeMap[k] = v
-			}
+			mergeMaps = append(mergeMaps, maps...)
 		} else {
 			keyVal, err := d.nodeToValue(keyNode)
 			if err != nil {
@@ -1244,9 +2182,44 @@ func (d *Decoder) keyToNodeMap(node ast.Node, ignoreMergeKey bool, getKeyOrValue
 			keyToNodeMap[key] = getKeyOrValueNode(mapIter)
 		}
 	}
+	// the keys defined explicitly override the merged keys, and the mapping merged first takes precedence.
+	for _, mergeMap := range mergeMaps {
+		for k, v := range mergeMap {
+			if _, exists := keyToNodeMap[k]; !exists {
+				keyToNodeMap[k] = v
+			}
+		}
+	}
 	return keyToNodeMap, nil
 }
 
+// mergeKeyToNodeMaps returns the maps of the mappings merged by the value of the merge key,
+// which is the mapping or the sequence of the mappings.
+func (d *Decoder) mergeKeyToNodeMaps(node ast.Node, getKeyOrValueNode func(*ast.MapNodeIter) ast.Node) ([]map[string]ast.Node, error) {
+	if alias, ok := node.(*ast.AliasNode); ok {
+		if anchor, _ := d.anchorOf(alias); anchor != nil && anchor.Type() == ast.SequenceType {
+			node = anchor
+		}
+	}
+	seq, ok := node.(*ast.SequenceNode)
+	if !ok {
+		m, err := d.keyToNodeMap(node, false, getKeyOrValueNode)
+		if err != nil {
+			return nil, err
+		}
+		return []map[string]ast.Node{m}, nil
+	}
+	maps := make([]map[string]ast.Node, 0, len(seq.Values))
+	for _, value := range seq.Values {
+		m, err := d.keyToNodeMap(value, false, getKeyOrValueNode)
+		if err != nil {
+			return nil, err
+		}
+		maps = append(maps, m)
+	}
+	return maps, nil
+}
+
 func (d *Decoder) keyToKeyNodeMap(node ast.Node, ignoreMergeKey bool) (map[string]ast.Node, error) {
 	m, err := d.keyToNodeMap(node, ignoreMergeKey, func(nodeMap *ast.MapNodeIter) ast.Node { return nodeMap.Key() })
 	if err != nil {
@@ -1271,7 +2244,7 @@ func (d *Decoder) setDefaultValueIfConflicted(v reflect.Value, fieldMap StructFi
 	if typ.Kind() != reflect.Struct {
 		return nil
 	}
-	embeddedStructFieldMap, err := structFieldMap(typ)
+	embeddedStructFieldMap, err := structFieldMap(typ, d.honorJSONTagOptions)
 	if err != nil {
 		return err
 	}
@@ -1302,7 +2275,7 @@ var allowedTimestampFormats = []string{
 	"2006-1-2",                        // date only
 }
 
-func (d *Decoder) castToTime(src ast.Node) (time.Time, error) {
+func (d *Decoder) castToTime(ctx context.Context, src ast.Node) (time.Time, error) {
 	if src == nil {
 		return time.Time{}, nil
 	}
@@ -1310,12 +2283,15 @@ func (d *Decoder) castToTime(src ast.Node) (time.Time, error) {
 	if err != nil {
 		return time.Time{}, err
 	}
+	if layout := timeLayoutFromContext(ctx); layout != "" {
+		return castToTimeByLayout(src, v, layout)
+	}
 	if t, ok := v.(time.Time); ok {
 		return t, nil
 	}
 	s, ok := v.(string)
 	if !ok {
-		return time.Time{}, errors.ErrTypeMismatch(reflect.TypeOf(time.Time{}), reflect.TypeOf(v), src.GetToken())
+		return time.Time{}, errors.ErrTypeMismatch(timeType, reflect.TypeOf(v), src.GetToken())
 	}
 	for _, format := range allowedTimestampFormats {
 		t, err := time.Parse(format, s)
@@ -1328,12 +2304,37 @@ func (d *Decoder) castToTime(src ast.Node) (time.Time, error) {
 	return time.Time{}, nil
 }
 
+// castToTimeByLayout parses the text of the scalar by layout specified by the layout option.
+// The text is used as it is written even if it's resolved as the other type like the timestamp or the integer.
+func castToTimeByLayout(src ast.Node, v interface{}, layout string) (time.Time, error) {
+	if v == nil {
+		return time.Time{}, nil
+	}
+	text, ok := v.(string)
+	if !ok {
+		if _, isScalar := src.(ast.ScalarNode); !isScalar {
+			if t, ok := v.(time.Time); ok {
+				// the explicit timestamp like `!!timestamp 2006-01-02`.
+				return t, nil
+			}
+			return time.Time{}, errors.ErrTypeMismatch(timeType, reflect.TypeOf(v), src.GetToken())
+		}
+		text = src.GetToken().Value
+	}
+	t, err := time.Parse(layout, text)
+	if err != nil {
+		return time.Time{}, errors.ErrSyntax(fmt.Sprintf("cannot parse %q as time by the layout %q", text, layout), src.GetToken())
+	}
+	return t, nil
+}
+
+// decodeTime decodes src into dst whose type is convertible to time.Time.
 func (d *Decoder) decodeTime(ctx context.Context, dst reflect.Value, src ast.Node) error {
-	t, err := d.castToTime(src)
+	t, err := d.castToTime(ctx, src)
 	if err != nil {
 		return err
 	}
-	dst.Set(reflect.ValueOf(t))
+	dst.Set(reflect.ValueOf(t).Convert(dst.Type()))
 	return nil
 }
 
@@ -1368,21 +2369,52 @@ func (d *Decoder) decodeDuration(ctx context.Context, dst reflect.Value, src ast
 	return nil
 }
 
-// getMergeAliasName support single alias only
-func (d *Decoder) getMergeAliasName(src ast.Node) string {
+// getMergeAlias support single alias only
+func (d *Decoder) getMergeAlias(src ast.Node) *ast.AliasNode {
 	mapNode, err := d.getMapNode(src, true)
 	if err != nil {
-		return ""
+		return nil
 	}
 	mapIter := mapNode.MapRange()
 	for mapIter.Next() {
 		key := mapIter.Key()
 		value := mapIter.Value()
 		if key.IsMergeKey() && value.Type() == ast.AliasType {
-			return value.(*ast.AliasNode).Value.GetToken().Value
+			return value.(*ast.AliasNode)
+		}
+	}
+	return nil
+}
+
+// createDecodedFieldValue decodes the node into the new value of the struct field.
+// The field having the string option of the json tag by HonorJSONTagOptions is decoded from the JSON text in the string like encoding/json.
+func (d *Decoder) createDecodedFieldValue(ctx context.Context, structField *StructField, fieldValue reflect.Value, node ast.Node) (reflect.Value, error) {
+	typ := fieldValue.Type()
+	if structField.TimeLayout != "" {
+		ctx = withTimeLayout(ctx, structField.TimeLayout)
+	}
+	if structField.IsRune {
+		ctx = withRune(ctx)
+	}
+	if !structField.IsJSON || !structField.IsString || !isJSONStringOptionType(typ) || d.isNullNode(node) {
+		return d.createDecodedNewValue(ctx, typ, fieldValue, node)
+	}
+	v, err := d.nodeToValue(node)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	text, ok := v.(string)
+	if !ok {
+		return reflect.Value{}, errors.ErrTypeMismatch(typ, reflect.TypeOf(v), node.GetToken())
+	}
+	newValue := reflect.New(typ)
+	if err := jsonUnmarshal([]byte(text), newValue.Interface()); err != nil {
+		if errors.Is(err, errJSONNotSupported) {
+			return reflect.Value{}, err
 		}
+		return reflect.Value{}, errors.ErrTypeMismatch(typ, reflect.TypeOf(v), node.GetToken())
 	}
-	return ""
+	return newValue.Elem(), nil
 }
 
 func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.Node) error {
@@ -1407,10 +2439,11 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 		dst.Set(srcValue)
 		return nil
 	}
-	structFieldMap, err := structFieldMap(structType)
+	structFieldMap, err := structFieldMap(structType, d.honorJSONTagOptions)
 	if err != nil {
 		return err
 	}
+	setYAMLDefaults(dst)
 	ignoreMergeKey := structFieldMap.hasMergeProperty()
 	keyToNodeMap, err := d.keyToValueNodeMap(src, ignoreMergeKey)
 	if err != nil {
@@ -1424,9 +2457,18 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 		}
 	}
 
-	aliasName := d.getMergeAliasName(src)
+	mergeAlias := d.getMergeAlias(src)
 	var foundErr error
 
+	// the keys matching the fields which are not inline aren't passed to the inline fields, so the explicit fields win.
+	inlineKeyToNodeMap := d.inlineKeyToNodeMap(structFieldMap, keyToNodeMap)
+	if d.disallowUnknownField {
+		if err := d.validateInlineConflict(structType, structFieldMap, keyToNodeMap, inlineKeyToNodeMap, unknownFields); err != nil {
+			return err
+		}
+	}
+	inlineMapKeys := d.inlineMapKeys(ctx, structType, structFieldMap, inlineKeyToNodeMap)
+
 	for i := 0; i < structType.NumField(); i++ {
 		field := structType.Field(i)
 		if isIgnoredStructField(field) {
@@ -1436,10 +2478,11 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 		if structField.IsInline {
 			fieldValue := dst.FieldByName(field.Name)
 			if structField.IsAutoAlias {
-				if aliasName != "" {
-					newFieldValue := d.anchorValueMap[aliasName]
+				if mergeAlias != nil {
+					anchor, _ := d.anchorOf(mergeAlias)
+					newFieldValue := d.anchorValueMap[anchor]
 					if newFieldValue.IsValid() {
-						value, err := d.castToAssignableValue(newFieldValue, fieldValue.Type(), d.anchorNodeMap[aliasName])
+						value, err := d.castToAssignableValue(newFieldValue, fieldValue.Type(), anchor)
 						if err != nil {
 							return err
 						}
@@ -1451,29 +2494,37 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 			if !fieldValue.CanSet() {
 				return fmt.Errorf("cannot set embedded type as unexported field %s.%s", field.PkgPath, field.Name)
 			}
-			if fieldValue.Type().Kind() == reflect.Ptr && src.Type() == ast.NullType {
+			if fieldValue.Type().Kind() == reflect.Ptr && d.isNullNode(src) {
 				// set nil value to pointer
 				fieldValue.Set(reflect.Zero(fieldValue.Type()))
 				continue
 			}
+			fieldKeyToNodeMap := inlineKeyToNodeMap
+			if keys, exists := inlineMapKeys[field.Name]; exists {
+				fieldKeyToNodeMap = keys
+			}
 			mapNode := ast.Mapping(nil, false)
-			for k, v := range keyToNodeMap {
+			for _, k := range sortedKeysByPosition(fieldKeyToNodeMap) {
 				key := &ast.StringNode{BaseNode: &ast.BaseNode{}, Value: k}
-				mapNode.Values = append(mapNode.Values, ast.MappingValue(nil, key, v))
+				mapNode.Values = append(mapNode.Values, ast.MappingValue(nil, key, fieldKeyToNodeMap[k]))
 			}
 			newFieldValue, err := d.createDecodedNewValue(ctx, fieldValue.Type(), fieldValue, mapNode)
 			if d.disallowUnknownField {
-				if err := d.deleteStructKeys(fieldValue.Type(), unknownFields); err != nil {
+				if isInlineMapType(fieldValue.Type()) {
+					for k := range fieldKeyToNodeMap {
+						delete(unknownFields, k)
+					}
+				} else if err := d.deleteStructKeys(fieldValue.Type(), unknownFields); err != nil {
 					return err
 				}
 			}
 
 			if err != nil {
-				if foundErr != nil {
+				if foundErr != nil && !d.allErrors {
 					continue
 				}
 				var te *errors.TypeError
-				if errors.As(err, &te) {
+				if _, ok := err.(*errors.MultiError); !ok && errors.As(err, &te) {
 					if te.StructFieldName != nil {
 						fieldName := fmt.Sprintf("%s.%s", structType.Name(), *te.StructFieldName)
 						te.StructFieldName = &fieldName
@@ -1481,10 +2532,10 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 						fieldName := fmt.Sprintf("%s.%s", structType.Name(), field.Name)
 						te.StructFieldName = &fieldName
 					}
-					foundErr = te
+					foundErr = d.appendError(foundErr, te)
 					continue
 				} else {
-					foundErr = err
+					foundErr = d.appendError(foundErr, err)
 				}
 				continue
 			}
@@ -1492,44 +2543,75 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 			fieldValue.Set(newFieldValue)
 			continue
 		}
-		v, exists := keyToNodeMap[structField.RenderName]
+		v, exists := d.lookupStructKey(keyToNodeMap, structField)
 		if !exists {
+			if err := d.setFieldDefault(ctx, dst.FieldByName(field.Name), structField); err != nil {
+				foundErr = d.appendError(foundErr, fmt.Errorf("failed to set default value of %s.%s: %w", structType.Name(), field.Name, err))
+			}
 			continue
 		}
-		delete(unknownFields, structField.RenderName)
+		d.deleteStructKey(unknownFields, structField)
 		fieldValue := dst.FieldByName(field.Name)
-		if fieldValue.Type().Kind() == reflect.Ptr && src.Type() == ast.NullType {
+		if (d.nullDoesNotOverwrite || structField.IsKeepExisting) && d.isNullNode(v) {
+			// the null value means that the document has no opinion about the field.
+			continue
+		}
+		if fieldValue.Type().Kind() == reflect.Ptr && d.isNullNode(src) {
 			// set nil value to pointer
 			fieldValue.Set(reflect.Zero(fieldValue.Type()))
 			continue
 		}
-		newFieldValue, err := d.createDecodedNewValue(ctx, fieldValue.Type(), fieldValue, v)
+		newFieldValue, err := d.createDecodedFieldValue(ctx, structField, fieldValue, v)
 		if err != nil {
-			if foundErr != nil {
+			if newFieldValue.IsValid() {
+				fieldValue.Set(newFieldValue)
+			}
+			if foundErr != nil && !d.allErrors {
 				continue
 			}
 			var te *errors.TypeError
-			if errors.As(err, &te) {
-				fieldName := fmt.Sprintf("%s.%s", structType.Name(), field.Name)
+			fieldName := fmt.Sprintf("%s.%s", structType.Name(), field.Name)
+			if d.isSkippedElementError(err) {
+				setElementStructFieldName(err, fieldName)
+			} else if _, ok := err.(*errors.MultiError); !ok && errors.As(err, &te) {
 				te.StructFieldName = &fieldName
-				foundErr = te
-			} else {
-				foundErr = err
+				var mergedErr *errors.MergedValueError
+				if !errors.As(err, &mergedErr) {
+					// the merged value error is kept to report the positions of the alias and the anchor.
+					err = te
+				}
 			}
+			foundErr = d.appendError(foundErr, d.mergedValueError(src, v, err))
 			continue
 		}
 		fieldValue.Set(newFieldValue)
 	}
-	if foundErr != nil {
+	if foundErr != nil && !d.allErrors {
 		return foundErr
 	}
 
 	// Ignore unknown fields when parsing an inline struct (recognized by a nil token).
 	// Unknown fields are expected (they could be fields from the parent struct).
 	if len(unknownFields) != 0 && d.disallowUnknownField && src.GetToken() != nil {
-		for key, node := range unknownFields {
-			return errors.ErrUnknownField(fmt.Sprintf(`unknown field "%s"`, key), node.GetToken())
+		if !d.allErrors {
+			for key, node := range unknownFields {
+				return d.mergedValueError(src, node, errors.ErrUnknownField(fmt.Sprintf(`unknown field "%s"`, key), node.GetToken()))
+			}
+		}
+		keys := make([]string, 0, len(unknownFields))
+		for key := range unknownFields {
+			keys = append(keys, key)
 		}
+		sort.Slice(keys, func(i, j int) bool {
+			return positionLess(unknownFields[keys[i]].GetToken().Position, unknownFields[keys[j]].GetToken().Position)
+		})
+		for _, key := range keys {
+			node := unknownFields[key]
+			foundErr = d.appendError(foundErr, d.mergedValueError(src, node, errors.ErrUnknownField(fmt.Sprintf(`unknown field "%s"`, key), node.GetToken())))
+		}
+	}
+	if foundErr != nil {
+		return foundErr
 	}
 
 	if d.validator != nil {
@@ -1546,14 +2628,18 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 					if !exists {
 						continue
 					}
+					elem := sequenceElementFromContext(ctx)
 					node, exists := keyToNodeMap[structField.RenderName]
 					if exists {
 						// TODO: to make FieldError message cutomizable
-						return errors.ErrSyntax(fmt.Sprintf("%s", err), node.GetToken())
+						return d.sequenceElementError(elem, errors.ErrSyntax(fmt.Sprintf("%s", err), node.GetToken()))
+					} else if elem != nil && elem.node == src {
+						// A missing required field of the sequence element is associated with the entry of the element
+						return d.sequenceElementError(elem, errors.ErrSyntax(fmt.Sprintf("%s", err), sequenceEntryToken(elem.node)))
 					} else if t := src.GetToken(); t != nil && t.Prev != nil && t.Prev.Prev != nil {
 						// A missing required field will not be in the keyToNodeMap
 						// the error needs to be associated with the parent of the source node
-						return errors.ErrSyntax(fmt.Sprintf("%s", err), t.Prev.Prev)
+						return d.sequenceElementError(elem, errors.ErrSyntax(fmt.Sprintf("%s", err), t.Prev.Prev))
 					}
 				}
 			}
@@ -1563,41 +2649,217 @@ func (d *Decoder) decodeStruct(ctx context.Context, dst reflect.Value, src ast.N
 	return nil
 }
 
-func (d *Decoder) decodeArray(ctx context.Context, dst reflect.Value, src ast.Node) error {
-	d.stepIn()
-	defer d.stepOut()
-	if d.isExceededMaxDepth() {
-		return ErrExceededMaxDepth
+// setYAMLDefaults calls SetYAMLDefaults of the struct value if it implements DefaultsSetter.
+func setYAMLDefaults(v reflect.Value) {
+	if !v.CanAddr() {
+		return
 	}
-
-	arrayNode, err := d.getArrayNode(src)
-	if err != nil {
-		return err
+	if setter, ok := v.Addr().Interface().(DefaultsSetter); ok {
+		setter.SetYAMLDefaults()
 	}
-	if arrayNode == nil {
+}
+
+// setFieldDefault sets the default value to the field whose key is absent.
+// The default value specified by the tag is set only if the field has the zero value,
+// so the values set before decoding or by SetYAMLDefaults are kept.
+// The struct field without the default value gets the default values of its fields.
+func (d *Decoder) setFieldDefault(ctx context.Context, v reflect.Value, field *StructField) error {
+	if !v.CanSet() {
 		return nil
 	}
-	iter := arrayNode.ArrayRange()
-	arrayValue := reflect.New(dst.Type()).Elem()
-	arrayType := dst.Type()
-	elemType := arrayType.Elem()
-	idx := 0
-
-	var foundErr error
+	if field.HasDefault {
+		if !v.IsZero() {
+			return nil
+		}
+		file, err := parser.ParseBytes([]byte(field.DefaultValue), 0)
+		if err != nil {
+			return err
+		}
+		if len(file.Docs) == 0 || file.Docs[0].Body == nil {
+			return nil
+		}
+		newValue, err := d.createDecodedNewValue(ctx, v.Type(), reflect.Value{}, file.Docs[0].Body)
+		if err != nil {
+			return err
+		}
+		v.Set(newValue)
+		return nil
+	}
+	if v.Kind() == reflect.Struct {
+		return d.setDefaults(ctx, v)
+	}
+	return nil
+}
+
+// setDefaults sets the default values to the struct decoded without the mapping.
+func (d *Decoder) setDefaults(ctx context.Context, v reflect.Value) error {
+	if v.Type().Implements(astNodeType) || reflect.PtrTo(v.Type()).Implements(astNodeType) {
+		return nil
+	}
+	fieldMap, err := structFieldMap(v.Type(), d.honorJSONTagOptions)
+	if err != nil {
+		return err
+	}
+	setYAMLDefaults(v)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if isIgnoredStructField(field) {
+			continue
+		}
+		if err := d.setFieldDefault(ctx, v.Field(i), fieldMap[field.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendError returns the error reporting err in addition to found, the error found before in the same collection.
+// It keeps only the first error unless the decoder collects all errors by AllErrors.
+func (d *Decoder) appendError(found, err error) error {
+	if found == nil {
+		return err
+	}
+	if !d.allErrors {
+		return found
+	}
+	return errors.ErrMulti(found, err)
+}
+
+func positionLess(a, b *token.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
+
+// sequenceElementError wraps the field error of the struct decoded as the sequence element
+// to report the index and the start position of the element.
+func (d *Decoder) sequenceElementError(elem *sequenceElement, err error) error {
+	if elem == nil {
+		return err
+	}
+	return errors.ErrSequenceElementField(elem.index, startToken(elem.node), err)
+}
+
+// sequenceEntryToken returns the '-' token of the element of the block sequence, or the first token of node.
+func sequenceEntryToken(node ast.Node) *token.Token {
+	tk := startToken(node)
+	if tk != nil && tk.Prev != nil && tk.Prev.Type == token.SequenceEntryType {
+		return tk.Prev
+	}
+	return tk
+}
+
+// elementError returns err found in the element at idx of the sequence.
+// By ContinueOnError, err is reported with the index and the position of the element,
+// and each error of the elements skipped in the nested sequences is reported with idx as well.
+func (d *Decoder) elementError(idx int, node ast.Node, err error) error {
+	if !d.continueOnError || d.isOwnElementError(idx, node, err) {
+		return err
+	}
+	tk := startToken(node)
+	if multi, ok := err.(*errors.MultiError); ok {
+		errs := make([]error, 0, len(multi.Errors))
+		for _, e := range multi.Errors {
+			errs = append(errs, errors.ErrSequenceElement(idx, tk, e))
+		}
+		return errors.ErrMulti(errs...)
+	}
+	return errors.ErrSequenceElement(idx, tk, err)
+}
+
+// isOwnElementError returns whether err is already reported as the error of the element at idx, like the validation error.
+func (d *Decoder) isOwnElementError(idx int, node ast.Node, err error) bool {
+	se, ok := err.(*errors.SequenceElementError)
+	return ok && se.Index == idx && se.Token == startToken(node)
+}
+
+// keepsElement returns whether the element at idx decoded with err is kept in the sequence by ContinueOnError.
+// The element is kept if value is decoded except the elements skipped in the nested sequences.
+func (d *Decoder) keepsElement(idx int, node ast.Node, value reflect.Value, err error) bool {
+	return value.IsValid() && !d.isOwnElementError(idx, node, err)
+}
+
+// isSkippedElementError returns whether err reports only the sequence elements skipped by ContinueOnError,
+// so the value having the sequences is decoded except the elements.
+func (d *Decoder) isSkippedElementError(err error) bool {
+	if !d.continueOnError {
+		return false
+	}
+	if multi, ok := err.(*errors.MultiError); ok {
+		for _, e := range multi.Errors {
+			if _, ok := e.(*errors.SequenceElementError); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	_, ok := err.(*errors.SequenceElementError)
+	return ok
+}
+
+// setElementStructFieldName sets fieldName to the TypeErrors of the elements in err, reported by ContinueOnError,
+// unless they are found in the struct fields nested in the elements.
+func setElementStructFieldName(err error, fieldName string) {
+	errs := []error{err}
+	if multi, ok := err.(*errors.MultiError); ok {
+		errs = multi.Errors
+	}
+	for _, e := range errs {
+		var te *errors.TypeError
+		if errors.As(e, &te) && te.StructFieldName == nil {
+			te.StructFieldName = &fieldName
+		}
+	}
+}
+
+// startToken returns the first token of node.
+func startToken(node ast.Node) *token.Token {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if !n.IsFlowStyle && len(n.Values) != 0 {
+			return startToken(n.Values[0])
+		}
+	case *ast.MappingValueNode:
+		return startToken(n.Key)
+	}
+	return node.GetToken()
+}
+
+func (d *Decoder) decodeArray(ctx context.Context, dst reflect.Value, src ast.Node) error {
+	d.stepIn()
+	defer d.stepOut()
+	if d.isExceededMaxDepth() {
+		return ErrExceededMaxDepth
+	}
+
+	arrayNode, err := d.getArrayNode(src)
+	if err != nil {
+		return err
+	}
+	if arrayNode == nil {
+		return nil
+	}
+	iter := arrayNode.ArrayRange()
+	arrayValue := reflect.New(dst.Type()).Elem()
+	arrayType := dst.Type()
+	elemType := arrayType.Elem()
+	idx := 0
+
+	var foundErr error
 	for iter.Next() {
 		v := iter.Value()
-		if elemType.Kind() == reflect.Ptr && v.Type() == ast.NullType {
+		if elemType.Kind() == reflect.Ptr && d.isNullNode(v) {
 			// set nil value to pointer
 			arrayValue.Index(idx).Set(reflect.Zero(elemType))
 		} else {
-			dstValue, err := d.createDecodedNewValue(ctx, elemType, reflect.Value{}, v)
+			dstValue, err := d.createDecodedNewValue(withSequenceElement(ctx, idx, v), elemType, reflect.Value{}, v)
 			if err != nil {
-				if foundErr == nil {
-					foundErr = err
-				}
-				continue
+				foundErr = d.appendError(foundErr, d.elementError(idx, v, err))
+			}
+			if err == nil || d.keepsElement(idx, v, dstValue, err) {
+				arrayValue.Index(idx).Set(dstValue)
 			}
-			arrayValue.Index(idx).Set(dstValue)
 		}
 		idx++
 	}
@@ -1615,6 +2877,9 @@ func (d *Decoder) decodeSlice(ctx context.Context, dst reflect.Value, src ast.No
 		return ErrExceededMaxDepth
 	}
 
+	if d.collectionTag(src) == token.SetTag {
+		return d.decodeSetSlice(ctx, dst, src)
+	}
 	arrayNode, err := d.getArrayNode(src)
 	if err != nil {
 		return err
@@ -1622,25 +2887,28 @@ func (d *Decoder) decodeSlice(ctx context.Context, dst reflect.Value, src ast.No
 	if arrayNode == nil {
 		return nil
 	}
+	if ok, err := d.decodeSliceFast(ctx, dst, arrayNode); ok {
+		return err
+	}
 	iter := arrayNode.ArrayRange()
 	sliceType := dst.Type()
 	sliceValue := reflect.MakeSlice(sliceType, 0, iter.Len())
 	elemType := sliceType.Elem()
 
 	var foundErr error
-	for iter.Next() {
+	for idx := 0; iter.Next(); idx++ {
 		v := iter.Value()
-		if elemType.Kind() == reflect.Ptr && v.Type() == ast.NullType {
+		if elemType.Kind() == reflect.Ptr && d.isNullNode(v) {
 			// set nil value to pointer
 			sliceValue = reflect.Append(sliceValue, reflect.Zero(elemType))
 			continue
 		}
-		dstValue, err := d.createDecodedNewValue(ctx, elemType, reflect.Value{}, v)
+		dstValue, err := d.createDecodedNewValue(withSequenceElement(ctx, idx, v), elemType, reflect.Value{}, v)
 		if err != nil {
-			if foundErr == nil {
-				foundErr = err
+			foundErr = d.appendError(foundErr, d.elementError(idx, v, err))
+			if !d.keepsElement(idx, v, dstValue, err) {
+				continue
 			}
-			continue
 		}
 		sliceValue = reflect.Append(sliceValue, dstValue)
 	}
@@ -1700,6 +2968,229 @@ func (d *Decoder) validateDuplicateKey(keyMap map[string]struct{}, key interface
 	return nil
 }
 
+// validateAnchorRedefinition returns AnchorRedefinitionError if the same anchor name is defined more than once.
+func (d *Decoder) validateAnchorRedefinition(node ast.Node) error {
+	var err error
+	anchors := map[string]*token.Token{}
+	ast.Walk(anchorCollector(func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		anchor, ok := n.(*ast.AnchorNode)
+		if !ok {
+			return true
+		}
+		name := anchor.Name.GetToken().Value
+		if prev, exists := anchors[name]; exists {
+			err = errors.ErrAnchorRedefinition(name, prev, anchor.GetToken())
+			return false
+		}
+		anchors[name] = anchor.GetToken()
+		return true
+	}), node)
+	return err
+}
+
+// reportDuplicateKeys calls the callback specified by OnDuplicateKey for each key defined more than once in the same mapping.
+func (d *Decoder) reportDuplicateKeys(node ast.Node) {
+	if d.duplicateKeyCallback == nil {
+		return
+	}
+	ast.Walk(anchorCollector(func(n ast.Node) bool {
+		mapping, ok := n.(*ast.MappingNode)
+		if !ok {
+			return true
+		}
+		keys := map[string]*token.Token{}
+		for _, value := range mapping.Values {
+			if value.Key.IsMergeKey() {
+				continue
+			}
+			path := value.Key.GetPath()
+			if first, exists := keys[path]; exists {
+				d.duplicateKeyCallback(path, first, value.Key.GetToken())
+				continue
+			}
+			keys[path] = value.Key.GetToken()
+		}
+		return true
+	}), node)
+}
+
+// validateMergeKeyOverride returns MergeKeyOverrideError if a key merged by the merge key
+// is also defined explicitly in the same mapping.
+func (d *Decoder) validateMergeKeyOverride(node ast.Node) error {
+	var mappings []*ast.MappingNode
+	ast.Walk(anchorCollector(func(n ast.Node) bool {
+		if mapping, ok := n.(*ast.MappingNode); ok {
+			mappings = append(mappings, mapping)
+		}
+		return true
+	}), node)
+
+	for _, mapping := range mappings {
+		merged := map[string]*token.Token{}
+		for _, value := range mapping.Values {
+			if value.Key.IsMergeKey() {
+				if err := d.collectMergedKeys(merged, value.Value); err != nil {
+					return err
+				}
+			}
+		}
+		for _, value := range mapping.Values {
+			if value.Key.IsMergeKey() {
+				continue
+			}
+			key := value.Key.GetToken()
+			if mergedTk, exists := merged[key.Value]; exists {
+				return errors.ErrMergeKeyOverride(key.Value, mergedTk, key)
+			}
+		}
+	}
+	return nil
+}
+
+// mergedValueError returns the MergedValueError wrapping err if node is the key or the value
+// merged into the mapping src by the alias of the merge key. Otherwise, it returns err.
+func (d *Decoder) mergedValueError(src, node ast.Node, err error) error {
+	mapNode, mapErr := d.getMapNode(src, false)
+	if mapErr != nil {
+		return err
+	}
+	iter := mapNode.MapRange()
+	for iter.Next() {
+		if !iter.Key().IsMergeKey() {
+			continue
+		}
+		if wrapped := d.mergeValueError(iter.Value(), node, err); wrapped != err {
+			return wrapped
+		}
+	}
+	return err
+}
+
+// mergeValueError returns the MergedValueError wrapping err if node is merged by the value of the merge key,
+// which is the alias, the sequence of the aliases or the mapping. The innermost alias is reported
+// if the merged mapping also has the merge key, because its anchor defines node.
+func (d *Decoder) mergeValueError(mergeValue, node ast.Node, err error) error {
+	d.stepIn()
+	defer d.stepOut()
+	if d.isExceededMaxDepth() {
+		return err
+	}
+
+	switch n := mergeValue.(type) {
+	case *ast.AliasNode:
+		anchor, _ := d.anchorOf(n)
+		if anchor == nil {
+			return err
+		}
+		if wrapped := d.mergedValueError(anchor, node, err); wrapped != err {
+			return wrapped
+		}
+		if !d.hasMappingEntry(anchor, node) {
+			return err
+		}
+		name := n.Value.GetToken().Value
+		return errors.ErrMergedValue(name, n.GetToken(), d.anchorToken(anchor), err)
+	case *ast.SequenceNode:
+		for _, value := range n.Values {
+			if wrapped := d.mergeValueError(value, node, err); wrapped != err {
+				return wrapped
+			}
+		}
+	case *ast.AnchorNode:
+		return d.mergedValueError(n.Value, node, err)
+	case *ast.MappingNode, *ast.MappingValueNode:
+		return d.mergedValueError(n, node, err)
+	}
+	return err
+}
+
+// hasMappingEntry returns whether node is the key or the value of the entry of the mapping.
+func (d *Decoder) hasMappingEntry(mapping, node ast.Node) bool {
+	mapNode, err := d.getMapNode(mapping, false)
+	if err != nil {
+		return false
+	}
+	iter := mapNode.MapRange()
+	for iter.Next() {
+		if iter.Key() == node || iter.Value() == node {
+			return true
+		}
+	}
+	return false
+}
+
+// anchorToken returns the token of the anchor defining value, or nil if it's not found.
+func (d *Decoder) anchorToken(value ast.Node) *token.Token {
+	if d.parsedFile == nil {
+		return nil
+	}
+	var tk *token.Token
+	for _, doc := range d.parsedFile.Docs {
+		ast.Walk(anchorCollector(func(n ast.Node) bool {
+			if anchor, ok := n.(*ast.AnchorNode); ok && anchor.Value == value {
+				tk = anchor.GetToken()
+			}
+			return tk == nil
+		}), doc)
+	}
+	return tk
+}
+
+// collectMergedKeys collects the key tokens of the mapping merged by the value of the merge key.
+// If the same key is merged more than once, the first one is collected because it takes precedence.
+func (d *Decoder) collectMergedKeys(merged map[string]*token.Token, node ast.Node) error {
+	d.stepIn()
+	defer d.stepOut()
+	if d.isExceededMaxDepth() {
+		return ErrExceededMaxDepth
+	}
+
+	switch n := node.(type) {
+	case *ast.AliasNode:
+		if anchor, _ := d.anchorOf(n); anchor != nil {
+			return d.collectMergedKeys(merged, anchor)
+		}
+	case *ast.AnchorNode:
+		return d.collectMergedKeys(merged, n.Value)
+	case *ast.TagNode:
+		return d.collectMergedKeys(merged, n.Value)
+	case *ast.SequenceNode:
+		for _, value := range n.Values {
+			if err := d.collectMergedKeys(merged, value); err != nil {
+				return err
+			}
+		}
+	case *ast.MappingNode:
+		// the keys defined explicitly take precedence over the keys merged in the mapping.
+		for _, value := range n.Values {
+			if !value.Key.IsMergeKey() {
+				if err := d.collectMergedKeys(merged, value); err != nil {
+					return err
+				}
+			}
+		}
+		for _, value := range n.Values {
+			if value.Key.IsMergeKey() {
+				if err := d.collectMergedKeys(merged, value); err != nil {
+					return err
+				}
+			}
+		}
+	case *ast.MappingValueNode:
+		if n.Key.IsMergeKey() {
+			return d.collectMergedKeys(merged, n.Value)
+		}
+		key := n.Key.GetToken()
+		if _, exists := merged[key.Value]; !exists {
+			merged[key.Value] = key
+		}
+	}
+	return nil
+}
+
 func (d *Decoder) decodeMapSlice(ctx context.Context, dst *MapSlice, src ast.Node) error {
 	d.stepIn()
 	defer d.stepOut()
@@ -1707,6 +3198,9 @@ func (d *Decoder) decodeMapSlice(ctx context.Context, dst *MapSlice, src ast.Nod
 		return ErrExceededMaxDepth
 	}
 
+	if tag := d.collectionTag(src); isPairsTag(tag) {
+		return d.decodePairsMapSlice(ctx, dst, src, tag)
+	}
 	mapNode, err := d.getMapNode(src, isMerge(ctx))
 	if err != nil {
 		return err
@@ -1747,6 +3241,60 @@ func (d *Decoder) decodeMapSlice(ctx context.Context, dst *MapSlice, src ast.Nod
 	return nil
 }
 
+// createMapKey creates the map key of keyType from node.
+// The key is decoded by the unmarshaler of keyType (or of its element type if keyType is a pointer) if it exists.
+// A pointer key is allocated for each entry, so pointer keys are compared by address.
+// An interface key holds the decoded value only if it implements the interface.
+// The decoded key must be comparable, so the sequence and mapping keys cannot be decoded into interface keys.
+// It returns the invalid value for the null key.
+func (d *Decoder) createMapKey(ctx context.Context, keyType reflect.Type, node ast.Node) (reflect.Value, error) {
+	if keyNode, ok := node.(*ast.MappingKeyNode); ok {
+		node = keyNode.Value
+	}
+	k := d.createDecodableValue(keyType)
+	if d.canDecodeByUnmarshaler(k) {
+		if err := d.decodeByUnmarshaler(ctx, k, node); err != nil {
+			return reflect.Value{}, err
+		}
+	} else if k.Kind() == reflect.Struct || k.Kind() == reflect.Array {
+		// the complex key such as `? {a: 1}` or `? [1, 2]` is decoded like the value.
+		if err := d.decodeValue(ctx, k, node); err != nil {
+			return reflect.Value{}, err
+		}
+	} else {
+		keyVal, err := d.nodeToValue(node)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if keyVal == nil {
+			return reflect.Value{}, nil
+		}
+		v := reflect.ValueOf(keyVal)
+		switch {
+		case k.Kind() == reflect.Interface:
+			if !v.Type().Implements(k.Type()) {
+				return reflect.Value{}, errors.ErrTypeMismatch(keyType, v.Type(), node.GetToken())
+			}
+		case v.Type().ConvertibleTo(k.Type()):
+			v = v.Convert(k.Type())
+		default:
+			return reflect.Value{}, errors.ErrSyntax(
+				fmt.Sprintf("cannot convert %q type to %q type", v.Kind(), keyType.Kind()),
+				node.GetToken(),
+			)
+		}
+		k.Set(v)
+	}
+	if k.Kind() == reflect.Interface && !k.IsNil() && !k.Elem().Type().Comparable() {
+		return reflect.Value{}, errors.ErrSyntax(
+			fmt.Sprintf("cannot use %q type as map key because it is not comparable", k.Elem().Type()),
+			node.GetToken(),
+		)
+	}
+	// the key is decoded into the addressable temporary, so take its address for the pointer key type.
+	return d.castToAssignableValue(k, keyType, node)
+}
+
 func (d *Decoder) decodeMap(ctx context.Context, dst reflect.Value, src ast.Node) error {
 	d.stepIn()
 	defer d.stepOut()
@@ -1754,10 +3302,17 @@ func (d *Decoder) decodeMap(ctx context.Context, dst reflect.Value, src ast.Node
 		return ErrExceededMaxDepth
 	}
 
+	tag := d.collectionTag(src)
+	if isPairsTag(tag) {
+		return d.decodePairsMap(ctx, dst, src)
+	}
 	mapNode, err := d.getMapNode(src, isMerge(ctx))
 	if err != nil {
 		return err
 	}
+	if ok, err := d.decodeMapFast(ctx, dst, mapNode); ok {
+		return err
+	}
 	mapType := dst.Type()
 	mapValue := reflect.MakeMap(mapType)
 	keyType := mapValue.Type().Key()
@@ -1782,37 +3337,32 @@ func (d *Decoder) decodeMap(ctx context.Context, dst reflect.Value, src ast.Node
 			continue
 		}
 
-		k := d.createDecodableValue(keyType)
-		if d.canDecodeByUnmarshaler(k) {
-			if err := d.decodeByUnmarshaler(ctx, k, key); err != nil {
-				return err
-			}
-		} else {
-			keyVal, err := d.nodeToValue(key)
-			if err != nil {
-				return err
-			}
-			k = reflect.ValueOf(keyVal)
-			if k.IsValid() && k.Type().ConvertibleTo(keyType) {
-				k = k.Convert(keyType)
-			}
+		k, err := d.createMapKey(ctx, keyType, key)
+		if err != nil {
+			return err
 		}
-
 		if k.IsValid() {
 			if err := d.validateDuplicateKey(keyMap, k.Interface(), key); err != nil {
 				return err
 			}
 		}
-		if valueType.Kind() == reflect.Ptr && value.Type() == ast.NullType {
+		if valueType.Kind() == reflect.Ptr && d.isNullNode(value) {
 			// set nil value to pointer
 			mapValue.SetMapIndex(k, reflect.Zero(valueType))
 			continue
 		}
+		if tag == token.SetTag && k.IsValid() && valueType.Kind() == reflect.Bool && d.isNullNode(value) {
+			// the key of !!set is decoded as the member of map[T]bool.
+			mapValue.SetMapIndex(k, reflect.ValueOf(true).Convert(valueType))
+			continue
+		}
 		dstValue, err := d.createDecodedNewValue(ctx, valueType, reflect.Value{}, value)
 		if err != nil {
-			if foundErr == nil {
-				foundErr = err
+			if isMerge(ctx) {
+				// src is the value of the merge key.
+				err = d.mergeValueError(src, value, err)
 			}
+			foundErr = d.appendError(foundErr, err)
 		}
 		if !k.IsValid() {
 			// expect nil key
@@ -1891,6 +3441,54 @@ func (d *Decoder) readersUnderDirRecursive(dir string) ([]io.Reader, error) {
 	return readers, nil
 }
 
+// referenceFS is the files and the directories in fsys passed by ReferenceFS.
+type referenceFS struct {
+	fsys  fs.FS
+	paths []string
+}
+
+func (d *Decoder) readersUnderFS(ref referenceFS) ([]io.Reader, error) {
+	readers := []io.Reader{}
+	for _, name := range ref.paths {
+		name = path.Clean(strings.TrimSuffix(name, "/"))
+		info, err := fs.Stat(ref.fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			reader, err := ref.fsys.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			readers = append(readers, reader)
+			continue
+		}
+		if err := fs.WalkDir(ref.fsys, name, func(p string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if p != name && !d.isRecursiveDir {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.isYAMLFile(p) {
+				return nil
+			}
+			reader, err := ref.fsys.Open(p)
+			if err != nil {
+				return err
+			}
+			readers = append(readers, reader)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return readers, nil
+}
+
 func (d *Decoder) resolveReference() error {
 	for _, opt := range d.opts {
 		if err := opt(d); err != nil {
@@ -1919,6 +3517,13 @@ func (d *Decoder) resolveReference() error {
 			d.referenceReaders = append(d.referenceReaders, readers...)
 		}
 	}
+	for _, ref := range d.referenceFS {
+		readers, err := d.readersUnderFS(ref)
+		if err != nil {
+			return err
+		}
+		d.referenceReaders = append(d.referenceReaders, readers...)
+	}
 	for _, reader := range d.referenceReaders {
 		bytes, err := io.ReadAll(reader)
 		if err != nil {
@@ -1935,6 +3540,25 @@ func (d *Decoder) resolveReference() error {
 }
 
 func (d *Decoder) parse(bytes []byte) (*ast.File, error) {
+	file, _, err := d.parseDocuments(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// parseDocuments parses bytes and returns the source range of each document as well.
+func (d *Decoder) parseDocuments(bytes []byte) (*ast.File, []*documentRange, error) {
+	skipOrigins := d.skipOrigins && d.toCommentMap == nil && d.redactor == nil
+	f, ranges, err := d.parseDocumentsWithOrigins(bytes, skipOrigins)
+	if err != nil && skipOrigins {
+		// parse again with the origins to print the source in the error message.
+		return d.parseDocumentsWithOrigins(bytes, false)
+	}
+	return f, ranges, err
+}
+
+func (d *Decoder) parseDocumentsWithOrigins(bytes []byte, skipOrigins bool) (*ast.File, []*documentRange, error) {
 	var parseMode parser.Mode
 	if d.toCommentMap != nil {
 		parseMode = parser.ParseComments
@@ -1943,22 +3567,150 @@ func (d *Decoder) parse(bytes []byte) (*ast.File, error) {
 	if d.allowDuplicateMapKey {
 		opts = append(opts, parser.AllowDuplicateMapKey())
 	}
-	f, err := parser.ParseBytes(bytes, parseMode, opts...)
+	var start time.Time
+	if d.tracer != nil {
+		d.tracer.TokenizeStart(len(bytes))
+		start = time.Now()
+	}
+	var tokens token.Tokens
+	if skipOrigins {
+		tokens = lexer.TokenizeForDecode(string(bytes))
+	} else {
+		tokens = lexer.Tokenize(string(bytes))
+	}
+	if d.tracer != nil {
+		d.tracer.TokenizeEnd(len(tokens), time.Since(start))
+		d.tracer.ParseStart()
+		start = time.Now()
+	}
+	f, err := parser.Parse(tokens, parseMode, opts...)
+	if d.tracer != nil {
+		d.tracer.ParseEnd(time.Since(start), err)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if d.redactor != nil {
+		// the origins are masked after the ranges of the documents are computed from them.
+		defer func() {
+			d.redactedValues = append(d.redactedValues, d.redactor.redactOrigins(f)...)
+		}()
+	}
+	var tokenOffsetMap map[*token.Token]int
+	if !skipOrigins {
+		tokenOffsetMap = make(map[*token.Token]int, len(tokens))
+		var offset int
+		for _, tk := range tokens {
+			tokenOffsetMap[tk] = offset
+			offset += len(tk.Origin)
+		}
 	}
 	normalizedFile := &ast.File{}
+	var (
+		ranges            []*documentRange
+		directiveComments []*ast.CommentGroupNode
+	)
 	for _, doc := range f.Docs {
+		d.setDocumentVersion(doc)
+		if d.includeLoader != nil {
+			if _, err := newIncludeResolver(d.includeLoader).resolve(&includeFile{}, doc); err != nil {
+				return nil, nil, err
+			}
+		}
+		if d.scalarExpansion != nil {
+			if _, err := d.expandScalars(doc); err != nil {
+				return nil, nil, err
+			}
+		}
 		// try to decode ast.Node to value and map anchor value to anchorMap
 		v, err := d.nodeToValue(doc.Body)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if _, ok := doc.Body.(*ast.DirectiveNode); ok && doc.HeadComment != nil && d.toCommentMap != nil {
+			// the head comments of the directives are kept for the document following them.
+			directiveComments = append(directiveComments, doc.HeadComment)
 		}
 		if v != nil {
+			if len(directiveComments) != 0 {
+				if d.directiveComments == nil {
+					d.directiveComments = map[*ast.DocumentNode][]*ast.CommentGroupNode{}
+				}
+				d.directiveComments[doc] = directiveComments
+				directiveComments = nil
+			}
 			normalizedFile.Docs = append(normalizedFile.Docs, doc)
+			if skipOrigins {
+				// the range can't be computed without the origins.
+				ranges = append(ranges, nil)
+			} else {
+				ranges = append(ranges, newDocumentRange(bytes, doc, tokenOffsetMap))
+			}
+		}
+	}
+	if skipOrigins {
+		d.skippedOrigins = append(d.skippedOrigins, &skippedOrigins{src: bytes, tokens: tokens})
+	}
+	return normalizedFile, ranges, nil
+}
+
+// skippedOrigins is the source tokenized without the origins by SkipOrigins.
+// It's kept to restore the origins when an error refers to the tokens.
+type skippedOrigins struct {
+	src    []byte
+	tokens token.Tokens
+}
+
+// restoreOrigins tokenizes the sources tokenized by SkipOrigins again, and sets the origins of the tokens
+// and links the comments dropped from them, so the errors print the source excerpts as they are.
+func (d *Decoder) restoreOrigins() {
+	for _, skipped := range d.skippedOrigins {
+		var (
+			idx  int
+			prev *token.Token
+		)
+		for _, tk := range lexer.Tokenize(string(skipped.src)) {
+			if tk.Type != token.CommentType {
+				if idx >= len(skipped.tokens) {
+					break
+				}
+				skippedTk := skipped.tokens[idx]
+				idx++
+				if skippedTk.Position.Line != tk.Position.Line || skippedTk.Position.Column != tk.Position.Column {
+					// the tokens are expected to be the same except the comments and the origins.
+					break
+				}
+				skippedTk.Origin = tk.Origin
+				tk = skippedTk
+			}
+			// link the comment tokens between the skipped tokens, which the error printer follows by Prev and Next.
+			if prev != nil {
+				prev.Next = tk
+				tk.Prev = prev
+			}
+			prev = tk
 		}
 	}
-	return normalizedFile, nil
+	d.skippedOrigins = nil
+}
+
+// setDocumentVersion switches the implicit typing of the plain scalars to the rules of YAML 1.1
+// if doc declares it by the %YAML directive and RespectVersionDirective is specified.
+// The resolver specified by PlainScalarResolver takes precedence over the directive.
+func (d *Decoder) setDocumentVersion(doc *ast.DocumentNode) {
+	if !d.respectVersionDirective {
+		return
+	}
+	if d.plainScalarResolver != nil && !d.isVersionResolver {
+		return
+	}
+	if doc.Version() == "1.1" {
+		d.plainScalarResolver = resolver.YAML11().Resolve
+		d.isVersionResolver = true
+		return
+	}
+	d.plainScalarResolver = nil
+	d.isVersionResolver = false
 }
 
 func (d *Decoder) isInitialized() bool {
@@ -1971,27 +3723,117 @@ func (d *Decoder) decodeInit() error {
 			return err
 		}
 	}
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, d.reader); err != nil {
+	src, err := d.readInput()
+	if err != nil {
+		return err
+	}
+	src, err = d.toUTF8(src)
+	if err != nil {
 		return err
 	}
-	file, err := d.parse(buf.Bytes())
+	file, ranges, err := d.parseDocuments(src)
 	if err != nil {
 		return err
 	}
 	d.parsedFile = file
+	d.documentRanges = ranges
 	return nil
 }
 
+// toUTF8 converts the UTF-16 and UTF-32 input to UTF-8 by the encoding detected by the byte order mark
+// or specified by InputEncoding. The byte order mark is kept to keep the offsets of the input.
+func (d *Decoder) toUTF8(src []byte) ([]byte, error) {
+	enc := d.inputEncoding
+	if enc == parser.EncodingAuto {
+		enc = parser.DetectEncoding(src)
+	}
+	converted, err := parser.ToUTF8(src, enc)
+	if err != nil {
+		return nil, err
+	}
+	// the NUL bytes of UTF-8 are already checked by readInput.
+	if d.disallowNULByte && enc != parser.UTF8 {
+		if idx := bytes.IndexByte(converted, 0); idx >= 0 {
+			return nil, nulByteError(converted[:idx])
+		}
+	}
+	return converted, nil
+}
+
+// nulByteError returns the error of the NUL byte found after src.
+func nulByteError(src []byte) error {
+	pos := offsetToPosition(src, len(src))
+	return fmt.Errorf("found NUL byte at line %d, column %d: %w", pos.Line, pos.Column, ErrNULByte)
+}
+
+// readInput reads all the input from the reader.
+// The size of the input and the NUL byte are checked while reading by MaxDocumentBytes and DisallowNULByte,
+// so the large or binary input is rejected before it's buffered.
+func (d *Decoder) readInput() ([]byte, error) {
+	var buf bytes.Buffer
+	if d.maxDocumentBytes <= 0 && !d.disallowNULByte {
+		if _, err := io.Copy(&buf, d.reader); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	chunk := make([]byte, 32*1024)
+	enc := d.inputEncoding
+	for {
+		n, err := d.reader.Read(chunk)
+		if n > 0 {
+			data := chunk[:n]
+			if d.maxDocumentBytes > 0 && int64(buf.Len())+int64(n) > d.maxDocumentBytes {
+				return nil, fmt.Errorf("the input exceeds %d bytes: %w", d.maxDocumentBytes, ErrExceededMaxDocumentBytes)
+			}
+			if enc == parser.EncodingAuto && buf.Len() == 0 {
+				enc = parser.DetectEncoding(data)
+			}
+			// the NUL bytes of UTF-16 and UTF-32 are checked after converting them to UTF-8.
+			if d.disallowNULByte && enc == parser.UTF8 {
+				if idx := bytes.IndexByte(data, 0); idx >= 0 {
+					buf.Write(data[:idx])
+					return nil, nulByteError(buf.Bytes())
+				}
+			}
+			buf.Write(data)
+		}
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
 func (d *Decoder) decode(ctx context.Context, v reflect.Value) error {
 	d.decodeDepth = 0
 	if len(d.parsedFile.Docs) <= d.streamIndex {
 		return io.EOF
 	}
-	body := d.parsedFile.Docs[d.streamIndex].Body
+	doc := d.parsedFile.Docs[d.streamIndex]
+	d.setDocumentVersion(doc)
+	d.stats = ast.CollectStats(doc)
+	body := doc.Body
 	if body == nil {
 		return nil
 	}
+	d.lastDocumentRange = d.documentRanges[d.streamIndex]
+	d.setSourcePositions(body)
+	d.addDocumentCommentToMap(doc)
+	d.resolveAliases(body)
+	if d.disallowAnchorRedefinition {
+		if err := d.validateAnchorRedefinition(body); err != nil {
+			return err
+		}
+	}
+	if d.disallowMergeKeyOverride {
+		if err := d.validateMergeKeyOverride(body); err != nil {
+			return err
+		}
+	}
+	d.reportDuplicateKeys(body)
 	if err := d.decodeValue(ctx, v.Elem(), body); err != nil {
 		return err
 	}
@@ -1999,6 +3841,27 @@ func (d *Decoder) decode(ctx context.Context, v reflect.Value) error {
 	return nil
 }
 
+// LastDocumentRange returns the start and end positions of the document decoded by the last call of Decode.
+// Like ast.NodeRange, the Offset of the returned positions is the 1-based character offset
+// and the ByteOffset is the 0-based byte offset in the input,
+// so input[start.ByteOffset:end.ByteOffset] is the source text of the document.
+// The end position points to the byte just after the document ( including its trailing line break ).
+// If no document has been decoded yet or SkipOrigins is specified, zero values are returned.
+// For the UTF-16 and UTF-32 input, the offsets are in the input converted to UTF-8.
+func (d *Decoder) LastDocumentRange() (token.Position, token.Position) {
+	if d.lastDocumentRange == nil {
+		return token.Position{}, token.Position{}
+	}
+	return d.lastDocumentRange.start, d.lastDocumentRange.end
+}
+
+// Stats returns the number of nodes in the document decoded by the last call of Decode or DecodeFromNode.
+// Aliases are counted as they are written, without expanding them.
+// To inspect the input before decoding, use ast.File.Stats with the result of parser.ParseBytes.
+func (d *Decoder) Stats() ast.Stats {
+	return d.stats
+}
+
 // Decode reads the next YAML-encoded value from its input
 // and stores it in the value pointed to by v.
 //
@@ -2015,33 +3878,79 @@ func (d *Decoder) DecodeContext(ctx context.Context, v interface{}) error {
 	if rv.Type().Kind() != reflect.Ptr {
 		return ErrDecodeRequiredPointerType
 	}
+	if d.subNode != nil {
+		return d.decodeSubNode(ctx, rv)
+	}
 	if d.isInitialized() {
 		if err := d.decode(ctx, rv); err != nil {
 			if err == io.EOF {
 				return err
 			}
-			return err
+			return d.wrapError(err)
 		}
 		return nil
 	}
 	if err := d.decodeInit(); err != nil {
-		return err
+		return d.wrapError(err)
 	}
 	if err := d.decode(ctx, rv); err != nil {
 		if err == io.EOF {
 			return err
 		}
-		return err
+		return d.wrapError(err)
 	}
 	return nil
 }
 
+// subNodeDecoding is the state of the decoder passed to UnmarshalerFrom, which decodes only the node of the value implementing it.
+type subNodeDecoding struct {
+	node    ast.Node
+	decoded bool
+}
+
+func (d *Decoder) decodeSubNode(ctx context.Context, rv reflect.Value) error {
+	if d.subNode.decoded {
+		return io.EOF
+	}
+	d.subNode.decoded = true
+	return d.decodeValue(ctx, rv.Elem(), d.subNode.node)
+}
+
+// DecodeTo reads the next YAML-encoded value from the input of d into the new value of T and returns it.
+// Like Decode, it returns io.EOF if there are no more documents.
+func DecodeTo[T any](d *Decoder) (T, error) {
+	v := newValue[T]()
+	if err := d.Decode(valuePtr(&v)); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// wrapError replaces the quoted values of the secrets found by the Redactor specified by RedactErrors in the message of err,
+// and formats it by the ErrorPrinter specified by WithErrorPrinter.
+func (d *Decoder) wrapError(err error) error {
+	if len(d.skippedOrigins) != 0 {
+		d.restoreOrigins()
+	}
+	if len(d.redactedValues) != 0 {
+		err = &redactedError{err: err, values: d.redactedValues}
+	}
+	if d.errorPrinter != nil {
+		err = &printedError{err: err, printer: d.errorPrinter}
+	}
+	return err
+}
+
 // DecodeFromNode decodes node into the value pointed to by v.
 func (d *Decoder) DecodeFromNode(node ast.Node, v interface{}) error {
 	return d.DecodeFromNodeContext(context.Background(), node, v)
 }
 
 // DecodeFromNodeContext decodes node into the value pointed to by v with context.Context.
+// node is decoded with all the options of d as if it's decoded from the source text.
+// If node is the document, its directives and comments are also handled like Decode.
+// Note that ResolveIncludes and WithScalarExpansion replace the nodes in node in place.
 func (d *Decoder) DecodeFromNodeContext(ctx context.Context, node ast.Node, v interface{}) error {
 	rv := reflect.ValueOf(v)
 	if rv.Type().Kind() != reflect.Ptr {
@@ -2049,9 +3958,53 @@ func (d *Decoder) DecodeFromNodeContext(ctx context.Context, node ast.Node, v in
 	}
 	if !d.isInitialized() {
 		if err := d.decodeInit(); err != nil {
+			return d.wrapError(err)
+		}
+	}
+	if err := d.decodeFromNode(ctx, rv, node); err != nil {
+		return d.wrapError(err)
+	}
+	return nil
+}
+
+func (d *Decoder) decodeFromNode(ctx context.Context, rv reflect.Value, node ast.Node) error {
+	d.decodeDepth = 0
+	if doc, ok := node.(*ast.DocumentNode); ok {
+		d.setDocumentVersion(doc)
+		d.addDocumentCommentToMap(doc)
+		if doc.Body == nil {
+			return nil
+		}
+		node = doc.Body
+	}
+	if d.includeLoader != nil {
+		resolved, err := newIncludeResolver(d.includeLoader).resolve(&includeFile{root: node}, node)
+		if err != nil {
 			return err
 		}
+		node = resolved
 	}
+	if d.scalarExpansion != nil {
+		expanded, err := d.expandScalars(node)
+		if err != nil {
+			return err
+		}
+		node = expanded
+	}
+	d.stats = ast.CollectStats(node)
+	d.setSourcePositions(node)
+	d.resolveAliases(node)
+	if d.disallowAnchorRedefinition {
+		if err := d.validateAnchorRedefinition(node); err != nil {
+			return err
+		}
+	}
+	if d.disallowMergeKeyOverride {
+		if err := d.validateMergeKeyOverride(node); err != nil {
+			return err
+		}
+	}
+	d.reportDuplicateKeys(node)
 	// resolve references to the anchor on the same file
 	if _, err := d.nodeToValue(node); err != nil {
 		return err
@@ -2061,3 +4014,189 @@ func (d *Decoder) DecodeFromNodeContext(ctx context.Context, node ast.Node, v in
 	}
 	return nil
 }
+
+// DecodeFromNodeWithDocument decodes node in doc into the value pointed to by v.
+// The aliases in node can refer to the anchors defined before node in doc
+// without specifying the document by ReferenceReaders.
+func (d *Decoder) DecodeFromNodeWithDocument(doc *ast.DocumentNode, node ast.Node, v interface{}) error {
+	return d.DecodeFromNodeWithDocumentContext(context.Background(), doc, node, v)
+}
+
+// DecodeFromNodeWithDocumentContext decodes node in doc into the value pointed to by v with context.Context.
+func (d *Decoder) DecodeFromNodeWithDocumentContext(ctx context.Context, doc *ast.DocumentNode, node ast.Node, v interface{}) error {
+	if !d.isInitialized() {
+		if err := d.decodeInit(); err != nil {
+			return err
+		}
+	}
+	d.registerAnchors(doc, node)
+	return d.DecodeFromNodeContext(ctx, node, v)
+}
+
+// registerAnchors registers the anchors defined before node in doc.
+// If the same anchor name is defined multiple times, the last definition before node is used.
+func (d *Decoder) registerAnchors(doc *ast.DocumentNode, node ast.Node) {
+	if doc == nil {
+		return
+	}
+	var found bool
+	ast.Walk(anchorCollector(func(n ast.Node) bool {
+		if found || n == node {
+			found = true
+			return false
+		}
+		if anchor, ok := n.(*ast.AnchorNode); ok {
+			d.anchorNodeMap[anchor.Name.GetToken().Value] = anchor.Value
+		}
+		return true
+	}), doc)
+}
+
+// resolveAliases records the anchor referred by each alias in node,
+// which is the last one defined before the alias in the source.
+// The aliases are decoded in the order of the struct fields, so the anchor can't be found by the name at that time
+// if the same anchor name is defined more than once.
+func (d *Decoder) resolveAliases(node ast.Node) {
+	if d.stats.Aliases == 0 {
+		return
+	}
+	anchors := make(map[string]ast.Node, len(d.anchorNodeMap))
+	for name, anchor := range d.anchorNodeMap {
+		anchors[name] = anchor
+	}
+	ast.Walk(anchorCollector(func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AnchorNode:
+			anchors[n.Name.GetToken().Value] = n.Value
+		case *ast.AliasNode:
+			if anchor, exists := anchors[n.Value.GetToken().Value]; exists {
+				d.aliasAnchorMap[n] = anchor
+			}
+		}
+		return true
+	}), node)
+}
+
+// anchorOf returns the node anchored by the anchor which alias refers to.
+// The alias not resolved by resolveAliases refers to the anchor registered last with the name.
+func (d *Decoder) anchorOf(alias *ast.AliasNode) (ast.Node, bool) {
+	if anchor, exists := d.aliasAnchorMap[alias]; exists {
+		return anchor, true
+	}
+	anchor, exists := d.anchorNodeMap[alias.Value.GetToken().Value]
+	return anchor, exists
+}
+
+// setSourcePositions records the positions of the node and the mapping values and the sequence elements in it to the SourceMap.
+func (d *Decoder) setSourcePositions(node ast.Node) {
+	if d.toSourceMap == nil {
+		return
+	}
+	clear(d.toSourceMap)
+	setPosition := func(n ast.Node) {
+		if tk := startToken(n); tk != nil && tk.Position != nil {
+			d.toSourceMap[n.GetPath()] = *tk.Position
+		}
+	}
+	setPosition(node)
+	ast.Walk(tokenCollector(func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.MappingValueNode:
+			setPosition(n.Key)
+		case *ast.SequenceNode:
+			for _, value := range n.Values {
+				setPosition(value)
+			}
+		}
+	}), node)
+}
+
+type anchorCollector func(ast.Node) bool
+
+func (f anchorCollector) Visit(node ast.Node) ast.Visitor {
+	if node == nil || !f(node) {
+		return nil
+	}
+	return f
+}
+
+type documentRange struct {
+	start token.Position
+	end   token.Position
+}
+
+func newDocumentRange(src []byte, doc *ast.DocumentNode, tokenOffsetMap map[*token.Token]int) *documentRange {
+	var (
+		first, last *token.Token
+		firstOffset int
+		lastOffset  int
+	)
+	for _, tk := range documentTokens(doc) {
+		offset, exists := tokenOffsetMap[tk]
+		if !exists {
+			// token created by parser.
+			continue
+		}
+		if first == nil || offset < firstOffset {
+			first, firstOffset = tk, offset
+		}
+		if last == nil || offset > lastOffset {
+			last, lastOffset = tk, offset
+		}
+	}
+	if first == nil {
+		return &documentRange{}
+	}
+	start := firstOffset + len(first.Origin) - len(strings.TrimLeft(first.Origin, " \t\r\n\ufeff"))
+	end := lastOffset + len(last.Origin)
+	return &documentRange{
+		start: offsetToPosition(src, start),
+		end:   offsetToPosition(src, end),
+	}
+}
+
+// documentTokens returns the tokens referenced by the nodes in the document.
+func documentTokens(doc *ast.DocumentNode) []*token.Token {
+	tokens := []*token.Token{doc.Start, doc.End}
+	if doc.Body != nil {
+		ast.Walk(tokenCollector(func(node ast.Node) {
+			tokens = append(tokens, node.GetToken())
+			switch n := node.(type) {
+			case *ast.MappingNode:
+				tokens = append(tokens, n.End)
+			case *ast.SequenceNode:
+				tokens = append(tokens, n.End)
+			}
+		}), doc.Body)
+	}
+	return tokens
+}
+
+type tokenCollector func(ast.Node)
+
+func (f tokenCollector) Visit(node ast.Node) ast.Visitor {
+	if node != nil {
+		f(node)
+	}
+	return f
+}
+
+// offsetToPosition returns the position of the byte offset in src.
+// The column and the offset are counted by character like the positions of the tokens.
+func offsetToPosition(src []byte, offset int) token.Position {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	line := bytes.Count(src[:offset], []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+	if lineStart == 0 && bytes.HasPrefix(src, []byte("\ufeff")) && offset >= len("\ufeff") {
+		// the byte order mark isn't counted in the column.
+		lineStart = len("\ufeff")
+	}
+	return token.Position{
+		Line:       line,
+		Column:     utf8.RuneCount(src[lineStart:offset]) + 1,
+		Offset:     utf8.RuneCount(src[:offset]) + 1,
+		ByteOffset: offset,
+	}
+}
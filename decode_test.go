@@ -3,21 +3,32 @@ package yaml_test
 import (
 	"bytes"
 	"context"
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"math"
 	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
+	"unicode/utf8"
 
 	"github.com/goccy/go-yaml"
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/internal/errors"
 	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/resolver"
+	"github.com/goccy/go-yaml/token"
 )
 
 type Child struct {
@@ -38,6 +49,10 @@ func TestDecoder(t *testing.T) {
 			"v: hi\n",
 			map[string]string{"v": "hi"},
 		},
+		{
+			"v: a\tb\t\n",
+			map[string]string{"v": "a\tb"},
+		},
 		{
 			"v: \"true\"\n",
 			map[string]string{"v": "true"},
@@ -1508,6 +1523,65 @@ func TestDecoder_AnchorReferenceDirsRecursive(t *testing.T) {
 	}
 }
 
+func TestDecoder_AnchorReferenceFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"anchor.yml":              {Data: []byte("a: &a\n  b: 1\n  c: hello\n")},
+		"anchors/b.yaml":          {Data: []byte("b: &b 2\n")},
+		"anchors/ignored.txt":     {Data: []byte("c: &c 3\n")},
+		"anchors/nested/d.yml":    {Data: []byte("d: &d 4\n")},
+		"anchors/nested/more/e.y": {Data: []byte("e: &e 5\n")},
+	}
+	type T struct {
+		A struct {
+			B int
+			C string
+		}
+		B int
+		D int
+	}
+	t.Run("files and dirs", func(t *testing.T) {
+		var v T
+		dec := yaml.NewDecoder(
+			strings.NewReader("a: *a\nb: *b\n"),
+			yaml.ReferenceFS(fsys, "anchor.yml", "anchors/"),
+		)
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.A.B != 1 || v.A.C != "hello" || v.B != 2 {
+			t.Fatalf("failed to decode by reference fs: %+v", v)
+		}
+		dec = yaml.NewDecoder(
+			strings.NewReader("d: *d\n"),
+			yaml.ReferenceFS(fsys, "anchors"),
+		)
+		if err := dec.Decode(&v); err == nil {
+			t.Fatal("expected error for the anchor in the nested directory")
+		}
+	})
+	t.Run("recursive", func(t *testing.T) {
+		var v T
+		dec := yaml.NewDecoder(
+			strings.NewReader("b: *b\nd: *d\n"),
+			yaml.ReferenceFS(fsys, "anchors"),
+			yaml.RecursiveDir(true),
+		)
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.B != 2 || v.D != 4 {
+			t.Fatalf("failed to decode by reference fs: %+v", v)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		var v T
+		dec := yaml.NewDecoder(strings.NewReader("a: 1\n"), yaml.ReferenceFS(fsys, "missing.yml"))
+		if err := dec.Decode(&v); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
 func TestDecoder_AnchorFiles(t *testing.T) {
 	buf := bytes.NewBufferString("a: *a\n")
 	dec := yaml.NewDecoder(buf, yaml.ReferenceFiles("testdata/anchor.yml"))
@@ -1729,6 +1803,434 @@ c: true
 	}
 }
 
+func TestDecoder_InlineMap(t *testing.T) {
+	yml := `
+a: 1
+b: 2
+c: three
+`
+	t.Run("explicit fields win", func(t *testing.T) {
+		var v struct {
+			A    int                    `yaml:"a"`
+			Rest map[string]interface{} `yaml:",inline"`
+		}
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]interface{}{"b": uint64(2), "c": "three"}
+		if v.A != 1 || !reflect.DeepEqual(v.Rest, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("pointer map", func(t *testing.T) {
+		var v struct {
+			A    int                `yaml:"a"`
+			Rest *map[string]string `yaml:",inline"`
+		}
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.Strict()); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{"b": "2", "c": "three"}
+		if v.A != 1 || v.Rest == nil || !reflect.DeepEqual(*v.Rest, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("inline struct and map", func(t *testing.T) {
+		type Base struct {
+			B int `yaml:"b"`
+		}
+		var v struct {
+			Base `yaml:",inline"`
+			Rest map[string]interface{} `yaml:",inline"`
+		}
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.Strict()); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]interface{}{"a": uint64(1), "c": "three"}
+		if v.B != 2 || !reflect.DeepEqual(v.Rest, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("multiple maps", func(t *testing.T) {
+		var v struct {
+			Ints    map[string]int    `yaml:",inline"`
+			Strings map[string]string `yaml:",inline"`
+		}
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v.Ints, map[string]int{"a": 1, "b": 2}) || !reflect.DeepEqual(v.Strings, map[string]string{"c": "three"}) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("type mismatch of the last map", func(t *testing.T) {
+		var v struct {
+			Ints  map[string]int  `yaml:",inline"`
+			Bools map[string]bool `yaml:",inline"`
+		}
+		err := yaml.Unmarshal([]byte(yml), &v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		var te *yaml.TypeError
+		if !errors.As(err, &te) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("merge key", func(t *testing.T) {
+		yml := `
+base: &base
+  b: 2
+value:
+  <<: *base
+  a: 1
+`
+		var v struct {
+			Base  map[string]int `yaml:"base"`
+			Value struct {
+				A    int            `yaml:"a"`
+				Rest map[string]int `yaml:",inline"`
+			} `yaml:"value"`
+		}
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.Strict()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Value.A != 1 || !reflect.DeepEqual(v.Value.Rest, map[string]int{"b": 2}) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("conflict with strict", func(t *testing.T) {
+		type Base struct {
+			B int `yaml:"b"`
+		}
+		type T struct {
+			Base `yaml:",inline"`
+			B    string                 `yaml:"b"`
+			Rest map[string]interface{} `yaml:",inline"`
+		}
+		var v T
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.B != "2" || v.Base.B != 0 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.Strict())
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		expected := `
+[3:1] key "b" matches both a field of T and a field of the inline T.Base
+   2 | a: 1
+>  3 | b: 2
+       ^
+   4 | c: three`
+		if "\n"+err.Error() != expected {
+			t.Fatalf("unexpected error:\n%s", err.Error())
+		}
+	})
+}
+
+func TestDecoder_FastPath(t *testing.T) {
+	// the named types are decoded by the generic path, so the results of both paths are compared.
+	type (
+		StringMap    map[string]string
+		InterfaceMap map[string]interface{}
+		Strings      []string
+		Interfaces   []interface{}
+	)
+	type fast struct {
+		StringMap    map[string]string      `yaml:"m"`
+		InterfaceMap map[string]interface{} `yaml:"im"`
+		Strings      []string               `yaml:"s"`
+		Interfaces   []interface{}          `yaml:"is"`
+	}
+	type generic struct {
+		StringMap    StringMap    `yaml:"m"`
+		InterfaceMap InterfaceMap `yaml:"im"`
+		Strings      Strings      `yaml:"s"`
+		Interfaces   Interfaces   `yaml:"is"`
+	}
+	tests := []struct {
+		name    string
+		src     string
+		options []yaml.DecodeOption
+	}{
+		{
+			name: "scalars",
+			src: `
+m: {a: b, int: 1, float: 1.5, bool: true, "null": null, 1: one, ~: nil, ? x : y}
+im: {a: b, int: -1, float: .inf, bool: false, "null": null, nested: {c: [1, 2]}}
+s: [a, 1, 0x10, 1e3, true, null, !!str 2, "quoted"]
+is: [a, 1, 18446744073709551615, 1.5, true, null, {b: c}, [d]]
+`,
+		},
+		{
+			name: "anchors and aliases",
+			src: `
+m: {a: &x v, b: *x}
+im: {a: &y {c: d}, b: *y}
+s: [&z w, *z]
+is: [&w {e: f}, *w]
+`,
+		},
+		{
+			name:    "use number",
+			src:     "m: {a: 1}\nim: {a: 1, b: 1.5}\ns: [12345678901234567890123]\nis: [1, 2.5]\n",
+			options: []yaml.DecodeOption{yaml.UseNumber()},
+		},
+		{
+			name:    "duplicate key",
+			src:     "m: {a: 1, a: 2}\n",
+			options: []yaml.DecodeOption{yaml.AllowDuplicateMapKey()},
+		},
+		{
+			name: "duplicate key error",
+			src:  "m: {a: 1, a: 2}\n",
+		},
+		{
+			name: "type mismatch",
+			src:  "m: {a: 1, b: [c]}\ns: [a, {b: c}, d]\n",
+		},
+		{
+			name:    "type mismatch with all errors",
+			src:     "m: {a: 1, b: [c]}\ns: [a, {b: c}, d]\n",
+			options: []yaml.DecodeOption{yaml.AllErrors()},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var f fast
+			fastErr := yaml.UnmarshalWithOptions([]byte(test.src), &f, test.options...)
+			var g generic
+			genericErr := yaml.UnmarshalWithOptions([]byte(test.src), &g, test.options...)
+			// the struct names in the errors are different.
+			if fmt.Sprint(fastErr) != strings.ReplaceAll(fmt.Sprint(genericErr), "generic.", "fast.") {
+				t.Fatalf("unexpected error:\nfast: %v\ngeneric: %v", fastErr, genericErr)
+			}
+			expected := fast{
+				StringMap:    map[string]string(g.StringMap),
+				InterfaceMap: map[string]interface{}(g.InterfaceMap),
+				Strings:      []string(g.Strings),
+				Interfaces:   []interface{}(g.Interfaces),
+			}
+			if !reflect.DeepEqual(f, expected) {
+				t.Fatalf("unexpected value:\nfast: %#v\ngeneric: %#v", f, expected)
+			}
+		})
+	}
+	t.Run("custom unmarshaler", func(t *testing.T) {
+		var v struct {
+			M map[string]string `yaml:"m"`
+			S []string          `yaml:"s"`
+		}
+		upper := yaml.CustomUnmarshaler[string](func(s *string, b []byte) error {
+			*s = strings.ToUpper(string(b))
+			return nil
+		})
+		if err := yaml.UnmarshalWithOptions([]byte("m: {a: b}\ns: [c]\n"), &v, upper); err != nil {
+			t.Fatal(err)
+		}
+		if v.M["A"] != "B" || len(v.S) != 1 || v.S[0] != "C" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+}
+
+type rawYAML []byte
+
+func (r *rawYAML) UnmarshalYAML(b []byte) error {
+	*r = append((*r)[:0], b...)
+	return nil
+}
+
+type recordingTracer struct {
+	events []string
+}
+
+func (t *recordingTracer) TokenizeStart(size int) {
+	t.events = append(t.events, fmt.Sprintf("TokenizeStart %d", size))
+}
+
+func (t *recordingTracer) TokenizeEnd(tokens int, duration time.Duration) {
+	t.events = append(t.events, fmt.Sprintf("TokenizeEnd %d", tokens))
+}
+
+func (t *recordingTracer) ParseStart() {
+	t.events = append(t.events, "ParseStart")
+}
+
+func (t *recordingTracer) ParseEnd(duration time.Duration, err error) {
+	t.events = append(t.events, fmt.Sprintf("ParseEnd %v", err != nil))
+}
+
+func (t *recordingTracer) NodeDecoded(path string, typ reflect.Type, duration time.Duration) {
+	t.events = append(t.events, fmt.Sprintf("NodeDecoded %s %s", path, typ))
+}
+
+func TestDecoder_WithTrace(t *testing.T) {
+	type T struct {
+		A int               `yaml:"a"`
+		B []string          `yaml:"b"`
+		C map[string]string `yaml:"c"`
+		D interface{}       `yaml:"d"`
+	}
+	src := `
+a: 1
+b: [x]
+c: {k: v}
+d: {e: [1]}
+`
+	var tr recordingTracer
+	var v T
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.WithTrace(&tr)); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		fmt.Sprintf("TokenizeStart %d", len(src)),
+		"TokenizeEnd 24",
+		"ParseStart",
+		"ParseEnd false",
+		"NodeDecoded $.a int",
+		"NodeDecoded $.b []string",
+		"NodeDecoded $.c map[string]string",
+		"NodeDecoded $.d interface {}",
+		"NodeDecoded $ yaml_test.T",
+	}
+	if !reflect.DeepEqual(tr.events, expected) {
+		t.Fatalf("unexpected events:\n%s", strings.Join(tr.events, "\n"))
+	}
+
+	t.Run("parse error", func(t *testing.T) {
+		var tr recordingTracer
+		var v T
+		if err := yaml.UnmarshalWithOptions([]byte("a: [1"), &v, yaml.WithTrace(&tr)); err == nil {
+			t.Fatal("expected error")
+		}
+		if tr.events[len(tr.events)-1] != "ParseEnd true" {
+			t.Fatalf("unexpected events: %v", tr.events)
+		}
+	})
+}
+
+func TestDecoder_SkipOrigins(t *testing.T) {
+	src := `# head comment
+a: 1 # line comment
+b:
+  - &x {c: [1, 2], d: "e"}
+  - *x
+  - ? f
+    : g
+h:
+  <<: *x
+  i: |
+    text
+      indented
+j: >-
+  folded
+  line
+raw:
+  k: [l, {m: n}]
+  o: p
+---
+- q
+- !!str 1
+`
+	type T struct {
+		A   int                    `yaml:"a"`
+		B   []interface{}          `yaml:"b"`
+		H   map[string]interface{} `yaml:"h"`
+		J   string                 `yaml:"j"`
+		Raw rawYAML                `yaml:"raw"`
+	}
+	decodeAll := func(opts ...yaml.DecodeOption) ([]interface{}, T) {
+		t.Helper()
+		dec := yaml.NewDecoder(strings.NewReader(src), opts...)
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		var docs []interface{}
+		for {
+			var doc interface{}
+			if err := dec.Decode(&doc); err != nil {
+				if err == io.EOF {
+					break
+				}
+				t.Fatal(err)
+			}
+			docs = append(docs, doc)
+		}
+		return docs, v
+	}
+	expectedDocs, expected := decodeAll()
+	gotDocs, got := decodeAll(yaml.SkipOrigins())
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %#v but got %#v", expected, got)
+	}
+	if !reflect.DeepEqual(expectedDocs, gotDocs) {
+		t.Fatalf("expected %#v but got %#v", expectedDocs, gotDocs)
+	}
+
+	t.Run("last document range", func(t *testing.T) {
+		dec := yaml.NewDecoder(strings.NewReader(src), yaml.SkipOrigins())
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		start, end := dec.LastDocumentRange()
+		if start != (token.Position{}) || end != (token.Position{}) {
+			t.Fatalf("expected zero positions but got %v and %v", start, end)
+		}
+	})
+	t.Run("comment map", func(t *testing.T) {
+		cm := yaml.CommentMap{}
+		var v T
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.SkipOrigins(), yaml.CommentToMap(cm)); err != nil {
+			t.Fatal(err)
+		}
+		if len(cm["$.a"]) == 0 {
+			t.Fatalf("the comments should be kept with CommentToMap: %v", cm)
+		}
+	})
+	t.Run("syntax error", func(t *testing.T) {
+		src := "a: 1\nb:\n  - [c, d]\ne: {\n"
+		var v interface{}
+		expected := yaml.Unmarshal([]byte(src), &v)
+		if expected == nil {
+			t.Fatal("expected error")
+		}
+		got := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.SkipOrigins())
+		if got == nil || got.Error() != expected.Error() {
+			t.Fatalf("expected:\n%v\nbut got:\n%v", expected, got)
+		}
+	})
+	t.Run("type error", func(t *testing.T) {
+		src := "items:\n  - name: a # c\n    port: [1, 2]\n"
+		var v struct {
+			Items []struct {
+				Name string `yaml:"name"`
+				Port int    `yaml:"port"`
+			} `yaml:"items"`
+		}
+		expected := yaml.Unmarshal([]byte(src), &v)
+		if expected == nil {
+			t.Fatal("expected error")
+		}
+		got := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.SkipOrigins())
+		if got == nil {
+			t.Fatal("expected error")
+		}
+		expectedMsg := yaml.FormatError(expected, false, true)
+		if !strings.Contains(expectedMsg, ">  3 |     port: [1, 2]") {
+			t.Fatalf("unexpected message:\n%s", expectedMsg)
+		}
+		if msg := yaml.FormatError(got, false, true); msg != expectedMsg {
+			t.Fatalf("expected:\n%s\nbut got:\n%s", expectedMsg, msg)
+		}
+	})
+}
+
 func TestDecoder_InlineAndWrongTypeStrict(t *testing.T) {
 	type Base struct {
 		A int
@@ -2070,6 +2572,147 @@ func TestDecoder_UseJSONUnmarshaler(t *testing.T) {
 	}
 }
 
+func TestDecoder_CollectionTags(t *testing.T) {
+	type T struct {
+		Tags  yaml.Set[string] `yaml:"tags"`
+		Flags map[string]bool  `yaml:"flags"`
+		List  []string         `yaml:"list"`
+		Omap  yaml.MapSlice    `yaml:"omap"`
+		Items []yaml.MapItem   `yaml:"items"`
+		Pairs yaml.MapSlice    `yaml:"pairs"`
+		M     map[string]int   `yaml:"m"`
+	}
+	src := `
+tags: !!set
+  ? a
+  ? b
+flags: !!set {x, y}
+list: !!set
+  ? c
+  ? d
+omap: !!omap
+  - z: 1
+  - a: 2
+items: !!omap [{b: 1}, {a: 2}]
+pairs: !!pairs
+  - k: 1
+  - k: 2
+m: &m !!omap
+  - x: 1
+  - y: 2
+`
+	var v T
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatal(err)
+	}
+	expected := T{
+		Tags:  yaml.NewSet("a", "b"),
+		Flags: map[string]bool{"x": true, "y": true},
+		List:  []string{"c", "d"},
+		Omap:  yaml.MapSlice{{Key: "z", Value: uint64(1)}, {Key: "a", Value: uint64(2)}},
+		Items: []yaml.MapItem{{Key: "b", Value: uint64(1)}, {Key: "a", Value: uint64(2)}},
+		Pairs: yaml.MapSlice{{Key: "k", Value: uint64(1)}, {Key: "k", Value: uint64(2)}},
+		M:     map[string]int{"x": 1, "y": 2},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected decoded value: %+v", v)
+	}
+
+	t.Run("interface", func(t *testing.T) {
+		var v map[string]any
+		if err := yaml.Unmarshal([]byte("s: !!set {a}\no: !!omap [{a: 1}]\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]any{
+			"s": map[string]any{"a": nil},
+			"o": []any{map[string]any{"a": uint64(1)}},
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected decoded value: %+v", v)
+		}
+	})
+	t.Run("duplicate key of omap", func(t *testing.T) {
+		var v T
+		err := yaml.Unmarshal([]byte("omap: !!omap\n  - a: 1\n  - a: 2\n"), &v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), `duplicate key "a"`) {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+}
+
+func TestDecoder_UseBinaryUnmarshaler(t *testing.T) {
+	type T struct {
+		Hash  useBinaryMarshalerTest   `yaml:"hash"`
+		Ptr   *useBinaryMarshalerTest  `yaml:"ptr"`
+		List  []useBinaryMarshalerTest `yaml:"list"`
+		Plain useBinaryMarshalerTest   `yaml:"plain"`
+		Block useBinaryMarshalerTest   `yaml:"block"`
+	}
+	src := `
+hash: !!binary aGVsbG8=
+ptr: !!binary ""
+list:
+- !!binary /wA=
+plain: 1234
+block: !!binary |
+  aGVs
+  bG8=
+`
+	var v T
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.UseBinaryUnmarshaler()); err != nil {
+		t.Fatal(err)
+	}
+	if string(v.Hash.b) != "hello" {
+		t.Fatalf("unexpected hash: %q", v.Hash.b)
+	}
+	if v.Ptr == nil || len(v.Ptr.b) != 0 {
+		t.Fatalf("unexpected ptr: %v", v.Ptr)
+	}
+	if len(v.List) != 1 || !bytes.Equal(v.List[0].b, []byte{0xff, 0x00}) {
+		t.Fatalf("unexpected list: %v", v.List)
+	}
+	if !bytes.Equal(v.Plain.b, []byte{0xd7, 0x6d, 0xf8}) {
+		t.Fatalf("unexpected plain: %v", v.Plain.b)
+	}
+	if string(v.Block.b) != "hello" {
+		t.Fatalf("unexpected block: %q", v.Block.b)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(v, yaml.UseBinaryMarshaler())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got T
+		if err := yaml.UnmarshalWithOptions(b, &got, yaml.UseBinaryUnmarshaler()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Hash, v.Hash) || !bytes.Equal(got.Plain.b, v.Plain.b) {
+			t.Fatalf("unexpected decoded value: %+v", got)
+		}
+	})
+	t.Run("invalid base64", func(t *testing.T) {
+		var v T
+		err := yaml.UnmarshalWithOptions([]byte(`hash: !!binary "a*b"`), &v, yaml.UseBinaryUnmarshaler())
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), `cannot decode "a*b" as base64`) {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+	t.Run("mapping", func(t *testing.T) {
+		var v T
+		err := yaml.UnmarshalWithOptions([]byte("hash:\n  a: b"), &v, yaml.UseBinaryUnmarshaler())
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
 func TestDecoder_CustomUnmarshaler(t *testing.T) {
 	t.Run("override struct type", func(t *testing.T) {
 		type T struct {
@@ -2116,12 +2759,78 @@ func TestDecoder_CustomUnmarshaler(t *testing.T) {
 			t.Fatalf("failed to switch to custom unmarshaler. got: %q", v.Foo)
 		}
 	})
-}
-
-type unmarshalContext struct {
-	v int
-}
-
+	t.Run("standalone document", func(t *testing.T) {
+		type T string
+		src := []byte(`
+anchors:
+  list: &list
+    - p # comment
+    - q
+  text: &text |
+    a
+     b
+values:
+  - - k: *list
+  - *text
+  - !!str &tagged foo
+`)
+		var v struct {
+			Values []T `yaml:"values"`
+		}
+		var docs []string
+		if err := yaml.UnmarshalWithOptions(src, &v, yaml.CommentToMap(yaml.CommentMap{}), yaml.CustomUnmarshaler[T](func(dst *T, b []byte) error {
+			docs = append(docs, string(b))
+			return nil
+		})); err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{
+			"- k:\n    - p\n    - q",
+			"|\n    a\n     b\n",
+			"!!str foo",
+		}
+		if !reflect.DeepEqual(docs, expected) {
+			t.Fatalf("unexpected documents:\nexpected: %q\ngot:      %q", expected, docs)
+		}
+	})
+}
+
+func TestDecoder_CustomUnmarshalerNode(t *testing.T) {
+	type T struct {
+		value string
+		line  int
+	}
+	src := []byte(`
+defs: {a: &x foo}
+a: &y bar
+b: *x
+`)
+	var v struct {
+		Defs any `yaml:"defs"`
+		A    T   `yaml:"a"`
+		B    T   `yaml:"b"`
+	}
+	if err := yaml.UnmarshalWithOptions(src, &v, yaml.CustomUnmarshaler[T](func(dst *T, b []byte) error {
+		return errors.New("overridden by CustomUnmarshalerNode")
+	}), yaml.CustomUnmarshalerNode[T](func(dst *T, node ast.Node) error {
+		dst.value = node.String()
+		dst.line = node.GetToken().Position.Line
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.value != "&y bar" || v.A.line != 3 {
+		t.Fatalf("unexpected value: %+v", v.A)
+	}
+	if v.B.value != "foo" || v.B.line != 2 {
+		t.Fatalf("unexpected value: %+v", v.B)
+	}
+}
+
+type unmarshalContext struct {
+	v int
+}
+
 func (c *unmarshalContext) UnmarshalYAML(ctx context.Context, b []byte) error {
 	v, ok := ctx.Value("k").(int)
 	if !ok {
@@ -2148,6 +2857,145 @@ func Test_UnmarshalerContext(t *testing.T) {
 	}
 }
 
+type yamlAndTextUnmarshaler struct {
+	v string
+}
+
+var _ yaml.Unmarshaler = (*yamlAndTextUnmarshaler)(nil)
+
+func (u *yamlAndTextUnmarshaler) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	u.v = "yaml:" + s
+	return nil
+}
+
+func (u *yamlAndTextUnmarshaler) UnmarshalText(b []byte) error {
+	u.v = "text:" + string(b)
+	return nil
+}
+
+type streamedConfig struct {
+	values map[string]int
+	eof    error
+}
+
+func (v *streamedConfig) UnmarshalYAMLFrom(dec *yaml.Decoder) error {
+	if err := dec.Decode(&v.values); err != nil {
+		return err
+	}
+	var extra interface{}
+	v.eof = dec.Decode(&extra)
+	return nil
+}
+
+type strictStreamedConfig struct {
+	A int `yaml:"a"`
+}
+
+func (v *strictStreamedConfig) UnmarshalYAMLFrom(dec *yaml.Decoder) error {
+	type alias strictStreamedConfig
+	return dec.Decode((*alias)(v))
+}
+
+func TestDecoder_UnmarshalerFrom(t *testing.T) {
+	t.Run("decode", func(t *testing.T) {
+		var v struct {
+			Config streamedConfig   `yaml:"config"`
+			List   []streamedConfig `yaml:"list"`
+		}
+		src := `
+base: &base {a: 1}
+config: *base
+list:
+  - {b: 2}
+`
+		if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if v.Config.values["a"] != 1 || v.List[0].values["b"] != 2 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		if v.Config.eof != io.EOF {
+			t.Fatalf("unexpected error of the second decode: %v", v.Config.eof)
+		}
+	})
+	t.Run("options", func(t *testing.T) {
+		var v struct {
+			Config strictStreamedConfig `yaml:"config"`
+		}
+		err := yaml.UnmarshalWithOptions([]byte("config: {a: 1, b: 2}"), &v, yaml.DisallowUnknownField())
+		if err == nil || !strings.Contains(err.Error(), `unknown field "b"`) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+type anchoredEnv struct {
+	anchor string
+	env    map[string]string
+}
+
+func (v *anchoredEnv) UnmarshalYAMLWithAnchor(name string, b []byte) error {
+	v.anchor = name
+	return yaml.Unmarshal(b, &v.env)
+}
+
+func TestDecoder_AnchorAwareUnmarshaler(t *testing.T) {
+	src := `
+defaults: &env_default
+  LOG: info
+dev: *env_default
+prod:
+  LOG: warn
+list:
+  - *env_default
+`
+	var v struct {
+		Defaults anchoredEnv   `yaml:"defaults"`
+		Dev      anchoredEnv   `yaml:"dev"`
+		Prod     anchoredEnv   `yaml:"prod"`
+		List     []anchoredEnv `yaml:"list"`
+	}
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, test := range []struct {
+		name   string
+		value  anchoredEnv
+		anchor string
+		log    string
+	}{
+		{name: "anchor", value: v.Defaults, anchor: "env_default", log: "info"},
+		{name: "alias", value: v.Dev, anchor: "env_default", log: "info"},
+		{name: "plain", value: v.Prod, anchor: "", log: "warn"},
+		{name: "alias in sequence", value: v.List[0], anchor: "env_default", log: "info"},
+	} {
+		if test.value.anchor != test.anchor {
+			t.Errorf("%s: unexpected anchor name: %q", test.name, test.value.anchor)
+		}
+		if test.value.env["LOG"] != test.log {
+			t.Errorf("%s: unexpected value: %v", test.name, test.value.env)
+		}
+	}
+}
+
+func TestDecoder_UnmarshalerPriority(t *testing.T) {
+	var v struct {
+		A yamlAndTextUnmarshaler
+		B *yamlAndTextUnmarshaler
+		C []yamlAndTextUnmarshaler
+	}
+	if err := yaml.Unmarshal([]byte("a: x\nb: y\nc: [z]\n"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.v != "yaml:x" || v.B.v != "yaml:y" || v.C[0].v != "yaml:z" {
+		t.Fatalf("UnmarshalYAML should take precedence over UnmarshalText: %+v %+v %+v", v.A, v.B, v.C)
+	}
+}
+
 func TestDecoder_DecodeFromNode(t *testing.T) {
 	t.Run("has reference", func(t *testing.T) {
 		str := `
@@ -2206,6 +3054,76 @@ map: &map
 	})
 }
 
+func TestNodeToValueContext(t *testing.T) {
+	src := `
+# head
+---
+name: ${NAME}
+port: 80
+unknown: 1
+`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type T struct {
+		Name string `yaml:"name"`
+		Port int    `yaml:"port"`
+	}
+	expand := yaml.WithScalarExpansion(func(path, raw string) (string, error) {
+		return "app", nil
+	})
+	t.Run("document", func(t *testing.T) {
+		var v map[string]any
+		cm := yaml.CommentMap{}
+		if err := yaml.NodeToValueContext(context.Background(), f.Docs[0], &v, yaml.CommentToMap(cm), expand); err != nil {
+			t.Fatal(err)
+		}
+		if v["name"] != "app" {
+			t.Fatalf("unexpected value: %v", v)
+		}
+		if comments := cm["$"]; len(comments) != 1 || comments[0].Texts[0] != " head" {
+			t.Fatalf("unexpected comment map: %v", cm)
+		}
+	})
+	t.Run("strict", func(t *testing.T) {
+		var v T
+		err := yaml.NodeToValueContext(context.Background(), f.Docs[0].Body, &v, yaml.Strict())
+		var unknownErr *yaml.UnknownFieldError
+		if !errors.As(err, &unknownErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("custom unmarshaler", func(t *testing.T) {
+		var v struct {
+			Port int `yaml:"port"`
+		}
+		if err := yaml.NodeToValueContext(context.Background(), f.Docs[0].Body, &v, yaml.CustomUnmarshaler[int](func(dst *int, b []byte) error {
+			*dst = 8080
+			return nil
+		})); err != nil {
+			t.Fatal(err)
+		}
+		if v.Port != 8080 {
+			t.Fatalf("unexpected value: %v", v)
+		}
+	})
+	t.Run("context", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte(`1`), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(context.Background(), "k", 1)
+		var v unmarshalContext
+		if err := yaml.NodeToValueContext(ctx, f.Docs[0].Body, &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.v != 1 {
+			t.Fatal("cannot call UnmarshalYAML")
+		}
+	})
+}
+
 func ExampleUnmarshal_jSONTags() {
 	yml := `---
 foo: 1
@@ -2894,6 +3812,27 @@ func TestDecoder_Canonical(t *testing.T) {
 	}
 }
 
+func TestDecoder_RootPointer(t *testing.T) {
+	type T struct {
+		A string `yaml:"a"`
+		B int    `yaml:"b"`
+	}
+	var v *T
+	if err := yaml.Unmarshal([]byte("a: x\n"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v == nil || v.A != "x" {
+		t.Fatalf("failed to decode into the nil pointer: %+v", v)
+	}
+	v = &T{B: 1}
+	if err := yaml.Unmarshal([]byte("a: y\n"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != "y" || v.B != 1 {
+		t.Fatalf("failed to decode into the value pointed to: %+v", v)
+	}
+}
+
 func TestDecoder_DecodeFromFile(t *testing.T) {
 	yml := `
 a: b
@@ -3175,3 +4114,2438 @@ func TestDecodeError(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoder_WithErrorPrinter(t *testing.T) {
+	src := "a: 1\nb: 2\nc: x\nd: 4\n"
+	var v struct {
+		C int `yaml:"c"`
+	}
+	p := &yaml.ErrorPrinter{IncludeSource: true, LinesBefore: 1}
+	err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.WithErrorPrinter(p))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expected := `
+[3:4] cannot unmarshal string into Go struct field .C of type int
+   2 | b: 2
+>  3 | c: x
+          ^
+`
+	if "\n"+err.Error() != expected {
+		t.Fatalf("unexpected error:\n%s", err)
+	}
+	if got := yaml.FormatError(err, false, false); got != "[3:4] cannot unmarshal string into Go struct field .C of type int" {
+		t.Fatalf("unexpected formatted error:\n%s", got)
+	}
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("failed to unwrap the error: %T", err)
+	}
+
+	t.Run("file name", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("a: 1\nb: [\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := parser.ParseFile(path, 0)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if _, ok := err.(*yaml.SyntaxError); !ok {
+			t.Fatalf("unexpected error: %T", err)
+		}
+		if got := yaml.NewErrorPrinter().Format(err); got != yaml.FormatError(err, false, true) {
+			t.Fatalf("unexpected formatted error:\n%s", got)
+		}
+		p := &yaml.ErrorPrinter{FileName: path}
+		if got := p.Format(err); got != fmt.Sprintf("[%s:2:4] sequence end token ']' not found", path) {
+			t.Fatalf("unexpected formatted error:\n%s", got)
+		}
+	})
+}
+
+func TestDecoder_LastDocumentRange(t *testing.T) {
+	src := `a: é
+b: |
+  text
+---
+# comment
+c: [1, 2]
+...
+--- {d: e}
+`
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	if start, end := dec.LastDocumentRange(); start.Offset != 0 || end.Offset != 0 {
+		t.Fatalf("unexpected range before decoding: %v %v", start, end)
+	}
+	expected := []string{
+		"a: é\nb: |\n  text\n",
+		"---\n# comment\nc: [1, 2]\n...",
+		"--- {d: e}\n",
+	}
+	for _, exp := range expected {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		start, end := dec.LastDocumentRange()
+		if got := src[start.ByteOffset:end.ByteOffset]; got != exp {
+			t.Fatalf("expected %q but got %q", exp, got)
+		}
+		if got := end.Offset - start.Offset; got != utf8.RuneCountInString(exp) {
+			t.Fatalf("expected the offsets counted by character but got %d for %q", got, exp)
+		}
+	}
+	start, end := dec.LastDocumentRange()
+	if start.Line != 8 || start.Column != 1 || end.Line != 9 || end.Column != 1 {
+		t.Fatalf("unexpected position: %+v %+v", start, end)
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         string
+		frontMatter string
+		content     string
+	}{
+		{
+			name:        "front matter",
+			src:         "---\ntitle: hello\ntags: [a]\n---\n# Heading\n",
+			frontMatter: "title: hello\ntags: [a]\n",
+			content:     "# Heading\n",
+		},
+		{
+			name:        "end with dots and crlf",
+			src:         "---\r\ntitle: hello\r\n...\r\nbody",
+			frontMatter: "title: hello\r\n",
+			content:     "body",
+		},
+		{
+			name:    "no front matter",
+			src:     "# Heading\n---\n",
+			content: "# Heading\n---\n",
+		},
+		{
+			name:    "not closed",
+			src:     "---\ntitle: hello\n",
+			content: "---\ntitle: hello\n",
+		},
+		{
+			name:        "bom",
+			src:         "\xef\xbb\xbf---\ntitle: hello\n---\nbody\n",
+			frontMatter: "title: hello\n",
+			content:     "body\n",
+		},
+		{
+			name:        "closed at the end",
+			src:         "---\ntitle: hello\n---",
+			frontMatter: "title: hello\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frontMatter, content := yaml.SplitFrontMatter([]byte(test.src))
+			if string(frontMatter) != test.frontMatter {
+				t.Fatalf("expected front matter %q but got %q", test.frontMatter, frontMatter)
+			}
+			if string(content) != test.content {
+				t.Fatalf("expected content %q but got %q", test.content, content)
+			}
+			frontMatter, content, err := yaml.SplitFrontMatterReader(strings.NewReader(test.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(frontMatter) != test.frontMatter || string(content) != test.content {
+				t.Fatalf("unexpected result of reader: %q %q", frontMatter, content)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFrontMatter(t *testing.T) {
+	var v struct {
+		Title string   `yaml:"title"`
+		Tags  []string `yaml:"tags"`
+	}
+	content, err := yaml.UnmarshalFrontMatter([]byte("---\r\ntitle: hello\r\ntags: [a, b]\r\n---\r\nbody\r\n"), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Title != "hello" || strings.Join(v.Tags, ",") != "a,b" {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	if string(content) != "body\r\n" {
+		t.Fatalf("unexpected content: %q", content)
+	}
+
+	var unchanged struct {
+		Title string `yaml:"title"`
+	}
+	content, err = yaml.UnmarshalFrontMatter([]byte("body\n"), &unchanged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.Title != "" || string(content) != "body\n" {
+		t.Fatalf("unexpected result: %+v %q", unchanged, content)
+	}
+
+	if _, err := yaml.UnmarshalFrontMatter([]byte("---\ntitle: [\n---\nbody\n"), &v); err == nil {
+		t.Fatal("expected error for the invalid front matter")
+	}
+}
+
+func TestDecoder_PlainScalarResolver(t *testing.T) {
+	resolver := yaml.PlainScalarResolver(func(v string) (string, bool) {
+		if len(v) > 1 && v[0] == '0' && strings.Trim(v, "01234567") == "" {
+			return "!!str", true
+		}
+		if v == "1_000" {
+			return "!!int", true
+		}
+		return "", false
+	})
+	t.Run("interface", func(t *testing.T) {
+		var v map[string]interface{}
+		src := `
+mode: 0755
+quoted: "0644"
+num: 10
+time: 1:30:00
+sep: 1_000
+`
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, resolver); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]interface{}{
+			"mode":   "0755",
+			"quoted": "0644",
+			"num":    uint64(10),
+			"time":   "1:30:00",
+			"sep":    int64(1000),
+		}
+		if !reflect.DeepEqual(expected, v) {
+			t.Fatalf("expected %#v but got %#v", expected, v)
+		}
+	})
+	t.Run("struct", func(t *testing.T) {
+		var v struct {
+			Mode string `yaml:"mode"`
+		}
+		if err := yaml.UnmarshalWithOptions([]byte(`mode: 0755`), &v, resolver); err != nil {
+			t.Fatal(err)
+		}
+		if v.Mode != "0755" {
+			t.Fatalf("unexpected value: %q", v.Mode)
+		}
+	})
+	t.Run("invalid value for tag", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte(`v: abc`), &v, yaml.PlainScalarResolver(func(string) (string, bool) {
+			return "!!int", true
+		}))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_TabCharacter(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		tests := []struct {
+			src      string
+			expected interface{}
+		}{
+			{"a: b\t", map[string]interface{}{"a": "b"}},
+			{"a:\tb", map[string]interface{}{"a": "b"}},
+			{"a: 'x\ty'", map[string]interface{}{"a": "x\ty"}},
+			{"a: [a,\tb]", map[string]interface{}{"a": []interface{}{"a", "b"}}},
+			{"a: [\ta\t, b\t]", map[string]interface{}{"a": []interface{}{"a", "b"}}},
+			{"a: {b:\tc,\td: e}", map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": "e"}}},
+			{"a: {\tb: c}", map[string]interface{}{"a": map[string]interface{}{"b": "c"}}},
+			{"a: {b\t: c}", map[string]interface{}{"a": map[string]interface{}{"b": "c"}}},
+			{"a: {\n\tb: c,\n\td: e\n}", map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": "e"}}},
+			{"a: [\"x\"\t, 'y'\t]", map[string]interface{}{"a": []interface{}{"x", "y"}}},
+			{"- [a,\n\tb]", []interface{}{[]interface{}{"a", "b"}}},
+		}
+		for _, test := range tests {
+			t.Run(test.src, func(t *testing.T) {
+				var v interface{}
+				if err := yaml.Unmarshal([]byte(test.src), &v); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(test.expected, v) {
+					t.Fatalf("expected %v but got %v", test.expected, v)
+				}
+			})
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, src := range []string{
+			"\tb: c",
+			"a:\n\tb: c",
+		} {
+			t.Run(src, func(t *testing.T) {
+				var v interface{}
+				if err := yaml.Unmarshal([]byte(src), &v); err == nil {
+					t.Fatal("expected error")
+				}
+			})
+		}
+	})
+}
+
+func TestDecoder_DecodeRune(t *testing.T) {
+	type config struct {
+		Delim  rune   `yaml:"delim"`
+		Quote  *rune  `yaml:"quote"`
+		Chars  []rune `yaml:"chars"`
+		Number int32  `yaml:"number"`
+	}
+	t.Run("valid", func(t *testing.T) {
+		var v config
+		src := `
+delim: ","
+quote: '1'
+chars: あいう
+number: 10
+`
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.DecodeRune()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Delim != ',' || v.Quote == nil || *v.Quote != '1' || string(v.Chars) != "あいう" || v.Number != 10 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("rune option", func(t *testing.T) {
+		type runeConfig struct {
+			Delim  rune   `yaml:"delim,rune"`
+			Quote  *rune  `yaml:"quote,rune"`
+			Chars  []rune `yaml:"chars,rune"`
+			Number int32  `yaml:"number"`
+		}
+		var v runeConfig
+		src := `
+delim: ","
+quote: '1'
+chars: あいう
+number: 10
+`
+		if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Delim != ',' || v.Quote == nil || *v.Quote != '1' || string(v.Chars) != "あいう" || v.Number != 10 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+delim: ","
+quote: "1"
+chars: あいう
+number: 10
+`
+		if actual := "\n" + string(b); expected != actual {
+			t.Fatalf("expected:%s but got %s", expected, actual)
+		}
+		if err := yaml.Unmarshal([]byte(`number: a`), &v); err == nil {
+			t.Fatal("expected error for the int32 field without the rune option")
+		}
+	})
+	t.Run("named rune type", func(t *testing.T) {
+		type char int32
+		type charConfig struct {
+			Delim char   `yaml:"delim,rune"`
+			Chars []char `yaml:"chars,rune"`
+		}
+		v := charConfig{Delim: ',', Chars: []char{'あ', 'い'}}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+delim: ","
+chars: あい
+`
+		if actual := "\n" + string(b); expected != actual {
+			t.Fatalf("expected:%s but got %s", expected, actual)
+		}
+		var decoded charConfig
+		if err := yaml.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("expected %+v but got %+v", v, decoded)
+		}
+	})
+	t.Run("invalid rune option", func(t *testing.T) {
+		var v struct {
+			A string `yaml:"a,rune"`
+		}
+		if _, err := yaml.Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("multiple characters", func(t *testing.T) {
+		var v config
+		if err := yaml.UnmarshalWithOptions([]byte(`delim: ab`), &v, yaml.DecodeRune()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("without option", func(t *testing.T) {
+		var v config
+		if err := yaml.Unmarshal([]byte(`delim: a`), &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+type nodeUnmarshalerValue struct {
+	node ast.Node
+}
+
+func (v *nodeUnmarshalerValue) UnmarshalYAML(node ast.Node) error {
+	v.node = node
+	return nil
+}
+
+func TestDecoder_NodeUnmarshaler(t *testing.T) {
+	var v struct {
+		A nodeUnmarshalerValue
+	}
+	if err := yaml.Unmarshal([]byte(`
+a:
+  b: 1 # comment
+`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.node == nil {
+		t.Fatal("failed to get node")
+	}
+	if v.A.node.Type() != ast.MappingType {
+		t.Fatalf("unexpected node type: %s", v.A.node.Type())
+	}
+}
+
+type pointerTextUnmarshaler struct {
+	v string
+}
+
+func (u *pointerTextUnmarshaler) UnmarshalText(b []byte) error {
+	u.v = "text:" + string(b)
+	return nil
+}
+
+type pointerInterfaceUnmarshaler struct {
+	v string
+}
+
+func (u *pointerInterfaceUnmarshaler) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	u.v = "yaml:" + s
+	return nil
+}
+
+func TestDecoder_PointerReceiverUnmarshaler(t *testing.T) {
+	t.Run("map value", func(t *testing.T) {
+		var v map[string]pointerTextUnmarshaler
+		if err := yaml.Unmarshal([]byte(`a: x`), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v["a"].v != "text:x" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("array element", func(t *testing.T) {
+		var v [2]pointerInterfaceUnmarshaler
+		if err := yaml.Unmarshal([]byte(`[x, y]`), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v[0].v != "yaml:x" || v[1].v != "yaml:y" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("map key", func(t *testing.T) {
+		var v map[pointerTextUnmarshaler]int
+		if err := yaml.Unmarshal([]byte(`x: 1`), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v[pointerTextUnmarshaler{v: "text:x"}] != 1 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("pointer map key", func(t *testing.T) {
+		var v map[*pointerInterfaceUnmarshaler]int
+		if err := yaml.Unmarshal([]byte(`x: 1`), &v); err != nil {
+			t.Fatal(err)
+		}
+		if len(v) != 1 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		for k, n := range v {
+			if k.v != "yaml:x" || n != 1 {
+				t.Fatalf("unexpected value: %+v: %d", k, n)
+			}
+		}
+	})
+	t.Run("interface holding pointer", func(t *testing.T) {
+		v := struct {
+			A encoding.TextUnmarshaler
+			B interface{}
+		}{
+			A: &pointerTextUnmarshaler{},
+			B: &pointerInterfaceUnmarshaler{},
+		}
+		if err := yaml.Unmarshal([]byte("a: x\nb: y\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if a, ok := v.A.(*pointerTextUnmarshaler); !ok || a.v != "text:x" {
+			t.Fatalf("unexpected value: %+v", v.A)
+		}
+		if b, ok := v.B.(*pointerInterfaceUnmarshaler); !ok || b.v != "yaml:y" {
+			t.Fatalf("unexpected value: %+v", v.B)
+		}
+	})
+	t.Run("unassignable interface", func(t *testing.T) {
+		var v map[string]encoding.TextUnmarshaler
+		if err := yaml.Unmarshal([]byte(`a: 1`), &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_Stats(t *testing.T) {
+	src := `
+a: &x [1, 2]
+b: *x
+c: |
+  text
+# comment
+---
+d: {e: f}
+`
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	expected := []ast.Stats{
+		{Documents: 1, Nodes: 13, Scalars: 6, Mappings: 1, Sequences: 1, Anchors: 1, Aliases: 1},
+		{Documents: 1, Nodes: 7, Scalars: 3, Mappings: 2},
+	}
+	for _, stats := range expected {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if got := dec.Stats(); got != stats {
+			t.Fatalf("unexpected stats. expected %+v but got %+v", stats, got)
+		}
+	}
+
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := ast.Stats{Documents: 2, Nodes: 20, Scalars: 9, Mappings: 3, Sequences: 1, Anchors: 1, Aliases: 1}
+	if got := f.Stats(); got != total {
+		t.Fatalf("unexpected stats. expected %+v but got %+v", total, got)
+	}
+}
+
+func TestDecoder_DecodeFromNodeWithDocument(t *testing.T) {
+	f, err := parser.ParseBytes([]byte(`
+defaults: &defaults
+  timeout: 10
+  retry: 3
+name: &name first
+services:
+  api:
+    <<: *defaults
+    name: *name
+name2: &name second
+`), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := f.Docs[0]
+	path, err := yaml.PathString("$.services.api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := path.FilterNode(doc.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		Timeout int
+		Retry   int
+		Name    string
+	}
+	dec := yaml.NewDecoder(strings.NewReader(""))
+	if err := dec.DecodeFromNodeWithDocument(doc, node, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Timeout != 10 || v.Retry != 3 || v.Name != "first" {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+
+	if err := yaml.NewDecoder(strings.NewReader("")).DecodeFromNode(node, &v); err == nil {
+		t.Fatal("expected error for undefined alias")
+	}
+}
+
+type reparsedBytes struct {
+	src []byte
+	v   interface{}
+}
+
+func (r *reparsedBytes) UnmarshalYAML(b []byte) error {
+	r.src = b
+	return yaml.Unmarshal(b, &r.v)
+}
+
+func TestDecoder_UnmarshalBytesWithAlias(t *testing.T) {
+	yml := `
+base: &base
+  a: 1
+  b: [1, 2]
+  c: |
+    lit
+top: &top x
+child:
+  val: *base
+  merged:
+    <<: *base
+    d: 2
+  flow: {k: *base, t: *top}
+  list:
+    - *base
+    - *top
+alias: *base
+`
+	var v struct {
+		Child reparsedBytes
+		Alias reparsedBytes
+	}
+	if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+		t.Fatal(err)
+	}
+	base := map[string]interface{}{
+		"a": uint64(1),
+		"b": []interface{}{uint64(1), uint64(2)},
+		"c": "lit\n",
+	}
+	merged := map[string]interface{}{
+		"a": uint64(1),
+		"b": []interface{}{uint64(1), uint64(2)},
+		"c": "lit\n",
+		"d": uint64(2),
+	}
+	expected := map[string]interface{}{
+		"val":    base,
+		"merged": merged,
+		"flow":   map[string]interface{}{"k": base, "t": "x"},
+		"list":   []interface{}{base, "x"},
+	}
+	if !reflect.DeepEqual(v.Child.v, expected) {
+		t.Fatalf("unexpected child value: %v\nsource:\n%s", v.Child.v, v.Child.src)
+	}
+	if !reflect.DeepEqual(v.Alias.v, base) {
+		t.Fatalf("unexpected alias value: %v\nsource:\n%s", v.Alias.v, v.Alias.src)
+	}
+	if strings.Contains(string(v.Child.src), "*") {
+		t.Fatalf("source passed to UnmarshalYAML must not contain aliases:\n%s", v.Child.src)
+	}
+
+	// the parsed AST is not modified by expanding the aliases.
+	f, err := parser.ParseBytes([]byte(yml), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := f.String()
+	for i := 0; i < 2; i++ {
+		var again struct {
+			Child reparsedBytes
+		}
+		if err := yaml.NewDecoder(strings.NewReader("")).DecodeFromNode(f.Docs[0].Body, &again); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(again.Child.v, expected) {
+			t.Fatalf("unexpected child value: %v", again.Child.v)
+		}
+	}
+	if after := f.String(); after != before {
+		t.Fatalf("source AST is modified:\n%s", after)
+	}
+}
+
+type mapKeyName interface {
+	Name() string
+}
+
+type mapKeyString string
+
+func (s mapKeyString) Name() string { return string(s) }
+
+func TestDecoder_MapKeyInstantiation(t *testing.T) {
+	t.Run("pointer key with text unmarshaler", func(t *testing.T) {
+		var v map[*pointerTextUnmarshaler]int
+		if err := yaml.Unmarshal([]byte("x: 1\ny: 2\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]int{}
+		for k, n := range v {
+			got[k.v] = n
+		}
+		if !reflect.DeepEqual(got, map[string]int{"text:x": 1, "text:y": 2}) {
+			t.Fatalf("unexpected value: %+v", got)
+		}
+	})
+	t.Run("pointer key without unmarshaler", func(t *testing.T) {
+		var v map[*string]int
+		if err := yaml.Unmarshal([]byte("x: 1\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		for k, n := range v {
+			if *k != "x" || n != 1 {
+				t.Fatalf("unexpected value: %s: %d", *k, n)
+			}
+		}
+	})
+	t.Run("interface key with custom unmarshaler", func(t *testing.T) {
+		var v map[mapKeyName]int
+		if err := yaml.UnmarshalWithOptions([]byte("x: 1\n"), &v, yaml.CustomUnmarshaler[mapKeyName](func(k *mapKeyName, b []byte) error {
+			*k = mapKeyString("custom:" + string(b))
+			return nil
+		})); err != nil {
+			t.Fatal(err)
+		}
+		if v[mapKeyString("custom:x")] != 1 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("interface key not implemented by decoded value", func(t *testing.T) {
+		var v map[mapKeyName]int
+		err := yaml.Unmarshal([]byte("x: 1\n"), &v)
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("expected type error but got %v", err)
+		}
+	})
+	t.Run("interface key holding non comparable value", func(t *testing.T) {
+		var v map[interface{}]int
+		err := yaml.UnmarshalWithOptions([]byte("x: 1\n"), &v, yaml.CustomUnmarshaler[interface{}](func(k *interface{}, b []byte) error {
+			*k = []string{string(b)}
+			return nil
+		}))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+type complexMapKey struct {
+	A int `yaml:"a"`
+	B int `yaml:"b"`
+}
+
+func TestDecoder_ComplexMapKey(t *testing.T) {
+	t.Run("struct key", func(t *testing.T) {
+		var v map[complexMapKey]string
+		if err := yaml.Unmarshal([]byte("? {a: 1, b: 2}\n: x\n? {a: 3}\n: y\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[complexMapKey]string{{A: 1, B: 2}: "x", {A: 3}: "y"}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("array key", func(t *testing.T) {
+		var v map[[2]int]string
+		if err := yaml.Unmarshal([]byte("? [1, 2]\n: x\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, map[[2]int]string{{1, 2}: "x"}) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("numeric key", func(t *testing.T) {
+		var v map[int]string
+		if err := yaml.Unmarshal([]byte("1: x\n0x10: y\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, map[int]string{1: "x", 16: "y"}) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("interface key holding sequence", func(t *testing.T) {
+		var v map[interface{}]string
+		err := yaml.Unmarshal([]byte("? [1, 2]\n: x\n"), &v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "not comparable") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("sequence into struct key", func(t *testing.T) {
+		var v map[complexMapKey]string
+		if err := yaml.Unmarshal([]byte("? [1, 2]\n: x\n"), &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_DisallowMergeKeyOverride(t *testing.T) {
+	t.Run("override", func(t *testing.T) {
+		yml := `
+a: &a
+  x: 1
+  y: 2
+b:
+  <<: *a
+  x: 3
+`
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.DisallowMergeKeyOverride())
+		var overrideErr *yaml.MergeKeyOverrideError
+		if !errors.As(err, &overrideErr) {
+			t.Fatalf("expected merge key override error but got %v", err)
+		}
+		if overrideErr.Key != "x" {
+			t.Fatalf("unexpected key: %s", overrideErr.Key)
+		}
+		if pos := overrideErr.MergedToken.Position; pos.Line != 3 || pos.Column != 3 {
+			t.Fatalf("unexpected merged key position: %d:%d", pos.Line, pos.Column)
+		}
+		if pos := overrideErr.Token.Position; pos.Line != 7 || pos.Column != 3 {
+			t.Fatalf("unexpected key position: %d:%d", pos.Line, pos.Column)
+		}
+		expected := `
+[7:3] key "x" overrides the merged key defined at [3:3]
+   4 |   y: 2
+   5 | b:
+   6 |   <<: *a
+>  7 |   x: 3
+         ^
+`
+		if got := "\n" + yaml.FormatError(err, false, true); got != expected {
+			t.Fatalf("unexpected error message:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("override key merged by nested merge", func(t *testing.T) {
+		yml := `
+a: &a {x: 1}
+b: &b
+  <<: *a
+  z: 1
+c:
+  <<: [*b]
+  x: 2
+`
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.DisallowMergeKeyOverride())
+		var overrideErr *yaml.MergeKeyOverrideError
+		if !errors.As(err, &overrideErr) {
+			t.Fatalf("expected merge key override error but got %v", err)
+		}
+		if pos := overrideErr.MergedToken.Position; pos.Line != 2 || pos.Column != 8 {
+			t.Fatalf("unexpected merged key position: %d:%d", pos.Line, pos.Column)
+		}
+	})
+	t.Run("no override", func(t *testing.T) {
+		yml := `
+a: &a {x: 1}
+b:
+  <<: *a
+  y: 2
+`
+		var v map[string]interface{}
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.DisallowMergeKeyOverride()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("default allows override", func(t *testing.T) {
+		yml := `
+a: &a {x: 1}
+b:
+  <<: *a
+  x: 2
+`
+		var v map[string]map[string]int
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v["b"]["x"] != 2 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+}
+
+func TestDecoder_MergedValueError(t *testing.T) {
+	type T struct {
+		X int `yaml:"x"`
+		Y int `yaml:"y"`
+	}
+	t.Run("alias", func(t *testing.T) {
+		yml := `
+base: &base
+  x: a
+t:
+  <<: *base
+  y: 2
+`
+		var v struct {
+			T T `yaml:"t"`
+		}
+		err := yaml.Unmarshal([]byte(yml), &v)
+		var mergedErr *yaml.MergedValueError
+		if !errors.As(err, &mergedErr) {
+			t.Fatalf("expected merged value error but got %v", err)
+		}
+		if mergedErr.Alias != "base" {
+			t.Fatalf("unexpected alias: %s", mergedErr.Alias)
+		}
+		if pos := mergedErr.AliasToken.Position; pos.Line != 5 || pos.Column != 7 {
+			t.Fatalf("unexpected alias position: %d:%d", pos.Line, pos.Column)
+		}
+		if pos := mergedErr.AnchorToken.Position; pos.Line != 2 || pos.Column != 7 {
+			t.Fatalf("unexpected anchor position: %d:%d", pos.Line, pos.Column)
+		}
+		var te *yaml.TypeError
+		if !errors.As(err, &te) {
+			t.Fatalf("expected type error but got %v", err)
+		}
+		expected := `
+value merged by *base at [5:7] from &base at [2:7]: [3:6] cannot unmarshal string into Go struct field .T of type int
+   2 | base: &base
+>  3 |   x: a
+            ^
+   4 | t:
+   5 |   <<: *base
+   6 |   y: 2`
+		if got := "\n" + yaml.FormatError(err, false, true); got != expected {
+			t.Fatalf("unexpected error message:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("sequence of aliases into map", func(t *testing.T) {
+		yml := `
+a: &a {x: 1}
+b: &b {y: b}
+t:
+  <<: [*a, *b]
+`
+		var v struct {
+			T map[string]int `yaml:"t"`
+		}
+		err := yaml.Unmarshal([]byte(yml), &v)
+		var mergedErr *yaml.MergedValueError
+		if !errors.As(err, &mergedErr) {
+			t.Fatalf("expected merged value error but got %v", err)
+		}
+		if mergedErr.Alias != "b" {
+			t.Fatalf("unexpected alias: %s", mergedErr.Alias)
+		}
+		if pos := mergedErr.AliasToken.Position; pos.Line != 5 || pos.Column != 12 {
+			t.Fatalf("unexpected alias position: %d:%d", pos.Line, pos.Column)
+		}
+	})
+	t.Run("nested merge", func(t *testing.T) {
+		yml := `
+a: &a
+  x: a
+b: &b
+  <<: *a
+t:
+  <<: [*b]
+`
+		var v struct {
+			T T `yaml:"t"`
+		}
+		err := yaml.Unmarshal([]byte(yml), &v)
+		var mergedErr *yaml.MergedValueError
+		if !errors.As(err, &mergedErr) {
+			t.Fatalf("expected merged value error but got %v", err)
+		}
+		// the alias merging the anchor defining the value is reported.
+		if pos := mergedErr.AliasToken.Position; mergedErr.Alias != "a" || pos.Line != 5 {
+			t.Fatalf("unexpected alias: %s at %d:%d", mergedErr.Alias, pos.Line, pos.Column)
+		}
+	})
+	t.Run("unknown field", func(t *testing.T) {
+		yml := `
+base: &base
+  z: 1
+t:
+  <<: *base
+`
+		var v struct {
+			T T `yaml:"t"`
+		}
+		err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.DisallowUnknownField())
+		var mergedErr *yaml.MergedValueError
+		if !errors.As(err, &mergedErr) {
+			t.Fatalf("expected merged value error but got %v", err)
+		}
+		var unknownErr *yaml.UnknownFieldError
+		if !errors.As(err, &unknownErr) {
+			t.Fatalf("expected unknown field error but got %v", err)
+		}
+	})
+	t.Run("explicit value", func(t *testing.T) {
+		yml := `
+base: &base
+  x: 1
+t:
+  <<: *base
+  y: b
+`
+		var v struct {
+			T T `yaml:"t"`
+		}
+		err := yaml.Unmarshal([]byte(yml), &v)
+		var mergedErr *yaml.MergedValueError
+		if err == nil || errors.As(err, &mergedErr) {
+			t.Fatalf("expected the error without the merged value error but got %v", err)
+		}
+	})
+	t.Run("override merged key of struct", func(t *testing.T) {
+		yml := `
+a: &a {x: 1, y: 2}
+b: &b {x: 3}
+t:
+  <<: [*b, *a]
+  y: 4
+`
+		var v struct {
+			T T `yaml:"t"`
+		}
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.T.X != 3 || v.T.Y != 4 {
+			t.Fatalf("unexpected value: %+v", v.T)
+		}
+	})
+}
+
+func TestDecoder_AnchorRedefinition(t *testing.T) {
+	yml := `
+a: &x 1
+b: *x
+c: &x 2
+d: *x
+`
+	t.Run("last definition wins", func(t *testing.T) {
+		var v struct {
+			A, B, C, D int
+		}
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.B != 1 || v.D != 2 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		var m map[string]interface{}
+		if err := yaml.Unmarshal([]byte(yml), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m["b"] != uint64(1) || m["d"] != uint64(2) {
+			t.Fatalf("unexpected value: %+v", m)
+		}
+	})
+	t.Run("fields in the reverse order", func(t *testing.T) {
+		var v struct {
+			D, C, B, A int
+		}
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.B != 1 || v.D != 2 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		var p struct {
+			D, C, B, A *int
+		}
+		if err := yaml.Unmarshal([]byte(yml), &p); err != nil {
+			t.Fatal(err)
+		}
+		if *p.B != 1 || *p.D != 2 {
+			t.Fatalf("unexpected value: B=%d D=%d", *p.B, *p.D)
+		}
+	})
+	t.Run("disallow", func(t *testing.T) {
+		var v map[string]int
+		err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.DisallowAnchorRedefinition())
+		var redefErr *yaml.AnchorRedefinitionError
+		if !errors.As(err, &redefErr) {
+			t.Fatalf("expected anchor redefinition error but got %v", err)
+		}
+		if redefErr.Name != "x" {
+			t.Fatalf("unexpected name: %s", redefErr.Name)
+		}
+		if pos := redefErr.PrevToken.Position; pos.Line != 2 || pos.Column != 4 {
+			t.Fatalf("unexpected previous position: %d:%d", pos.Line, pos.Column)
+		}
+		expected := `
+[4:4] anchor "x" is redefined, previously defined at [2:4]
+   2 | a: &x 1
+   3 | b: *x
+>  4 | c: &x 2
+          ^
+   5 | d: *x`
+		if got := "\n" + yaml.FormatError(err, false, true); got != expected {
+			t.Fatalf("unexpected error message:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("anchors in other documents", func(t *testing.T) {
+		dec := yaml.NewDecoder(strings.NewReader("a: &x 1\n---\nb: &x 2\n"), yaml.DisallowAnchorRedefinition())
+		for {
+			var v map[string]int
+			if err := dec.Decode(&v); err != nil {
+				if err == io.EOF {
+					break
+				}
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDecoder_TextUnmarshalerMapKey(t *testing.T) {
+	type config struct {
+		Port int
+	}
+	yml := `
+10.0.0.1:
+  port: 80
+::1:
+  port: 8080
+`
+	var v map[netip.Addr]config
+	if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[netip.Addr]config{
+		netip.MustParseAddr("10.0.0.1"): {Port: 80},
+		netip.MustParseAddr("::1"):      {Port: 8080},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[netip.Addr]config
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("failed to round trip: %+v", got)
+	}
+}
+
+type defaultServer struct {
+	Host    string   `yaml:"host,default=localhost"`
+	Port    int      `yaml:"port,default=8080"`
+	Enabled bool     `yaml:"enabled,default=true"`
+	Tags    []string `yaml:"tags,omitempty,default=[a, b]"`
+	Retry   defaultRetry
+}
+
+type defaultRetry struct {
+	Count    int           `yaml:"count,default=3"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+func (r *defaultRetry) SetYAMLDefaults() {
+	r.Interval = time.Second
+}
+
+func TestDecoder_DefaultValue(t *testing.T) {
+	t.Run("absent keys", func(t *testing.T) {
+		var v defaultServer
+		if err := yaml.Unmarshal([]byte("host: example.com\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		expected := defaultServer{
+			Host:    "example.com",
+			Port:    8080,
+			Enabled: true,
+			Tags:    []string{"a", "b"},
+			Retry:   defaultRetry{Count: 3, Interval: time.Second},
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("explicit zero values", func(t *testing.T) {
+		var v defaultServer
+		yml := `
+port: 0
+enabled: false
+tags: []
+retry:
+  count: 0
+  interval: 0s
+`
+		if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+			t.Fatal(err)
+		}
+		expected := defaultServer{Host: "localhost", Tags: []string{}}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("slice elements", func(t *testing.T) {
+		var v []defaultServer
+		if err := yaml.Unmarshal([]byte("- port: 1\n- retry: {count: 5}\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if len(v) != 2 {
+			t.Fatalf("unexpected length: %d", len(v))
+		}
+		if v[0].Host != "localhost" || v[0].Port != 1 || v[0].Retry.Count != 3 {
+			t.Fatalf("unexpected first element: %+v", v[0])
+		}
+		if v[1].Port != 8080 || v[1].Retry.Count != 5 || v[1].Retry.Interval != time.Second {
+			t.Fatalf("unexpected second element: %+v", v[1])
+		}
+	})
+	t.Run("pre-populated values", func(t *testing.T) {
+		v := defaultServer{Port: 9090}
+		if err := yaml.Unmarshal([]byte("host: example.com\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Port != 9090 {
+			t.Fatalf("unexpected port: %d", v.Port)
+		}
+	})
+	t.Run("invalid default value", func(t *testing.T) {
+		var v struct {
+			Port int `yaml:"port,default=abc"`
+		}
+		err := yaml.Unmarshal([]byte("{}"), &v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "failed to set default value") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDecoder_Present(t *testing.T) {
+	type config struct {
+		A yaml.Present[int]                `yaml:"a"`
+		B yaml.Present[string]             `yaml:"b"`
+		C yaml.Present[map[string]int]     `yaml:"c"`
+		D *yaml.Present[int]               `yaml:"d"`
+		E []yaml.Present[int]              `yaml:"e"`
+		F yaml.Present[*yaml.Present[int]] `yaml:"f"`
+	}
+	yml := `
+a: 0
+b: null
+c: {x: 1}
+e: [1, null]
+`
+	var v config
+	if err := yaml.Unmarshal([]byte(yml), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.A.Set || v.A.Null || v.A.Value != 0 {
+		t.Fatalf("unexpected a: %+v", v.A)
+	}
+	if !v.B.Set || !v.B.Null {
+		t.Fatalf("unexpected b: %+v", v.B)
+	}
+	if !v.C.Set || v.C.Value["x"] != 1 {
+		t.Fatalf("unexpected c: %+v", v.C)
+	}
+	if v.D != nil {
+		t.Fatalf("unexpected d: %+v", v.D)
+	}
+	expectedE := []yaml.Present[int]{{Value: 1, Set: true}, {Set: true, Null: true}}
+	if !reflect.DeepEqual(v.E, expectedE) {
+		t.Fatalf("unexpected e: %+v", v.E)
+	}
+	if v.F.Set {
+		t.Fatalf("unexpected f: %+v", v.F)
+	}
+}
+
+func TestUnmarshalTo(t *testing.T) {
+	type config struct {
+		Name string `yaml:"name"`
+		Port int    `yaml:"port"`
+	}
+	t.Run("struct", func(t *testing.T) {
+		v, err := yaml.UnmarshalTo[config]([]byte("name: a\nport: 80\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != (config{Name: "a", Port: 80}) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("pointer", func(t *testing.T) {
+		v, err := yaml.UnmarshalTo[*config]([]byte("name: a\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v == nil || v.Name != "a" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("options", func(t *testing.T) {
+		_, err := yaml.UnmarshalTo[config]([]byte("name: a\nhost: b\n"), yaml.DisallowUnknownField())
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("error returns zero value", func(t *testing.T) {
+		v, err := yaml.UnmarshalTo[config]([]byte("name: a\nport: x\n"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if v != (config{}) {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("must", func(t *testing.T) {
+		if v := yaml.MustUnmarshalTo[[]int]([]byte("[1, 2]")); len(v) != 2 || v[1] != 2 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		yaml.MustUnmarshalTo[int]([]byte("a"))
+	})
+	t.Run("decoder", func(t *testing.T) {
+		dec := yaml.NewDecoder(strings.NewReader("name: a\n---\nname: b\n"))
+		var names []string
+		for {
+			v, err := yaml.DecodeTo[config](dec)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, v.Name)
+		}
+		if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+			t.Fatalf("unexpected values: %v", names)
+		}
+	})
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	type item struct {
+		Name  string `yaml:"name"`
+		Count int    `yaml:"count"`
+	}
+	type config struct {
+		Items []item `yaml:"items"`
+		Port  int    `yaml:"port"`
+	}
+	t.Run("all errors", func(t *testing.T) {
+		yml := `
+items:
+  - name: a
+    count: x
+  - name: b
+    size: 1
+port: y
+host: localhost
+`
+		var v config
+		err := yaml.UnmarshalStrict([]byte(yml), &v)
+		var multi *yaml.MultiError
+		if !errors.As(err, &multi) {
+			t.Fatalf("expected MultiError but got %v", err)
+		}
+		if len(multi.Errors) != 4 {
+			t.Fatalf("unexpected number of errors: %d\n%v", len(multi.Errors), err)
+		}
+		var typeErr *yaml.TypeError
+		if !errors.As(multi.Errors[0], &typeErr) || !errors.As(multi.Errors[2], &typeErr) {
+			t.Fatalf("expected type errors but got %v", err)
+		}
+		var unknownErr *yaml.UnknownFieldError
+		if !errors.As(multi.Errors[1], &unknownErr) || !errors.As(multi.Errors[3], &unknownErr) {
+			t.Fatalf("expected unknown field errors but got %v", err)
+		}
+		if !strings.Contains(yaml.FormatError(err, false, true), `unknown field "host"`) {
+			t.Fatalf("unexpected error message: %s", yaml.FormatError(err, false, true))
+		}
+	})
+	t.Run("duplicate key", func(t *testing.T) {
+		var v config
+		if err := yaml.UnmarshalStrict([]byte("port: 1\nport: 2\n"), &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("valid", func(t *testing.T) {
+		var v config
+		if err := yaml.UnmarshalStrict([]byte("items: [{name: a, count: 1}]\nport: 80\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if len(v.Items) != 1 || v.Items[0].Count != 1 || v.Port != 80 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("first error by default", func(t *testing.T) {
+		var v config
+		err := yaml.UnmarshalWithOptions([]byte("port: y\nhost: localhost\n"), &v, yaml.Strict())
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("expected type error but got %v", err)
+		}
+	})
+}
+
+func TestDecoder_CaseInsensitiveKeys(t *testing.T) {
+	type Embedded struct {
+		Host string `yaml:"host"`
+	}
+	type config struct {
+		Embedded `yaml:",inline"`
+		Port     int
+		Name     string `yaml:"name"`
+	}
+	t.Run("mixed case", func(t *testing.T) {
+		yml := `
+HOST: localhost
+PORT: 8080
+Name: app
+`
+		var v config
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.CaseInsensitiveKeys(), yaml.Strict()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Host != "localhost" || v.Port != 8080 || v.Name != "app" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("exact match is preferred", func(t *testing.T) {
+		yml := `
+name: exact
+NAME: upper
+Name: title
+`
+		var v config
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.CaseInsensitiveKeys(), yaml.Strict()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Name != "exact" {
+			t.Fatalf("unexpected name: %q", v.Name)
+		}
+	})
+	t.Run("last key wins", func(t *testing.T) {
+		yml := `
+NAME: upper
+Name: title
+`
+		var v config
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.CaseInsensitiveKeys()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Name != "title" {
+			t.Fatalf("unexpected name: %q", v.Name)
+		}
+	})
+	t.Run("case sensitive by default", func(t *testing.T) {
+		var v config
+		if err := yaml.Unmarshal([]byte("PORT: 8080\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Port != 0 {
+			t.Fatalf("unexpected port: %d", v.Port)
+		}
+	})
+}
+
+func TestDecoder_CaptureSourcePositions(t *testing.T) {
+	yml := `
+spec:
+  replicas: 0
+  containers:
+    - name: app
+      image: app:latest
+    - &sidecar {name: proxy}
+metadata: {name: test}
+`
+	var v struct {
+		Spec struct {
+			Replicas   int `yaml:"replicas"`
+			Containers []struct {
+				Name  string `yaml:"name"`
+				Image string `yaml:"image"`
+			} `yaml:"containers"`
+		} `yaml:"spec"`
+		Metadata map[string]any `yaml:"metadata"`
+	}
+	var sm yaml.SourceMap
+	if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.CaptureSourcePositions(&sm)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path   string
+		line   int
+		column int
+	}{
+		{"$", 2, 1},
+		{"$.spec", 2, 1},
+		{"$.spec.replicas", 3, 3},
+		{"$.spec.containers[0]", 5, 7},
+		{"$.spec.containers[0].image", 6, 7},
+		{"$.spec.containers[1]", 7, 7},
+		{"$.spec.containers[1].name", 7, 17},
+		{"$.metadata.name", 8, 12},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			path, err := yaml.PathString(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pos, ok := sm.Position(path)
+			if !ok {
+				t.Fatalf("position of %s is not found", test.path)
+			}
+			if pos.Line != test.line || pos.Column != test.column {
+				t.Fatalf("unexpected position: expected %d:%d but got %d:%d", test.line, test.column, pos.Line, pos.Column)
+			}
+		})
+	}
+	path, err := yaml.PathString("$.spec.unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sm.Position(path); ok {
+		t.Fatal("expected no position for the unknown path")
+	}
+	if err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.CaptureSourcePositions(nil)); err == nil {
+		t.Fatal("expected error for nil source map")
+	}
+}
+
+type infiniteReader struct{}
+
+func (infiniteReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+func TestDecoder_MaxDocumentBytes(t *testing.T) {
+	t.Run("exceeded", func(t *testing.T) {
+		var v any
+		err := yaml.NewDecoder(infiniteReader{}, yaml.MaxDocumentBytes(1024)).Decode(&v)
+		if !errors.Is(err, yaml.ErrExceededMaxDocumentBytes) {
+			t.Fatalf("expected ErrExceededMaxDocumentBytes but got %v", err)
+		}
+	})
+	t.Run("within limit", func(t *testing.T) {
+		var v map[string]int
+		if err := yaml.UnmarshalWithOptions([]byte("a: 1\n"), &v, yaml.MaxDocumentBytes(5)); err != nil {
+			t.Fatal(err)
+		}
+		if v["a"] != 1 {
+			t.Fatalf("unexpected value: %v", v)
+		}
+	})
+	t.Run("NUL byte", func(t *testing.T) {
+		var v any
+		err := yaml.UnmarshalWithOptions([]byte("a: 1\nb: \x00\n"), &v, yaml.DisallowNULByte())
+		if !errors.Is(err, yaml.ErrNULByte) {
+			t.Fatalf("expected ErrNULByte but got %v", err)
+		}
+		if !strings.Contains(err.Error(), "line 2, column 4") {
+			t.Fatalf("unexpected error message: %v", err)
+		}
+	})
+}
+
+func TestDecoder_OnDuplicateKey(t *testing.T) {
+	yml := `
+a: 1
+b:
+  c: 2
+  c: 3
+a: 4
+`
+	var reported []string
+	var v struct {
+		A int            `yaml:"a"`
+		B map[string]int `yaml:"b"`
+	}
+	err := yaml.UnmarshalWithOptions([]byte(yml), &v, yaml.OnDuplicateKey(func(path string, firstTk, dupTk *token.Token) {
+		reported = append(reported, fmt.Sprintf("%s %d:%d %d:%d", path, firstTk.Position.Line, firstTk.Position.Column, dupTk.Position.Line, dupTk.Position.Column))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 4 || v.B["c"] != 3 {
+		t.Fatalf("the last value should be used: %+v", v)
+	}
+	sort.Strings(reported)
+	expected := []string{"$.a 2:1 6:1", "$.b.c 4:3 5:3"}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Fatalf("unexpected duplicated keys: %q", reported)
+	}
+}
+
+type selectorDriver interface {
+	DSN() string
+}
+
+type selectorPostgres struct {
+	Type string `yaml:"type"`
+	Host string `yaml:"host"`
+}
+
+func (p *selectorPostgres) DSN() string { return "postgres://" + p.Host }
+
+type selectorSQLite struct {
+	Type string `yaml:"type"`
+	Path string `yaml:"path"`
+}
+
+func (s selectorSQLite) DSN() string { return "sqlite://" + s.Path }
+
+func TestDecoder_TypeSelector(t *testing.T) {
+	opt := yaml.TypeSelector("type", map[string]reflect.Type{
+		"postgres": reflect.TypeOf(selectorPostgres{}),
+		"sqlite":   reflect.TypeOf(selectorSQLite{}),
+	})
+	t.Run("select type", func(t *testing.T) {
+		yml := `
+primary:
+  type: postgres
+  host: db
+replicas:
+  - type: sqlite
+    path: a.db
+`
+		var v struct {
+			Primary  selectorDriver   `yaml:"primary"`
+			Replicas []selectorDriver `yaml:"replicas"`
+		}
+		if err := yaml.UnmarshalWithOptions([]byte(yml), &v, opt, yaml.Strict()); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := v.Primary.(*selectorPostgres); !ok || v.Primary.DSN() != "postgres://db" {
+			t.Fatalf("unexpected primary: %#v", v.Primary)
+		}
+		if _, ok := v.Replicas[0].(selectorSQLite); !ok || v.Replicas[0].DSN() != "sqlite://a.db" {
+			t.Fatalf("unexpected replica: %#v", v.Replicas[0])
+		}
+	})
+	t.Run("unknown type", func(t *testing.T) {
+		var v struct {
+			Primary selectorDriver `yaml:"primary"`
+		}
+		err := yaml.UnmarshalWithOptions([]byte("primary:\n  type: mysql\n"), &v, opt)
+		if err == nil || !strings.Contains(err.Error(), `unknown type "mysql"`) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("empty interface is not affected", func(t *testing.T) {
+		var v map[string]any
+		if err := yaml.UnmarshalWithOptions([]byte("primary:\n  type: postgres\n"), &v, opt); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := v["primary"].(map[string]any); !ok {
+			t.Fatalf("unexpected value: %#v", v["primary"])
+		}
+	})
+}
+
+func TestDecoder_UseNumber(t *testing.T) {
+	src := `
+int: 1
+negative: -2
+hex: 0x1F
+underscore: 1_000
+float: 1.5
+big: 18446744073709551616
+list: [3, 4.0]
+str: "12"
+`
+	var v map[string]interface{}
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"int":        yaml.Number("1"),
+		"negative":   yaml.Number("-2"),
+		"hex":        yaml.Number("0x1F"),
+		"underscore": yaml.Number("1_000"),
+		"float":      yaml.Number("1.5"),
+		"big":        yaml.Number("18446744073709551616"),
+		"list":       []interface{}{yaml.Number("3"), yaml.Number("4.0")},
+		"str":        "12",
+	}
+	if !reflect.DeepEqual(expected, v) {
+		t.Fatalf("unexpected value: %#v", v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{"big":18446744073709551616,"float":1.5,"hex":31,"int":1,"list":[3,4.0],"negative":-2,"str":"12","underscore":1000}`
+	if string(b) != expectedJSON {
+		t.Fatalf("expected %s but got %s", expectedJSON, b)
+	}
+
+	t.Run("conversion", func(t *testing.T) {
+		if i, err := yaml.Number("0o17").Int64(); err != nil || i != 15 {
+			t.Fatalf("unexpected int64: %d, %v", i, err)
+		}
+		if _, err := yaml.Number("18446744073709551616").Int64(); err == nil {
+			t.Fatal("expected overflow error")
+		}
+		if _, err := yaml.Number("1.5").BigInt(); err == nil {
+			t.Fatal("expected error for float")
+		}
+		if f, err := yaml.Number("-1_000").Float64(); err != nil || f != -1000 {
+			t.Fatalf("unexpected float64: %v, %v", f, err)
+		}
+		i, err := yaml.Number("18446744073709551616").BigInt()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i.String() != "18446744073709551616" {
+			t.Fatalf("unexpected big int: %s", i)
+		}
+		for n, expected := range map[yaml.Number]string{
+			"-1.5e+3": "-1.5e+3",
+			"0":       "0",
+			"+1":      "1",
+			"012":     "10",
+			"1.":      "1",
+			".5":      "0.5",
+		} {
+			b, err := n.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != expected {
+				t.Fatalf("expected %s for %s but got %s", expected, n, b)
+			}
+		}
+	})
+	t.Run("typed", func(t *testing.T) {
+		var v struct {
+			N yaml.Number `yaml:"num"`
+		}
+		if err := yaml.Unmarshal([]byte("num: 0x10\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.N != "0x10" {
+			t.Fatalf("unexpected number: %s", v.N)
+		}
+		if err := yaml.Unmarshal([]byte("num: abc\n"), &v); err == nil {
+			t.Fatal("expected error")
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "num: 0x10\n" {
+			t.Fatalf("unexpected output: %q", b)
+		}
+	})
+}
+
+func TestDecoder_StrictNumberConversion(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		value     interface{}
+		precision bool
+		errMsg    string
+	}{
+		{
+			name:      "fraction into int",
+			src:       "v: 1.5",
+			value:     &struct{ V int }{},
+			precision: true,
+			errMsg:    "[1:4] cannot unmarshal 1.5 into Go value of type int ( precision loss )",
+		},
+		{
+			name:      "fraction into uint in slice",
+			src:       "v: [1, 2.5e0]",
+			value:     &struct{ V []uint }{},
+			precision: true,
+		},
+		{
+			name:      "int64 into float32",
+			src:       "v: 16777217",
+			value:     &struct{ V float32 }{},
+			precision: true,
+		},
+		{
+			name:      "int64 into float64 in map",
+			src:       "v: 9007199254740993",
+			value:     &map[string]float64{},
+			precision: true,
+		},
+		{
+			name:   "overflow float32",
+			src:    "v: 1e39",
+			value:  &struct{ V float32 }{},
+			errMsg: "[1:4] cannot unmarshal 1e+39 into Go value of type float32 ( overflow )",
+		},
+		{
+			name:      "precision of float32",
+			src:       "v: 0.123456789",
+			value:     &struct{ V float32 }{},
+			precision: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := yaml.Unmarshal([]byte(test.src), test.value); err != nil {
+				t.Fatalf("expected lossy conversion without the option but got %v", err)
+			}
+			err := yaml.UnmarshalWithOptions([]byte(test.src), test.value, yaml.StrictNumberConversion())
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			var precisionErr *yaml.PrecisionError
+			var overflowErr *yaml.OverflowError
+			if test.precision && !errors.As(err, &precisionErr) {
+				t.Fatalf("expected PrecisionError but got %T: %v", err, err)
+			}
+			if !test.precision && !errors.As(err, &overflowErr) {
+				t.Fatalf("expected OverflowError but got %T: %v", err, err)
+			}
+			if test.errMsg != "" && !strings.HasPrefix(err.Error(), test.errMsg) {
+				t.Fatalf("expected error %q but got %q", test.errMsg, err.Error())
+			}
+		})
+	}
+	t.Run("exact values", func(t *testing.T) {
+		var v struct {
+			I int
+			U uint8
+			F float32
+			D float64
+		}
+		src := "i: 2.0\nu: 1e2\nf: 0.1\nd: 9007199254740992\n"
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.StrictNumberConversion()); err != nil {
+			t.Fatal(err)
+		}
+		if v.I != 2 || v.U != 100 || v.F != 0.1 || v.D != 9007199254740992 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+}
+
+func TestDecoder_PlainScalarResolverProfile(t *testing.T) {
+	src := `
+empty: null
+tilde: ~
+bool: true
+answer: yes
+int: 10
+octal: 0o17
+float: 1.5
+inf: -.inf
+`
+	tests := []struct {
+		name     string
+		resolver *resolver.Resolver
+		expected map[string]interface{}
+	}{
+		{
+			name:     "json",
+			resolver: resolver.JSON(),
+			expected: map[string]interface{}{
+				"empty":  nil,
+				"tilde":  "~",
+				"bool":   true,
+				"answer": "yes",
+				"int":    int64(10),
+				"octal":  "0o17",
+				"float":  1.5,
+				"inf":    "-.inf",
+			},
+		},
+		{
+			name:     "yaml 1.1",
+			resolver: resolver.YAML11(),
+			expected: map[string]interface{}{
+				"empty":  nil,
+				"tilde":  nil,
+				"bool":   true,
+				"answer": true,
+				"int":    int64(10),
+				"octal":  "0o17",
+				"float":  1.5,
+				"inf":    math.Inf(-1),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v map[string]interface{}
+			if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.PlainScalarResolver(test.resolver.Resolve)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(test.expected, v) {
+				t.Fatalf("expected %#v but got %#v", test.expected, v)
+			}
+		})
+	}
+}
+
+func TestDecoder_RespectVersionDirective(t *testing.T) {
+	src := `%YAML 1.1
+---
+answer: yes
+flag: off
+binary: 0b101
+...
+---
+answer: yes
+flag: off
+`
+	decodeAll := func(opts ...yaml.DecodeOption) []map[string]interface{} {
+		t.Helper()
+		dec := yaml.NewDecoder(strings.NewReader(src), opts...)
+		var docs []map[string]interface{}
+		for {
+			var v map[string]interface{}
+			if err := dec.Decode(&v); err != nil {
+				if err == io.EOF {
+					return docs
+				}
+				t.Fatal(err)
+			}
+			docs = append(docs, v)
+		}
+	}
+	t.Run("enabled", func(t *testing.T) {
+		expected := []map[string]interface{}{
+			{"answer": true, "flag": false, "binary": int64(5)},
+			{"answer": "yes", "flag": "off"},
+		}
+		if got := decodeAll(yaml.RespectVersionDirective(true)); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected %#v but got %#v", expected, got)
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		got := decodeAll()
+		if got[0]["answer"] != "yes" || got[0]["flag"] != "off" {
+			t.Fatalf("unexpected result %#v", got[0])
+		}
+	})
+	t.Run("resolver takes precedence", func(t *testing.T) {
+		got := decodeAll(yaml.RespectVersionDirective(true), yaml.PlainScalarResolver(resolver.JSON().Resolve))
+		if got[0]["answer"] != "yes" {
+			t.Fatalf("unexpected result %#v", got[0])
+		}
+	})
+}
+
+func TestDecoder_InputEncoding(t *testing.T) {
+	src := "a: héllo\nb: [1, 2]\n"
+	var utf16LE bytes.Buffer
+	for _, r := range "\ufeff" + src {
+		utf16LE.Write([]byte{byte(r), byte(r >> 8)})
+	}
+	expected := map[string]interface{}{"a": "héllo", "b": []interface{}{uint64(1), uint64(2)}}
+	t.Run("detect by bom", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.UnmarshalWithOptions(utf16LE.Bytes(), &v, yaml.DisallowNULByte()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("expected %v but got %v", expected, v)
+		}
+	})
+	t.Run("utf-8 bom", func(t *testing.T) {
+		input := []byte("\ufeff" + src)
+		var v map[string]interface{}
+		dec := yaml.NewDecoder(bytes.NewReader(input))
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("expected %v but got %v", expected, v)
+		}
+		start, end := dec.LastDocumentRange()
+		if got := string(input[start.ByteOffset:end.ByteOffset]); got != src {
+			t.Fatalf("unexpected document range %q", got)
+		}
+		if start.Line != 1 || start.Column != 1 || start.Offset != 2 {
+			t.Fatalf("the byte order mark should be counted only in the offset: %+v", start)
+		}
+	})
+	t.Run("specified encoding", func(t *testing.T) {
+		var v map[string]interface{}
+		if err := yaml.UnmarshalWithOptions(utf16LE.Bytes()[2:], &v, yaml.InputEncoding(parser.UTF16LE)); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Fatalf("expected %v but got %v", expected, v)
+		}
+	})
+	t.Run("nul byte", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte{0xFF, 0xFE, 'a', 0, ':', 0, 0, 0}, &v, yaml.DisallowNULByte())
+		if !errors.Is(err, yaml.ErrNULByte) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDecoder_NullDoesNotOverwrite(t *testing.T) {
+	type Inner struct {
+		X int `yaml:"x"`
+	}
+	type T struct {
+		Name   string            `yaml:"name"`
+		Port   *int              `yaml:"port"`
+		Labels map[string]string `yaml:"labels"`
+		Inner  *Inner            `yaml:"inner"`
+		Keep   *int              `yaml:"keep,keepexisting"`
+	}
+	src := `
+name: null
+port: ~
+labels: null
+inner: null
+keep: null
+`
+	newValue := func() T {
+		port, keep := 8080, 1
+		return T{
+			Name:   "web",
+			Port:   &port,
+			Labels: map[string]string{"app": "web"},
+			Inner:  &Inner{X: 1},
+			Keep:   &keep,
+		}
+	}
+	t.Run("option", func(t *testing.T) {
+		v := newValue()
+		if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.NullDoesNotOverwrite()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, newValue()) {
+			t.Fatalf("the values are overwritten: %+v", v)
+		}
+	})
+	t.Run("tag", func(t *testing.T) {
+		v := newValue()
+		if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Port != nil || v.Inner != nil {
+			t.Fatalf("the pointers should be reset: %+v", v)
+		}
+		if v.Keep == nil || *v.Keep != 1 {
+			t.Fatalf("the field having keepexisting is overwritten: %v", v.Keep)
+		}
+	})
+	t.Run("non-null values", func(t *testing.T) {
+		v := newValue()
+		if err := yaml.UnmarshalWithOptions([]byte("name: api\nkeep: 2\n"), &v, yaml.NullDoesNotOverwrite()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Name != "api" || *v.Keep != 2 {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+}
+
+func TestDecoder_WithScalarExpansion(t *testing.T) {
+	t.Setenv("YAML_TEST_HOST", "example.com")
+	t.Setenv("YAML_TEST_PORT", "8080")
+	t.Setenv("YAML_TEST_EMPTY", "")
+	src := `
+host: ${YAML_TEST_HOST}
+port: ${YAML_TEST_PORT}
+url: "http://${YAML_TEST_HOST}:${YAML_TEST_PORT}/"
+name: ${YAML_TEST_NAME:-web}
+empty: ${YAML_TEST_EMPTY-unused}
+price: $$5 and $HOME
+script: |
+  curl ${YAML_TEST_HOST}
+anchor: &a ${YAML_TEST_HOST}
+alias: *a
+`
+	type T struct {
+		Host   string `yaml:"host"`
+		Port   int    `yaml:"port"`
+		URL    string `yaml:"url"`
+		Name   string `yaml:"name"`
+		Empty  string `yaml:"empty"`
+		Price  string `yaml:"price"`
+		Script string `yaml:"script"`
+		Anchor string `yaml:"anchor"`
+		Alias  string `yaml:"alias"`
+	}
+	var v T
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.WithScalarExpansion(yaml.ExpandEnv)); err != nil {
+		t.Fatal(err)
+	}
+	expected := T{
+		Host:   "example.com",
+		Port:   8080,
+		URL:    "http://example.com:8080/",
+		Name:   "web",
+		Empty:  "",
+		Price:  "$5 and $HOME",
+		Script: "curl example.com\n",
+		Anchor: "example.com",
+		Alias:  "example.com",
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, v)
+	}
+
+	t.Run("path", func(t *testing.T) {
+		var paths []string
+		var v map[string]interface{}
+		if err := yaml.UnmarshalWithOptions([]byte("a:\n  b: [x, y]\n"), &v, yaml.WithScalarExpansion(func(path, raw string) (string, error) {
+			paths = append(paths, path)
+			return strings.ToUpper(raw), nil
+		})); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(paths, []string{"$.a.b[0]", "$.a.b[1]"}) {
+			t.Fatalf("unexpected paths %q", paths)
+		}
+		if !reflect.DeepEqual(v, map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{"X", "Y"}}}) {
+			t.Fatalf("unexpected value %v", v)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte("a:\n  b: ${YAML_TEST_MISSING}\n"), &v, yaml.WithScalarExpansion(yaml.ExpandEnv))
+		var expansionErr *yaml.ExpansionError
+		if !errors.As(err, &expansionErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expansionErr.Path != "$.a.b" || expansionErr.Token.Position.Line != 2 {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := `
+[2:6] failed to expand $.a.b: variable YAML_TEST_MISSING is not set
+   1 | a:
+>  2 |   b: ${YAML_TEST_MISSING}
+            ^
+`
+		if got := "\n" + yaml.FormatError(err, false, true); got != expected {
+			t.Fatalf("unexpected error message:%s", got)
+		}
+	})
+	t.Run("lookup expander", func(t *testing.T) {
+		expand := yaml.LookupExpander(func(name string) (string, bool) {
+			if name == "SET" {
+				return "value", true
+			}
+			if name == "EMPTY" {
+				return "", true
+			}
+			return "", false
+		})
+		for _, test := range []struct {
+			raw      string
+			expected string
+			isErr    bool
+		}{
+			{raw: "${SET}", expected: "value"},
+			{raw: "a${SET}b", expected: "avalueb"},
+			{raw: "${UNSET:-default}", expected: "default"},
+			{raw: "${EMPTY:-default}", expected: "default"},
+			{raw: "${EMPTY-default}", expected: ""},
+			{raw: "${UNSET-default}", expected: "default"},
+			{raw: "$$${SET}", expected: "$value"},
+			{raw: "$SET", expected: "$SET"},
+			{raw: "${UNSET}", isErr: true},
+			{raw: "${EMPTY:?required}", isErr: true},
+			{raw: "${SET", isErr: true},
+			{raw: "${}", isErr: true},
+		} {
+			got, err := expand("$", test.raw)
+			if test.isErr {
+				if err == nil {
+					t.Fatalf("%s: expected error", test.raw)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Fatalf("%s: expected %q but got %q", test.raw, test.expected, got)
+			}
+		}
+	})
+}
+
+func TestDecoder_ResolveIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db.yaml":              {Data: []byte("host: localhost\nport: 5432\n")},
+		"shared/defaults.yaml": {Data: []byte("timeout: 30\nretry: !include retry.yaml\n")},
+		"shared/retry.yaml":    {Data: []byte("- 1\n- 2\n")},
+		"fragments.yaml":       {Data: []byte("servers:\n  - name: a\n  - name: b\n")},
+		"cycle-a.yaml":         {Data: []byte("next: !include cycle-b.yaml\n")},
+		"cycle-b.yaml":         {Data: []byte("next: !include cycle-a.yaml\n")},
+		"invalid.yaml":         {Data: []byte("a: 1\n  b: 2\n")},
+	}
+	loader := yaml.FSIncludeLoader(fsys)
+	src := `
+db: !include db.yaml
+defaults: !include shared/defaults.yaml
+server:
+  $ref: ./fragments.yaml#/servers/1
+names:
+  base: web
+  primary:
+    $ref: "#/names/base"
+`
+	var v map[string]interface{}
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.ResolveIncludes(loader)); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"db":       map[string]interface{}{"host": "localhost", "port": uint64(5432)},
+		"defaults": map[string]interface{}{"timeout": uint64(30), "retry": []interface{}{uint64(1), uint64(2)}},
+		"server":   map[string]interface{}{"name": "b"},
+		"names":    map[string]interface{}{"base": "web", "primary": "web"},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("expected %v but got %v", expected, v)
+	}
+
+	t.Run("cycle", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte("a: !include cycle-a.yaml\n"), &v, yaml.ResolveIncludes(loader))
+		if !errors.Is(err, yaml.ErrIncludeCycle) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = yaml.UnmarshalWithOptions([]byte("a:\n  $ref: '#/a'\n"), &v, yaml.ResolveIncludes(loader))
+		if !errors.Is(err, yaml.ErrIncludeCycle) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte("a: !include missing.yaml\n"), &v, yaml.ResolveIncludes(loader))
+		var includeErr *yaml.IncludeError
+		if !errors.As(err, &includeErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if includeErr.Ref != "missing.yaml" || includeErr.Token.Position.Line != 1 {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = yaml.UnmarshalWithOptions([]byte("a:\n  $ref: db.yaml#/missing\n"), &v, yaml.ResolveIncludes(loader))
+		if !errors.Is(err, yaml.ErrNotFoundNode) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("error in included file", func(t *testing.T) {
+		var v map[string]interface{}
+		err := yaml.UnmarshalWithOptions([]byte("a: !include invalid.yaml\n"), &v, yaml.ResolveIncludes(loader))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		expected := `
+failed to include "invalid.yaml" at [1:12]: [1:4] mapping value is not allowed in this context
+>  1 | a: 1
+   2 |   b: 2
+          ^
+`
+		if got := "\n" + yaml.FormatError(err, false, true); got != expected {
+			t.Fatalf("unexpected error message:%s", got)
+		}
+	})
+}
+
+type layoutDate time.Time
+
+type layoutTimestamp struct{ time.Time }
+
+type layoutTextDate time.Time
+
+func (d *layoutTextDate) UnmarshalText(b []byte) error {
+	t, err := time.Parse("2006/01/02", string(b))
+	if err != nil {
+		return err
+	}
+	*d = layoutTextDate(t)
+	return nil
+}
+
+func TestDecoder_TimeLayout(t *testing.T) {
+	type T struct {
+		CreatedAt time.Time       `yaml:"created_at,layout=2006-01-02 15:04"`
+		Ptr       *time.Time      `yaml:"ptr,layout=RFC1123"`
+		Year      time.Time       `yaml:"year,layout=2006"`
+		Date      layoutDate      `yaml:"date,layout=02-01-2006"`
+		Stamp     layoutTimestamp `yaml:"stamp,layout=Jan 2 2006"`
+		Null      *time.Time      `yaml:"none,layout=2006"`
+	}
+	src := `
+created_at: 2024-03-05 14:30
+ptr: Tue, 05 Mar 2024 14:30:00 UTC
+year: 2024
+date: 05-03-2024
+stamp: Mar 5 2024
+none: null
+`
+	var v T
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	expected := T{
+		CreatedAt: time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC),
+		Year:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Date:      layoutDate(date),
+		Stamp:     layoutTimestamp{Time: date},
+	}
+	if v.Ptr == nil || !v.Ptr.Equal(expected.CreatedAt) {
+		t.Fatalf("unexpected ptr %v", v.Ptr)
+	}
+	v.Ptr = nil
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, v)
+	}
+
+	t.Run("time types", func(t *testing.T) {
+		var v struct {
+			Date  layoutDate      `yaml:"date"`
+			Stamp layoutTimestamp `yaml:"stamp"`
+			Text  layoutTextDate  `yaml:"text"`
+		}
+		if err := yaml.Unmarshal([]byte("date: 2024-03-05\nstamp: 2024-03-05\ntext: 2024/03/05\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		if !time.Time(v.Date).Equal(date) || !v.Stamp.Equal(date) || !time.Time(v.Text).Equal(date) {
+			t.Fatalf("unexpected times %v, %v and %v", time.Time(v.Date), v.Stamp.Time, time.Time(v.Text))
+		}
+	})
+	t.Run("invalid time", func(t *testing.T) {
+		var v T
+		err := yaml.Unmarshal([]byte("created_at: 2024/03/05\n"), &v)
+		expected := `
+[1:13] cannot parse "2024/03/05" as time by the layout "2006-01-02 15:04"
+>  1 | created_at: 2024/03/05
+                   ^
+`
+		if err == nil || "\n"+err.Error() != expected {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("invalid option", func(t *testing.T) {
+		var v struct {
+			A string `yaml:"a,layout=2006"`
+		}
+		if err := yaml.Unmarshal([]byte("a: b\n"), &v); err == nil || !strings.Contains(err.Error(), `invalid option "layout=2006"`) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDecoder_ContinueOnError(t *testing.T) {
+	type Item struct {
+		ID   int   `yaml:"id"`
+		Tags []int `yaml:"tags"`
+	}
+	type T struct {
+		Nums  []int            `yaml:"nums"`
+		Items []Item           `yaml:"items"`
+		Arr   [3]int           `yaml:"arr"`
+		Map   map[string][]int `yaml:"map"`
+		Strs  []string         `yaml:"strs"`
+	}
+	src := `
+nums: [1, x, 3, y]
+items:
+  - id: 1
+    tags: [1, a, 3]
+  - id: b
+  - id: 3
+arr: [1, z, 3]
+map:
+  k: [1, w]
+strs: [a, {b: c}, d]
+`
+	var v T
+	err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.ContinueOnError())
+	expected := T{
+		Nums:  []int{1, 3},
+		Items: []Item{{ID: 1, Tags: []int{1, 3}}, {ID: 3}},
+		Arr:   [3]int{1, 0, 3},
+		Map:   map[string][]int{"k": {1}},
+		Strs:  []string{"a", "d"},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, v)
+	}
+	var multi *errors.MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("expected MultiError but got %v", err)
+	}
+	type elementError struct {
+		index    int
+		position string
+	}
+	expectedErrors := []elementError{
+		{1, "[2:11]"}, {3, "[2:17]"}, {0, "[4:5]"}, {1, "[6:5]"}, {1, "[8:10]"}, {1, "[10:10]"}, {1, "[11:11]"},
+	}
+	if len(multi.Errors) != len(expectedErrors) {
+		t.Fatalf("expected %d errors but got %d: %v", len(expectedErrors), len(multi.Errors), err)
+	}
+	for i, e := range multi.Errors {
+		var se *yaml.SequenceElementError
+		if !errors.As(e, &se) {
+			t.Fatalf("expected SequenceElementError but got %v", e)
+		}
+		if got := (elementError{se.Index, fmt.Sprintf("[%d:%d]", se.Token.Position.Line, se.Token.Position.Column)}); got != expectedErrors[i] {
+			t.Errorf("expected element %v but got %v", expectedErrors[i], got)
+		}
+	}
+	if !strings.HasPrefix(multi.Errors[2].Error(), "sequence element [0] starting at [4:5]: sequence element [1] starting at [5:15]: cannot unmarshal string into Go struct field Item.Tags of type int") {
+		t.Fatalf("unexpected nested error: %v", multi.Errors[2])
+	}
+
+	t.Run("top level", func(t *testing.T) {
+		var v []int
+		err := yaml.UnmarshalWithOptions([]byte("- 1\n- a\n- 3\n"), &v, yaml.ContinueOnError())
+		if !reflect.DeepEqual(v, []int{1, 3}) {
+			t.Fatalf("unexpected value %v", v)
+		}
+		var se *yaml.SequenceElementError
+		if !errors.As(err, &se) || se.Index != 1 {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	t.Run("without option", func(t *testing.T) {
+		var v T
+		err := yaml.Unmarshal([]byte(src), &v)
+		var se *yaml.SequenceElementError
+		if err == nil || errors.As(err, &se) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if v.Nums != nil {
+			t.Fatalf("unexpected value %v", v.Nums)
+		}
+	})
+}
@@ -0,0 +1,457 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/astutil"
+	"github.com/goccy/go-yaml/token"
+)
+
+// ChangeType represents the kind of the change.
+type ChangeType int
+
+const (
+	// Added is the change that the node exists only in the new file.
+	Added ChangeType = iota
+	// Removed is the change that the node exists only in the old file.
+	Removed
+	// Modified is the change that the node exists in both files, but the value, the style or the comment is different.
+	Modified
+	// Reordered is the change that the mapping has the same keys in both files, but the order of them is different.
+	Reordered
+)
+
+// String returns the name of the change type.
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	case Reordered:
+		return "reordered"
+	}
+	return ""
+}
+
+// Change is the difference of the node between two files.
+type Change struct {
+	Type ChangeType
+	// DocumentIndex is the index of the document containing the node.
+	DocumentIndex int
+	// Path is the path to the node from the root of the document.
+	Path *yaml.Path
+	// Old is the node in the old file. It is nil if the change type is Added.
+	Old ast.Node
+	// New is the node in the new file. It is nil if the change type is Removed.
+	New ast.Node
+}
+
+// OldPosition returns the position of the node in the old file.
+func (c *Change) OldPosition() *token.Position {
+	return nodePosition(c.Old)
+}
+
+// NewPosition returns the position of the node in the new file.
+func (c *Change) NewPosition() *token.Position {
+	return nodePosition(c.New)
+}
+
+// String returns the change type and the path.
+func (c *Change) String() string {
+	return fmt.Sprintf("%s %s", c.Type, c.Path)
+}
+
+func nodePosition(node ast.Node) *token.Position {
+	if node == nil || node.GetToken() == nil {
+		return nil
+	}
+	return node.GetToken().Position
+}
+
+// Option is the option to change the comparison.
+type Option func(*comparer)
+
+// IgnoreComments ignores the difference of the comments.
+func IgnoreComments() Option {
+	return func(c *comparer) {
+		c.ignoreComments = true
+	}
+}
+
+// IgnoreKeyOrder ignores the order of the mapping keys, so Reordered is not reported.
+func IgnoreKeyOrder() Option {
+	return func(c *comparer) {
+		c.ignoreKeyOrder = true
+	}
+}
+
+// IgnoreStyle ignores the difference of the styles that don't change the value,
+// such as flow or block style collections, quoted or plain scalars, and the names of the anchors.
+func IgnoreStyle() Option {
+	return func(c *comparer) {
+		c.ignoreStyle = true
+	}
+}
+
+// Compare compares the documents of a and b at the same index, and returns the changes from a to b.
+// The mapping values are compared by the key, and the sequence entries are compared by the index.
+// Aliases are compared by the name, or by the anchored values if IgnoreStyle is specified.
+// Old and New of the change are the value nodes, not the mapping value nodes containing the key.
+func Compare(a, b *ast.File, opts ...Option) []Change {
+	c := &comparer{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	var oldDocs, newDocs []*ast.DocumentNode
+	if a != nil {
+		oldDocs = a.Docs
+	}
+	if b != nil {
+		newDocs = b.Docs
+	}
+	for idx := 0; idx < len(oldDocs) || idx < len(newDocs); idx++ {
+		c.docIdx = idx
+		var oldBody, newBody ast.Node
+		if idx < len(oldDocs) {
+			oldBody = oldDocs[idx].Body
+		}
+		if idx < len(newDocs) {
+			newBody = newDocs[idx].Body
+		}
+		c.oldAnchors = collectAnchors(oldBody)
+		c.newAnchors = collectAnchors(newBody)
+		c.aliasResults = map[[2]ast.Node]bool{}
+		c.compare(nil, oldBody, newBody)
+	}
+	return c.changes
+}
+
+type comparer struct {
+	ignoreComments bool
+	ignoreKeyOrder bool
+	ignoreStyle    bool
+	docIdx         int
+	changes        []Change
+
+	// the anchors of the documents in the order of the definitions, used to resolve the aliases.
+	oldAnchors []*ast.AnchorNode
+	newAnchors []*ast.AnchorNode
+	// aliasResults caches whether the anchored values referred by the aliases are equal.
+	aliasResults map[[2]ast.Node]bool
+}
+
+func collectAnchors(node ast.Node) []*ast.AnchorNode {
+	if node == nil {
+		return nil
+	}
+	var anchors []*ast.AnchorNode
+	for _, n := range ast.Filter(ast.AnchorType, node) {
+		anchors = append(anchors, n.(*ast.AnchorNode))
+	}
+	return anchors
+}
+
+// resolveAlias returns the value of the last anchor defined before the alias.
+func resolveAlias(anchors []*ast.AnchorNode, alias *ast.AliasNode) ast.Node {
+	name := alias.Value.GetToken().Value
+	pos := alias.GetToken().Position
+	var resolved ast.Node
+	for _, anchor := range anchors {
+		if anchor.GetToken().Position.Offset >= pos.Offset {
+			break
+		}
+		if anchor.Name.GetToken().Value == name {
+			resolved = anchor.Value
+		}
+	}
+	return resolved
+}
+
+// equalAlias reports whether the anchored values referred by the aliases are equal.
+func (c *comparer) equalAlias(oldAlias, newAlias *ast.AliasNode) bool {
+	oldValue := resolveAlias(c.oldAnchors, oldAlias)
+	newValue := resolveAlias(c.newAnchors, newAlias)
+	if oldValue == nil || newValue == nil {
+		return oldAlias.Value.GetToken().Value == newAlias.Value.GetToken().Value
+	}
+	key := [2]ast.Node{oldValue, newValue}
+	if result, exists := c.aliasResults[key]; exists {
+		return result
+	}
+	sub := *c
+	sub.changes = nil
+	sub.compare(nil, oldValue, newValue)
+	result := len(sub.changes) == 0
+	c.aliasResults[key] = result
+	return result
+}
+
+// pathElem is the element of the path, which is the key of the mapping or the index of the sequence.
+type pathElem struct {
+	name    string
+	idx     uint
+	isIndex bool
+}
+
+func (c *comparer) add(typ ChangeType, path []pathElem, oldNode, newNode ast.Node) {
+	b := (&yaml.PathBuilder{}).Root()
+	for _, elem := range path {
+		if elem.isIndex {
+			b = b.Index(elem.idx)
+		} else {
+			b = b.Child(quoteName(elem.name))
+		}
+	}
+	c.changes = append(c.changes, Change{
+		Type:          typ,
+		DocumentIndex: c.docIdx,
+		Path:          b.Build(),
+		Old:           oldNode,
+		New:           newNode,
+	})
+}
+
+// quoteName encloses the name in the single quotes if it is empty or contains the special characters of the path.
+func quoteName(name string) string {
+	if name != "" && !strings.ContainsAny(name, `$*.[]'`) {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, `'`, `\'`) + "'"
+}
+
+func (c *comparer) compare(path []pathElem, oldNode, newNode ast.Node) {
+	switch {
+	case oldNode == nil && newNode == nil:
+		return
+	case oldNode == nil:
+		c.add(Added, path, nil, newNode)
+		return
+	case newNode == nil:
+		c.add(Removed, path, oldNode, nil)
+		return
+	}
+	if !c.ignoreComments && commentText(oldNode) != commentText(newNode) {
+		c.add(Modified, path, oldNode, newNode)
+		c.compareContent(path, oldNode, newNode, true)
+		return
+	}
+	c.compareContent(path, oldNode, newNode, false)
+}
+
+// compareContent compares the nodes without the comments of themselves.
+// If reported is true, the change of the nodes is already reported, so only the changes of the children are reported.
+func (c *comparer) compareContent(path []pathElem, oldNode, newNode ast.Node, reported bool) {
+	oldNode, oldAnchor := c.unwrap(oldNode)
+	newNode, newAnchor := c.unwrap(newNode)
+	if !c.ignoreStyle && oldAnchor != newAnchor && !reported {
+		c.add(Modified, path, oldNode, newNode)
+		reported = true
+	}
+	switch o := oldNode.(type) {
+	case *ast.MappingNode:
+		if n, ok := newNode.(*ast.MappingNode); ok {
+			if !c.ignoreStyle && o.IsFlowStyle != n.IsFlowStyle && !reported {
+				c.add(Modified, path, oldNode, newNode)
+			}
+			c.compareMapping(path, o, n)
+			return
+		}
+	case *ast.SequenceNode:
+		if n, ok := newNode.(*ast.SequenceNode); ok {
+			if !c.ignoreStyle && o.IsFlowStyle != n.IsFlowStyle && !reported {
+				c.add(Modified, path, oldNode, newNode)
+			}
+			c.compareSequence(path, o, n)
+			return
+		}
+	default:
+		if !reported && !c.equalScalar(oldNode, newNode) {
+			c.add(Modified, path, oldNode, newNode)
+		}
+		return
+	}
+	if !reported {
+		c.add(Modified, path, oldNode, newNode)
+	}
+}
+
+// unwrap returns the node without the anchor and the name of the anchor.
+// The mapping value node with the single entry is also unwrapped to the mapping node.
+func (c *comparer) unwrap(node ast.Node) (ast.Node, string) {
+	var anchor string
+	for {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			anchor = n.Name.GetToken().Value
+			node = n.Value
+			continue
+		case *ast.MappingValueNode:
+			mapping := ast.Mapping(n.GetToken(), false, n)
+			node = mapping
+		}
+		return node, anchor
+	}
+}
+
+func (c *comparer) compareMapping(path []pathElem, oldNode, newNode *ast.MappingNode) {
+	oldKeys, oldValues := c.mappingValues(oldNode)
+	newKeys, newValues := c.mappingValues(newNode)
+	for _, key := range oldKeys {
+		oldValue := oldValues[key]
+		newValue, exists := newValues[key]
+		if !exists {
+			c.add(Removed, c.child(path, key), oldValue.Value, nil)
+			continue
+		}
+		if !c.ignoreComments && (commentText(oldValue) != commentText(newValue) || commentText(oldValue.Key) != commentText(newValue.Key)) {
+			c.add(Modified, c.child(path, key), oldValue.Value, newValue.Value)
+			c.compareContent(c.child(path, key), oldValue.Value, newValue.Value, true)
+			continue
+		}
+		c.compare(c.child(path, key), oldValue.Value, newValue.Value)
+	}
+	for _, key := range newKeys {
+		if _, exists := oldValues[key]; !exists {
+			c.add(Added, c.child(path, key), nil, newValues[key].Value)
+		}
+	}
+	if !c.ignoreKeyOrder && !sameOrder(oldKeys, newKeys, oldValues, newValues) {
+		c.add(Reordered, path, oldNode, newNode)
+	}
+}
+
+func (c *comparer) mappingValues(node *ast.MappingNode) ([]string, map[string]*ast.MappingValueNode) {
+	keys := make([]string, 0, len(node.Values))
+	values := make(map[string]*ast.MappingValueNode, len(node.Values))
+	for _, value := range node.Values {
+		key := astutil.KeyText(value.Key)
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values
+}
+
+// sameOrder reports whether the keys defined in both mappings are in the same order.
+func sameOrder(oldKeys, newKeys []string, oldValues, newValues map[string]*ast.MappingValueNode) bool {
+	var oldCommon, newCommon []string
+	for _, key := range oldKeys {
+		if _, exists := newValues[key]; exists {
+			oldCommon = append(oldCommon, key)
+		}
+	}
+	for _, key := range newKeys {
+		if _, exists := oldValues[key]; exists {
+			newCommon = append(newCommon, key)
+		}
+	}
+	return reflect.DeepEqual(oldCommon, newCommon)
+}
+
+func (c *comparer) compareSequence(path []pathElem, oldNode, newNode *ast.SequenceNode) {
+	for idx := 0; idx < len(oldNode.Values) || idx < len(newNode.Values); idx++ {
+		var oldValue, newValue ast.Node
+		if idx < len(oldNode.Values) {
+			oldValue = oldNode.Values[idx]
+		}
+		if idx < len(newNode.Values) {
+			newValue = newNode.Values[idx]
+		}
+		elemPath := append(append([]pathElem{}, path...), pathElem{idx: uint(idx), isIndex: true})
+		if !c.ignoreComments && oldValue != nil && newValue != nil &&
+			sequenceHeadComment(oldNode, idx) != sequenceHeadComment(newNode, idx) {
+			c.add(Modified, elemPath, oldValue, newValue)
+			c.compareContent(elemPath, oldValue, newValue, true)
+			continue
+		}
+		c.compare(elemPath, oldValue, newValue)
+	}
+}
+
+func (c *comparer) child(path []pathElem, name string) []pathElem {
+	return append(append([]pathElem{}, path...), pathElem{name: name})
+}
+
+// equalScalar compares the nodes other than the collections.
+func (c *comparer) equalScalar(oldNode, newNode ast.Node) bool {
+	if oldNode.Type() != newNode.Type() && !c.ignoreStyle {
+		return false
+	}
+	switch o := oldNode.(type) {
+	case *ast.AliasNode:
+		n, ok := newNode.(*ast.AliasNode)
+		if !ok {
+			return false
+		}
+		if c.ignoreStyle {
+			return c.equalAlias(o, n)
+		}
+		return o.Value.GetToken().Value == n.Value.GetToken().Value
+	case *ast.TagNode:
+		n, ok := newNode.(*ast.TagNode)
+		if !ok || o.Start.Value != n.Start.Value {
+			return false
+		}
+		if _, isCollection := o.Value.(ast.MapNode); isCollection {
+			return o.Value.String() == n.Value.String()
+		}
+		return c.equalScalar(o.Value, n.Value)
+	}
+	if _, ok := newNode.(*ast.AliasNode); ok {
+		return false
+	}
+	if _, ok := newNode.(*ast.TagNode); ok {
+		return false
+	}
+	if !reflect.DeepEqual(scalarValue(oldNode), scalarValue(newNode)) {
+		return false
+	}
+	if c.ignoreStyle {
+		return true
+	}
+	oldTk, newTk := oldNode.GetToken(), newNode.GetToken()
+	return oldTk.Type == newTk.Type && oldTk.Value == newTk.Value && literalText(oldNode) == literalText(newNode)
+}
+
+func scalarValue(node ast.Node) interface{} {
+	switch n := node.(type) {
+	case *ast.LiteralNode:
+		return n.Value.GetValue()
+	case *ast.NanNode:
+		// NaN is not equal to itself, so it is compared by the text.
+		return ".nan"
+	case ast.ScalarNode:
+		return n.GetValue()
+	}
+	return node.String()
+}
+
+// literalText returns the text of the literal or folded block scalar, because the token of it is the header.
+func literalText(node ast.Node) string {
+	if n, ok := node.(*ast.LiteralNode); ok {
+		return n.Value.GetToken().Value
+	}
+	return ""
+}
+
+func commentText(node ast.Node) string {
+	if node == nil || node.GetComment() == nil {
+		return ""
+	}
+	return node.GetComment().String()
+}
+
+func sequenceHeadComment(node *ast.SequenceNode, idx int) string {
+	if idx >= len(node.ValueHeadComments) || node.ValueHeadComments[idx] == nil {
+		return ""
+	}
+	return node.ValueHeadComments[idx].String()
+}
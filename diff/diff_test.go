@@ -0,0 +1,198 @@
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/goccy/go-yaml/diff"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestCompare(t *testing.T) {
+	ignoreAll := []diff.Option{diff.IgnoreComments(), diff.IgnoreKeyOrder(), diff.IgnoreStyle()}
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		options  []diff.Option
+		expected []string
+	}{
+		{
+			name: "values",
+			old: `
+a: 1
+b: [1, 2]
+c: {x: 1}
+`,
+			new: `
+a: 2
+b: [1, 2, 3]
+d: 1
+`,
+			expected: []string{"modified $.a", "added $.b[2]", "removed $.c", "added $.d"},
+		},
+		{
+			name:     "same",
+			old:      "a: {b: [1, 'x']}\n",
+			new:      "a: {b: [1, 'x']}\n",
+			expected: nil,
+		},
+		{
+			name:     "key order",
+			old:      "a: 1\nb: 2\n",
+			new:      "b: 2\na: 1\n",
+			expected: []string{"reordered $"},
+		},
+		{
+			name:     "ignore key order",
+			old:      "a: 1\nb: 2\n",
+			new:      "b: 2\na: 1\n",
+			options:  []diff.Option{diff.IgnoreKeyOrder()},
+			expected: nil,
+		},
+		{
+			name:     "comments",
+			old:      "# head\na: 1 # line\nb:\n- 1\n- 2\n",
+			new:      "# changed\na: 1 # line\nb:\n- 1\n# added\n- 2\n",
+			expected: []string{"modified $.a", "modified $.b[1]"},
+		},
+		{
+			name:     "ignore comments",
+			old:      "# head\na: 1 # line\n",
+			new:      "a: 1 # changed\n",
+			options:  []diff.Option{diff.IgnoreComments()},
+			expected: nil,
+		},
+		{
+			name: "styles",
+			old: `
+a: 'x'
+b: [1]
+c: 0x10
+d: |
+  text
+e: &x 1
+f: *x
+`,
+			new: `
+a: "x"
+b:
+- 1
+c: 16
+d: "text\n"
+e: &y 1
+f: *y
+`,
+			expected: []string{"modified $.a", "modified $.b", "modified $.c", "modified $.d", "modified $.e", "modified $.f"},
+		},
+		{
+			name: "ignore styles",
+			old: `
+a: 'x'
+b: [1]
+c: 0x10
+d: |
+  text
+e: &x 1
+f: *x
+`,
+			new: `
+a: "x"
+b:
+- 1
+c: 16
+d: "text\n"
+e: &y 1
+f: *y
+`,
+			options:  []diff.Option{diff.IgnoreStyle()},
+			expected: nil,
+		},
+		{
+			name:     "type change is not style",
+			old:      "a: 1\nb: [1]\n",
+			new:      "a: '1'\nb: {c: 1}\n",
+			options:  ignoreAll,
+			expected: []string{"modified $.a", "modified $.b"},
+		},
+		{
+			name:     "alias referring changed anchor",
+			old:      "a: &x 1\nb: *x\n",
+			new:      "a: &x 2\nb: *x\n",
+			options:  ignoreAll,
+			expected: []string{"modified $.a", "modified $.b"},
+		},
+		{
+			name:     "special characters in key",
+			old:      "a.b: 1\n? [c]\n: 1\n",
+			new:      "a.b: 2\n? [c]\n: 2\n",
+			expected: []string{"modified $.'a.b'", "modified $.'[c]'"},
+		},
+		{
+			name:     "empty key",
+			old:      "\"\": 1\n",
+			new:      "\"\": 2\n",
+			expected: []string{"modified $.''"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oldFile, err := parser.ParseBytes([]byte(test.old), parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newFile, err := parser.ParseBytes([]byte(test.new), parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, change := range diff.Compare(oldFile, newFile, test.options...) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Fatalf("unexpected changes:\nexpected: %q\ngot:      %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestChange(t *testing.T) {
+	oldFile, err := parser.ParseBytes([]byte("a:\n  b: 1\n---\nc: 1\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newFile, err := parser.ParseBytes([]byte("a:\n  b: 2\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := diff.Compare(oldFile, newFile)
+	if len(changes) != 2 {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+
+	modified := changes[0]
+	if modified.Type != diff.Modified || modified.DocumentIndex != 0 {
+		t.Fatalf("unexpected change: %+v", modified)
+	}
+	if pos := modified.OldPosition(); pos.Line != 2 || pos.Column != 6 {
+		t.Fatalf("unexpected old position: %d:%d", pos.Line, pos.Column)
+	}
+	if pos := modified.NewPosition(); pos.Line != 2 || pos.Column != 6 {
+		t.Fatalf("unexpected new position: %d:%d", pos.Line, pos.Column)
+	}
+	node, err := modified.Path.FilterFile(newFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node != modified.New {
+		t.Fatalf("the path refers to another node: %s", node)
+	}
+
+	removed := changes[1]
+	if removed.Type != diff.Removed || removed.DocumentIndex != 1 || removed.New != nil || removed.NewPosition() != nil {
+		t.Fatalf("unexpected change: %+v", removed)
+	}
+	if removed.Path.String() != "$" {
+		t.Fatalf("unexpected path: %s", removed.Path)
+	}
+}
@@ -0,0 +1,355 @@
+package yaml
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Document is the YAML file having one document to read and modify the values by YAMLPath keeping the formatting of the file.
+// The nodes not modified are written as they are, including the comments and the styles.
+//
+//	doc, err := yaml.OpenFile("deployment.yaml")
+//	if err != nil { ... }
+//	if err := doc.SetString("$.spec.image", "nginx:1.25"); err != nil { ... }
+//	if err := doc.Save(); err != nil { ... }
+type Document struct {
+	path string
+	file *ast.File
+}
+
+// OpenFile reads and parses the YAML file at path.
+func OpenFile(path string) (*Document, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := ParseDocument(src)
+	if err != nil {
+		return nil, err
+	}
+	doc.path = path
+	return doc, nil
+}
+
+// ParseDocument parses src as the Document not associated with a file.
+// Use SaveAs or Bytes to write it.
+// src must have at most one document, otherwise ErrMultipleDocuments is returned.
+func ParseDocument(src []byte) (*Document, error) {
+	file, err := parser.ParseBytes(src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Docs) > 1 {
+		return nil, fmt.Errorf("%w: found %d documents", ErrMultipleDocuments, len(file.Docs))
+	}
+	if len(file.Docs) == 0 {
+		file.Docs = append(file.Docs, ast.Document(nil, nil))
+	}
+	return &Document{file: file}, nil
+}
+
+// File returns the AST of the document.
+func (d *Document) File() *ast.File {
+	return d.file
+}
+
+// Bytes returns the YAML text of the document.
+func (d *Document) Bytes() []byte {
+	return []byte(d.file.String())
+}
+
+// Save writes the document to the file opened by OpenFile, keeping the permission of the file.
+func (d *Document) Save() error {
+	if d.path == "" {
+		return fmt.Errorf("the document is not opened from a file. use SaveAs instead")
+	}
+	return d.SaveAs(d.path)
+}
+
+// SaveAs writes the document to the file at path.
+// The permission of the file is kept if it exists, otherwise the file is created with 0644.
+func (d *Document) SaveAs(path string) error {
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return os.WriteFile(path, d.Bytes(), perm)
+}
+
+// Get decodes the value at path into v.
+func (d *Document) Get(path string, v interface{}, opts ...DecodeOption) error {
+	p, err := PathString(path)
+	if err != nil {
+		return err
+	}
+	node, err := p.FilterFile(d.file)
+	if err != nil {
+		return err
+	}
+	return NodeToValue(node, v, opts...)
+}
+
+// GetString returns the string value at path.
+func (d *Document) GetString(path string) (string, error) {
+	var v string
+	if err := d.Get(path, &v); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+// GetInt returns the integer value at path.
+func (d *Document) GetInt(path string) (int64, error) {
+	var v int64
+	if err := d.Get(path, &v); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// GetBool returns the boolean value at path.
+func (d *Document) GetBool(path string) (bool, error) {
+	var v bool
+	if err := d.Get(path, &v); err != nil {
+		return false, err
+	}
+	return v, nil
+}
+
+// Set sets v encoded by the options to the value at path.
+// If the mapping at path doesn't have the key, the key is added at the end of the mapping,
+// creating the missing mappings of the intermediate keys as well.
+//
+// The line comment of the replaced value is kept, and the quote style of the replaced string is kept
+// if the new value is also a string. The path must consist of the keys and the indexes, without wildcards.
+func (d *Document) Set(path string, v interface{}, opts ...EncodeOption) error {
+	p, err := PathString(path)
+	if err != nil {
+		return err
+	}
+	selectors, err := documentPathSelectors(p)
+	if err != nil {
+		return err
+	}
+	doc := d.file.Docs[0]
+	if len(selectors) == 0 {
+		node, err := documentValueNode(v, false, opts)
+		if err != nil {
+			return err
+		}
+		if doc.Body != nil {
+			keepStyle(doc.Body, node)
+		}
+		doc.Body = node
+		return nil
+	}
+	if doc.Body == nil {
+		for i := len(selectors) - 1; i >= 0; i-- {
+			key, ok := selectors[i].(string)
+			if !ok {
+				return fmt.Errorf("failed to find index %v: %w", selectors[i], ErrNotFoundNode)
+			}
+			v = MapSlice{{Key: key, Value: v}}
+		}
+		node, err := documentValueNode(v, false, opts)
+		if err != nil {
+			return err
+		}
+		doc.Body = node
+		return nil
+	}
+	return setDocumentValue(doc.Body, selectors, v, opts)
+}
+
+// SetString sets the string value at path.
+func (d *Document) SetString(path, value string) error {
+	return d.Set(path, value)
+}
+
+// SetInt sets the integer value at path.
+func (d *Document) SetInt(path string, value int64) error {
+	return d.Set(path, value)
+}
+
+// SetBool sets the boolean value at path.
+func (d *Document) SetBool(path string, value bool) error {
+	return d.Set(path, value)
+}
+
+// documentPathSelectors returns the selectors of p, which is the key as string or the index as uint.
+func documentPathSelectors(p *Path) ([]interface{}, error) {
+	var selectors []interface{}
+	root, ok := p.node.(*rootNode)
+	if !ok {
+		return nil, ErrInvalidPath
+	}
+	for node := root.child; node != nil; {
+		switch n := node.(type) {
+		case *selectorNode:
+			selector := n.selector
+			if len(selector) > 1 && selector[0] == '\'' && selector[len(selector)-1] == '\'' {
+				selector = selector[1 : len(selector)-1]
+			}
+			selectors = append(selectors, selector)
+			node = n.child
+		case *indexNode:
+			selectors = append(selectors, n.selector)
+			node = n.child
+		default:
+			return nil, fmt.Errorf("cannot set the value by %s: %w", p, ErrInvalidPath)
+		}
+	}
+	return selectors, nil
+}
+
+func setDocumentValue(node ast.Node, selectors []interface{}, v interface{}, opts []EncodeOption) error {
+	switch selector := selectors[0].(type) {
+	case string:
+		mapping, ok := documentMapping(node)
+		if !ok {
+			return fmt.Errorf("expected node type is map but got %s for key %q: %w", node.Type(), selector, ErrInvalidQuery)
+		}
+		for _, value := range mapping.Values {
+			if value.Key.GetToken().Value != selector {
+				continue
+			}
+			if len(selectors) > 1 {
+				return setDocumentValue(value.Value, selectors[1:], v, opts)
+			}
+			entry, err := documentMappingEntry(mapping, selector, v, opts)
+			if err != nil {
+				return err
+			}
+			// align the entry to the key of the value.
+			entry.AddColumn(value.Key.GetToken().Position.Column - entry.Key.GetToken().Position.Column)
+			keepStyle(value.Value, entry.Value)
+			value.Value = entry.Value
+			return nil
+		}
+		// create the missing mappings of the rest of the keys.
+		for i := len(selectors) - 1; i > 0; i-- {
+			key, ok := selectors[i].(string)
+			if !ok {
+				return fmt.Errorf("failed to find index %v: %w", selectors[i], ErrNotFoundNode)
+			}
+			v = MapSlice{{Key: key, Value: v}}
+		}
+		entry, err := documentMappingEntry(mapping, selector, v, opts)
+		if err != nil {
+			return err
+		}
+		mapping.Values = append(mapping.Values, entry)
+		return nil
+	case uint:
+		seq, ok := node.(*ast.SequenceNode)
+		if !ok {
+			return fmt.Errorf("expected node type is sequence but got %s for index %d: %w", node.Type(), selector, ErrInvalidQuery)
+		}
+		if selector >= uint(len(seq.Values)) {
+			return fmt.Errorf("index %d is out of the sequence having %d items: %w", selector, len(seq.Values), ErrNotFoundNode)
+		}
+		if len(selectors) > 1 {
+			return setDocumentValue(seq.Values[selector], selectors[1:], v, opts)
+		}
+		node, err := documentValueNode([]interface{}{v}, seq.IsFlowStyle, opts)
+		if err != nil {
+			return err
+		}
+		entries, ok := node.(*ast.SequenceNode)
+		if !ok {
+			return fmt.Errorf("unexpected node type %s", node.Type())
+		}
+		entry := entries.Values[0]
+		if !seq.IsFlowStyle {
+			entry.AddColumn(seq.Start.Position.Column - entries.Start.Position.Column)
+		}
+		keepStyle(seq.Values[selector], entry)
+		seq.Values[selector] = entry
+	}
+	return nil
+}
+
+// documentMapping returns the mapping node of node, skipping the anchor and the tag.
+func documentMapping(node ast.Node) (*ast.MappingNode, bool) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		return n, true
+	case *ast.AnchorNode:
+		return documentMapping(n.Value)
+	case *ast.TagNode:
+		return documentMapping(n.Value)
+	}
+	return nil, false
+}
+
+// documentMappingEntry creates the mapping value node of key and v aligned with the keys of mapping.
+func documentMappingEntry(mapping *ast.MappingNode, key string, v interface{}, opts []EncodeOption) (*ast.MappingValueNode, error) {
+	if len(mapping.Values) == 0 {
+		// the empty mapping is written as `{}`, so the entries are added in flow style.
+		mapping.IsFlowStyle = true
+	}
+	node, err := documentValueNode(MapSlice{{Key: key, Value: v}}, mapping.IsFlowStyle, opts)
+	if err != nil {
+		return nil, err
+	}
+	var entry *ast.MappingValueNode
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		entry = n
+	case *ast.MappingNode:
+		entry = n.Values[0]
+	default:
+		return nil, fmt.Errorf("unexpected node type %s", node.Type())
+	}
+	if !mapping.IsFlowStyle {
+		entry.AddColumn(mapping.Values[0].Key.GetToken().Position.Column - entry.Key.GetToken().Position.Column)
+	}
+	return entry, nil
+}
+
+// documentValueNode creates the node of v by encoding it.
+func documentValueNode(v interface{}, isFlowStyle bool, opts []EncodeOption) (ast.Node, error) {
+	if isFlowStyle {
+		opts = append(append([]EncodeOption{}, opts...), Flow(true))
+	}
+	b, err := MarshalWithOptions(v, opts...)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseBytes(b, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return f.Docs[0].Body, nil
+}
+
+// keepStyle copies the line comment and the quote style of the string of old to node.
+func keepStyle(old, node ast.Node) {
+	if comment := old.GetComment(); comment != nil && node.GetComment() == nil {
+		_ = node.SetComment(comment)
+	}
+	oldString, ok := old.(*ast.StringNode)
+	if !ok {
+		return
+	}
+	newString, ok := node.(*ast.StringNode)
+	if !ok {
+		return
+	}
+	switch oldString.Token.Type {
+	case token.SingleQuoteType:
+		// the special characters can be escaped only in the double-quoted style.
+		if !hasSpecialCharacter(newString.Value) && !strings.ContainsAny(newString.Value, "\n\r") {
+			newString.Token.Type = token.SingleQuoteType
+		}
+	case token.DoubleQuoteType:
+		if newString.Token.Type == token.StringType {
+			newString.Token.Type = token.DoubleQuoteType
+		}
+	}
+}
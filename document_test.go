@@ -0,0 +1,171 @@
+package yaml_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestDocument(t *testing.T) {
+	src := `# deployment
+spec:
+  image: "nginx:1.24" # pinned
+  replicas: 1
+  labels: {app: web}
+  ports:
+    - 80
+    - 443
+  name: 'x'
+`
+	path := filepath.Join(t.TempDir(), "deployment.yaml")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := yaml.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(doc.Bytes()); got != src {
+		t.Fatalf("failed to round trip. got:\n%s", got)
+	}
+	image, err := doc.GetString("$.spec.image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != "nginx:1.24" {
+		t.Fatalf("unexpected image %q", image)
+	}
+	replicas, err := doc.GetInt("$.spec.replicas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicas != 1 {
+		t.Fatalf("unexpected replicas %d", replicas)
+	}
+
+	if err := doc.SetString("$.spec.image", "nginx:1.25"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.SetInt("$.spec.replicas", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.SetString("$.spec.labels.tier", "frontend"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.SetInt("$.spec.ports[1]", 8443); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.SetString("$.spec.name", "z"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.SetBool("$.spec.resources.enabled", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Save(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# deployment
+spec:
+  image: "nginx:1.25" # pinned
+  replicas: 3
+  labels: {app: web, tier: frontend}
+  ports:
+    - 80
+    - 8443
+  name: 'z'
+  resources:
+    enabled: true
+`
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expected {
+		t.Fatalf("unexpected output.\nexpected:\n%s\ngot:\n%s", expected, string(got))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("the permission is not kept: %v", info.Mode().Perm())
+	}
+
+	t.Run("empty document", func(t *testing.T) {
+		doc, err := yaml.ParseDocument([]byte(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := doc.SetInt("$.a.b", 1); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(doc.Bytes()); got != "a:\n  b: 1\n" {
+			t.Fatalf("unexpected output %q", got)
+		}
+		if err := doc.Save(); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		doc, err := yaml.ParseDocument([]byte("a: [1, 2]\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := doc.SetInt("$.a[5]", 1); !errors.Is(err, yaml.ErrNotFoundNode) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := doc.SetInt("$.a[*]", 1); !errors.Is(err, yaml.ErrInvalidPath) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := doc.SetInt("$.a.b", 1); !errors.Is(err, yaml.ErrInvalidQuery) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDocument_SetOptions(t *testing.T) {
+	doc, err := yaml.ParseDocument([]byte("a: {b: 1}\nc:\n  d: 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := make([]yaml.EncodeOption, 2)
+	opts[0], opts[1] = yaml.Indent(2), yaml.Flow(false)
+	if err := doc.Set("$.a.e", 2, opts[:1]...); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Set("$.c.f", map[string]int{"g": 1}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a: {b: 1, e: 2}\nc:\n  d: 1\n  f:\n    g: 1\n"
+	if got := string(doc.Bytes()); got != expected {
+		t.Fatalf("unexpected document:\n%s", got)
+	}
+}
+
+func TestDocument_MultipleDocuments(t *testing.T) {
+	if _, err := yaml.ParseDocument([]byte("a: 1\n---\nb: 2\n")); !errors.Is(err, yaml.ErrMultipleDocuments) {
+		t.Fatalf("expected ErrMultipleDocuments but got %v", err)
+	}
+	if _, err := yaml.ParseDocument([]byte("---\na: 1\n")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDocument_Empty(t *testing.T) {
+	doc, err := yaml.ParseDocument([]byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(doc.Bytes()); got != "\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if err := doc.Set("$.a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(doc.Bytes()); got != "a: 1\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
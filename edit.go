@@ -0,0 +1,148 @@
+package yaml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Edit is the replacement of the source text of a node applied by EditSource.
+// The node is selected by Path, or specified by Node if it has been found by the query of the AST
+// parsed from the same source.
+type Edit struct {
+	// Path selects the node to replace. It's used only if Node is nil.
+	Path *Path
+	// Node is the node to replace. It must be parsed from the source passed to EditSource.
+	Node ast.Node
+	// Text is written in place of the source text of the node as is.
+	// It must be valid YAML at the position of the node, e.g. quoted if it's required, and indented for the block collections.
+	Text string
+}
+
+type sourceSplice struct {
+	start int
+	end   int
+	text  string
+}
+
+// tokenPositionKey identifies the token in the source regardless of the parse producing it.
+type tokenPositionKey struct {
+	line   int
+	column int
+	offset int
+}
+
+func newTokenPositionKey(tk *token.Token) tokenPositionKey {
+	return tokenPositionKey{line: tk.Position.Line, column: tk.Position.Column, offset: tk.Position.Offset}
+}
+
+// EditSource applies edits to src by replacing the byte ranges of the source text of the nodes,
+// so that every byte not covered by the edits, including the comments, the indentation and the quoting style
+// of the other values, is kept identical.
+// It complements the re-serialization of the AST for the tools requiring the minimal diffs such as the version bumpers.
+//
+// The range of the node covers the text from its first token to its last one, without the surrounding white spaces
+// and the comments. If the ranges of edits overlap, EditSource returns an error.
+func EditSource(src []byte, edits []Edit) ([]byte, error) {
+	tokens := lexer.Tokenize(string(src))
+	f, err := parser.Parse(tokens, 0)
+	if err != nil {
+		return nil, err
+	}
+	offsetMap := make(map[tokenPositionKey]int, len(tokens))
+	var offset int
+	for _, tk := range tokens {
+		offsetMap[newTokenPositionKey(tk)] = offset
+		offset += len(tk.Origin)
+	}
+	splices := make([]*sourceSplice, 0, len(edits))
+	for _, edit := range edits {
+		node := edit.Node
+		if node == nil {
+			if edit.Path == nil {
+				return nil, ErrInvalidPath
+			}
+			found, err := edit.Path.FilterFile(f)
+			if err != nil {
+				return nil, err
+			}
+			node = found
+		}
+		start, end, ok := nodeSourceRange(node, offsetMap)
+		if !ok {
+			return nil, fmt.Errorf("failed to find the source text of %s node: %w", node.Type(), ErrNotFoundNode)
+		}
+		splices = append(splices, &sourceSplice{start: start, end: end, text: edit.Text})
+	}
+	sort.SliceStable(splices, func(i, j int) bool {
+		return splices[i].start < splices[j].start
+	})
+	var (
+		b    strings.Builder
+		prev int
+	)
+	b.Grow(len(src))
+	for _, splice := range splices {
+		if splice.start < prev {
+			return nil, fmt.Errorf("the edits overlap at offset %d", splice.start)
+		}
+		b.Write(src[prev:splice.start])
+		b.WriteString(splice.text)
+		prev = splice.end
+	}
+	b.Write(src[prev:])
+	return []byte(b.String()), nil
+}
+
+// nodeSourceRange returns the byte range of the source text of node.
+func nodeSourceRange(node ast.Node, offsetMap map[tokenPositionKey]int) (int, int, bool) {
+	var (
+		first, last *token.Token
+		firstOffset int
+		lastOffset  int
+	)
+	for _, tk := range nodeTokens(node) {
+		if tk == nil {
+			continue
+		}
+		offset, exists := offsetMap[newTokenPositionKey(tk)]
+		if !exists {
+			// token created by parser.
+			continue
+		}
+		if first == nil || offset < firstOffset {
+			first, firstOffset = tk, offset
+		}
+		if last == nil || offset > lastOffset {
+			last, lastOffset = tk, offset
+		}
+	}
+	if first == nil {
+		return 0, 0, false
+	}
+	start := firstOffset + len(first.Origin) - len(strings.TrimLeft(first.Origin, " \t\r\n\ufeff"))
+	end := lastOffset + len(strings.TrimRight(last.Origin, " \t\r\n"))
+	return start, end, true
+}
+
+// nodeTokens returns the tokens referenced by node and its children except the comments.
+func nodeTokens(node ast.Node) []*token.Token {
+	var tokens []*token.Token
+	ast.Walk(tokenCollector(func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.CommentGroupNode, *ast.CommentNode:
+			return
+		case *ast.MappingNode:
+			tokens = append(tokens, n.End)
+		case *ast.SequenceNode:
+			tokens = append(tokens, n.End)
+		}
+		tokens = append(tokens, node.GetToken())
+	}), node)
+	return tokens
+}
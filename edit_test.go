@@ -0,0 +1,113 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestEditSource(t *testing.T) {
+	src := `# release
+name: app   # the name
+version: "1.2.3" # bump
+deps:
+  - a
+  - {name: b, tags: [1, 2]}
+notes: |
+  old
+title: あいう
+`
+	mustPath := func(t *testing.T, s string) *yaml.Path {
+		t.Helper()
+		p, err := yaml.PathString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	t.Run("path", func(t *testing.T) {
+		got, err := yaml.EditSource([]byte(src), []yaml.Edit{
+			{Path: mustPath(t, "$.title"), Text: "done"},
+			{Path: mustPath(t, "$.version"), Text: `"1.2.4"`},
+			{Path: mustPath(t, "$.deps[1].tags"), Text: "[3]"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `# release
+name: app   # the name
+version: "1.2.4" # bump
+deps:
+  - a
+  - {name: b, tags: [3]}
+notes: |
+  old
+title: done
+`
+		if string(got) != expected {
+			t.Fatalf("unexpected source:\nexpected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+	t.Run("node", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte(src), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err := mustPath(t, "$.notes").FilterFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := yaml.EditSource([]byte(src), []yaml.Edit{{Node: node, Text: "|\n  new"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `# release
+name: app   # the name
+version: "1.2.3" # bump
+deps:
+  - a
+  - {name: b, tags: [1, 2]}
+notes: |
+  new
+title: あいう
+`
+		if string(got) != expected {
+			t.Fatalf("unexpected source:\nexpected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+	t.Run("node with bom", func(t *testing.T) {
+		src := "\ufeffa: 1\nb: 2\n"
+		f, err := parser.ParseBytes([]byte(src), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err := mustPath(t, "$.b").FilterFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := yaml.EditSource([]byte(src), []yaml.Edit{{Node: node, Text: "3"}, {Path: mustPath(t, "$.a"), Text: "0"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "\ufeffa: 0\nb: 3\n"; string(got) != expected {
+			t.Fatalf("unexpected source: %q", got)
+		}
+	})
+	t.Run("overlap", func(t *testing.T) {
+		if _, err := yaml.EditSource([]byte(src), []yaml.Edit{
+			{Path: mustPath(t, "$.deps"), Text: "[]"},
+			{Path: mustPath(t, "$.deps[0]"), Text: "b"},
+		}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		if _, err := yaml.EditSource([]byte(src), []yaml.Edit{
+			{Path: mustPath(t, "$.missing"), Text: "x"},
+		}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,542 @@
+// Package emitter provides the low-level writer of YAML driven by a stream of events,
+// like DocumentStart, MappingStart and Scalar, for the transcoders that convert other formats to YAML
+// without building the whole value or the AST in memory.
+//
+//	e := emitter.New(w)
+//	for _, ev := range []emitter.Event{
+//		{Type: emitter.DocumentStartEvent},
+//		{Type: emitter.MappingStartEvent},
+//		{Type: emitter.ScalarEvent, Value: "name"},
+//		{Type: emitter.ScalarEvent, Value: "go-yaml"},
+//		{Type: emitter.MappingEndEvent},
+//		{Type: emitter.DocumentEndEvent},
+//	} {
+//		if err := e.Emit(ev); err != nil {
+//			return err
+//		}
+//	}
+//	return e.Flush()
+package emitter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/internal/blockscalar"
+	"github.com/goccy/go-yaml/token"
+)
+
+// EventType is the type of the event.
+type EventType int
+
+const (
+	// DocumentStartEvent starts a document. The second and subsequent documents are preceded by "---".
+	DocumentStartEvent EventType = iota + 1
+	// DocumentEndEvent ends the document, which must have exactly one root node.
+	DocumentEndEvent
+	// MappingStartEvent starts a mapping. The following nodes are the keys and the values alternately.
+	MappingStartEvent
+	// MappingEndEvent ends the mapping.
+	MappingEndEvent
+	// SequenceStartEvent starts a sequence.
+	SequenceStartEvent
+	// SequenceEndEvent ends the sequence.
+	SequenceEndEvent
+	// ScalarEvent writes a scalar.
+	ScalarEvent
+	// AliasEvent writes an alias to the anchor named Value.
+	AliasEvent
+)
+
+func (t EventType) String() string {
+	switch t {
+	case DocumentStartEvent:
+		return "DocumentStart"
+	case DocumentEndEvent:
+		return "DocumentEnd"
+	case MappingStartEvent:
+		return "MappingStart"
+	case MappingEndEvent:
+		return "MappingEnd"
+	case SequenceStartEvent:
+		return "SequenceStart"
+	case SequenceEndEvent:
+		return "SequenceEnd"
+	case ScalarEvent:
+		return "Scalar"
+	case AliasEvent:
+		return "Alias"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// ScalarStyle is the style of the scalar.
+type ScalarStyle int
+
+const (
+	// AnyStyle writes the scalar as a string in the plain style if possible.
+	// Otherwise, the multiline string is written in the literal style in the block context,
+	// and the other strings are quoted.
+	AnyStyle ScalarStyle = iota
+	// PlainStyle writes the value as it is, so the numbers, the booleans and null keep their types.
+	PlainStyle
+	// SingleQuotedStyle writes the value quoted with '.
+	SingleQuotedStyle
+	// DoubleQuotedStyle writes the value quoted with ".
+	DoubleQuotedStyle
+	// LiteralStyle writes the value as the literal block scalar ( | ).
+	LiteralStyle
+	// FoldedStyle writes the value as the folded block scalar ( > ).
+	FoldedStyle
+)
+
+// Event is the event written by Emitter.
+type Event struct {
+	Type EventType
+	// Value is the value of the scalar or the anchor name referred to by the alias.
+	Value string
+	// Style is the style of the scalar.
+	Style ScalarStyle
+	// Tag is the tag of the node like "!!str" or "!custom".
+	Tag string
+	// Anchor is the anchor name of the node.
+	Anchor string
+	// Flow writes the mapping or the sequence in the flow style.
+	// The collections in a flow collection are always written in the flow style.
+	Flow bool
+}
+
+var (
+	// ErrUnexpectedEvent is the error that the event is not allowed at the position of the stream.
+	ErrUnexpectedEvent = errors.New("unexpected event")
+	// ErrInvalidScalar is the error that the scalar can't be written in the specified style.
+	ErrInvalidScalar = errors.New("invalid scalar")
+)
+
+// Option is the option to change the format of Emitter.
+type Option func(*Emitter)
+
+// Indent sets the number of the spaces to indent the nested mappings. The default is 2.
+func Indent(spaces int) Option {
+	return func(e *Emitter) {
+		e.indent = spaces
+	}
+}
+
+// IndentSequence causes the sequences in the mappings to be indented.
+func IndentSequence(indent bool) Option {
+	return func(e *Emitter) {
+		e.indentSequence = indent
+	}
+}
+
+// UseSingleQuote quotes the strings of AnyStyle with ' instead of ".
+func UseSingleQuote(sq bool) Option {
+	return func(e *Emitter) {
+		e.singleQuote = sq
+	}
+}
+
+// Emitter writes YAML from the events in the same format as the Encoder of the yaml package.
+type Emitter struct {
+	writer         *bufio.Writer
+	indent         int
+	indentSequence bool
+	singleQuote    bool
+	stack          []*collection
+	inDocument     bool
+	hasRoot        bool
+	written        bool
+}
+
+// collection is the state of the mapping or the sequence being written.
+type collection struct {
+	isMapping bool
+	isFlow    bool
+	// column is the column of the entries in the block style.
+	column int
+	// count is the number of the nodes written in the collection.
+	count int
+	// opened reports whether the first entry of the block collection has been started.
+	opened bool
+	// continued reports whether the first entry is written on the line of the parent like "- key: value".
+	continued bool
+	// separator is written before the empty collection like " {}".
+	separator string
+}
+
+func (c *collection) isKey() bool {
+	return c.isMapping && c.count%2 == 0
+}
+
+// New returns a new emitter writing to w. Flush must be called after the last event.
+func New(w io.Writer, opts ...Option) *Emitter {
+	e := &Emitter{
+		writer: bufio.NewWriter(w),
+		indent: 2,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Flush writes the buffered data to the underlying writer.
+func (e *Emitter) Flush() error {
+	return e.writer.Flush()
+}
+
+// Emit writes the event.
+// It returns ErrUnexpectedEvent if the event breaks the structure, like MappingEnd in a sequence.
+func (e *Emitter) Emit(ev Event) error {
+	switch ev.Type {
+	case DocumentStartEvent:
+		if e.inDocument {
+			return e.unexpected(ev)
+		}
+		if e.written {
+			e.writer.WriteString("---\n")
+		}
+		e.inDocument = true
+		e.hasRoot = false
+		return nil
+	case DocumentEndEvent:
+		if !e.inDocument || !e.hasRoot || len(e.stack) != 0 {
+			return e.unexpected(ev)
+		}
+		e.inDocument = false
+		return nil
+	case MappingStartEvent, SequenceStartEvent:
+		return e.emitCollectionStart(ev)
+	case MappingEndEvent, SequenceEndEvent:
+		return e.emitCollectionEnd(ev)
+	case ScalarEvent, AliasEvent:
+		return e.emitScalar(ev)
+	}
+	return e.unexpected(ev)
+}
+
+func (e *Emitter) unexpected(ev Event) error {
+	return fmt.Errorf("%w %s", ErrUnexpectedEvent, ev.Type)
+}
+
+func (e *Emitter) parent() *collection {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	return e.stack[len(e.stack)-1]
+}
+
+func (e *Emitter) isFlowContext() bool {
+	parent := e.parent()
+	return parent != nil && parent.isFlow
+}
+
+// beginNode writes the indicators before the node and its properties, and returns the separator before the content.
+func (e *Emitter) beginNode(ev Event) (string, error) {
+	if !e.inDocument || (len(e.stack) == 0 && e.hasRoot) {
+		return "", e.unexpected(ev)
+	}
+	e.written = true
+	sep := ""
+	if parent := e.parent(); parent != nil {
+		if parent.isFlow {
+			if parent.isKey() && parent.count > 0 {
+				e.writer.WriteString(", ")
+			} else if !parent.isKey() && parent.isMapping {
+				sep = " "
+			} else if !parent.isMapping && parent.count > 0 {
+				e.writer.WriteString(", ")
+			}
+		} else {
+			e.openCollection(parent)
+			switch {
+			case !parent.isMapping:
+				e.writeEntryIndent(parent)
+				e.writer.WriteString("-")
+				sep = " "
+			case parent.isKey():
+				e.writeEntryIndent(parent)
+			default:
+				sep = " "
+			}
+		}
+	}
+	if ev.Anchor != "" {
+		e.writer.WriteString(sep + "&" + ev.Anchor)
+		sep = " "
+	}
+	if ev.Tag != "" {
+		e.writer.WriteString(sep + ev.Tag)
+		sep = " "
+	}
+	return sep, nil
+}
+
+// openCollection writes the line break before the first entry of the block collection.
+func (e *Emitter) openCollection(c *collection) {
+	if c.opened {
+		return
+	}
+	c.opened = true
+	if c.continued {
+		e.writer.WriteString(" ")
+		return
+	}
+	if len(e.stack) > 1 || c.separator != "" {
+		e.writer.WriteString("\n")
+	}
+}
+
+func (e *Emitter) writeEntryIndent(c *collection) {
+	if c.continued {
+		c.continued = false
+		return
+	}
+	e.writer.WriteString(strings.Repeat(" ", c.column))
+}
+
+// endNode writes the indicators after the node and counts it in the parent.
+func (e *Emitter) endNode() {
+	parent := e.parent()
+	if parent == nil {
+		e.hasRoot = true
+		e.writer.WriteString("\n")
+		return
+	}
+	if parent.isKey() {
+		e.writer.WriteString(":")
+	} else if !parent.isFlow {
+		e.writer.WriteString("\n")
+	}
+	parent.count++
+}
+
+func (e *Emitter) emitCollectionStart(ev Event) error {
+	parent := e.parent()
+	if parent != nil && parent.isKey() && !parent.isFlow {
+		return fmt.Errorf("%w %s: the key of the block mapping must be a scalar", ErrUnexpectedEvent, ev.Type)
+	}
+	sep, err := e.beginNode(ev)
+	if err != nil {
+		return err
+	}
+	c := &collection{
+		isMapping: ev.Type == MappingStartEvent,
+		isFlow:    ev.Flow || e.isFlowContext(),
+		separator: sep,
+	}
+	if c.isFlow {
+		if c.isMapping {
+			e.writer.WriteString(sep + "{")
+		} else {
+			e.writer.WriteString(sep + "[")
+		}
+		e.stack = append(e.stack, c)
+		return nil
+	}
+	hasProperties := ev.Anchor != "" || ev.Tag != ""
+	switch {
+	case parent == nil:
+		c.column = 0
+	case !parent.isMapping:
+		c.column = parent.column + 2
+		c.continued = !hasProperties
+	case c.isMapping:
+		c.column = parent.column + e.indent
+	case e.indentSequence:
+		c.column = parent.column + e.indent
+	default:
+		c.column = parent.column
+	}
+	e.stack = append(e.stack, c)
+	return nil
+}
+
+func (e *Emitter) emitCollectionEnd(ev Event) error {
+	c := e.parent()
+	if c == nil || c.isMapping != (ev.Type == MappingEndEvent) || (c.isMapping && !c.isKey()) {
+		return e.unexpected(ev)
+	}
+	e.stack = e.stack[:len(e.stack)-1]
+	switch {
+	case c.isFlow && c.isMapping:
+		e.writer.WriteString("}")
+	case c.isFlow:
+		e.writer.WriteString("]")
+	case c.count == 0 && c.isMapping:
+		e.writer.WriteString(c.separator + "{}")
+	case c.count == 0:
+		e.writer.WriteString(c.separator + "[]")
+	default:
+		// the line break has been written by the last entry.
+		e.countBlockCollection()
+		return nil
+	}
+	e.endNode()
+	return nil
+}
+
+// countBlockCollection counts the block collection ended on its last entry in the parent.
+func (e *Emitter) countBlockCollection() {
+	if parent := e.parent(); parent != nil {
+		parent.count++
+		return
+	}
+	e.hasRoot = true
+}
+
+func (e *Emitter) emitScalar(ev Event) error {
+	text, err := e.scalarText(ev)
+	if err != nil {
+		return err
+	}
+	sep, err := e.beginNode(ev)
+	if err != nil {
+		return err
+	}
+	if parent := e.parent(); parent != nil && parent.isKey() && ev.Type == AliasEvent {
+		// `*alias:` is read as the alias name including ':'.
+		text += " "
+	}
+	e.writer.WriteString(sep + text)
+	e.endNode()
+	return nil
+}
+
+// scalarText returns the text of the scalar or the alias in the style.
+func (e *Emitter) scalarText(ev Event) (string, error) {
+	if ev.Type == AliasEvent {
+		if ev.Value == "" {
+			return "", fmt.Errorf("%w: the alias name must not be empty", ErrInvalidScalar)
+		}
+		return "*" + ev.Value, nil
+	}
+	value := ev.Value
+	parent := e.parent()
+	isInline := e.isFlowContext() || (parent != nil && parent.isKey())
+	isMultiline := strings.ContainsAny(value, "\r\n")
+	switch ev.Style {
+	case AnyStyle:
+		switch {
+		case isMultiline && !isInline && canBeBlockScalar(value):
+			return e.blockScalarText(value, "|"), nil
+		case isMultiline:
+			return strconv.Quote(value), nil
+		case !isPlain(value, e.isFlowContext()) || token.IsNeedQuoted(value) || (isInline && strings.ContainsAny(value, "[]{},:")):
+			if e.singleQuote {
+				return singleQuote(value), nil
+			}
+			return strconv.Quote(value), nil
+		}
+		return value, nil
+	case PlainStyle:
+		if !isPlain(value, e.isFlowContext()) {
+			return "", fmt.Errorf("%w %q: it can't be written in the plain style", ErrInvalidScalar, value)
+		}
+		return value, nil
+	case SingleQuotedStyle:
+		if isMultiline {
+			return "", fmt.Errorf("%w %q: the line break can't be written in the single-quoted style", ErrInvalidScalar, value)
+		}
+		return singleQuote(value), nil
+	case DoubleQuotedStyle:
+		return strconv.Quote(value), nil
+	case LiteralStyle, FoldedStyle:
+		if isInline {
+			return "", fmt.Errorf("%w %q: the block scalar can't be written in the flow style or as a key", ErrInvalidScalar, value)
+		}
+		if strings.Contains(value, "\r") {
+			return "", fmt.Errorf("%w %q: the carriage return can't be written in the block scalar", ErrInvalidScalar, value)
+		}
+		if !canBeBlockScalar(value) {
+			return "", fmt.Errorf("%w %q: it can't be read back from the block scalar", ErrInvalidScalar, value)
+		}
+		if ev.Style == FoldedStyle {
+			return e.blockScalarText(value, ">"), nil
+		}
+		return e.blockScalarText(value, "|"), nil
+	}
+	return "", fmt.Errorf("%w: unknown style %d", ErrInvalidScalar, ev.Style)
+}
+
+// blockScalarText returns the header and the lines of the block scalar, indented to the content column.
+func (e *Emitter) blockScalarText(value, indicator string) string {
+	content := strings.TrimSuffix(value, "\n")
+	lines := strings.Split(content, "\n")
+	if indicator == ">" {
+		lines = blockscalar.FoldedLines(lines, 0)
+	}
+	header := indicator
+	if blockscalar.IsNeedIndentIndicator(lines) {
+		header += strconv.Itoa(e.indent)
+	}
+	switch {
+	case value == "" || !strings.HasSuffix(value, "\n"):
+		header += "-"
+	case strings.HasSuffix(value, "\n\n"):
+		header += "+"
+	}
+	column := e.indent
+	if parent := e.parent(); parent != nil {
+		if parent.isMapping {
+			column = parent.column + e.indent
+		} else {
+			column = parent.column + 2
+		}
+	}
+	space := strings.Repeat(" ", column)
+	var b strings.Builder
+	b.WriteString(header)
+	for _, line := range lines {
+		b.WriteString("\n")
+		if line != "" {
+			b.WriteString(space + line)
+		}
+	}
+	return b.String()
+}
+
+// canBeBlockScalar returns whether value is read as the same text when it's written in the block scalar.
+// The parser rejects the indentation indicator followed by the trailing empty lines,
+// and removes the trailing spaces of the block scalar without the trailing line break.
+func canBeBlockScalar(value string) bool {
+	if strings.Contains(value, "\r") || !blockscalar.IsIndentDetectable(value) {
+		return false
+	}
+	if strings.HasSuffix(value, " ") || strings.HasSuffix(value, "\t") {
+		return false
+	}
+	return !strings.HasSuffix(value, "\n\n") || !blockscalar.IsNeedIndentIndicator(strings.Split(value, "\n"))
+}
+
+// isPlain returns whether value is read as the same text when it's written in the plain style.
+func isPlain(value string, isFlow bool) bool {
+	if value == "" || strings.ContainsAny(value, "\r\n") {
+		return false
+	}
+	switch value[0] {
+	case '*', '&', '[', '{', '}', ']', ',', '!', '|', '>', '%', '\'', '"', '@', '`', '#', ' ', '\t':
+		return false
+	case '-', '?', ':':
+		if len(value) == 1 || value[1] == ' ' || value[1] == '\t' {
+			return false
+		}
+	}
+	switch value[len(value)-1] {
+	case ':', ' ', '\t':
+		return false
+	}
+	if strings.Contains(value, ": ") || strings.Contains(value, ":\t") || strings.Contains(value, " #") || strings.Contains(value, "\t#") {
+		return false
+	}
+	return !isFlow || !strings.ContainsAny(value, "[]{},")
+}
+
+func singleQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,324 @@
+package emitter_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/emitter"
+)
+
+var (
+	docStart = emitter.Event{Type: emitter.DocumentStartEvent}
+	docEnd   = emitter.Event{Type: emitter.DocumentEndEvent}
+	mapStart = emitter.Event{Type: emitter.MappingStartEvent}
+	mapEnd   = emitter.Event{Type: emitter.MappingEndEvent}
+	seqStart = emitter.Event{Type: emitter.SequenceStartEvent}
+	seqEnd   = emitter.Event{Type: emitter.SequenceEndEvent}
+	flowMap  = emitter.Event{Type: emitter.MappingStartEvent, Flow: true}
+	flowSeq  = emitter.Event{Type: emitter.SequenceStartEvent, Flow: true}
+)
+
+func scalar(v string) emitter.Event {
+	return emitter.Event{Type: emitter.ScalarEvent, Value: v}
+}
+
+func plain(v string) emitter.Event {
+	return emitter.Event{Type: emitter.ScalarEvent, Value: v, Style: emitter.PlainStyle}
+}
+
+func emit(t *testing.T, events []emitter.Event, opts ...emitter.Option) string {
+	t.Helper()
+	var buf bytes.Buffer
+	e := emitter.New(&buf, opts...)
+	for _, ev := range events {
+		if err := e.Emit(ev); err != nil {
+			t.Fatalf("failed to emit %s: %v", ev.Type, err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestEmitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []emitter.Event
+		options  []emitter.Option
+		expected string
+	}{
+		{
+			name:     "scalar",
+			events:   []emitter.Event{docStart, scalar("hello"), docEnd},
+			expected: "hello\n",
+		},
+		{
+			name: "mapping",
+			events: []emitter.Event{
+				docStart, mapStart,
+				scalar("name"), scalar("go-yaml"),
+				scalar("version"), plain("1"),
+				scalar("quoted"), scalar("1"),
+				scalar("empty"), scalar(""),
+				scalar("null"), plain("null"),
+				mapEnd, docEnd,
+			},
+			expected: `name: go-yaml
+version: 1
+quoted: "1"
+empty: ""
+"null": null
+`,
+		},
+		{
+			name: "nested",
+			events: []emitter.Event{
+				docStart, mapStart,
+				scalar("a"), mapStart,
+				scalar("b"), seqStart, scalar("c"), mapStart, scalar("d"), scalar("e"), scalar("f"), scalar("g"), mapEnd, seqStart, scalar("h"), scalar("i"), seqEnd, seqEnd,
+				scalar("j"), scalar("k"),
+				mapEnd,
+				scalar("l"), seqStart, seqStart, seqEnd, mapStart, mapEnd, seqEnd,
+				scalar("m"), mapStart, mapEnd,
+				mapEnd, docEnd,
+			},
+			expected: `a:
+  b:
+  - c
+  - d: e
+    f: g
+  - - h
+    - i
+  j: k
+l:
+- []
+- {}
+m: {}
+`,
+		},
+		{
+			name: "indent sequence",
+			events: []emitter.Event{
+				docStart, mapStart,
+				scalar("a"), seqStart, scalar("b"), mapStart, scalar("c"), seqStart, scalar("d"), seqEnd, mapEnd, seqEnd,
+				mapEnd, docEnd,
+			},
+			options: []emitter.Option{emitter.Indent(4), emitter.IndentSequence(true)},
+			expected: `a:
+    - b
+    - c:
+          - d
+`,
+		},
+		{
+			name: "flow",
+			events: []emitter.Event{
+				docStart, mapStart,
+				scalar("a"), flowSeq, scalar("b"), scalar("c, d"), mapStart, scalar("e"), plain("1"), mapEnd, seqEnd,
+				scalar("f"), flowMap, scalar("g"), seqStart, seqEnd, scalar("h"), scalar("line1\nline2"), mapEnd,
+				scalar("i"), flowSeq, seqEnd,
+				mapEnd, docEnd,
+			},
+			expected: `a: [b, "c, d", {e: 1}]
+f: {g: [], h: "line1\nline2"}
+i: []
+`,
+		},
+		{
+			name: "properties",
+			events: []emitter.Event{
+				docStart, mapStart,
+				scalar("a"), {Type: emitter.MappingStartEvent, Anchor: "x"}, scalar("b"), scalar("c"), mapEnd,
+				scalar("d"), {Type: emitter.AliasEvent, Value: "x"},
+				scalar("e"), seqStart, {Type: emitter.MappingStartEvent, Tag: "!item"}, scalar("f"), scalar("g"), mapEnd, {Type: emitter.ScalarEvent, Value: "1", Tag: "!!str", Anchor: "y"}, seqEnd,
+				{Type: emitter.AliasEvent, Value: "y"}, scalar("h"),
+				scalar("i"), {Type: emitter.SequenceStartEvent, Anchor: "z"}, seqEnd,
+				mapEnd, docEnd,
+			},
+			expected: `a: &x
+  b: c
+d: *x
+e:
+- !item
+  f: g
+- &y !!str "1"
+*y : h
+i: &z []
+`,
+		},
+		{
+			name: "scalar styles",
+			events: []emitter.Event{
+				docStart, mapStart,
+				scalar("single"), {Type: emitter.ScalarEvent, Value: "it's", Style: emitter.SingleQuotedStyle},
+				scalar("double"), {Type: emitter.ScalarEvent, Value: "a", Style: emitter.DoubleQuotedStyle},
+				scalar("literal"), scalar("a\nb\n"),
+				scalar("strip"), {Type: emitter.ScalarEvent, Value: "a\n  b", Style: emitter.LiteralStyle},
+				scalar("keep"), {Type: emitter.ScalarEvent, Value: "a\n\n", Style: emitter.LiteralStyle},
+				scalar("indicator"), {Type: emitter.ScalarEvent, Value: "  a\nb\n", Style: emitter.LiteralStyle},
+				scalar("folded"), {Type: emitter.ScalarEvent, Value: "a b\nc\n\nd\n", Style: emitter.FoldedStyle},
+				scalar("items"), seqStart, scalar("a\nb"), seqEnd,
+				mapEnd, docEnd,
+			},
+			expected: `single: 'it''s'
+double: "a"
+literal: |
+  a
+  b
+strip: |-
+  a
+    b
+keep: |+
+  a
+
+indicator: |2
+    a
+  b
+folded: >
+  a b
+
+  c
+
+
+  d
+items:
+- |-
+  a
+  b
+`,
+		},
+		{
+			name:     "single quote",
+			events:   []emitter.Event{docStart, seqStart, scalar("true"), scalar("a: b"), seqEnd, docEnd},
+			options:  []emitter.Option{emitter.UseSingleQuote(true)},
+			expected: "- 'true'\n- 'a: b'\n",
+		},
+		{
+			name: "documents",
+			events: []emitter.Event{
+				docStart, mapStart, scalar("a"), plain("1"), mapEnd, docEnd,
+				docStart, seqStart, scalar("b"), seqEnd, docEnd,
+				docStart, {Type: emitter.MappingStartEvent, Anchor: "root"}, scalar("c"), plain("2"), mapEnd, docEnd,
+			},
+			expected: `a: 1
+---
+- b
+---
+&root
+c: 2
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := emit(t, test.events, test.options...)
+			if got != test.expected {
+				t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestEmitter_RoundTrip(t *testing.T) {
+	got := emit(t, []emitter.Event{
+		docStart, mapStart,
+		scalar("literal"), seqStart, scalar("a\nb\n"), {Type: emitter.ScalarEvent, Value: "  a\nb", Style: emitter.LiteralStyle}, seqEnd,
+		scalar("folded"), {Type: emitter.ScalarEvent, Value: "a b\nc\n\nd\n  e\nf", Style: emitter.FoldedStyle},
+		scalar("quoted"), flowSeq, scalar("true"), scalar("# c"), scalar("x: y"), scalar("[z]"), seqEnd,
+		scalar("indicators"), seqStart, scalar("-"), scalar("- d"), scalar("? e"), scalar(": f"), scalar("a #b"), scalar("-\t x"), scalar("?\ty"), scalar("x:\ty"), scalar("a\t#b"), seqEnd,
+		scalar("line breaks"), seqStart, scalar("\n"), scalar("\n\n"), scalar("\ta\nb"), scalar(" a\n\n"), scalar("a\n x "), scalar("a\n x\t"), seqEnd,
+		mapEnd, docEnd,
+	})
+	var v map[string]interface{}
+	if err := yaml.Unmarshal([]byte(got), &v); err != nil {
+		t.Fatalf("failed to unmarshal:\n%s\n%v", got, err)
+	}
+	expected := map[string]interface{}{
+		"literal":     []interface{}{"a\nb\n", "  a\nb"},
+		"folded":      "a b\nc\n\nd\n  e\nf",
+		"quoted":      []interface{}{"true", "# c", "x: y", "[z]"},
+		"indicators":  []interface{}{"-", "- d", "? e", ": f", "a #b", "-\t x", "?\ty", "x:\ty", "a\t#b"},
+		"line breaks": []interface{}{"\n", "\n\n", "\ta\nb", " a\n\n", "a\n x ", "a\n x\t"},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected value %#v from:\n%s", v, got)
+	}
+}
+
+func TestEmitter_Error(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []emitter.Event
+		err    error
+	}{
+		{
+			name:   "node without document",
+			events: []emitter.Event{scalar("a")},
+			err:    emitter.ErrUnexpectedEvent,
+		},
+		{
+			name:   "second root",
+			events: []emitter.Event{docStart, scalar("a"), scalar("b")},
+			err:    emitter.ErrUnexpectedEvent,
+		},
+		{
+			name:   "document without root",
+			events: []emitter.Event{docStart, docEnd},
+			err:    emitter.ErrUnexpectedEvent,
+		},
+		{
+			name:   "mismatched end",
+			events: []emitter.Event{docStart, mapStart, seqEnd},
+			err:    emitter.ErrUnexpectedEvent,
+		},
+		{
+			name:   "mapping without value",
+			events: []emitter.Event{docStart, mapStart, scalar("a"), mapEnd},
+			err:    emitter.ErrUnexpectedEvent,
+		},
+		{
+			name:   "collection key",
+			events: []emitter.Event{docStart, mapStart, seqStart},
+			err:    emitter.ErrUnexpectedEvent,
+		},
+		{
+			name:   "invalid plain",
+			events: []emitter.Event{docStart, plain("a: b")},
+			err:    emitter.ErrInvalidScalar,
+		},
+		{
+			name:   "block scalar in flow",
+			events: []emitter.Event{docStart, flowSeq, {Type: emitter.ScalarEvent, Value: "a", Style: emitter.LiteralStyle}},
+			err:    emitter.ErrInvalidScalar,
+		},
+		{
+			name:   "literal only line break",
+			events: []emitter.Event{docStart, {Type: emitter.ScalarEvent, Value: "\n", Style: emitter.LiteralStyle}},
+			err:    emitter.ErrInvalidScalar,
+		},
+		{
+			name:   "folded starting with tab",
+			events: []emitter.Event{docStart, {Type: emitter.ScalarEvent, Value: "\ta\nb", Style: emitter.FoldedStyle}},
+			err:    emitter.ErrInvalidScalar,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := emitter.New(&buf)
+			var err error
+			for _, ev := range test.events {
+				if err = e.Emit(ev); err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v but got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -3,17 +3,25 @@ package yaml
 import (
 	"context"
 	"encoding"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/blockscalar"
 	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/lexer"
 	"github.com/goccy/go-yaml/parser"
 	"github.com/goccy/go-yaml/printer"
 	"github.com/goccy/go-yaml/token"
@@ -30,16 +38,48 @@ type Encoder struct {
 	opts                       []EncodeOption
 	indent                     int
 	indentSequence             bool
+	isExpandedSequence         bool
 	singleQuote                bool
 	isFlowStyle                bool
 	isJSONStyle                bool
 	useJSONMarshaler           bool
+	useBinaryMarshaler         bool
 	anchorCallback             func(*ast.AnchorNode, interface{}) error
 	anchorPtrToNameMap         map[uintptr]string
+	anchorNameSanitizer        func(string) string
+	anchorNames                map[string]string
+	usedAnchorNames            map[string]string
+	disallowAnchorCollision    bool
 	customMarshalerMap         map[reflect.Type]func(interface{}) ([]byte, error)
 	useLiteralStyleIfMultiline bool
+	isCanonical                bool
+	sortStructFields           bool
+	quoteYAML11Scalar          bool
+	plainScalarResolver        func(string) (string, bool)
+	forceQuoteStrings          func(string, string) bool
+	useExplicitKeyIfNeeded     bool
+	foldedStyleWidth           int
+	escapeSpecialCharacter     bool
+	honorJSONTagOptions        bool
+	disallowUnsupportedValue   bool
 	commentMap                 map[*Path][]*Comment
+	commentIndent              int
+	lineCommentSpacing         int
+	alignLineComments          bool
+	preserveCommentBlankLines  bool
+	preserveSequenceBlankLines bool
+	allowCycles                bool
+	skipAliasValidation        bool
+	subValue                   *subValueEncoding
+	references                 *references
+	path                       []pathElement
+	directives                 []string
+	documentStart              bool
+	documentEnd                bool
+	documentHeadComment        *ast.CommentGroupNode
+	documentFootComment        *ast.CommentGroupNode
 	written                    bool
+	ended                      bool
 
 	line        int
 	column      int
@@ -56,6 +96,7 @@ func NewEncoder(w io.Writer, opts ...EncodeOption) *Encoder {
 		opts:               opts,
 		indent:             DefaultIndentSpaces,
 		anchorPtrToNameMap: map[uintptr]string{},
+		references:         &references{values: map[referenceKey]*reference{}},
 		customMarshalerMap: map[reflect.Type]func(interface{}) ([]byte, error){},
 		line:               1,
 		column:             1,
@@ -72,7 +113,7 @@ func (e *Encoder) Close() error {
 // Encode writes the YAML encoding of v to the stream.
 // If multiple items are encoded to the stream,
 // the second and subsequent document will be preceded with a "---" document separator,
-// but the first will not.
+// but the first will not unless WithDocumentStart is specified.
 //
 // See the documentation for Marshal for details about the conversion of Go values to YAML.
 func (e *Encoder) Encode(v interface{}) error {
@@ -81,6 +122,9 @@ func (e *Encoder) Encode(v interface{}) error {
 
 // EncodeContext writes the YAML encoding of v to the stream with context.Context.
 func (e *Encoder) EncodeContext(ctx context.Context, v interface{}) error {
+	if e.subValue != nil {
+		return e.encodeSubValue(ctx, v)
+	}
 	node, err := e.EncodeToNodeContext(ctx, v)
 	if err != nil {
 		return err
@@ -88,17 +132,91 @@ func (e *Encoder) EncodeContext(ctx context.Context, v interface{}) error {
 	if err := e.setCommentByCommentMap(node); err != nil {
 		return err
 	}
-	if !e.written {
+	if err := e.validateDirectives(); err != nil {
+		return err
+	}
+	if e.isCanonical {
+		// canonical form always starts with the document separator
+		text := e.canonicalText(node)
+		e.writeDirectives()
 		e.written = true
-	} else {
+		_, _ = e.writer.Write([]byte(text))
+		e.ended = false
+		if e.documentEnd {
+			_, _ = e.writer.Write([]byte("...\n"))
+			e.ended = true
+		}
+		return nil
+	}
+	if len(e.directives) != 0 || e.documentHeadComment != nil {
+		e.writeDirectives()
+		_, _ = e.writer.Write([]byte("---\n"))
+	} else if e.written || e.documentStart {
 		// write document separator
 		_, _ = e.writer.Write([]byte("---\n"))
 	}
+	e.written = true
 	var p printer.Printer
-	_, _ = e.writer.Write(p.PrintNode(node))
+	_, _ = e.writer.Write(e.formatComments(p.PrintNode(node)))
+	e.writeDocumentEnd()
+	return nil
+}
+
+// subValueEncoding is the state of the encoder passed to MarshalerTo, which encodes the value in place of the value implementing it.
+type subValueEncoding struct {
+	column  int
+	node    ast.Node
+	encoded bool
+}
+
+func (e *Encoder) encodeSubValue(ctx context.Context, v interface{}) error {
+	if e.subValue.encoded {
+		return errors.New("the value has already been encoded by MarshalYAMLTo")
+	}
+	node, err := e.encodeValue(ctx, reflect.ValueOf(v), e.subValue.column)
+	if err != nil {
+		return err
+	}
+	e.subValue.node = node
+	e.subValue.encoded = true
+	return nil
+}
+
+// SetDirectives sets the directives ( e.g. "%YAML 1.2", "%TAG !e! tag:example.com,2000:" ) written before the following documents.
+// The document with the directives starts with the "---" marker,
+// and the previous document is ended with the "..." marker, because the directives can't follow the document without it.
+// SetDirectives without arguments stops writing the directives.
+func (e *Encoder) SetDirectives(directives ...string) {
+	e.directives = directives
+}
+
+func (e *Encoder) validateDirectives() error {
+	for _, directive := range e.directives {
+		if !strings.HasPrefix(directive, "%") || strings.ContainsAny(directive, "\r\n") {
+			return fmt.Errorf("invalid directive %q: it must be a single line starting with %%", directive)
+		}
+	}
 	return nil
 }
 
+// writeDirectives writes the head comment and the directives of the document, after the end marker of the previous document.
+// The comment after the previous document without the end marker would be parsed as the comment of the previous document.
+func (e *Encoder) writeDirectives() {
+	if len(e.directives) == 0 && e.documentHeadComment == nil {
+		return
+	}
+	if e.written && !e.ended {
+		_, _ = e.writer.Write([]byte("...\n"))
+	}
+	e.ended = false
+	if e.documentHeadComment != nil {
+		_, _ = e.writer.Write([]byte(e.documentHeadComment.String() + "\n"))
+	}
+	for _, directive := range e.directives {
+		_, _ = e.writer.Write([]byte(directive + "\n"))
+	}
+}
+
 // EncodeToNode convert v to ast.Node.
 func (e *Encoder) EncodeToNode(v interface{}) (ast.Node, error) {
 	return e.EncodeToNodeContext(context.Background(), v)
@@ -106,19 +224,162 @@ func (e *Encoder) EncodeToNode(v interface{}) (ast.Node, error) {
 
 // EncodeToNodeContext convert v to ast.Node with context.Context.
 func (e *Encoder) EncodeToNodeContext(ctx context.Context, v interface{}) (ast.Node, error) {
+	e.anchorNames = map[string]string{}
+	e.usedAnchorNames = map[string]string{}
 	for _, opt := range e.opts {
 		if err := opt(e); err != nil {
 			return nil, err
 		}
 	}
+	if e.isJSONStyle && e.allowCycles {
+		// JSON has no aliases to write the cycles.
+		return nil, ErrOptionConflict("JSON", "AllowCycles")
+	}
 	node, err := e.encodeValue(ctx, reflect.ValueOf(v), 1)
 	if err != nil {
 		return nil, err
 	}
+	if err := e.validateAliases(node); err != nil {
+		return nil, err
+	}
 	return node, nil
 }
 
+// validateAliases returns an error if an alias refers to an anchor that is not defined in the document
+// or defined after the alias, like the manually built AST. Such document cannot be parsed by the YAML parsers.
+// The error reports the path of the alias.
+func (e *Encoder) validateAliases(node ast.Node) error {
+	if node == nil || e.skipAliasValidation {
+		return nil
+	}
+	if ast.CollectStats(node).Aliases == 0 {
+		return nil
+	}
+	anchorNames := map[string]struct{}{}
+	for _, anchor := range ast.Filter(ast.AnchorType, node) {
+		anchorNames[referenceName(anchor.(*ast.AnchorNode).Name)] = struct{}{}
+	}
+	v := &aliasValidator{
+		anchorNames:  anchorNames,
+		definedNames: map[string]struct{}{},
+	}
+	if err := v.validate(node); err != nil {
+		return err.toError()
+	}
+	return nil
+}
+
+type aliasValidator struct {
+	anchorNames  map[string]struct{}
+	definedNames map[string]struct{}
+}
+
+// aliasValidationError is the error found by aliasValidator.
+// The selectors of the path are collected from the alias to the root while returning the error,
+// so the path is built only when the error is found.
+type aliasValidationError struct {
+	err       error
+	selectors []string
+}
+
+func (e *aliasValidationError) toError() error {
+	var path strings.Builder
+	path.WriteString("$")
+	for i := len(e.selectors) - 1; i >= 0; i-- {
+		path.WriteString(e.selectors[i])
+	}
+	return fmt.Errorf("%w at %s", e.err, path.String())
+}
+
+func (v *aliasValidator) validate(node ast.Node) *aliasValidationError {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		// the anchor is defined before its value, so the value can refer to it like the cycle written by AllowCycles.
+		v.definedNames[referenceName(n.Name)] = struct{}{}
+		return v.validate(n.Value)
+	case *ast.AliasNode:
+		aliasName := referenceName(n.Value)
+		if _, exists := v.definedNames[aliasName]; exists {
+			return nil
+		}
+		if _, exists := v.anchorNames[aliasName]; exists {
+			return &aliasValidationError{err: ErrAliasBeforeAnchor(aliasName)}
+		}
+		return &aliasValidationError{err: ErrAliasWithoutAnchor(aliasName)}
+	case *ast.TagNode:
+		return v.validate(n.Value)
+	case *ast.MappingKeyNode:
+		return v.validate(n.Value)
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			if err := v.validate(value); err != nil {
+				return err
+			}
+		}
+	case *ast.MappingValueNode:
+		if err := v.validate(n.Key); err != nil {
+			return err
+		}
+		if err := v.validate(n.Value); err != nil {
+			err.selectors = append(err.selectors, mapKeySelector(aliasValidatorKeyText(n.Key)))
+			return err
+		}
+	case *ast.SequenceNode:
+		for i, value := range n.Values {
+			if err := v.validate(value); err != nil {
+				err.selectors = append(err.selectors, fmt.Sprintf("[%d]", i))
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// referenceName returns the name of the anchor or the alias.
+// The name can be renamed by MarshalAnchor without updating its token.
+func referenceName(name ast.Node) string {
+	if s, ok := name.(*ast.StringNode); ok {
+		return s.Value
+	}
+	return name.GetToken().Value
+}
+
+// aliasValidatorKeyText returns the text of the key used for the path of the value.
+func aliasValidatorKeyText(key ast.Node) string {
+	switch k := key.(type) {
+	case *ast.MappingKeyNode:
+		return aliasValidatorKeyText(k.Value)
+	case *ast.TagNode:
+		return aliasValidatorKeyText(k.Value)
+	case *ast.AnchorNode:
+		return aliasValidatorKeyText(k.Value)
+	case *ast.AliasNode:
+		return k.String()
+	}
+	if tk := key.GetToken(); tk != nil {
+		return tk.Value
+	}
+	return key.String()
+}
+
+// writeDocumentEnd writes the end marker of the document if WithDocumentEnd is specified,
+// and the foot comment of the document after the end marker.
+func (e *Encoder) writeDocumentEnd() {
+	e.ended = false
+	if e.documentFootComment == nil {
+		if e.documentEnd {
+			_, _ = e.writer.Write([]byte("...\n"))
+			e.ended = true
+		}
+		return
+	}
+	_, _ = e.writer.Write([]byte("...\n" + e.documentFootComment.String() + "\n"))
+	e.ended = true
+}
+
 func (e *Encoder) setCommentByCommentMap(node ast.Node) error {
+	e.documentHeadComment = nil
+	e.documentFootComment = nil
 	if e.commentMap == nil {
 		return nil
 	}
@@ -131,11 +392,18 @@ func (e *Encoder) setCommentByCommentMap(node ast.Node) error {
 			continue
 		}
 		for _, comment := range comments {
-			commentTokens := []*token.Token{}
-			for _, text := range comment.Texts {
-				commentTokens = append(commentTokens, token.New(text, text, nil))
+			commentGroup := e.commentGroup(comment.Texts)
+			if n == node {
+				// the head comment and the foot comment of the root are written around the document.
+				switch comment.Position {
+				case CommentHeadPosition:
+					e.documentHeadComment = commentGroup
+					continue
+				case CommentFootPosition:
+					e.documentFootComment = commentGroup
+					continue
+				}
 			}
-			commentGroup := ast.CommentGroup(commentTokens)
 			switch comment.Position {
 			case CommentHeadPosition:
 				if err := e.setHeadComment(node, n, commentGroup); err != nil {
@@ -149,6 +417,8 @@ func (e *Encoder) setCommentByCommentMap(node ast.Node) error {
 				if err := e.setFootComment(node, n, commentGroup); err != nil {
 					return err
 				}
+			case CommentBlankLinePosition:
+				e.setSequenceBlankLine(node, n)
 			default:
 				return ErrUnknownCommentPositionType
 			}
@@ -157,6 +427,30 @@ func (e *Encoder) setCommentByCommentMap(node ast.Node) error {
 	return nil
 }
 
+// commentGroup creates the comment group having the texts as the lines.
+// If PreserveCommentBlankLines is specified, the empty texts between the lines are written as the blank lines
+// by the positions of the comment tokens, in the same way as the blank lines in the parsed comments.
+func (e *Encoder) commentGroup(texts []string) *ast.CommentGroupNode {
+	commentTokens := []*token.Token{}
+	if !e.preserveCommentBlankLines {
+		for _, text := range texts {
+			commentTokens = append(commentTokens, token.New(text, text, nil))
+		}
+		return ast.CommentGroup(commentTokens)
+	}
+	var prev *token.Token
+	for idx, text := range texts {
+		if text == "" && prev != nil {
+			continue
+		}
+		tk := token.Comment(text, "#"+text, &token.Position{Line: idx + 1, Column: 1})
+		tk.Prev = prev
+		commentTokens = append(commentTokens, tk)
+		prev = tk
+	}
+	return ast.CommentGroup(commentTokens)
+}
+
 func (e *Encoder) setHeadComment(node ast.Node, filtered ast.Node, comment *ast.CommentGroupNode) error {
 	parent := ast.Parent(node, filtered)
 	if parent == nil {
@@ -189,6 +483,27 @@ func (e *Encoder) setHeadComment(node ast.Node, filtered ast.Node, comment *ast.
 	return nil
 }
 
+// setSequenceBlankLine writes the blank line before the sequence entry if PreserveSequenceBlankLines is specified.
+// The blank line of the other node is ignored.
+func (e *Encoder) setSequenceBlankLine(node ast.Node, filtered ast.Node) {
+	if !e.preserveSequenceBlankLines {
+		return
+	}
+	seq, ok := ast.Parent(node, filtered).(*ast.SequenceNode)
+	if !ok || seq.IsFlowStyle {
+		return
+	}
+	if len(seq.ValueBlankLines) != len(seq.Values) {
+		seq.ValueBlankLines = make([]bool, len(seq.Values))
+	}
+	for idx, v := range seq.Values {
+		if v == filtered {
+			seq.ValueBlankLines[idx] = true
+			break
+		}
+	}
+}
+
 func (e *Encoder) setLineComment(node ast.Node, filtered ast.Node, comment *ast.CommentGroupNode) error {
 	switch filtered.(type) {
 	case *ast.MappingValueNode, *ast.SequenceNode:
@@ -243,6 +558,94 @@ func (e *Encoder) setFootComment(node ast.Node, filtered ast.Node, comment *ast.
 	return nil
 }
 
+// formatComments changes the spaces around the comments of the encoded text
+// by CommentIndent, LineCommentSpacing and AlignLineComments.
+// The comments are found by the tokens of the text, and the other text is kept as it is.
+func (e *Encoder) formatComments(text []byte) []byte {
+	if e.commentIndent == 0 && e.lineCommentSpacing == 0 && !e.alignLineComments {
+		return text
+	}
+	tokens := lexer.Tokenize(string(text))
+	type lineComment struct {
+		tk    *token.Token
+		width int
+	}
+	var lineComments []*lineComment
+	for idx, tk := range tokens {
+		if tk.Type != token.CommentType {
+			continue
+		}
+		start := strings.IndexByte(tk.Origin, '#')
+		if start < 0 {
+			continue
+		}
+		var prev *token.Token
+		if idx > 0 {
+			prev = tokens[idx-1]
+		}
+		if prev == nil || strings.Contains(tk.Origin[:start], "\n") || strings.HasSuffix(prev.Origin, "\n") {
+			if e.commentIndent > 0 && !isInBlockScalarIndent(tokens, idx) {
+				tk.Origin = tk.Origin[:start] + strings.Repeat(" ", e.commentIndent) + tk.Origin[start:]
+			}
+			continue
+		}
+		prev.Origin = strings.TrimRight(prev.Origin, " \t")
+		tk.Origin = tk.Origin[start:]
+		lineComments = append(lineComments, &lineComment{tk: tk, width: lineWidth(tokens[:idx])})
+	}
+	spacing := e.lineCommentSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+	for start := 0; start < len(lineComments); {
+		end := start + 1
+		if e.alignLineComments {
+			// the line comments of the consecutive lines are aligned like a table.
+			for end < len(lineComments) && lineComments[end].tk.Position.Line == lineComments[end-1].tk.Position.Line+1 {
+				end++
+			}
+		}
+		column := 0
+		for _, c := range lineComments[start:end] {
+			column = max(column, c.width)
+		}
+		for _, c := range lineComments[start:end] {
+			c.tk.Origin = strings.Repeat(" ", column-c.width+spacing) + c.tk.Origin
+		}
+		start = end
+	}
+	return []byte(tokens.Source())
+}
+
+// isInBlockScalarIndent returns whether the comment at idx follows the block scalar,
+// so the indented comment would be read as the content of the scalar.
+func isInBlockScalarIndent(tokens token.Tokens, idx int) bool {
+	for idx--; idx > 0; idx-- {
+		if tokens[idx].Type == token.CommentType {
+			continue
+		}
+		switch tokens[idx-1].Type {
+		case token.LiteralType, token.FoldedType:
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// lineWidth returns the number of the characters of the last line written by the tokens.
+func lineWidth(tokens token.Tokens) int {
+	var width int
+	for idx := len(tokens) - 1; idx >= 0; idx-- {
+		origin := tokens[idx].Origin
+		if pos := strings.LastIndexByte(origin, '\n'); pos >= 0 {
+			return width + utf8.RuneCountInString(origin[pos+1:])
+		}
+		width += utf8.RuneCountInString(origin)
+	}
+	return width
+}
+
 func (e *Encoder) encodeDocument(doc []byte) (ast.Node, error) {
 	f, err := parser.ParseBytes(doc, 0)
 	if err != nil {
@@ -256,6 +659,24 @@ func (e *Encoder) encodeDocument(doc []byte) (ast.Node, error) {
 	return nil, nil
 }
 
+// encodeASTNode encodes the copy of node keeping its styles and comments.
+// node isn't modified, because it's owned by the caller.
+func (e *Encoder) encodeASTNode(node ast.Node, column int) (ast.Node, error) {
+	if node == nil {
+		return e.encodeNil(), nil
+	}
+	f, err := parser.ParseBytes([]byte(node.String()), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, docNode := range f.Docs {
+		if docNode.Body != nil {
+			return e.alignMarshaledNode(docNode.Body, column), nil
+		}
+	}
+	return e.encodeNil(), nil
+}
+
 func (e *Encoder) isInvalidValue(v reflect.Value) bool {
 	if !v.IsValid() {
 		return true
@@ -309,6 +730,8 @@ func (e *Encoder) canEncodeByMarshaler(v reflect.Value) bool {
 	}
 	iface := v.Interface()
 	switch iface.(type) {
+	case MarshalerTo:
+		return true
 	case BytesMarshalerContext:
 		return true
 	case BytesMarshaler:
@@ -317,16 +740,27 @@ func (e *Encoder) canEncodeByMarshaler(v reflect.Value) bool {
 		return true
 	case InterfaceMarshaler:
 		return true
+	case NodeMarshalerContext:
+		return true
+	case NodeMarshaler:
+		return true
 	case time.Time:
 		return true
 	case time.Duration:
 		return true
+	case *big.Int, *big.Float, *big.Rat, big.Int, big.Float, big.Rat:
+		return true
 	case encoding.TextMarshaler:
 		return true
 	case jsonMarshaler:
-		return e.useJSONMarshaler
+		if e.useJSONMarshaler {
+			return true
+		}
 	}
-	return false
+	if _, ok := iface.(encoding.BinaryMarshaler); ok && e.useBinaryMarshaler {
+		return true
+	}
+	return isTimeStructType(v.Type())
 }
 
 func (e *Encoder) encodeByMarshaler(ctx context.Context, v reflect.Value, column int) (ast.Node, error) {
@@ -344,6 +778,22 @@ func (e *Encoder) encodeByMarshaler(ctx context.Context, v reflect.Value, column
 		return node, nil
 	}
 
+	if node, ok := iface.(ast.Node); ok {
+		return e.encodeASTNode(node, column)
+	}
+
+	if marshaler, ok := iface.(MarshalerTo); ok {
+		sub := *e
+		sub.subValue = &subValueEncoding{column: column}
+		if err := marshaler.MarshalYAMLTo(&sub); err != nil {
+			return nil, err
+		}
+		if !sub.subValue.encoded {
+			return nil, fmt.Errorf("MarshalYAMLTo of %s didn't encode the value", reflect.TypeOf(iface))
+		}
+		return sub.subValue.node, nil
+	}
+
 	if marshaler, ok := iface.(BytesMarshalerContext); ok {
 		doc, err := marshaler.MarshalYAML(ctx)
 		if err != nil {
@@ -384,7 +834,23 @@ func (e *Encoder) encodeByMarshaler(ctx context.Context, v reflect.Value, column
 		return e.encodeValue(ctx, reflect.ValueOf(marshalV), column)
 	}
 
-	if t, ok := iface.(time.Time); ok {
+	if marshaler, ok := iface.(NodeMarshalerContext); ok {
+		node, err := marshaler.MarshalYAML(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return e.encodeASTNode(node, column)
+	}
+
+	if marshaler, ok := iface.(NodeMarshaler); ok {
+		node, err := marshaler.MarshalYAML()
+		if err != nil {
+			return nil, err
+		}
+		return e.encodeASTNode(node, column)
+	}
+
+	if t, ok := toTime(v); ok {
 		return e.encodeTime(t, column), nil
 	}
 
@@ -392,6 +858,21 @@ func (e *Encoder) encodeByMarshaler(ctx context.Context, v reflect.Value, column
 		return e.encodeDuration(t, column), nil
 	}
 
+	switch t := iface.(type) {
+	case *big.Int:
+		return e.encodeBigInt(t, column), nil
+	case big.Int:
+		return e.encodeBigInt(&t, column), nil
+	case *big.Float:
+		return e.encodeBigFloat(t, column), nil
+	case big.Float:
+		return e.encodeBigFloat(&t, column), nil
+	case *big.Rat:
+		return e.encodeBigRat(t, column), nil
+	case big.Rat:
+		return e.encodeBigRat(&t, column), nil
+	}
+
 	if marshaler, ok := iface.(encoding.TextMarshaler); ok {
 		doc, err := marshaler.MarshalText()
 		if err != nil {
@@ -422,9 +903,48 @@ func (e *Encoder) encodeByMarshaler(ctx context.Context, v reflect.Value, column
 		}
 	}
 
+	if e.useBinaryMarshaler {
+		if marshaler, ok := iface.(encoding.BinaryMarshaler); ok {
+			b, err := marshaler.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			return e.encodeBinary(b, column), nil
+		}
+	}
+
 	return nil, errors.New("does not implemented Marshaler")
 }
 
+// alignMarshaledNode moves the node copied from the node returned by NodeMarshaler or ast.Node to the column.
+func (e *Encoder) alignMarshaledNode(node ast.Node, column int) ast.Node {
+	tk := node.GetToken()
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		// the token of the block mapping node is ':', so use the first key instead.
+		if !n.IsFlowStyle && len(n.Values) != 0 {
+			tk = n.Values[0].Key.GetToken()
+		}
+	case *ast.MappingValueNode:
+		tk = n.Key.GetToken()
+	case *ast.SequenceNode:
+		// block sequence is placed at the same column as the sequence encoded from slice.
+		if !n.IsFlowStyle {
+			column = e.column
+			if e.indentSequence {
+				column += e.indent
+			}
+		}
+	}
+	if tk == nil || tk.Position == nil {
+		return node
+	}
+	node.AddColumn(column - tk.Position.Column)
+	return node
+}
+
+var orderedIteratorType = reflect.TypeOf((*OrderedIterator)(nil)).Elem()
+
 func (e *Encoder) encodeValue(ctx context.Context, v reflect.Value, column int) (ast.Node, error) {
 	if e.isInvalidValue(v) {
 		return e.encodeNil(), nil
@@ -436,6 +956,9 @@ func (e *Encoder) encodeValue(ctx context.Context, v reflect.Value, column int)
 		}
 		return node, nil
 	}
+	if v.Type().Implements(orderedIteratorType) && v.CanInterface() {
+		return e.encodeOrderedIterator(ctx, v.Interface().(OrderedIterator), column)
+	}
 	switch v.Type().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return e.encodeInt(v.Int()), nil
@@ -453,10 +976,18 @@ func (e *Encoder) encodeValue(ctx context.Context, v reflect.Value, column int)
 			alias.Value = ast.String(token.New(aliasName, aliasName, e.pos(column)))
 			return alias, nil
 		}
-		return e.encodeValue(ctx, v.Elem(), column)
+		return e.encodeReference(v, column, func() (ast.Node, error) {
+			return e.encodeValue(ctx, v.Elem(), column)
+		})
 	case reflect.Interface:
 		return e.encodeValue(ctx, v.Elem(), column)
 	case reflect.String:
+		if e.disallowUnsupportedValue && !utf8.ValidString(v.String()) {
+			return nil, fmt.Errorf("invalid UTF-8 string %q", v.String())
+		}
+		if node := e.encodeFoldedIfLong(v.String(), column); node != nil {
+			return node, nil
+		}
 		return e.encodeString(v.String(), column), nil
 	case reflect.Bool:
 		return e.encodeBool(v.Bool()), nil
@@ -464,7 +995,9 @@ func (e *Encoder) encodeValue(ctx context.Context, v reflect.Value, column int)
 		if mapSlice, ok := v.Interface().(MapSlice); ok {
 			return e.encodeMapSlice(ctx, mapSlice, column)
 		}
-		return e.encodeSlice(ctx, v)
+		return e.encodeReference(v, column, func() (ast.Node, error) {
+			return e.encodeSlice(ctx, v)
+		})
 	case reflect.Array:
 		return e.encodeArray(ctx, v)
 	case reflect.Struct:
@@ -472,18 +1005,129 @@ func (e *Encoder) encodeValue(ctx context.Context, v reflect.Value, column int)
 			if mapItem, ok := v.Interface().(MapItem); ok {
 				return e.encodeMapItem(ctx, mapItem, column)
 			}
-			if t, ok := v.Interface().(time.Time); ok {
+			if t, ok := toTime(v); ok {
 				return e.encodeTime(t, column), nil
 			}
 		}
 		return e.encodeStruct(ctx, v, column)
 	case reflect.Map:
-		return e.encodeMap(ctx, v, column), nil
+		return e.encodeReference(v, column, func() (ast.Node, error) {
+			if isSetType(v.Type()) && !e.isJSONStyle {
+				return e.encodeTaggedCollection(ctx, token.SetTag, v, column)
+			}
+			return e.encodeMap(ctx, v, column)
+		})
 	default:
 		return nil, fmt.Errorf("unknown value type %s", v.Type().String())
 	}
 }
 
+// referenceKey identifies the value referred by the pointer, the map or the slice.
+// The type is included because the pointer to the struct and the pointer to its first field have the same address.
+type referenceKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// reference is the value being encoded, to detect the cycle.
+// The path isn't kept but the depth of it, because the value being encoded is always the ancestor of the current value.
+type reference struct {
+	depth      int
+	anchorName string
+}
+
+// references is shared with the copies of the Encoder for the flow style fields.
+type references struct {
+	values    map[referenceKey]*reference
+	anchorNum int
+}
+
+// encodeReference encodes the value referred by the pointer, the map or the slice v by encode.
+// If v refers to the value being encoded, it's the cycle, so the error is returned,
+// or the alias is written and the anchor is added to the outer value if AllowCycles is specified.
+func (e *Encoder) encodeReference(v reflect.Value, column int, encode func() (ast.Node, error)) (ast.Node, error) {
+	if v.IsNil() || (v.Kind() != reflect.Ptr && v.Len() == 0) {
+		return encode()
+	}
+	key := referenceKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if ref, exists := e.references.values[key]; exists {
+		if !e.allowCycles {
+			return nil, fmt.Errorf("encountered a cycle via %s at %s, which refers to %s", v.Type(), e.currentPath(), e.pathString(ref.depth))
+		}
+		if ref.anchorName == "" {
+			e.references.anchorNum++
+			ref.anchorName = fmt.Sprintf("cycle%d", e.references.anchorNum)
+		}
+		alias := ast.Alias(token.New("*", "*", e.pos(column)))
+		alias.Value = ast.String(token.New(ref.anchorName, ref.anchorName, e.pos(column)))
+		return alias, nil
+	}
+	ref := &reference{depth: len(e.path)}
+	e.references.values[key] = ref
+	node, err := encode()
+	delete(e.references.values, key)
+	if err != nil {
+		return nil, err
+	}
+	if ref.anchorName == "" {
+		return node, nil
+	}
+	if e.isMapNode(node) {
+		// the mapping wrapped by the anchor is indented here, like the struct with the inline anchor field.
+		node.AddColumn(e.indent)
+	}
+	return e.encodeAnchor(ref.anchorName, node, v, column)
+}
+
+// pathElement is the element of the path reported by the error.
+// It's formatted into the selector only when the path is reported, so isIndex selects index or key.
+type pathElement struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// pushKey adds the key of the value to be encoded to the path reported by the error.
+func (e *Encoder) pushKey(key string) {
+	e.path = append(e.path, pathElement{key: key})
+}
+
+// pushIndex adds the index of the value to be encoded to the path reported by the error.
+func (e *Encoder) pushIndex(index int) {
+	e.path = append(e.path, pathElement{index: index, isIndex: true})
+}
+
+func (e *Encoder) popPath() {
+	e.path = e.path[:len(e.path)-1]
+}
+
+func (e *Encoder) currentPath() string {
+	return e.pathString(len(e.path))
+}
+
+// pathString returns the path of the ancestor of the current value at depth.
+func (e *Encoder) pathString(depth int) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, elem := range e.path[:depth] {
+		if elem.isIndex {
+			b.WriteString("[" + strconv.Itoa(elem.index) + "]")
+			continue
+		}
+		b.WriteString(mapKeySelector(elem.key))
+	}
+	return b.String()
+}
+
+func mapKeySelector(key string) string {
+	var b PathBuilder
+	return "." + b.normalizeSelectorName(key)
+}
+
 func (e *Encoder) pos(column int) *token.Position {
 	return &token.Position{
 		Line:        e.line,
@@ -520,18 +1164,40 @@ func (e *Encoder) encodeFloat(v float64, bitSize int) ast.Node {
 		value := ".nan"
 		return ast.Nan(token.New(value, value, e.pos(e.column)))
 	}
+	value := formatFloat(v, bitSize)
+	return ast.Float(token.New(value, value, e.pos(e.column)))
+}
+
+func formatFloat(v float64, bitSize int) string {
+	switch {
+	case math.IsInf(v, 1):
+		return ".inf"
+	case math.IsInf(v, -1):
+		return "-.inf"
+	case math.IsNaN(v):
+		return ".nan"
+	}
 	value := strconv.FormatFloat(v, 'g', -1, bitSize)
 	if !strings.Contains(value, ".") && !strings.Contains(value, "e") {
 		// append x.0 suffix to keep float value context
 		value = fmt.Sprintf("%s.0", value)
 	}
-	return ast.Float(token.New(value, value, e.pos(e.column)))
+	return value
 }
 
 func (e *Encoder) isNeedQuoted(v string) bool {
 	if e.isJSONStyle {
 		return true
 	}
+	if e.forceQuoteStrings != nil && e.forceQuoteStrings(v, e.currentPath()) {
+		return true
+	}
+	if e.escapeSpecialCharacter && hasSpecialCharacter(v) {
+		return true
+	}
+	if strings.ContainsRune(v, '\n') && !blockscalar.IsIndentDetectable(v) {
+		return true
+	}
 	if e.useLiteralStyleIfMultiline && strings.ContainsAny(v, "\n\r") {
 		return false
 	}
@@ -552,12 +1218,64 @@ func (e *Encoder) isNeedQuoted(v string) bool {
 	if token.IsNeedQuoted(v) {
 		return true
 	}
+	if e.quoteYAML11Scalar && isYAML11NonStringScalar(v) {
+		return true
+	}
+	if e.plainScalarResolver != nil {
+		if tag, ok := e.plainScalarResolver(v); ok && token.ReservedTagKeyword(tag) != token.StringTag {
+			return true
+		}
+	}
+	return false
+}
+
+// The regexps are compiled at the first use instead of the initialization of the package,
+// which matters for the small runtimes like TinyGo and js/wasm.
+var (
+	yaml11FloatRegexp = sync.OnceValue(func() *regexp.Regexp {
+		return regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	})
+	// yaml11SexagesimalRegexp matches the base 60 numbers like 1:20:30 or 1:20.5 of YAML 1.1.
+	yaml11SexagesimalRegexp = sync.OnceValue(func() *regexp.Regexp {
+		return regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+	})
+)
+
+// isYAML11NonStringScalar returns whether v is interpreted as other than string by YAML 1.1 parsers.
+func isYAML11NonStringScalar(v string) bool {
+	switch v {
+	case "<<", "=", "-",
+		".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF", "-.inf", "-.Inf", "-.INF",
+		".nan", ".NaN", ".NAN":
+		return true
+	}
+	return yaml11FloatRegexp().MatchString(v) || yaml11SexagesimalRegexp().MatchString(v)
+}
+
+// hasSpecialCharacter returns whether v contains the characters to be escaped in the double-quoted style.
+// They are the characters out of the printable set of YAML 1.2 and the characters treated as line breaks
+// or ignored by some parsers, like the byte order mark and the unicode line and paragraph separators.
+func hasSpecialCharacter(v string) bool {
+	for _, c := range v {
+		switch {
+		case c == '\t', c == '\n', c == '\r':
+		case c == utf8.RuneError, c == '\u0085', c == '\u2028', c == '\u2029', c == '\ufeff':
+			return true
+		case c < 0x20, c == 0x7f, 0x80 <= c && c < 0xa0:
+			return true
+		case 0xd800 <= c && c < 0xe000, c == 0xfffe, c == 0xffff:
+			return true
+		}
+	}
 	return false
 }
 
 func (e *Encoder) encodeString(v string, column int) *ast.StringNode {
 	if e.isNeedQuoted(v) {
-		if e.singleQuote {
+		if e.escapeSpecialCharacter && hasSpecialCharacter(v) {
+			// the special characters can be escaped only in the double-quoted style.
+			v = strconv.Quote(v)
+		} else if e.singleQuote {
 			v = quoteWith(v, '\'')
 		} else {
 			v = strconv.Quote(v)
@@ -566,22 +1284,64 @@ func (e *Encoder) encodeString(v string, column int) *ast.StringNode {
 	return ast.String(token.New(v, v, e.pos(column)))
 }
 
-func (e *Encoder) encodeBool(v bool) *ast.BoolNode {
-	value := strconv.FormatBool(v)
-	return ast.Bool(token.New(value, value, e.pos(e.column)))
-}
-
-func (e *Encoder) encodeSlice(ctx context.Context, value reflect.Value) (*ast.SequenceNode, error) {
+// encodeRuneField encodes the value of the struct field having the rune option as the string.
+// It returns nil for the nil pointer and the value implementing the marshaler, which are encoded as usual.
+func (e *Encoder) encodeRuneField(v reflect.Value, column int) ast.Node {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() || e.canEncodeByMarshaler(v) {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if e.canEncodeByMarshaler(v) {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int32:
+		return e.encodeRuneValue(rune(v.Int()), column)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		runes := make([]rune, v.Len())
+		for i := range runes {
+			runes[i] = rune(v.Index(i).Int())
+		}
+		return e.encodeString(string(runes), column)
+	}
+	return nil
+}
+
+func (e *Encoder) encodeRuneValue(v rune, column int) *ast.StringNode {
+	value := string(v)
+	if e.singleQuote && !e.isJSONStyle {
+		value = quoteWith(value, '\'')
+	} else {
+		value = strconv.Quote(value)
+	}
+	return ast.String(token.New(value, value, e.pos(column)))
+}
+
+func (e *Encoder) encodeBool(v bool) *ast.BoolNode {
+	value := strconv.FormatBool(v)
+	return ast.Bool(token.New(value, value, e.pos(e.column)))
+}
+
+func (e *Encoder) encodeSlice(ctx context.Context, value reflect.Value) (*ast.SequenceNode, error) {
 	if e.indentSequence {
 		e.column += e.indent
 	}
 	column := e.column
 	sequence := ast.Sequence(token.New("-", "-", e.pos(column)), e.isFlowStyle)
+	sequence.IsExpandedStyle = e.isExpandedSequence && !e.isFlowStyle
 	for i := 0; i < value.Len(); i++ {
+		e.pushIndex(i)
 		node, err := e.encodeValue(ctx, value.Index(i), column)
+		e.popPath()
 		if err != nil {
 			return nil, err
 		}
+		e.alignExpandedSequenceEntry(sequence, node, column)
 		sequence.Values = append(sequence.Values, node)
 	}
 	if e.indentSequence {
@@ -590,17 +1350,42 @@ func (e *Encoder) encodeSlice(ctx context.Context, value reflect.Value) (*ast.Se
 	return sequence, nil
 }
 
+// alignExpandedSequenceEntry moves the block collection entry of the expanded style sequence
+// to be indented by Indent from the dash.
+func (e *Encoder) alignExpandedSequenceEntry(sequence *ast.SequenceNode, node ast.Node, column int) {
+	if !sequence.IsExpandedStyle {
+		return
+	}
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		if n.IsFlowStyle || len(n.Values) == 0 {
+			return
+		}
+	case *ast.SequenceNode:
+		if n.IsFlowStyle || len(n.Values) == 0 {
+			return
+		}
+	default:
+		return
+	}
+	node.AddColumn(column + e.indent - node.GetToken().Position.Column)
+}
+
 func (e *Encoder) encodeArray(ctx context.Context, value reflect.Value) (*ast.SequenceNode, error) {
 	if e.indentSequence {
 		e.column += e.indent
 	}
 	column := e.column
 	sequence := ast.Sequence(token.New("-", "-", e.pos(column)), e.isFlowStyle)
+	sequence.IsExpandedStyle = e.isExpandedSequence && !e.isFlowStyle
 	for i := 0; i < value.Len(); i++ {
+		e.pushIndex(i)
 		node, err := e.encodeValue(ctx, value.Index(i), column)
+		e.popPath()
 		if err != nil {
 			return nil, err
 		}
+		e.alignExpandedSequenceEntry(sequence, node, column)
 		sequence.Values = append(sequence.Values, node)
 	}
 	if e.indentSequence {
@@ -610,22 +1395,144 @@ func (e *Encoder) encodeArray(ctx context.Context, value reflect.Value) (*ast.Se
 }
 
 func (e *Encoder) encodeMapItem(ctx context.Context, item MapItem, column int) (*ast.MappingValueNode, error) {
-	k := reflect.ValueOf(item.Key)
+	key, err := e.mapKeyString(reflect.ValueOf(item.Key), isPtrTextMarshalerKeyType(reflect.TypeOf(item.Key)))
+	if err != nil {
+		return nil, err
+	}
 	v := reflect.ValueOf(item.Value)
+	e.pushKey(key)
 	value, err := e.encodeValue(ctx, v, column)
+	e.popPath()
 	if err != nil {
 		return nil, err
 	}
 	if e.isMapNode(value) {
 		value.AddColumn(e.indent)
 	}
+	keyNode, err := e.encodeMapKey(ctx, reflect.ValueOf(item.Key), key, column)
+	if err != nil {
+		return nil, err
+	}
 	return ast.MappingValue(
 		token.New("", "", e.pos(column)),
-		e.encodeString(k.Interface().(string), column),
+		keyNode,
 		value,
 	), nil
 }
 
+// mapKeyString returns the text of the map key.
+// If the key implements encoding.TextMarshaler, the text is the result of MarshalText.
+// isPtrMarshaler is the result of isPtrTextMarshalerKeyType for the type of the keys,
+// which is resolved once for all the keys of the map.
+// The null, bool and number keys are written in the same way as the values, so they are decoded to the same keys.
+func (e *Encoder) mapKeyString(key reflect.Value, isPtrMarshaler bool) (string, error) {
+	if isNilMapKey(key) {
+		if e.disallowUnsupportedValue {
+			return "", fmt.Errorf("unsupported map key nil")
+		}
+		return "null", nil
+	}
+	if e.disallowUnsupportedValue {
+		if err := e.validateMapKey(key); err != nil {
+			return "", err
+		}
+	}
+	marshaler, ok := key.Interface().(encoding.TextMarshaler)
+	if !ok && isPtrMarshaler {
+		// map keys are not addressable, so call the method with the pointer receiver on the copy.
+		ptr := reflect.New(key.Type())
+		ptr.Elem().Set(key)
+		marshaler, ok = ptr.Interface().(encoding.TextMarshaler)
+	}
+	if !ok {
+		if text, ok := scalarMapKeyString(key); ok {
+			return text, nil
+		}
+		return fmt.Sprint(key.Interface()), nil
+	}
+	text, err := marshaler.MarshalText()
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// isPtrTextMarshalerKeyType returns whether only the pointer of the key type implements encoding.TextMarshaler.
+// The interface keys are checked by the dynamic values in mapKeyString.
+func isPtrTextMarshalerKeyType(typ reflect.Type) bool {
+	if typ == nil || typ.Kind() == reflect.Interface || typ.Implements(textMarshalerType) {
+		return false
+	}
+	return reflect.PtrTo(typ).Implements(textMarshalerType)
+}
+
+func isNilMapKey(key reflect.Value) bool {
+	for key.IsValid() && (key.Kind() == reflect.Interface || key.Kind() == reflect.Ptr) {
+		if key.IsNil() {
+			return true
+		}
+		if key.Kind() == reflect.Ptr {
+			return false
+		}
+		key = key.Elem()
+	}
+	return !key.IsValid()
+}
+
+// scalarMapKeyString returns the text of the bool or number key written as the plain scalar.
+func scalarMapKeyString(key reflect.Value) (string, bool) {
+	for key.Kind() == reflect.Interface {
+		key = key.Elem()
+	}
+	switch key.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(key.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), true
+	case reflect.Float32:
+		return formatFloat(key.Float(), 32), true
+	case reflect.Float64:
+		return formatFloat(key.Float(), 64), true
+	}
+	return "", false
+}
+
+// validateMapKey returns the error if the key can't be encoded as the key decoded to the same value,
+// like the pointer written as the address.
+func (e *Encoder) validateMapKey(key reflect.Value) error {
+	if e.isComplexMapKey(key) {
+		return nil
+	}
+	if _, ok := key.Interface().(encoding.TextMarshaler); ok {
+		return nil
+	}
+	if _, ok := reflect.New(key.Type()).Interface().(encoding.TextMarshaler); ok {
+		return nil
+	}
+	v := key
+	for v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return fmt.Errorf("unsupported map key nil")
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if !utf8.ValidString(v.String()) {
+			return fmt.Errorf("invalid UTF-8 map key %q", v.String())
+		}
+		return nil
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	}
+	return fmt.Errorf("unsupported map key type %s", key.Type())
+}
+
 func (e *Encoder) encodeMapSlice(ctx context.Context, value MapSlice, column int) (*ast.MappingNode, error) {
 	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
 	for _, item := range value {
@@ -638,37 +1545,197 @@ func (e *Encoder) encodeMapSlice(ctx context.Context, value MapSlice, column int
 	return node, nil
 }
 
+// encodeOrderedIterator encodes the key and value pairs yielded by iterator to the mapping in the order of them.
+func (e *Encoder) encodeOrderedIterator(ctx context.Context, iterator OrderedIterator, column int) (*ast.MappingNode, error) {
+	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
+	var err error
+	iterator.Iterate(func(key, value any) bool {
+		var mapValue *ast.MappingValueNode
+		mapValue, err = e.encodeMapItem(ctx, MapItem{Key: key, Value: value}, column)
+		if err != nil {
+			return false
+		}
+		node.Values = append(node.Values, mapValue)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
 func (e *Encoder) isMapNode(node ast.Node) bool {
+	if tag, ok := node.(*ast.TagNode); ok {
+		// the mapping tagged like !!set is indented in the same way.
+		node = tag.Value
+	}
 	_, ok := node.(ast.MapNode)
 	return ok
 }
 
-func (e *Encoder) encodeMap(ctx context.Context, value reflect.Value, column int) ast.Node {
-	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
-	keys := make([]interface{}, len(value.MapKeys()))
-	for i, k := range value.MapKeys() {
-		keys[i] = k.Interface()
+// mapKey is the key of the map with the text written in the document.
+type mapKey struct {
+	value reflect.Value
+	text  string
+}
+
+// sortedMapKeys returns the keys of the map sorted by the texts.
+func (e *Encoder) sortedMapKeys(value reflect.Value) ([]mapKey, error) {
+	keys := make([]mapKey, 0, value.Len())
+	isPtrMarshaler := isPtrTextMarshalerKeyType(value.Type().Key())
+	for _, k := range value.MapKeys() {
+		text, err := e.mapKeyString(k, isPtrMarshaler)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, mapKey{value: k, text: text})
 	}
 	sort.Slice(keys, func(i, j int) bool {
-		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		if keys[i].text != keys[j].text {
+			return keys[i].text < keys[j].text
+		}
+		// the keys of the same text like 1 and "1" are sorted by the type to keep the order stable.
+		return mapKeyTypeName(keys[i].value) < mapKeyTypeName(keys[j].value)
 	})
+	return keys, nil
+}
+
+func (e *Encoder) encodeMap(ctx context.Context, value reflect.Value, column int) (ast.Node, error) {
+	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
+	keys, err := e.sortedMapKeys(value)
+	if err != nil {
+		return nil, err
+	}
 	for _, key := range keys {
-		k := reflect.ValueOf(key)
-		v := value.MapIndex(k)
+		keyNode, err := e.encodeMapKey(ctx, key.value, key.text, column)
+		if err != nil {
+			return nil, err
+		}
+		v := value.MapIndex(key.value)
+		e.pushKey(key.text)
 		value, err := e.encodeValue(ctx, v, column)
+		e.popPath()
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		if e.isMapNode(value) {
 			value.AddColumn(e.indent)
 		}
-		node.Values = append(node.Values, ast.MappingValue(
-			nil,
-			e.encodeString(fmt.Sprint(key), column),
-			value,
-		))
+		node.Values = append(node.Values, ast.MappingValue(nil, keyNode, value))
 	}
-	return node
+	return node, nil
+}
+
+func mapKeyTypeName(key reflect.Value) string {
+	for key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	if !key.IsValid() {
+		return ""
+	}
+	return key.Type().String()
+}
+
+// encodeMapKey encodes the struct or array key as the flow style collection with the explicit key indicator `?`,
+// the null, bool and number key as the plain scalar, and the other key as the string.
+// In JSON style, all the keys are written as the strings.
+func (e *Encoder) encodeMapKey(ctx context.Context, key reflect.Value, text string, column int) (ast.MapKeyNode, error) {
+	if !e.isComplexMapKey(key) {
+		if !e.isJSONStyle {
+			if node := e.encodeScalarMapKey(key, text, column); node != nil {
+				return node, nil
+			}
+		}
+		if e.useExplicitKeyIfNeeded && !e.isJSONStyle && isNeedExplicitKey(text) {
+			keyNode := ast.MappingKey(token.New("?", "?", e.pos(column)))
+			keyNode.Value = e.encodeString(text, column+2)
+			return keyNode, nil
+		}
+		if strings.ContainsAny(text, "\n\r") {
+			// the implicit key can't be written in the multiple lines.
+			return ast.String(token.New(strconv.Quote(text), strconv.Quote(text), e.pos(column))), nil
+		}
+		return e.encodeString(text, column), nil
+	}
+	isFlowStyle := e.isFlowStyle
+	e.isFlowStyle = true
+	defer func() { e.isFlowStyle = isFlowStyle }()
+	value, err := e.encodeValue(ctx, key, column+2)
+	if err != nil {
+		return nil, err
+	}
+	keyNode := ast.MappingKey(token.New("?", "?", e.pos(column)))
+	keyNode.Value = value
+	return keyNode, nil
+}
+
+// encodeScalarMapKey returns the plain scalar node of the null, bool or number key, or nil for the other key.
+func (e *Encoder) encodeScalarMapKey(key reflect.Value, text string, column int) ast.MapKeyNode {
+	if isNilMapKey(key) {
+		return ast.Null(token.New(text, text, e.pos(column)))
+	}
+	if _, ok := scalarMapKeyString(key); !ok {
+		return nil
+	}
+	if _, ok := key.Interface().(encoding.TextMarshaler); ok {
+		return nil
+	}
+	if _, ok := reflect.New(key.Type()).Interface().(encoding.TextMarshaler); ok {
+		return nil
+	}
+	for key.Kind() == reflect.Interface {
+		key = key.Elem()
+	}
+	tk := token.New(text, text, e.pos(column))
+	switch key.Kind() {
+	case reflect.Bool:
+		return ast.Bool(tk)
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case math.IsInf(key.Float(), 0):
+			return ast.Infinity(tk)
+		case math.IsNaN(key.Float()):
+			return ast.Nan(tk)
+		}
+		return ast.Float(tk)
+	}
+	return ast.Integer(tk)
+}
+
+// isNeedExplicitKey returns whether the key can't be written as the implicit key,
+// which must be in the single line and at most 1024 characters.
+func isNeedExplicitKey(text string) bool {
+	return strings.ContainsAny(text, "\n\r") || utf8.RuneCountInString(text) > 1024
+}
+
+func (e *Encoder) isComplexMapKey(key reflect.Value) bool {
+	for key.Kind() == reflect.Interface || key.Kind() == reflect.Ptr {
+		if key.IsNil() {
+			return false
+		}
+		key = key.Elem()
+	}
+	if key.Kind() != reflect.Struct && key.Kind() != reflect.Array {
+		return false
+	}
+	if _, ok := key.Interface().(encoding.TextMarshaler); ok {
+		return false
+	}
+	ptr := reflect.New(key.Type())
+	_, ok := ptr.Interface().(encoding.TextMarshaler)
+	return !ok
+}
+
+// mapKeyText returns the unquoted text of the key encoded by encodeString.
+func mapKeyText(key ast.MapKeyNode) string {
+	text := key.GetToken().Value
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		return unquoted
+	}
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'")
+	}
+	return text
 }
 
 // IsZeroer is used to check whether an object is zero to determine
@@ -720,12 +1787,24 @@ func (e *Encoder) isZeroValue(v reflect.Value) bool {
 
 func (e *Encoder) encodeTime(v time.Time, column int) *ast.StringNode {
 	value := v.Format(time.RFC3339Nano)
-	if e.isJSONStyle {
+	if e.isJSONStyle || e.quoteYAML11Scalar {
 		value = strconv.Quote(value)
 	}
 	return ast.String(token.New(value, value, e.pos(column)))
 }
 
+// encodeBinary encodes b as the base64 string with the !!binary tag.
+// In JSON style, the tag is omitted because JSON has no tags.
+func (e *Encoder) encodeBinary(b []byte, column int) ast.Node {
+	value := e.encodeString(base64.StdEncoding.EncodeToString(b), column)
+	if e.isJSONStyle {
+		return value
+	}
+	tag := ast.Tag(token.Tag("!!binary", "!!binary", e.pos(column)))
+	tag.Value = value
+	return tag
+}
+
 func (e *Encoder) encodeDuration(v time.Duration, column int) *ast.StringNode {
 	value := v.String()
 	if e.isJSONStyle {
@@ -734,6 +1813,35 @@ func (e *Encoder) encodeDuration(v time.Duration, column int) *ast.StringNode {
 	return ast.String(token.New(value, value, e.pos(column)))
 }
 
+func (e *Encoder) encodeBigInt(v *big.Int, column int) *ast.IntegerNode {
+	value := v.String()
+	return ast.Integer(token.New(value, value, e.pos(column)))
+}
+
+func (e *Encoder) encodeBigFloat(v *big.Float, column int) ast.Node {
+	if v.IsInf() {
+		value := ".inf"
+		if v.Sign() < 0 {
+			value = "-.inf"
+		}
+		return ast.Infinity(token.New(value, value, e.pos(column)))
+	}
+	value := v.Text('g', -1)
+	if !strings.Contains(value, ".") && !strings.Contains(value, "e") {
+		// append x.0 suffix to keep float value context
+		value = fmt.Sprintf("%s.0", value)
+	}
+	return ast.Float(token.New(value, value, e.pos(column)))
+}
+
+// encodeBigRat encodes the integer as an integer and the others as a string like 1/3.
+func (e *Encoder) encodeBigRat(v *big.Rat, column int) ast.Node {
+	if v.IsInt() {
+		return e.encodeBigInt(v.Num(), column)
+	}
+	return e.encodeString(v.RatString(), column)
+}
+
 func (e *Encoder) encodeAnchor(anchorName string, value ast.Node, fieldValue reflect.Value, column int) (*ast.AnchorNode, error) {
 	anchorNode := ast.Anchor(token.New("&", "&", e.pos(column)))
 	anchorNode.Name = ast.String(token.New(anchorName, anchorName, e.pos(column)))
@@ -746,21 +1854,406 @@ func (e *Encoder) encodeAnchor(anchorName string, value ast.Node, fieldValue ref
 			anchorName = snode.Value
 		}
 	}
+	anchorName, err := e.setAnchorName(anchorNode, anchorName)
+	if err != nil {
+		return nil, err
+	}
 	if fieldValue.Kind() == reflect.Ptr {
 		e.anchorPtrToNameMap[fieldValue.Pointer()] = anchorName
 	}
 	return anchorNode, nil
 }
 
+// encodeFieldAnchor encodes the anchor of the struct field, reporting the path of the field on the name collision.
+func (e *Encoder) encodeFieldAnchor(structField *StructField, anchorName string, value ast.Node, fieldValue reflect.Value, column int) (*ast.AnchorNode, error) {
+	e.pushKey(structField.RenderName)
+	defer e.popPath()
+	return e.encodeAnchor(anchorName, value, fieldValue, column)
+}
+
+// setAnchorName validates the name of anchorNode and sets the sanitized name if AnchorNameSanitizer is specified.
+// If the name is already used by another anchor in the document, the number suffix is added to make it unique.
+func (e *Encoder) setAnchorName(anchorNode *ast.AnchorNode, name string) (string, error) {
+	validName, err := e.validateAnchorName(name)
+	if err != nil {
+		return "", err
+	}
+	validName, err = e.uniqueAnchorName(validName)
+	if err != nil {
+		return "", err
+	}
+	if validName != name {
+		if err := anchorNode.SetName(validName); err != nil {
+			return "", err
+		}
+	}
+	e.anchorNames[name] = validName
+	return validName, nil
+}
+
+// validateAnchorName returns the name if it's a valid anchor name.
+// If AnchorNameSanitizer is specified, it returns the sanitized name instead of the error.
+func (e *Encoder) validateAnchorName(name string) (string, error) {
+	if e.anchorNameSanitizer == nil {
+		if !isValidAnchorName(name) {
+			return "", ErrInvalidAnchorNameValue(name)
+		}
+		return name, nil
+	}
+	sanitized := e.anchorNameSanitizer(name)
+	if !isValidAnchorName(sanitized) {
+		return "", ErrInvalidAnchorNameValue(sanitized)
+	}
+	return sanitized, nil
+}
+
+// uniqueAnchorName returns the name not used by the other anchors in the document, like `name_2` for the second `name`,
+// so that every alias refers to the anchor of its own value.
+// If ResolveAnchorCollision(false) is specified, it returns AnchorCollisionError instead.
+func (e *Encoder) uniqueAnchorName(name string) (string, error) {
+	path := e.currentPath()
+	unique := name
+	for i := 2; ; i++ {
+		prevPath, exists := e.usedAnchorNames[unique]
+		if !exists {
+			break
+		}
+		if e.disallowAnchorCollision {
+			return "", errors.ErrAnchorCollision(name, prevPath, path)
+		}
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	e.usedAnchorNames[unique] = path
+	return unique, nil
+}
+
+// aliasAnchorName returns the name of the last anchor defined with the name specified by the alias option.
+func (e *Encoder) aliasAnchorName(name string) (string, error) {
+	if anchorName, exists := e.anchorNames[name]; exists {
+		return anchorName, nil
+	}
+	return e.validateAnchorName(name)
+}
+
+// isValidAnchorName returns whether name consists of the characters allowed in the anchor name.
+// They are the printable characters except for the white spaces and the flow indicators.
+func isValidAnchorName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !isAnchorChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAnchorChar(r rune) bool {
+	switch r {
+	case ',', '[', ']', '{', '}', utf8.RuneError, '\ufeff':
+		return false
+	}
+	return unicode.IsPrint(r) && !unicode.IsSpace(r)
+}
+
+// SanitizeAnchorName replaces the characters not allowed in the anchor name with '_'.
+// It's the default sanitizer used by AnchorNameSanitizer.
+func SanitizeAnchorName(name string) string {
+	if name == "" {
+		return "anchor"
+	}
+	return strings.Map(func(r rune) rune {
+		if isAnchorChar(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func (e *Encoder) fieldComments(value reflect.Value) map[string]string {
+	if e.isFlowStyle {
+		return nil
+	}
+	if value.CanInterface() {
+		if commenter, ok := value.Interface().(FieldCommenter); ok {
+			return commenter.YAMLComments()
+		}
+	}
+	if value.CanAddr() && value.Addr().CanInterface() {
+		if commenter, ok := value.Addr().Interface().(FieldCommenter); ok {
+			return commenter.YAMLComments()
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) fieldOrder(value reflect.Value) []string {
+	if value.CanInterface() {
+		if orderer, ok := value.Interface().(FieldOrderer); ok {
+			return orderer.YAMLFieldOrder()
+		}
+	}
+	if value.CanAddr() && value.Addr().CanInterface() {
+		if orderer, ok := value.Addr().Interface().(FieldOrderer); ok {
+			return orderer.YAMLFieldOrder()
+		}
+	}
+	return nil
+}
+
+// sortFieldsByOrder moves the fields listed in names to the front in that order,
+// followed by the fields having the order option in fieldOrders in ascending order.
+// The other fields keep their order.
+func sortFieldsByOrder(values []*ast.MappingValueNode, names []string, fieldOrders map[*ast.MappingValueNode]int) {
+	if len(names) == 0 && len(fieldOrders) == 0 {
+		return
+	}
+	nameOrders := make(map[string]int, len(names))
+	for idx, name := range names {
+		if _, exists := nameOrders[name]; !exists {
+			nameOrders[name] = idx
+		}
+	}
+	rank := func(v *ast.MappingValueNode) (int, int) {
+		if idx, exists := nameOrders[mapKeyText(v.Key)]; exists {
+			return 0, idx
+		}
+		if order, exists := fieldOrders[v]; exists {
+			return 1, order
+		}
+		return 2, 0
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		gi, oi := rank(values[i])
+		gj, oj := rank(values[j])
+		if gi != gj {
+			return gi < gj
+		}
+		return oi < oj
+	})
+}
+
+// setFieldComment sets a line comment to the mapping value node created from the struct field.
+func (e *Encoder) setFieldComment(node *ast.MappingValueNode, text string) {
+	if e.isFlowStyle || text == "" {
+		return
+	}
+	comment := " " + text
+	commentGroup := ast.CommentGroup([]*token.Token{token.New(comment, comment, e.pos(e.column))})
+	switch v := node.Value.(type) {
+	case *ast.MappingNode:
+		if len(v.Values) != 0 && !v.IsFlowStyle {
+			_ = node.Key.SetComment(commentGroup)
+			return
+		}
+	case *ast.SequenceNode:
+		if len(v.Values) != 0 && !v.IsFlowStyle {
+			_ = node.Key.SetComment(commentGroup)
+			return
+		}
+	case *ast.AnchorNode, *ast.AliasNode:
+		// comment for anchor or alias value is not supported.
+		return
+	}
+	_ = node.Value.SetComment(commentGroup)
+}
+
+// isOmittedValue reports whether the value of the field with the omitempty option is omitted.
+// The field following encoding/json by HonorJSONTagOptions is omitted by the rules of encoding/json,
+// so the struct value is not omitted.
+func (e *Encoder) isOmittedValue(structField *StructField, v reflect.Value) bool {
+	if !structField.IsJSON {
+		return e.isZeroValue(v)
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// encodeFieldValue encodes the value of the struct field.
+// The field having the string option of the json tag by HonorJSONTagOptions is encoded as the string of the JSON text like encoding/json.
+func (e *Encoder) encodeFieldValue(ctx context.Context, structField *StructField, v reflect.Value, column int) (ast.Node, error) {
+	if structField.CollectionTag != "" && !e.isJSONStyle && !e.canEncodeByMarshaler(v) {
+		return e.encodeTaggedCollection(ctx, token.ReservedTagKeyword(structField.CollectionTag), v, column)
+	}
+	if structField.TimeLayout != "" {
+		if t, ok := toTime(v); ok {
+			return e.encodeString(t.Format(structField.TimeLayout), column), nil
+		}
+	}
+	if structField.IsRune {
+		if node := e.encodeRuneField(v, column); node != nil {
+			return node, nil
+		}
+	}
+	if structField.isBlockScalar() && !e.isFlowStyle && !e.isJSONStyle {
+		if s, ok := e.blockScalarString(v); ok {
+			if node := e.encodeBlockScalar(s, structFieldBlockScalarStyle(structField), column); node != nil {
+				return node, nil
+			}
+		}
+	}
+	if !structField.IsJSON || !structField.IsString || !isJSONStringOptionType(v.Type()) || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return e.encodeValue(ctx, v, column)
+	}
+	b, err := jsonMarshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return e.encodeString(string(b), column), nil
+}
+
+// blockScalarString returns the string of v if it can be written as the block scalar.
+// The other strings, like the strings including the control characters or the carriage return,
+// are encoded in the default style.
+func (e *Encoder) blockScalarString(v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String || e.canEncodeByMarshaler(v) {
+		return "", false
+	}
+	s := v.String()
+	if !canBeBlockScalar(s) {
+		return "", false
+	}
+	return s, true
+}
+
+// canBeBlockScalar returns whether s can be written as the block scalar.
+// The parser removes the trailing spaces of the block scalar without the trailing line break,
+// so such strings can't be written as the block scalar either.
+func canBeBlockScalar(s string) bool {
+	if s == "" || hasSpecialCharacter(s) || strings.ContainsRune(s, '\r') || !blockscalar.IsIndentDetectable(s) {
+		return false
+	}
+	return !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\t")
+}
+
+// blockScalarStyle is the style of the block scalar written by encodeBlockScalar.
+type blockScalarStyle struct {
+	folded bool
+	// keep uses the keep chomping indicator `+` if the string ends with the line break.
+	keep bool
+	// indent is the indentation indicator. It's written only if it's needed when indent is 0.
+	indent int
+}
+
+func structFieldBlockScalarStyle(structField *StructField) blockScalarStyle {
+	return blockScalarStyle{
+		folded: structField.IsFolded,
+		keep:   structField.IsKeep,
+		indent: structField.BlockIndent,
+	}
+}
+
+// encodeBlockScalar encodes s as the literal or folded block scalar by style.
+// The chomping indicator is chosen to keep the trailing line breaks of s exactly.
+// It returns nil if s can't be decoded from the block scalar, which is the case of the indentation indicator
+// followed by the empty lines at the end, so the caller encodes s in the default style.
+func (e *Encoder) encodeBlockScalar(s string, style blockScalarStyle, column int) *ast.LiteralNode {
+	content := strings.TrimRight(s, "\n")
+	trailing := len(s) - len(content)
+	lines := strings.Split(content, "\n")
+	indicator := "|"
+	if style.folded {
+		indicator = ">"
+		lines = blockscalar.FoldedLines(lines, e.foldedStyleWidth)
+	}
+	indent := style.indent
+	if indent > 0 {
+		indicator += strconv.Itoa(indent)
+	} else {
+		indent = e.indent
+		if blockscalar.IsNeedIndentIndicator(lines) {
+			indicator += strconv.Itoa(indent)
+		}
+	}
+	if trailing > 1 && len(indicator) > 1 {
+		return nil
+	}
+	switch {
+	case trailing == 0:
+		indicator += "-"
+	case trailing > 1 || style.keep:
+		indicator += "+"
+	}
+	space := strings.Repeat(" ", column-1+indent)
+	for idx, line := range lines {
+		if line != "" {
+			lines[idx] = space + line
+		}
+	}
+	origin := strings.Join(lines, "\n") + "\n"
+	if trailing > 1 {
+		origin += strings.Repeat("\n", trailing-1)
+	}
+	tk := token.New(s, origin, e.pos(column))
+	tk.Type = token.StringType
+	var start *token.Token
+	if style.folded {
+		start = token.Folded(indicator, indicator, e.pos(column))
+	} else {
+		start = token.Literal(indicator, indicator, e.pos(column))
+	}
+	node := ast.Literal(start)
+	node.Value = ast.String(tk)
+	return node
+}
+
+// encodeFoldedIfLong encodes s as the folded block scalar by UseFoldedStyleIfLong
+// if s has the line longer than the width which can be folded. Otherwise, it returns nil.
+func (e *Encoder) encodeFoldedIfLong(s string, column int) ast.Node {
+	if e.foldedStyleWidth <= 0 || e.isFlowStyle || e.isJSONStyle {
+		return nil
+	}
+	if e.useLiteralStyleIfMultiline && strings.Contains(s, "\n") {
+		return nil
+	}
+	if !canBeBlockScalar(s) {
+		return nil
+	}
+	if e.forceQuoteStrings != nil && e.forceQuoteStrings(s, e.currentPath()) {
+		return nil
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if len(blockscalar.WrapLine(line, e.foldedStyleWidth)) > 1 {
+			if node := e.encodeBlockScalar(s, blockScalarStyle{folded: true}, column); node != nil {
+				return node
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
 func (e *Encoder) encodeStruct(ctx context.Context, value reflect.Value, column int) (ast.Node, error) {
 	node := ast.Mapping(token.New("", "", e.pos(column)), e.isFlowStyle)
 	structType := value.Type()
-	structFieldMap, err := structFieldMap(structType)
+	structFieldMap, err := structFieldMap(structType, e.honorJSONTagOptions)
 	if err != nil {
 		return nil, err
 	}
 	hasInlineAnchorField := false
 	var inlineAnchorValue reflect.Value
+	fieldComments := e.fieldComments(value)
+	fieldOrders := map[*ast.MappingValueNode]int{}
 	for i := 0; i < value.NumField(); i++ {
 		field := structType.Field(i)
 		if isIgnoredStructField(field) {
@@ -768,7 +2261,7 @@ func (e *Encoder) encodeStruct(ctx context.Context, value reflect.Value, column
 		}
 		fieldValue := value.FieldByName(field.Name)
 		structField := structFieldMap[field.Name]
-		if structField.IsOmitEmpty && e.isZeroValue(fieldValue) {
+		if structField.IsOmitEmpty && e.isOmittedValue(structField, fieldValue) {
 			// omit encoding
 			continue
 		}
@@ -778,7 +2271,13 @@ func (e *Encoder) encodeStruct(ctx context.Context, value reflect.Value, column
 			*ve = *e
 			ve.isFlowStyle = true
 		}
-		value, err := ve.encodeValue(ctx, fieldValue, column)
+		if !structField.IsInline {
+			e.pushKey(structField.RenderName)
+		}
+		value, err := ve.encodeFieldValue(ctx, structField, fieldValue, column)
+		if !structField.IsInline {
+			e.popPath()
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -793,6 +2292,10 @@ func (e *Encoder) encodeStruct(ctx context.Context, value reflect.Value, column
 				if !ok {
 					return nil, errors.ErrUnexpectedNodeType(value.Type(), ast.AliasType, value.GetToken())
 				}
+				aliasName, err := e.aliasAnchorName(aliasName)
+				if err != nil {
+					return nil, err
+				}
 				got := alias.Value.String()
 				if aliasName != got {
 					return nil, fmt.Errorf("expected alias name is %q but got %q", aliasName, got)
@@ -803,7 +2306,7 @@ func (e *Encoder) encodeStruct(ctx context.Context, value reflect.Value, column
 				key = ast.MergeKey(token.New("<<", "<<", e.pos(column)))
 			}
 		case structField.AnchorName != "":
-			anchorNode, err := e.encodeAnchor(structField.AnchorName, value, fieldValue, column)
+			anchorNode, err := e.encodeFieldAnchor(structField, structField.AnchorName, value, fieldValue, column)
 			if err != nil {
 				return nil, err
 			}
@@ -835,18 +2338,37 @@ func (e *Encoder) encodeStruct(ctx context.Context, value reflect.Value, column
 				}
 				key.AddColumn(-e.indent)
 				value.AddColumn(-e.indent)
-				node.Values = append(node.Values, ast.MappingValue(nil, key, value))
+				mappingValue := ast.MappingValue(nil, key, value)
+				if structField.HasOrder {
+					fieldOrders[mappingValue] = structField.Order
+				}
+				node.Values = append(node.Values, mappingValue)
 			}
 			continue
 		case structField.IsAutoAnchor:
-			anchorNode, err := e.encodeAnchor(structField.RenderName, value, fieldValue, column)
+			anchorNode, err := e.encodeFieldAnchor(structField, structField.RenderName, value, fieldValue, column)
 			if err != nil {
 				return nil, err
 			}
 			value = anchorNode
 		}
-		node.Values = append(node.Values, ast.MappingValue(nil, key, value))
+		mappingValue := ast.MappingValue(nil, key, value)
+		if structField.HasOrder {
+			fieldOrders[mappingValue] = structField.Order
+		}
+		if comment, exists := fieldComments[structField.RenderName]; exists {
+			e.setFieldComment(mappingValue, comment)
+		} else if structField.Comment != "" {
+			e.setFieldComment(mappingValue, structField.Comment)
+		}
+		node.Values = append(node.Values, mappingValue)
+	}
+	if e.sortStructFields {
+		sort.SliceStable(node.Values, func(i, j int) bool {
+			return mapKeyText(node.Values[i].Key) < mapKeyText(node.Values[j].Key)
+		})
 	}
+	sortFieldsByOrder(node.Values, e.fieldOrder(value), fieldOrders)
 	if hasInlineAnchorField {
 		node.AddColumn(e.indent)
 		anchorName := "anchor"
@@ -861,6 +2383,10 @@ func (e *Encoder) encodeStruct(ctx context.Context, value reflect.Value, column
 				anchorName = snode.Value
 			}
 		}
+		anchorName, err := e.setAnchorName(anchorNode, anchorName)
+		if err != nil {
+			return nil, err
+		}
 		if inlineAnchorValue.Kind() == reflect.Ptr {
 			e.anchorPtrToNameMap[inlineAnchorValue.Pointer()] = anchorName
 		}
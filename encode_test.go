@@ -5,7 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
@@ -15,6 +17,7 @@ import (
 	"github.com/goccy/go-yaml"
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/resolver"
 )
 
 var zero = 0
@@ -1220,6 +1223,151 @@ queues:
 	}
 }
 
+func TestEncoder_AnchorNameSanitizer(t *testing.T) {
+	type Host struct {
+		Hostname string `yaml:"hostname"`
+	}
+	type HostDecl struct {
+		Host *Host `yaml:",anchor"`
+	}
+	var doc struct {
+		Hosts []*HostDecl `yaml:"hosts"`
+		Main  *Host       `yaml:"main"`
+	}
+	host1 := &Host{Hostname: "web {1}"}
+	host2 := &Host{Hostname: "web [1]"}
+	doc.Hosts = []*HostDecl{{Host: host1}, {Host: host2}}
+	doc.Main = host2
+	opt := yaml.MarshalAnchor(func(anchor *ast.AnchorNode, value interface{}) error {
+		if host, ok := value.(*Host); ok {
+			return anchor.SetName(host.Hostname)
+		}
+		return nil
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := yaml.MarshalWithOptions(doc, opt)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !yaml.IsInvalidAnchorNameError(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := `invalid anchor name "web {1}": it must not be empty or contain white spaces and flow indicators`
+		if err.Error() != expected {
+			t.Fatalf("expected error %q but got %q", expected, err.Error())
+		}
+	})
+	t.Run("sanitize", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(doc, opt, yaml.AnchorNameSanitizer(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+hosts:
+- host: &web__1_
+    hostname: web {1}
+- host: &web__1__2
+    hostname: web [1]
+main: *web__1__2
+`
+		if "\n"+string(b) != expected {
+			t.Fatalf("unexpected output:\n%s", b)
+		}
+		var v map[string]interface{}
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestEncoder_AnchorCollision(t *testing.T) {
+	type Host struct {
+		Hostname string `yaml:"hostname"`
+	}
+	type Service struct {
+		Host *Host `yaml:",anchor"`
+	}
+	var doc struct {
+		Web     Service `yaml:"web"`
+		DB      Service `yaml:"db"`
+		Primary *Host   `yaml:"primary"`
+		Replica *Host   `yaml:"replica"`
+	}
+	web := &Host{Hostname: "web"}
+	db := &Host{Hostname: "db"}
+	doc.Web.Host = web
+	doc.DB.Host = db
+	doc.Primary = web
+	doc.Replica = db
+
+	t.Run("resolve", func(t *testing.T) {
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+web:
+  host: &host
+    hostname: web
+db:
+  host: &host_2
+    hostname: db
+primary: *host
+replica: *host_2
+`
+		if "\n"+string(b) != expected {
+			t.Fatalf("unexpected output:\n%s", b)
+		}
+		var v struct {
+			Primary Host `yaml:"primary"`
+			Replica Host `yaml:"replica"`
+		}
+		if err := yaml.UnmarshalWithOptions(b, &v, yaml.DisallowAnchorRedefinition()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Primary.Hostname != "web" || v.Replica.Hostname != "db" {
+			t.Fatalf("unexpected aliases: %+v", v)
+		}
+	})
+	t.Run("disallow", func(t *testing.T) {
+		_, err := yaml.MarshalWithOptions(doc, yaml.ResolveAnchorCollision(false))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		var collisionErr *yaml.AnchorCollisionError
+		if !errors.As(err, &collisionErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if collisionErr.Name != "host" || collisionErr.PrevPath != "$.web.host" || collisionErr.Path != "$.db.host" {
+			t.Fatalf("unexpected error: %+v", collisionErr)
+		}
+		expected := `anchor "host" at $.db.host collides with the anchor at $.web.host`
+		if err.Error() != expected {
+			t.Fatalf("expected error %q but got %q", expected, err.Error())
+		}
+	})
+	t.Run("every document", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf, yaml.ResolveAnchorCollision(false))
+		for _, v := range []interface{}{Service{Host: web}, Service{Host: db}} {
+			if err := enc.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expected := `
+host: &host
+  hostname: web
+---
+host: &host
+  hostname: db
+`
+		if "\n"+buf.String() != expected {
+			t.Fatalf("unexpected output:\n%s", buf.String())
+		}
+	})
+}
+
 type useJSONMarshalerTest struct{}
 
 func (t useJSONMarshalerTest) MarshalJSON() ([]byte, error) {
@@ -1242,6 +1390,151 @@ a:
 	}
 }
 
+type useBinaryMarshalerTest struct {
+	b []byte
+}
+
+func (t useBinaryMarshalerTest) MarshalBinary() ([]byte, error) {
+	return t.b, nil
+}
+
+func (t *useBinaryMarshalerTest) UnmarshalBinary(b []byte) error {
+	t.b = b
+	return nil
+}
+
+func TestEncoder_UseBinaryMarshaler(t *testing.T) {
+	v := map[string]any{
+		"hash":  useBinaryMarshalerTest{b: []byte("hello")},
+		"empty": &useBinaryMarshalerTest{},
+		"list":  []useBinaryMarshalerTest{{b: []byte{0xff, 0x00}}},
+	}
+	t.Run("default", func(t *testing.T) {
+		got, err := yaml.MarshalWithOptions(v, yaml.UseBinaryMarshaler())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+empty: !!binary ""
+hash: !!binary aGVsbG8=
+list:
+- !!binary /wA=
+`
+		if expected != "\n"+string(got) {
+			t.Fatalf("failed to use binary marshaler. expected [%q] but got [%q]", expected, string(got))
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		got, err := yaml.MarshalWithOptions(v, yaml.UseBinaryMarshaler(), yaml.JSON())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"empty": "", "hash": "aGVsbG8=", "list": ["/wA="]}
+`
+		if expected != string(got) {
+			t.Fatalf("failed to use binary marshaler. expected [%q] but got [%q]", expected, string(got))
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		got, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(got), "!!binary") {
+			t.Fatalf("unexpected binary output: %s", got)
+		}
+	})
+}
+
+func TestEncoder_CollectionTags(t *testing.T) {
+	type T struct {
+		Tags  []string            `yaml:"tags,set"`
+		Keys  map[string]int      `yaml:"keys,set"`
+		Omap  yaml.MapSlice       `yaml:"omap,omap"`
+		Pairs []yaml.MapItem      `yaml:"pairs,pairs"`
+		M     map[string]int      `yaml:"m,omap"`
+		S     yaml.Set[int]       `yaml:"s"`
+		List  []yaml.Set[string]  `yaml:"list"`
+		Nil   map[string]struct{} `yaml:"nil,set,omitempty"`
+	}
+	v := T{
+		Tags:  []string{"b", "a"},
+		Keys:  map[string]int{"y": 1, "x": 2},
+		Omap:  yaml.MapSlice{{Key: "z", Value: 1}, {Key: "a", Value: map[string]int{"q": 1}}},
+		Pairs: []yaml.MapItem{{Key: "k", Value: 1}, {Key: "k", Value: 2}},
+		M:     map[string]int{"b": 1, "a": 2},
+		S:     yaml.NewSet(3, 1),
+		List:  []yaml.Set[string]{yaml.NewSet("a")},
+	}
+	got, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+tags: !!set
+  ? b
+  ? a
+keys: !!set
+  ? x
+  ? "y"
+omap: !!omap
+- z: 1
+- a:
+    q: 1
+pairs: !!pairs
+- k: 1
+- k: 2
+m: !!omap
+- a: 2
+- b: 1
+s: !!set
+  ? 1
+  ? 3
+list:
+- !!set
+  ? a
+`
+	if expected != "\n"+string(got) {
+		t.Fatalf("failed to encode collection tags. expected [%q] but got [%q]", expected, string(got))
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		var decoded T
+		if err := yaml.Unmarshal(got, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded.Tags, v.Tags) || !reflect.DeepEqual(decoded.S, v.S) || !reflect.DeepEqual(decoded.M, v.M) {
+			t.Fatalf("unexpected decoded value: %+v", decoded)
+		}
+	})
+	t.Run("flow", func(t *testing.T) {
+		got, err := yaml.MarshalWithOptions(map[string]yaml.Set[string]{"s": yaml.NewSet("a")}, yaml.Flow(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "{s: !!set {a: null}}\n" {
+			t.Fatalf("unexpected output: %q", got)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		got, err := yaml.MarshalWithOptions(map[string]yaml.Set[string]{"s": yaml.NewSet("a")}, yaml.JSON())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "{\"s\": {\"a\": {}}}\n" {
+			t.Fatalf("unexpected output: %q", got)
+		}
+	})
+	t.Run("invalid option", func(t *testing.T) {
+		type T struct {
+			A string `yaml:"a,omap"`
+		}
+		if _, err := yaml.Marshal(T{}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
 func TestEncoder_CustomMarshaler(t *testing.T) {
 	t.Run("override struct type", func(t *testing.T) {
 		type T struct {
@@ -1410,6 +1703,170 @@ func (c *marshalContext) MarshalYAML(ctx context.Context) ([]byte, error) {
 	return []byte("1"), nil
 }
 
+type yamlAndTextMarshaler struct{}
+
+var _ yaml.Marshaler = yamlAndTextMarshaler{}
+
+func (yamlAndTextMarshaler) MarshalYAML() (interface{}, error) {
+	return []string{"yaml"}, nil
+}
+
+func (yamlAndTextMarshaler) MarshalText() ([]byte, error) {
+	return []byte("text"), nil
+}
+
+type pointerInterfaceMarshaler struct {
+	v string
+}
+
+func (m *pointerInterfaceMarshaler) MarshalYAML() (interface{}, error) {
+	return "yaml:" + m.v, nil
+}
+
+type streamedBlock struct {
+	lines []string
+	calls int
+}
+
+func (v streamedBlock) MarshalYAMLTo(enc *yaml.Encoder) error {
+	for i := 0; i < v.calls; i++ {
+		if err := enc.Encode(strings.Join(v.lines, "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestEncoder_MarshalerTo(t *testing.T) {
+	t.Run("encode", func(t *testing.T) {
+		v := map[string]interface{}{
+			"block": streamedBlock{lines: []string{"a", "b"}, calls: 1},
+			"list":  []streamedBlock{{lines: []string{"c"}, calls: 1}},
+		}
+		b, err := yaml.MarshalWithOptions(v, yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+block: |-
+  a
+  b
+list:
+- c
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("not encoded", func(t *testing.T) {
+		_, err := yaml.Marshal(map[string]interface{}{"a": streamedBlock{}})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !strings.Contains(err.Error(), "yaml_test.streamedBlock") {
+			t.Fatalf("error message should contain the type of the value: %v", err)
+		}
+	})
+	t.Run("encoded twice", func(t *testing.T) {
+		if _, err := yaml.Marshal(map[string]streamedBlock{"a": {lines: []string{"x"}, calls: 2}}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) Set(key string, value interface{}) *orderedMap {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+	return m
+}
+
+func (m *orderedMap) Iterate(yield func(key, value any) bool) {
+	for _, key := range m.keys {
+		if !yield(key, m.values[key]) {
+			return
+		}
+	}
+}
+
+func TestEncoder_OrderedIterator(t *testing.T) {
+	t.Run("encode", func(t *testing.T) {
+		inner := (&orderedMap{}).Set("z", 1).Set("a", []int{1, 2})
+		v := map[string]interface{}{
+			"m": (&orderedMap{}).Set("c", "x").Set("b", inner).Set("a", nil),
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+m:
+  c: x
+  b:
+    z: 1
+    a:
+    - 1
+    - 2
+  a: null
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("flow", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions((&orderedMap{}).Set("b", 1).Set("a", 2), yaml.Flow(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "{b: 1, a: 2}\n"; string(b) != expected {
+			t.Fatalf("unexpected output: %q", b)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		m := (&orderedMap{}).Set("a", 1).Set("b", func() {})
+		if _, err := yaml.MarshalWithOptions(m, yaml.DisallowUnsupportedValue()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_MarshalerPriority(t *testing.T) {
+	v := struct {
+		A yamlAndTextMarshaler
+		B *pointerInterfaceMarshaler
+		C *pointerInterfaceMarshaler
+		D pointerInterfaceMarshaler
+	}{
+		C: &pointerInterfaceMarshaler{v: "c"},
+		D: pointerInterfaceMarshaler{v: "d"},
+	}
+	b, err := yaml.Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// MarshalYAML takes precedence over MarshalText, the nil pointer is encoded as null without calling MarshalYAML,
+	// and MarshalYAML of the pointer receiver isn't called for the value like gopkg.in/yaml.v3.
+	expected := `
+a:
+- yaml
+b: null
+c: yaml:c
+d: {}
+`
+	if got := "\n" + string(b); got != expected {
+		t.Fatalf("unexpected output:%s", got)
+	}
+}
+
 func Test_MarshalerContext(t *testing.T) {
 	ctx := context.WithValue(context.Background(), "k", 1)
 	bytes, err := yaml.MarshalContext(ctx, &marshalContext{})
@@ -1717,3 +2174,1913 @@ values:
 		t.Fatalf("failed to encode: got = %s", string(b))
 	}
 }
+
+type fieldCommentConfig struct {
+	Host string            `yaml:"host"`
+	Port int               `yaml:"port,comment=TCP port to listen on"`
+	Tags []string          `yaml:"tags,omitempty,comment=tags, separated by role"`
+	Sub  map[string]string `yaml:"sub"`
+}
+
+func (fieldCommentConfig) YAMLComments() map[string]string {
+	return map[string]string{
+		"host": "server host name",
+		"sub":  "sub config",
+	}
+}
+
+func TestEncoder_FieldComment(t *testing.T) {
+	t.Run("struct tag and interface", func(t *testing.T) {
+		v := fieldCommentConfig{
+			Host: "localhost",
+			Port: 8080,
+			Tags: []string{"web"},
+			Sub:  map[string]string{"a": "b"},
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+host: localhost # server host name
+port: 8080 # TCP port to listen on
+tags: # tags, separated by role
+- web
+sub: # sub config
+  a: b
+`
+		if actual := "\n" + string(b); expected != actual {
+			t.Fatalf("expected:%s but got %s", expected, actual)
+		}
+	})
+	t.Run("flow style", func(t *testing.T) {
+		v := struct {
+			A int `yaml:"a,comment=ignored"`
+		}{A: 1}
+		b, err := yaml.MarshalWithOptions(v, yaml.Flow(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "{a: 1}\n"; expected != string(b) {
+			t.Fatalf("expected:%q but got %q", expected, string(b))
+		}
+	})
+}
+
+type fieldOrderMeta struct {
+	Name   string `yaml:"name"`
+	Labels string `yaml:"labels"`
+}
+
+type fieldOrderResource struct {
+	Spec           string `yaml:"spec"`
+	fieldOrderMeta `yaml:",inline"`
+	Kind           string `yaml:"kind"`
+	APIVersion     string `yaml:"apiVersion"`
+}
+
+func (fieldOrderResource) YAMLFieldOrder() []string {
+	return []string{"apiVersion", "kind", "name"}
+}
+
+func TestEncoder_FieldOrder(t *testing.T) {
+	t.Run("interface", func(t *testing.T) {
+		v := fieldOrderResource{
+			Spec:           "spec",
+			fieldOrderMeta: fieldOrderMeta{Name: "app", Labels: "web"},
+			Kind:           "Pod",
+			APIVersion:     "v1",
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+apiVersion: v1
+kind: Pod
+name: app
+spec: spec
+labels: web
+`
+		if actual := "\n" + string(b); expected != actual {
+			t.Fatalf("expected:%s but got %s", expected, actual)
+		}
+	})
+	t.Run("struct tag", func(t *testing.T) {
+		v := struct {
+			C int `yaml:"c"`
+			B int `yaml:"b,order=2"`
+			D int `yaml:"d"`
+			A int `yaml:"a,order=1"`
+		}{A: 1, B: 2, C: 3, D: 4}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+a: 1
+b: 2
+c: 3
+d: 4
+`
+		if actual := "\n" + string(b); expected != actual {
+			t.Fatalf("expected:%s but got %s", expected, actual)
+		}
+		b, err = yaml.MarshalWithOptions(v, yaml.Flow(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "{a: 1, b: 2, c: 3, d: 4}\n"; expected != string(b) {
+			t.Fatalf("expected:%q but got %q", expected, string(b))
+		}
+	})
+	t.Run("sorted fields", func(t *testing.T) {
+		v := struct {
+			B int `yaml:"b"`
+			A int `yaml:"a"`
+			Z int `yaml:"z,order=0"`
+		}{A: 1, B: 2, Z: 3}
+		b, err := yaml.MarshalWithOptions(v, yaml.KubernetesStyle())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "z: 3\na: 1\nb: 2\n"; expected != string(b) {
+			t.Fatalf("expected:%q but got %q", expected, string(b))
+		}
+	})
+	t.Run("invalid order", func(t *testing.T) {
+		v := struct {
+			A int `yaml:"a,order=first"`
+		}{}
+		if _, err := yaml.Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_BlockScalar(t *testing.T) {
+	type script struct {
+		Run    string  `yaml:"run,literal"`
+		Keep   string  `yaml:"keep,literal,keep"`
+		Folded string  `yaml:"folded,folded"`
+		Indent string  `yaml:"indent,indent=4"`
+		Ptr    *string `yaml:"ptr,literal"`
+		Single string  `yaml:"single,literal"`
+		Empty  string  `yaml:"empty,literal"`
+	}
+	ptr := "a\n"
+	v := script{
+		Run:    "echo a\necho b\n",
+		Keep:   "x\n",
+		Folded: "a\nb\n\nc\n  d\ne",
+		Indent: "  indented\nline\n",
+		Ptr:    &ptr,
+		Single: "one line",
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+run: |
+  echo a
+  echo b
+keep: |+
+  x
+folded: >-
+  a
+
+  b
+
+
+  c
+    d
+  e
+indent: |4
+      indented
+    line
+ptr: |
+  a
+single: |-
+  one line
+empty: ""
+`
+	if actual := "\n" + string(b); expected != actual {
+		t.Fatalf("expected:%s but got %s", expected, actual)
+	}
+	var decoded script
+	if err := yaml.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, decoded) {
+		t.Fatalf("failed to round trip: %#v", decoded)
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		v := map[string][]script{"steps": {{Run: "a\n  \nb", Keep: "c\n\n"}}}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded map[string][]script
+		if err := yaml.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("failed to round trip: %#v\n%s", decoded, b)
+		}
+	})
+	t.Run("flow style", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(script{Run: "echo"}, yaml.Flow(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(b), `{run: echo, `) {
+			t.Fatalf("unexpected output: %s", b)
+		}
+	})
+	t.Run("invalid indent", func(t *testing.T) {
+		v := struct {
+			A string `yaml:"a,indent=10"`
+		}{}
+		if _, err := yaml.Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("default style", func(t *testing.T) {
+		type block struct {
+			Literal string `yaml:"literal,literal"`
+			Folded  string `yaml:"folded,folded"`
+			Indent  string `yaml:"indent,indent=4"`
+		}
+		tests := []struct {
+			name     string
+			value    block
+			expected string
+		}{
+			{
+				name:     "indent indicator with trailing empty lines",
+				value:    block{Indent: "a\n\n"},
+				expected: `indent: |+`,
+			},
+			{
+				name:     "auto indent indicator with trailing empty lines",
+				value:    block{Literal: " a\n\n"},
+				expected: `literal: " a\n\n"`,
+			},
+			{
+				name:     "literal starting with tab",
+				value:    block{Literal: "\ta\nb"},
+				expected: `literal: "\ta\nb"`,
+			},
+			{
+				name:     "folded starting with tab",
+				value:    block{Folded: "\ta\nb"},
+				expected: `folded: "\ta\nb"`,
+			},
+			{
+				name:     "only line break",
+				value:    block{Literal: "\n"},
+				expected: `literal: "\n"`,
+			},
+			{
+				name:     "only line breaks",
+				value:    block{Folded: "\n\n"},
+				expected: `folded: "\n\n"`,
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				b, err := yaml.Marshal(test.value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(b), test.expected+"\n") {
+					t.Fatalf("expected %s in the output but got:\n%s", test.expected, b)
+				}
+				var decoded block
+				if err := yaml.Unmarshal(b, &decoded); err != nil {
+					t.Fatal(err)
+				}
+				if decoded != test.value {
+					t.Fatalf("failed to round trip: %#v\n%s", decoded, b)
+				}
+			})
+		}
+	})
+}
+
+func TestEncoder_UseFoldedStyleIfLong(t *testing.T) {
+	description := "The quick brown fox jumps over the lazy dog. The quick brown fox jumps over the lazy dog."
+	v := map[string]interface{}{
+		"description": description,
+		"paragraphs":  []string{description + "\n\n  indented line\n" + description + "\n"},
+		"short":       "short text",
+		"word":        strings.Repeat("x", 50),
+		"trailing":    description + " ",
+	}
+	b, err := yaml.MarshalWithOptions(v, yaml.UseFoldedStyleIfLong(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+description: >-
+  The quick brown fox jumps over
+  the lazy dog. The quick brown
+  fox jumps over the lazy dog.
+paragraphs:
+- >
+  The quick brown fox jumps over
+  the lazy dog. The quick brown
+  fox jumps over the lazy dog.
+
+    indented line
+  The quick brown fox jumps over
+  the lazy dog. The quick brown
+  fox jumps over the lazy dog.
+short: short text
+trailing: "The quick brown fox jumps over the lazy dog. The quick brown fox jumps over the lazy dog. "
+word: xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx
+`
+	if actual := "\n" + string(b); expected != actual {
+		t.Fatalf("expected:%s but got %s", expected, actual)
+	}
+	var decoded map[string]interface{}
+	if err := yaml.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(decoded) != fmt.Sprint(v) {
+		t.Fatalf("failed to round trip: %v", decoded)
+	}
+
+	t.Run("struct tag", func(t *testing.T) {
+		v := struct {
+			Message string `yaml:"message,folded"`
+		}{Message: description}
+		b, err := yaml.MarshalWithOptions(v, yaml.UseFoldedStyleIfLong(50))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+message: >-
+  The quick brown fox jumps over the lazy dog. The
+  quick brown fox jumps over the lazy dog.
+`
+		if actual := "\n" + string(b); expected != actual {
+			t.Fatalf("expected:%s but got %s", expected, actual)
+		}
+	})
+	t.Run("literal style for multiline", func(t *testing.T) {
+		v := map[string]string{"a": description + "\n" + description}
+		b, err := yaml.MarshalWithOptions(v, yaml.UseFoldedStyleIfLong(30), yaml.UseLiteralStyleIfMultiline(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(b), "a: |-\n") {
+			t.Fatalf("unexpected output: %s", b)
+		}
+	})
+}
+
+func TestEncoder_ForwardAlias(t *testing.T) {
+	f, err := parser.ParseBytes([]byte("a: &x 1\nb: *x\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := f.Docs[0].Body.(*ast.MappingNode).Values
+	anchor, alias := values[0].Value, values[1].Value
+
+	t.Run("alias after anchor", func(t *testing.T) {
+		b, err := yaml.Marshal(yaml.MapSlice{{Key: "a", Value: anchor}, {Key: "b", Value: alias}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "a: &x 1\nb: *x\n"; expected != string(b) {
+			t.Fatalf("expected:%q but got %q", expected, string(b))
+		}
+	})
+	t.Run("alias before anchor", func(t *testing.T) {
+		_, err := yaml.Marshal(yaml.MapSlice{{Key: "b", Value: alias}, {Key: "a", Value: anchor}})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if !yaml.IsForwardAliasError(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(err.Error(), "&x") {
+			t.Fatalf("error message should contain anchor name: %v", err)
+		}
+		if !strings.HasSuffix(err.Error(), "at $.b") {
+			t.Fatalf("error message should contain the path of the alias: %v", err)
+		}
+	})
+	t.Run("undefined anchor", func(t *testing.T) {
+		_, err := yaml.Marshal(map[string]interface{}{"list": []interface{}{1, alias}})
+		if !yaml.IsUndefinedAnchorError(err) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasSuffix(err.Error(), "at $.list[1]") {
+			t.Fatalf("error message should contain the path of the alias: %v", err)
+		}
+	})
+	t.Run("disable validation", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(map[string]interface{}{"b": alias}, yaml.ValidateAliases(false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "b: *x\n"; expected != string(b) {
+			t.Fatalf("expected:%q but got %q", expected, string(b))
+		}
+	})
+}
+
+func TestEncoder_CompactSequence(t *testing.T) {
+	v := map[string]interface{}{
+		"list": []interface{}{
+			map[string]interface{}{"a": 1, "b": []int{1}},
+			[]int{1, 2},
+			"x",
+			[]int{},
+		},
+	}
+	tests := []struct {
+		name     string
+		options  []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name:    "compact",
+			options: []yaml.EncodeOption{yaml.CompactSequence(true)},
+			expected: `
+list:
+- a: 1
+  b:
+  - 1
+- - 1
+  - 2
+- x
+- []
+`,
+		},
+		{
+			name:    "expanded",
+			options: []yaml.EncodeOption{yaml.CompactSequence(false)},
+			expected: `
+list:
+-
+  a: 1
+  b:
+  - 1
+-
+  - 1
+  - 2
+- x
+- []
+`,
+		},
+		{
+			name:    "expanded with indent sequence",
+			options: []yaml.EncodeOption{yaml.Indent(4), yaml.IndentSequence(true), yaml.CompactSequence(false)},
+			expected: `
+list:
+    -
+        a: 1
+        b:
+            - 1
+    -
+        - 1
+        - 2
+    - x
+    - []
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := yaml.MarshalWithOptions(v, test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := "\n" + string(b); test.expected != actual {
+				t.Fatalf("expected:%s but got %s", test.expected, actual)
+			}
+			var decoded interface{}
+			if err := yaml.Unmarshal(b, &decoded); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type nodeMarshalerValue struct {
+	node ast.Node
+}
+
+func (v nodeMarshalerValue) MarshalYAML() (ast.Node, error) {
+	return v.node, nil
+}
+
+func TestEncoder_NodeMarshaler(t *testing.T) {
+	file, err := parser.ParseBytes([]byte(`
+# comment
+a: &x [1, 2]
+b: *x # line
+`), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := map[string]interface{}{
+		"v": nodeMarshalerValue{node: file.Docs[0].Body},
+	}
+	got, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+v:
+  # comment
+  a: &x [1, 2]
+  b: *x # line
+`
+	if string(got) != strings.TrimPrefix(expected, "\n") {
+		t.Fatalf("failed to encode. expected:\n%s\nbut got:\n%s", expected, string(got))
+	}
+	t.Run("node isn't modified", func(t *testing.T) {
+		node := file.Docs[0].Body
+		before := node.String()
+		column := node.GetToken().Position.Column
+		for i := 0; i < 2; i++ {
+			got, err := yaml.Marshal(map[string]interface{}{"v": map[string]interface{}{"w": nodeMarshalerValue{node: node}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded map[string]map[string]map[string]interface{}
+			if err := yaml.Unmarshal(got, &decoded); err != nil {
+				t.Fatalf("failed to decode:\n%s\n%v", got, err)
+			}
+		}
+		if node.GetToken().Position.Column != column || node.String() != before {
+			t.Fatalf("the node is modified:\n%s", node.String())
+		}
+	})
+}
+
+func TestEncoder_ASTNodeContainer(t *testing.T) {
+	file, err := parser.ParseBytes([]byte(`
+a:
+  # head
+  x: 'a' # comment
+  y: [1, 2]
+  z: |
+    text
+b:
+  - 1 # one
+  - {c: 1}
+`), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, ok := file.Docs[0].Body.(*ast.MappingNode)
+	if !ok {
+		t.Fatalf("unexpected node type: %T", file.Docs[0].Body)
+	}
+	a := body.Values[0].Value
+	b := body.Values[1].Value
+	tests := []struct {
+		name     string
+		value    interface{}
+		options  []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name: "map",
+			value: map[string]interface{}{
+				"m": map[string]ast.Node{"a": a, "b": b},
+				"v": 1,
+			},
+			expected: `
+m:
+  a:
+    # head
+    x: 'a' # comment
+    y: [1, 2]
+    z: |
+      text
+  b:
+  - 1 # one
+  - {c: 1}
+v: 1
+`,
+		},
+		{
+			name:  "slice",
+			value: []ast.Node{a, b, a.(*ast.MappingNode).Values[0].Value},
+			expected: `
+- # head
+  x: 'a' # comment
+  y: [1, 2]
+  z: |
+    text
+- - 1 # one
+  - {c: 1}
+- 'a' # comment
+`,
+		},
+		{
+			name:    "indent",
+			value:   map[string]interface{}{"m": map[string]ast.Node{"a": a, "b": b}},
+			options: []yaml.EncodeOption{yaml.Indent(4), yaml.IndentSequence(true)},
+			expected: `
+m:
+    a:
+        # head
+        x: 'a' # comment
+        y: [1, 2]
+        z: |
+          text
+    b:
+        - 1 # one
+        - {c: 1}
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := yaml.MarshalWithOptions(test.value, test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := strings.TrimPrefix(test.expected, "\n")
+			if string(got) != expected {
+				t.Fatalf("failed to encode. expected:\n%s\nbut got:\n%s", expected, string(got))
+			}
+		})
+	}
+	if got := file.String(); !strings.Contains(got, "\n  z: |\n    text") {
+		t.Fatalf("source node is modified:\n%s", got)
+	}
+}
+
+func TestEncoder_Canonical(t *testing.T) {
+	type T struct {
+		Name  string            `yaml:"name"`
+		Tags  []string          `yaml:"tags"`
+		Attrs map[string]string `yaml:"attrs"`
+		Count int               `yaml:"count"`
+		Rate  float64           `yaml:"rate"`
+		Ok    bool              `yaml:"ok"`
+		Ptr   *int              `yaml:"ptr"`
+		Empty []int             `yaml:"empty"`
+	}
+	v := T{
+		Name:  "a\nb",
+		Tags:  []string{"x", "123"},
+		Attrs: map[string]string{"z": "true", "y": "it's"},
+		Count: 10,
+		Rate:  0.5,
+		Ok:    true,
+		Empty: []int{},
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf, yaml.Canonical())
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode([]interface{}{1, "a"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+---
+!!map {
+  ? !!str "attrs"
+  : !!map {
+    ? !!str "y"
+    : !!str "it's",
+    ? !!str "z"
+    : !!str "true",
+  },
+  ? !!str "count"
+  : !!int "10",
+  ? !!str "empty"
+  : !!seq [],
+  ? !!str "name"
+  : !!str "a\nb",
+  ? !!str "ok"
+  : !!bool "true",
+  ? !!str "ptr"
+  : !!null "",
+  ? !!str "rate"
+  : !!float "0.5",
+  ? !!str "tags"
+  : !!seq [
+    !!str "x",
+    !!str "123",
+  ],
+}
+---
+!!seq [
+  !!int "1",
+  !!str "a",
+]
+`
+	if got := buf.String(); got != strings.TrimPrefix(expected, "\n") {
+		t.Fatalf("failed to encode. expected:\n%s\nbut got:\n%s", expected, got)
+	}
+
+	t.Run("anchor", func(t *testing.T) {
+		type Item struct {
+			A *T `yaml:"a,anchor=x"`
+			B *T `yaml:"b,alias=x"`
+		}
+		item := &T{Name: "n"}
+		got, err := yaml.MarshalWithOptions(Item{A: item, B: item}, yaml.Canonical())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(got), `: &x !!map {`) || !strings.Contains(string(got), `: *x,`) {
+			t.Fatalf("unexpected output:\n%s", got)
+		}
+	})
+	t.Run("alias sorted before anchor", func(t *testing.T) {
+		type T struct {
+			Name string `yaml:"name"`
+		}
+		type Item struct {
+			Z *T                `yaml:"z,anchor=x"`
+			Y map[string]string `yaml:"y"`
+			A *T                `yaml:"a,alias=x"`
+		}
+		item := &T{Name: "n"}
+		v := Item{Z: item, Y: map[string]string{"k": "v"}, A: item}
+		got, err := yaml.MarshalWithOptions(v, yaml.Canonical())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `---
+!!map {
+  ? !!str "y"
+  : !!map {
+    ? !!str "k"
+    : !!str "v",
+  },
+  ? !!str "z"
+  : &x !!map {
+    ? !!str "name"
+    : !!str "n",
+  },
+  ? !!str "a"
+  : *x,
+}
+`
+		if string(got) != expected {
+			t.Fatalf("unexpected output:\n%s", got)
+		}
+		var decoded Item
+		if err := yaml.Unmarshal(got, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("failed to decode canonical output. expected %+v but got %+v", v, decoded)
+		}
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		got, err := yaml.MarshalWithOptions(v, yaml.Canonical())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded T
+		if err := yaml.Unmarshal(got, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("failed to decode canonical output. expected %+v but got %+v", v, decoded)
+		}
+	})
+	t.Run("max uint64", func(t *testing.T) {
+		v := map[string]uint64{"v": math.MaxUint64}
+		got, err := yaml.MarshalWithOptions(v, yaml.Canonical())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded map[string]uint64
+		if err := yaml.Unmarshal(got, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("failed to decode canonical output. expected %+v but got %+v", v, decoded)
+		}
+		var overflow map[string]int64
+		if err := yaml.Unmarshal(got, &overflow); err == nil {
+			t.Fatalf("expected overflow error but got %+v", overflow)
+		}
+	})
+	t.Run("float exponent", func(t *testing.T) {
+		v := map[string]any{"f": 1e300, "s": "it's \"quoted\""}
+		got, err := yaml.MarshalWithOptions(v, yaml.Canonical())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `---
+!!map {
+  ? !!str "f"
+  : !!float "1e+300",
+  ? !!str "s"
+  : !!str "it's \"quoted\"",
+}
+`
+		if string(got) != expected {
+			t.Fatalf("unexpected output:\n%s", got)
+		}
+		var decoded map[string]any
+		if err := yaml.Unmarshal(got, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("failed to decode canonical output. expected %+v but got %+v", v, decoded)
+		}
+	})
+}
+
+func TestEncoder_KubernetesStyle(t *testing.T) {
+	type Container struct {
+		Name  string   `yaml:"name"`
+		Image string   `yaml:"image"`
+		Args  []string `yaml:"args"`
+	}
+	type Manifest struct {
+		Kind              string                 `yaml:"kind"`
+		APIVersion        string                 `yaml:"apiVersion"`
+		Metadata          map[string]interface{} `yaml:"metadata"`
+		Containers        []Container            `yaml:"containers"`
+		Script            string                 `yaml:"script"`
+		CreationTimestamp time.Time              `yaml:"creationTimestamp"`
+		Replicas          *int                   `yaml:"replicas"`
+	}
+	v := Manifest{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Metadata: map[string]interface{}{
+			"name":   "app",
+			"labels": map[string]interface{}{"on": "yes", "scale": "1e3"},
+		},
+		Containers: []Container{
+			{Name: "app", Image: "nginx:1.0", Args: []string{"-", "<<", ".inf"}},
+		},
+		Script:            "echo a\necho b\n",
+		CreationTimestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	expected := `
+apiVersion: v1
+containers:
+- args:
+  - "-"
+  - "<<"
+  - ".inf"
+  image: nginx:1.0
+  name: app
+creationTimestamp: "2020-01-01T00:00:00Z"
+kind: Pod
+metadata:
+  labels:
+    "on": "yes"
+    scale: "1e3"
+  name: app
+replicas: null
+script: |
+  echo a
+  echo b
+`
+	got, err := yaml.MarshalWithOptions(v, yaml.Indent(4), yaml.IndentSequence(true), yaml.KubernetesStyle())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != strings.TrimPrefix(expected, "\n") {
+		t.Fatalf("failed to encode. expected:\n%s\nbut got:\n%s", expected, string(got))
+	}
+	var decoded Manifest
+	if err := yaml.Unmarshal(got, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, decoded) {
+		t.Fatalf("failed to decode. expected %+v but got %+v", v, decoded)
+	}
+}
+
+type pointerTextMarshalerKey struct {
+	v string
+}
+
+func (k *pointerTextMarshalerKey) MarshalText() ([]byte, error) {
+	return []byte("key:" + k.v), nil
+}
+
+type failTextMarshalerKey string
+
+func (failTextMarshalerKey) MarshalText() ([]byte, error) {
+	return nil, errors.New("failed to marshal key")
+}
+
+func TestEncoder_TextMarshalerMapKey(t *testing.T) {
+	t.Run("value receiver", func(t *testing.T) {
+		b, err := yaml.Marshal(map[TextMarshaler]int{8: 1, 9: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+"10": 1
+"11": 2
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("pointer receiver", func(t *testing.T) {
+		b, err := yaml.Marshal(map[pointerTextMarshalerKey]int{{v: "b"}: 2, {v: "a"}: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+key:a: 1
+key:b: 2
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("map slice", func(t *testing.T) {
+		b, err := yaml.Marshal(yaml.MapSlice{{Key: TextMarshaler(8), Value: 1}, {Key: 2, Value: 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+"10": 1
+2: 2
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		if _, err := yaml.Marshal(map[failTextMarshalerKey]int{"a": 1}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestEncoder_ComplexMapKey(t *testing.T) {
+	type key struct {
+		A int `yaml:"a"`
+		B int `yaml:"b"`
+	}
+	tests := []struct {
+		name     string
+		value    interface{}
+		options  []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name:  "struct key",
+			value: map[key]string{{A: 3, B: 4}: "z", {A: 1, B: 2}: "x"},
+			expected: `
+? {a: 1, b: 2}
+: x
+? {a: 3, b: 4}
+: z
+`,
+		},
+		{
+			name:  "array key with block value",
+			value: map[[2]int]map[string]int{{1, 2}: {"c": 1}},
+			expected: `
+? [1, 2]
+:
+  c: 1
+`,
+		},
+		{
+			name:  "nested",
+			value: map[string]map[[2]string]int{"m": {{"a", "b"}: 1}},
+			expected: `
+m:
+  ? [a, b]
+  : 1
+`,
+		},
+		{
+			name:     "flow style",
+			value:    map[[2]int]string{{1, 2}: "x"},
+			options:  []yaml.EncodeOption{yaml.Flow(true)},
+			expected: "\n{? [1, 2]: x}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := yaml.MarshalWithOptions(test.value, test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := "\n" + string(b); got != test.expected {
+				t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", test.expected, got)
+			}
+			decoded := reflect.New(reflect.TypeOf(test.value))
+			if err := yaml.Unmarshal(b, decoded.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.Elem().Interface(), test.value) {
+				t.Fatalf("failed to round trip: %+v", decoded.Elem().Interface())
+			}
+		})
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	v := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": 1,
+			"c": []int{1, 2},
+		},
+	}
+	b, err := yaml.MarshalIndent(v, 4, yaml.Indent(2), yaml.IndentSequence(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+a:
+    b: 1
+    c:
+        - 1
+        - 2
+`
+	if got := "\n" + string(b); got != expected {
+		t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+	}
+	t.Run("options are not modified", func(t *testing.T) {
+		opts := make([]yaml.EncodeOption, 2)
+		opts[0], opts[1] = yaml.IndentSequence(true), yaml.Indent(8)
+		if _, err := yaml.MarshalIndent(v, 4, opts[:1]...); err != nil {
+			t.Fatal(err)
+		}
+		b, err := yaml.MarshalWithOptions(v, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), "\n        b: 1") {
+			t.Fatalf("the options are overwritten:\n%s", b)
+		}
+	})
+}
+
+func TestValidateIndent(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected int
+	}{
+		{
+			name:     "nested mapping",
+			src:      "a:\n    b: 1\n    c:\n        d: 2\n",
+			expected: 4,
+		},
+		{
+			name:     "sequence at the same column as the key",
+			src:      "a:\n  b: 1\nlist:\n- x: 1\n  y:\n    z: 2\n",
+			expected: 2,
+		},
+		{
+			name:     "indented sequence",
+			src:      "a:\n   - 1\n",
+			expected: 3,
+		},
+		{
+			name:     "anchored mapping",
+			src:      "a: &x\n   b: 1\n",
+			expected: 3,
+		},
+		{
+			name:     "literal is ignored",
+			src:      "a: |\n    x\nb:\n  c: 1\n",
+			expected: 2,
+		},
+		{
+			name:     "flat",
+			src:      "a: 1\nb: [1, 2]\n",
+			expected: yaml.DefaultIndentSpaces,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indent, err := yaml.ValidateIndent([]byte(test.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if indent != test.expected {
+				t.Fatalf("expected %d but got %d", test.expected, indent)
+			}
+		})
+	}
+	t.Run("inconsistent", func(t *testing.T) {
+		_, err := yaml.ValidateIndent([]byte("a:\n  b:\n     c: 1\n"))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		expected := `
+[3:6] found 3 spaces indent, but 2 spaces indent is used at [2:3]
+   1 | a:
+   2 |   b:
+>  3 |      c: 1
+            ^
+`
+		if got := "\n" + yaml.FormatError(err, false, true); got != expected {
+			t.Fatalf("unexpected error:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+}
+
+func TestEncoder_Present(t *testing.T) {
+	v := struct {
+		A yaml.Present[int]    `yaml:"a,omitempty"`
+		B yaml.Present[int]    `yaml:"b,omitempty"`
+		C yaml.Present[string] `yaml:"c,omitempty"`
+		D yaml.Present[int]    `yaml:"d"`
+	}{
+		A: yaml.Present[int]{Value: 0, Set: true},
+		C: yaml.Present[string]{Set: true, Null: true},
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+a: 0
+c: null
+d: 0
+`
+	if got := "\n" + string(b); got != expected {
+		t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+	}
+}
+
+func TestEncoder_StrictQuote(t *testing.T) {
+	v := yaml.MapSlice{
+		{Key: "separator", Value: "a\u2028b\u2029c"},
+		{Key: "bom", Value: "\ufeffd"},
+		{Key: "control", Value: "e\x01f\x7f"},
+		{Key: "next_line", Value: "g\u0085h"},
+		{Key: "multiline", Value: "i\n\x1b"},
+		{Key: "bool", Value: "on"},
+		{Key: "sexagesimal", Value: "1:20:30"},
+		{Key: "text", Value: "日本語"},
+	}
+	got, err := yaml.MarshalWithOptions(v, yaml.StrictQuote(), yaml.UseSingleQuote(true), yaml.UseLiteralStyleIfMultiline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+separator: "a\u2028b\u2029c"
+bom: "\ufeffd"
+control: "e\x01f\x7f"
+next_line: "g\u0085h"
+multiline: "i\n\x1b"
+bool: 'on'
+sexagesimal: '1:20:30'
+text: 日本語
+`
+	if "\n"+string(got) != expected {
+		t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, "\n"+string(got))
+	}
+	var decoded yaml.MapSlice
+	if err := yaml.Unmarshal(got, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, v) {
+		t.Fatalf("failed to round trip: %+v", decoded)
+	}
+}
+
+func TestEncoder_ScalarMapKey(t *testing.T) {
+	v := map[interface{}]interface{}{
+		nil:           "a",
+		true:          "b",
+		false:         "c",
+		1:             "d",
+		-2:            "e",
+		uint(3):       "f",
+		1.5:           "g",
+		2.0:           "h",
+		math.Inf(1):   "i",
+		"1":           "j",
+		"true":        "k",
+		"null":        "l",
+		"~":           "m",
+		"":            "x",
+		"multi\nline": "o",
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+"": x
+-2: e
+.inf: i
+1: d
+"1": j
+1.5: g
+2.0: h
+3: f
+false: c
+"multi\nline": o
+null: a
+"null": l
+true: b
+"true": k
+"~": m
+`
+	if got := "\n" + string(b); got != expected {
+		t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+	}
+	var decoded map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(v) {
+		t.Fatalf("failed to decode all the keys: %v", decoded)
+	}
+	for _, key := range []interface{}{nil, true, false, uint64(1), int64(-2), uint64(3), 1.5, 2.0, math.Inf(1), "1", "true", "null", "~", "", "multi\nline"} {
+		if _, exists := decoded[key]; !exists {
+			t.Fatalf("failed to decode the key %#v: %v", key, decoded)
+		}
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		var v map[interface{}]interface{}
+		if err := yaml.Unmarshal([]byte("~: a\n1: b\n\"1\": c\n"), &v); err != nil {
+			t.Fatal(err)
+		}
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+"1": c
+1: b
+null: a
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("flow", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(map[interface{}]int{nil: 1, true: 2, 3: 3}, yaml.Flow(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := string(b), "{3: 3, null: 1, true: 2}\n"; got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(map[interface{}]int{true: 1, 2: 2}, yaml.JSON())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, expected := string(b), "{\"2\": 2, \"true\": 1}\n"; got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("explicit key", func(t *testing.T) {
+		v := map[string]int{"a\nb": 1, "c": 2}
+		b, err := yaml.MarshalWithOptions(v, yaml.UseExplicitKeyIfNeeded(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+? |-
+    a
+    b
+: 1
+c: 2
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+		var decoded map[string]int
+		if err := yaml.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, v) {
+			t.Fatalf("unexpected decoded value: %v", decoded)
+		}
+	})
+}
+
+func TestMarshalStrict(t *testing.T) {
+	n := 1
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{name: "pointer key", v: map[*int]string{&n: "a"}},
+		{name: "nil key", v: map[interface{}]string{nil: "a"}},
+		{name: "invalid UTF-8 string", v: map[string]string{"a": "\xff"}},
+		{name: "invalid UTF-8 key", v: map[string]string{"\xff": "a"}},
+		{name: "func", v: struct{ F func() }{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := yaml.MarshalStrict(test.v); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+	b, err := yaml.MarshalStrict(map[interface{}]int{"a": 1, 2: 2, true: 3, [2]int{1, 2}: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+2: 2
+? [1, 2]
+: 4
+a: 1
+true: 3
+`
+	if got := "\n" + string(b); got != expected {
+		t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+	}
+}
+
+func TestEncoder_CommentFormat(t *testing.T) {
+	v := map[string]interface{}{
+		"a":        1,
+		"bb":       2,
+		"long_key": 3,
+		"sub":      map[string]string{"c": "d"},
+	}
+	comments := yaml.CommentMap{
+		"$.a":        {yaml.LineComment(" first")},
+		"$.bb":       {yaml.LineComment(" second")},
+		"$.long_key": {yaml.HeadComment(" head1", "", " head2"), yaml.LineComment(" third")},
+		"$.sub.c":    {yaml.HeadComment(" sub head")},
+	}
+	tests := []struct {
+		name     string
+		opts     []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name: "default",
+			expected: `
+a: 1 # first
+bb: 2 # second
+# head1
+#
+# head2
+long_key: 3 # third
+sub:
+  # sub head
+  c: d
+`,
+		},
+		{
+			name: "preserve blank lines",
+			opts: []yaml.EncodeOption{yaml.PreserveCommentBlankLines()},
+			expected: `
+a: 1 # first
+bb: 2 # second
+# head1
+
+# head2
+long_key: 3 # third
+sub:
+  # sub head
+  c: d
+`,
+		},
+		{
+			name: "indent and align",
+			opts: []yaml.EncodeOption{yaml.CommentIndent(2), yaml.AlignLineComments(), yaml.LineCommentSpacing(2)},
+			expected: `
+a: 1   # first
+bb: 2  # second
+  # head1
+  #
+  # head2
+long_key: 3  # third
+sub:
+    # sub head
+  c: d
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := yaml.MarshalWithOptions(v, append(test.opts, yaml.WithComment(comments))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := "\n" + string(b); got != test.expected {
+				t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", test.expected, got)
+			}
+			var decoded map[string]interface{}
+			if err := yaml.Unmarshal(b, &decoded); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type cyclicNode struct {
+	Name     string        `yaml:"name"`
+	Children []*cyclicNode `yaml:"children,omitempty"`
+	Parent   *cyclicNode   `yaml:"parent,omitempty"`
+}
+
+func TestEncoder_Cycles(t *testing.T) {
+	root := &cyclicNode{Name: "root"}
+	root.Children = []*cyclicNode{{Name: "child", Parent: root}}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := yaml.Marshal(root)
+		if err == nil {
+			t.Fatal("expected error for the cycle")
+		}
+		if !strings.HasSuffix(err.Error(), "at $.children[0].parent, which refers to $") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		m := map[string]any{}
+		m["self"] = m
+		if _, err := yaml.Marshal(m); err == nil {
+			t.Fatal("expected error for the cyclic map")
+		}
+	})
+	t.Run("allow cycles", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(root, yaml.AllowCycles(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+&cycle1
+  name: root
+  children:
+  - name: child
+    parent: *cycle1
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+		var decoded cyclicNode
+		if err := yaml.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Children[0].Parent != &decoded {
+			t.Fatal("failed to decode the cyclic pointer")
+		}
+	})
+	t.Run("allow cycles with pointer", func(t *testing.T) {
+		type N struct {
+			Name string `yaml:"name"`
+			Next *N     `yaml:"next"`
+		}
+		n := &N{Name: "a"}
+		n.Next = n
+		b, err := yaml.MarshalWithOptions(n, yaml.AllowCycles(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+&cycle1
+  name: a
+  next: *cycle1
+`
+		if got := "\n" + string(b); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+		var decoded *N
+		if err := yaml.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded == nil || decoded.Name != "a" || decoded.Next != decoded {
+			t.Fatalf("failed to decode the cyclic pointer: %+v", decoded)
+		}
+	})
+	t.Run("allow cycles with json", func(t *testing.T) {
+		if _, err := yaml.MarshalWithOptions(root, yaml.JSON(), yaml.AllowCycles(true)); !errors.Is(err, yaml.ErrConflictingOptions) {
+			t.Fatalf("expected ErrConflictingOptions but got %v", err)
+		}
+	})
+	t.Run("shared value is not cycle", func(t *testing.T) {
+		shared := &cyclicNode{Name: "shared"}
+		b, err := yaml.Marshal([]*cyclicNode{shared, shared})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "- name: shared\n- name: shared\n"; string(b) != expected {
+			t.Fatalf("unexpected output: %q", string(b))
+		}
+	})
+}
+
+func TestEncoder_SetDirectives(t *testing.T) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	if err := enc.Encode(map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	enc.SetDirectives("%YAML 1.2", "%TAG !e! tag:example.com,2000:")
+	for _, v := range []map[string]int{{"b": 2}, {"c": 3}} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	enc.SetDirectives()
+	if err := enc.Encode(map[string]int{"d": 4}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+a: 1
+...
+%YAML 1.2
+%TAG !e! tag:example.com,2000:
+---
+b: 2
+...
+%YAML 1.2
+%TAG !e! tag:example.com,2000:
+---
+c: 3
+---
+d: 4
+`
+	if got := "\n" + buf.String(); got != expected {
+		t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+	}
+
+	dec := yaml.NewDecoder(&buf)
+	var keys []string
+	for {
+		var v map[string]int
+		if err := dec.Decode(&v); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	if got := strings.Join(keys, ","); got != "a,b,c,d" {
+		t.Fatalf("unexpected documents: %s", got)
+	}
+
+	enc.SetDirectives("YAML 1.2")
+	if err := enc.Encode(1); err == nil {
+		t.Fatal("expected error for the invalid directive")
+	}
+}
+
+func TestEncoder_DocumentMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: "a: 1\n---\nb: 2\n",
+		},
+		{
+			name:     "start",
+			opts:     []yaml.EncodeOption{yaml.WithDocumentStart()},
+			expected: "---\na: 1\n---\nb: 2\n",
+		},
+		{
+			name:     "end",
+			opts:     []yaml.EncodeOption{yaml.WithDocumentEnd()},
+			expected: "a: 1\n...\n---\nb: 2\n...\n",
+		},
+		{
+			name:     "start and end",
+			opts:     []yaml.EncodeOption{yaml.WithDocumentStart(), yaml.WithDocumentEnd()},
+			expected: "---\na: 1\n...\n---\nb: 2\n...\n",
+		},
+		{
+			name:     "end with directives",
+			opts:     []yaml.EncodeOption{yaml.WithDocumentEnd(), yaml.Directives("%YAML 1.2")},
+			expected: "%YAML 1.2\n---\na: 1\n...\n%YAML 1.2\n---\nb: 2\n...\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf, test.opts...)
+			for _, v := range []map[string]int{{"a": 1}, {"b": 2}} {
+				if err := enc.Encode(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := buf.String(); got != test.expected {
+				t.Fatalf("unexpected output:\nexpected:%q\ngot:%q", test.expected, got)
+			}
+			dec := yaml.NewDecoder(&buf)
+			var docs int
+			for {
+				var v map[string]int
+				if err := dec.Decode(&v); err != nil {
+					if err == io.EOF {
+						break
+					}
+					t.Fatal(err)
+				}
+				docs++
+			}
+			if docs != 2 {
+				t.Fatalf("unexpected number of documents: %d", docs)
+			}
+		})
+	}
+	t.Run("marshal", func(t *testing.T) {
+		b, err := yaml.MarshalWithOptions(map[string]int{"a": 1}, yaml.WithDocumentStart(), yaml.WithDocumentEnd())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "---\na: 1\n...\n"; string(b) != expected {
+			t.Fatalf("unexpected output: %q", b)
+		}
+	})
+}
+
+func TestFillTemplate(t *testing.T) {
+	type database struct {
+		Host     string  `yaml:"host,omitempty"`
+		Port     int     `yaml:"port,omitempty"`
+		Password *string `yaml:"password"`
+	}
+	type config struct {
+		Name     string                 `yaml:"name"`
+		Database *database              `yaml:"database"`
+		Tags     []string               `yaml:"tags,omitempty"`
+		Extra    map[string]interface{} `yaml:"extra"`
+	}
+	template := `# Sample configuration.
+
+# name of the application
+name: sample # change me
+database:
+  # database host
+  host: localhost
+  port: 5432 # default port
+  # password: secret
+tags: [a, b]
+extra:
+  debug: false
+`
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{
+			name: "fill values",
+			value: config{
+				Name:     "app",
+				Database: &database{Host: "db.example.com"},
+				Tags:     []string{"x"},
+			},
+			expected: `# Sample configuration.
+
+# name of the application
+name: app # change me
+database:
+  # database host
+  host: db.example.com
+  port: 5432 # default port
+  # password: secret
+tags:
+- x
+extra:
+  debug: false
+`,
+		},
+		{
+			name:  "add keys",
+			value: map[string]interface{}{"extra": map[string]interface{}{"debug": true, "level": 1}},
+			expected: `# Sample configuration.
+
+# name of the application
+name: sample # change me
+database:
+  # database host
+  host: localhost
+  port: 5432 # default port
+  # password: secret
+tags: [a, b]
+extra:
+  debug: true
+  level: 1
+`,
+		},
+		{
+			name:     "nil",
+			value:    nil,
+			expected: template,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := yaml.FillTemplate([]byte(template), test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.expected {
+				t.Fatalf("unexpected output.\nexpected:\n%s\ngot:\n%s", test.expected, got)
+			}
+		})
+	}
+	t.Run("empty template", func(t *testing.T) {
+		for _, template := range []string{"", "\n"} {
+			got, err := yaml.FillTemplate([]byte(template), map[string]int{"a": 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "a: 1\n" {
+				t.Fatalf("unexpected output for %q: %q", template, got)
+			}
+		}
+	})
+}
+
+func TestEncoder_BigNumber(t *testing.T) {
+	type bigValues struct {
+		Int      *big.Int   `yaml:"int"`
+		Float    *big.Float `yaml:"float"`
+		Rat      *big.Rat   `yaml:"rat"`
+		IntRat   *big.Rat   `yaml:"intRat"`
+		Inf      *big.Float `yaml:"inf"`
+		IntValue big.Int    `yaml:"intValue"`
+		Nil      *big.Int   `yaml:"nil"`
+	}
+	i, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	v := bigValues{
+		Int:    i,
+		Float:  big.NewFloat(2),
+		Rat:    big.NewRat(1, 3),
+		IntRat: big.NewRat(4, 2),
+		Inf:    new(big.Float).SetInf(true),
+	}
+	v.IntValue.SetInt64(-5)
+
+	tests := []struct {
+		name     string
+		opts     []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name: "block",
+			expected: `int: 123456789012345678901234567890
+float: 2.0
+rat: 1/3
+intRat: 2
+inf: -.inf
+intValue: -5
+nil: null
+`,
+		},
+		{
+			name:     "json",
+			opts:     []yaml.EncodeOption{yaml.JSON()},
+			expected: `{"int": 123456789012345678901234567890, "float": 2.0, "rat": "1/3", "intRat": 2, "inf": -.inf, "intValue": -5, "nil": null}` + "\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := yaml.MarshalWithOptions(v, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", test.expected, b)
+			}
+		})
+	}
+	t.Run("round trip", func(t *testing.T) {
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bigValues
+		if err := yaml.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Int.Cmp(v.Int) != 0 || got.Float.Cmp(v.Float) != 0 || got.Rat.Cmp(v.Rat) != 0 ||
+			got.IntRat.Cmp(v.IntRat) != 0 || !got.Inf.IsInf() || got.IntValue.Cmp(&v.IntValue) != 0 || got.Nil != nil {
+			t.Fatalf("unexpected value: %+v", got)
+		}
+	})
+}
+
+func TestEncoder_PlainScalarResolver(t *testing.T) {
+	v := map[string]string{"a": "yes", "b": "0b101", "c": "hello", "d": "1_000"}
+	b, err := yaml.MarshalWithOptions(v, yaml.EncodePlainScalarResolver(resolver.YAML11().Resolve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `a: "yes"
+b: "0b101"
+c: hello
+d: "1_000"
+`
+	if string(b) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, b)
+	}
+}
+
+func TestEncoder_ForceQuoteStrings(t *testing.T) {
+	type T struct {
+		Country string   `yaml:"country"`
+		Version string   `yaml:"version"`
+		Commits []string `yaml:"commits"`
+		Name    string   `yaml:"name"`
+	}
+	v := T{
+		Country: "NO",
+		Version: "v1",
+		Commits: []string{"a1b2c3d", "abc"},
+		Name:    "web",
+	}
+	var paths []string
+	b, err := yaml.MarshalWithOptions(v, yaml.ForceQuoteStrings(func(s, path string) bool {
+		paths = append(paths, path)
+		return s == "NO" || path == "$.version" || strings.HasPrefix(path, "$.commits[0]")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `country: "NO"
+version: "v1"
+commits:
+- "a1b2c3d"
+- abc
+name: web
+`
+	if string(b) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, b)
+	}
+	if !reflect.DeepEqual(paths[:4], []string{"$.country", "$", "$.version", "$"}) {
+		t.Fatalf("unexpected paths %v", paths)
+	}
+}
+
+func TestEncoder_TimeLayout(t *testing.T) {
+	type Date time.Time
+	type Timestamp struct{ time.Time }
+	date := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	v := struct {
+		CreatedAt time.Time  `yaml:"created_at,layout=2006-01-02 15:04"`
+		Ptr       *time.Time `yaml:"ptr,layout=RFC1123"`
+		Year      time.Time  `yaml:"year,layout=2006"`
+		Nil       *time.Time `yaml:"nil,layout=2006"`
+		Date      Date       `yaml:"date"`
+		Stamp     Timestamp  `yaml:"stamp"`
+	}{
+		CreatedAt: date,
+		Ptr:       &date,
+		Year:      date,
+		Date:      Date(date),
+		Stamp:     Timestamp{Time: date},
+	}
+	tests := []struct {
+		name     string
+		options  []yaml.EncodeOption
+		expected string
+	}{
+		{
+			name: "block",
+			expected: `created_at: 2024-03-05 14:30
+ptr: Tue, 05 Mar 2024 14:30:00 UTC
+year: "2024"
+nil: null
+date: 2024-03-05T14:30:00Z
+stamp: 2024-03-05T14:30:00Z
+`,
+		},
+		{
+			name:    "json",
+			options: []yaml.EncodeOption{yaml.JSON()},
+			expected: `{"created_at": "2024-03-05 14:30", "ptr": "Tue, 05 Mar 2024 14:30:00 UTC", "year": "2024", "nil": null, "date": "2024-03-05T14:30:00Z", "stamp": "2024-03-05T14:30:00Z"}
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := yaml.MarshalWithOptions(v, test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != test.expected {
+				t.Fatalf("expected:\n%s\nbut got:\n%s", test.expected, string(b))
+			}
+		})
+	}
+}
@@ -14,17 +14,39 @@ var (
 	ErrNotFoundNode               = errors.New("node not found")
 	ErrUnknownCommentPositionType = errors.New("unknown comment position type")
 	ErrInvalidCommentMapValue     = errors.New("invalid comment map value. it must be not nil value")
+	ErrInvalidSourceMapValue      = errors.New("invalid source map value. it must be not nil pointer")
 	ErrDecodeRequiredPointerType  = errors.New("required pointer type value")
 	ErrExceededMaxDepth           = errors.New("exceeded max depth")
+	ErrForwardAlias               = errors.New("alias is referenced before anchor definition")
+	ErrUndefinedAnchor            = errors.New("alias is referenced without anchor definition")
+	ErrExceededMaxDocumentBytes   = errors.New("exceeded max document bytes")
+	ErrNULByte                    = errors.New("NUL byte is not allowed")
+	ErrIncludeCycle               = errors.New("include cycle")
+	ErrInvalidOption              = errors.New("invalid option")
+	ErrConflictingOptions         = errors.New("conflicting options")
+	ErrMultipleDocuments          = errors.New("multiple documents")
 )
 
+// errJSONNotSupported is returned for the string option of the json tag by HonorJSONTagOptions
+// in the build with the yaml_lean tag, which doesn't depend on encoding/json.
+var errJSONNotSupported = errors.New("the string option of the json tag is not supported in the yaml_lean build")
+
 type (
 	SyntaxError             = errors.SyntaxError
 	TypeError               = errors.TypeError
 	OverflowError           = errors.OverflowError
+	PrecisionError          = errors.PrecisionError
 	DuplicateKeyError       = errors.DuplicateKeyError
 	UnknownFieldError       = errors.UnknownFieldError
+	MergeKeyOverrideError   = errors.MergeKeyOverrideError
+	AnchorRedefinitionError = errors.AnchorRedefinitionError
+	AnchorCollisionError    = errors.AnchorCollisionError
+	SequenceElementError    = errors.SequenceElementError
+	MergedValueError        = errors.MergedValueError
+	ExpansionError          = errors.ExpansionError
+	IncludeError            = errors.IncludeError
 	UnexpectedNodeTypeError = errors.UnexpectedNodeTypeError
+	MultiError              = errors.MultiError
 )
 
 func ErrUnsupportedHeadPositionType(node ast.Node) error {
@@ -39,6 +61,24 @@ func ErrUnsupportedFootPositionType(node ast.Node) error {
 	return fmt.Errorf("unsupported comment foot position for %s", node.Type())
 }
 
+func ErrOptionConflict(option, other string) error {
+	return fmt.Errorf("%w: %s can't be used with %s", ErrConflictingOptions, option, other)
+}
+
+func ErrAliasBeforeAnchor(name string) error {
+	return fmt.Errorf("%w: anchor &%s is defined after alias *%s", ErrForwardAlias, name, name)
+}
+
+// ErrAliasWithoutAnchor returns the error wrapping ErrUndefinedAnchor for the alias referring to the anchor not defined in the document.
+func ErrAliasWithoutAnchor(name string) error {
+	return fmt.Errorf("%w: anchor &%s is not defined for alias *%s", ErrUndefinedAnchor, name, name)
+}
+
+// ErrInvalidAnchorNameValue returns the error wrapping ast.ErrInvalidAnchorName for the name not allowed in YAML.
+func ErrInvalidAnchorNameValue(name string) error {
+	return fmt.Errorf("%w %q: it must not be empty or contain white spaces and flow indicators", ast.ErrInvalidAnchorName, name)
+}
+
 // IsInvalidQueryError whether err is ErrInvalidQuery or not.
 func IsInvalidQueryError(err error) bool {
 	return errors.Is(err, ErrInvalidQuery)
@@ -73,3 +113,13 @@ func IsInvalidAnchorNameError(err error) bool {
 func IsInvalidAliasNameError(err error) bool {
 	return errors.Is(err, ast.ErrInvalidAliasName)
 }
+
+// IsForwardAliasError whether err is ErrForwardAlias or not.
+func IsForwardAliasError(err error) bool {
+	return errors.Is(err, ErrForwardAlias)
+}
+
+// IsUndefinedAnchorError whether err is ErrUndefinedAnchor or not.
+func IsUndefinedAnchorError(err error) bool {
+	return errors.Is(err, ErrUndefinedAnchor)
+}
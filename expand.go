@@ -0,0 +1,217 @@
+package yaml
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/token"
+)
+
+// ExpandEnv is the scalar expansion hook for WithScalarExpansion expanding the environment variables.
+// It's the same as LookupExpander(os.LookupEnv).
+func ExpandEnv(path, raw string) (string, error) {
+	return LookupExpander(os.LookupEnv)(path, raw)
+}
+
+// LookupExpander returns the scalar expansion hook for WithScalarExpansion expanding the variables looked up by lookup.
+// The following forms are supported, and `$$` is written as `$`.
+//
+//	${VAR}          the value of VAR. It's an error if VAR is not set.
+//	${VAR:-default} the value of VAR, or default if VAR is not set or empty.
+//	${VAR-default}  the value of VAR, or default if VAR is not set.
+//	${VAR:?message} the value of VAR. It's an error with message if VAR is not set or empty.
+//
+// `$VAR` without the braces is written as it is, so the values like the password hashes are not broken.
+func LookupExpander(lookup func(name string) (string, bool)) func(path, raw string) (string, error) {
+	return func(_, raw string) (string, error) {
+		if !strings.Contains(raw, "$") {
+			return raw, nil
+		}
+		var b strings.Builder
+		for i := 0; i < len(raw); i++ {
+			c := raw[i]
+			if c != '$' || i+1 >= len(raw) {
+				b.WriteByte(c)
+				continue
+			}
+			switch raw[i+1] {
+			case '$':
+				b.WriteByte('$')
+				i++
+				continue
+			case '{':
+			default:
+				b.WriteByte(c)
+				continue
+			}
+			end := strings.IndexByte(raw[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference %q", raw[i:])
+			}
+			value, err := expandVariable(raw[i+2:i+end], lookup)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += end
+		}
+		return b.String(), nil
+	}
+}
+
+// expandVariable expands the variable reference expr written in the braces.
+func expandVariable(expr string, lookup func(string) (string, bool)) (string, error) {
+	name, op, arg := expr, "", ""
+	if idx := strings.IndexAny(expr, ":-?"); idx >= 0 {
+		name = expr[:idx]
+		rest := expr[idx:]
+		for _, prefix := range []string{":-", ":?", "-"} {
+			if strings.HasPrefix(rest, prefix) {
+				op, arg = prefix, rest[len(prefix):]
+				break
+			}
+		}
+		if op == "" {
+			return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+	}
+	value, exists := lookup(name)
+	switch op {
+	case ":-":
+		if value == "" {
+			return arg, nil
+		}
+	case "-":
+		if !exists {
+			return arg, nil
+		}
+	case ":?":
+		if value == "" {
+			if arg == "" {
+				arg = "not set or empty"
+			}
+			return "", fmt.Errorf("variable %s: %s", name, arg)
+		}
+	default:
+		if !exists {
+			return "", fmt.Errorf("variable %s is not set", name)
+		}
+	}
+	return value, nil
+}
+
+// expandScalars applies the scalar expansion hook to the string scalars of node, and returns the replaced node.
+// The expanded plain scalar is typed again, so `${PORT}` can be decoded into the integer.
+// The keys, the aliases and the tagged scalars other than !!str are not expanded.
+func (d *Decoder) expandScalars(node ast.Node) (ast.Node, error) {
+	switch n := node.(type) {
+	case *ast.DocumentNode:
+		body, err := d.expandScalars(n.Body)
+		if err != nil {
+			return nil, err
+		}
+		n.Body = body
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			if _, err := d.expandScalars(value); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.MappingValueNode:
+		value, err := d.expandScalars(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value = value
+	case *ast.SequenceNode:
+		for idx, value := range n.Values {
+			v, err := d.expandScalars(value)
+			if err != nil {
+				return nil, err
+			}
+			n.Values[idx] = v
+		}
+	case *ast.AnchorNode:
+		value, err := d.expandScalars(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value = value
+	case *ast.TagNode:
+		if token.ReservedTagKeyword(n.Start.Value) != token.StringTag {
+			if _, ok := n.Value.(ast.ScalarNode); ok {
+				return n, nil
+			}
+		}
+		value, err := d.expandScalars(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value = value
+	case *ast.LiteralNode:
+		expanded, err := d.expandScalar(n.GetPath(), n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value.Value = expanded
+	case *ast.StringNode:
+		expanded, err := d.expandScalar(n.GetPath(), n)
+		if err != nil {
+			return nil, err
+		}
+		if expanded == n.Value {
+			return n, nil
+		}
+		if n.Token.Type != token.StringType {
+			// the quoted scalar is always the string.
+			n.Value = expanded
+			return n, nil
+		}
+		return newExpandedNode(n, expanded), nil
+	}
+	return node, nil
+}
+
+func (d *Decoder) expandScalar(path string, n *ast.StringNode) (string, error) {
+	expanded, err := d.scalarExpansion(path, n.Value)
+	if err != nil {
+		return "", errors.ErrExpansion(path, n.GetToken(), err)
+	}
+	return expanded, nil
+}
+
+// newExpandedNode creates the scalar node of the expanded value of the plain scalar n, typed by the expanded value.
+func newExpandedNode(n *ast.StringNode, expanded string) ast.Node {
+	pos := *n.Token.Position
+	tk := token.New(expanded, expanded, &pos)
+	tk.Prev, tk.Next = n.Token.Prev, n.Token.Next
+	var node ast.ScalarNode
+	switch tk.Type {
+	case token.NullType:
+		node = ast.Null(tk)
+	case token.BoolType:
+		node = ast.Bool(tk)
+	case token.IntegerType, token.BinaryIntegerType, token.OctetIntegerType, token.HexIntegerType:
+		node = ast.Integer(tk)
+	case token.FloatType:
+		node = ast.Float(tk)
+	case token.InfinityType:
+		node = ast.Infinity(tk)
+	case token.NanType:
+		node = ast.Nan(tk)
+	default:
+		tk.Type = token.StringType
+		node = ast.String(tk)
+	}
+	node.SetPath(n.GetPath())
+	if comment := n.GetComment(); comment != nil {
+		_ = node.SetComment(comment)
+	}
+	return node
+}
@@ -0,0 +1,239 @@
+package yaml
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+var (
+	stringType             = reflect.TypeOf("")
+	interfaceType          = reflect.TypeOf((*interface{})(nil)).Elem()
+	mapStringStringType    = reflect.TypeOf(map[string]string(nil))
+	mapStringInterfaceType = reflect.TypeOf(map[string]interface{}(nil))
+	stringSliceType        = reflect.TypeOf([]string(nil))
+	interfaceSliceType     = reflect.TypeOf([]interface{}(nil))
+)
+
+// canUseFastPath returns whether the values of elemType are decoded without the reflect.Value for each entry.
+// The custom unmarshaler registered for the type needs the generic path.
+func (d *Decoder) canUseFastPath(elemType reflect.Type) bool {
+	return !d.existsTypeInCustomUnmarshalerMap(reflect.PointerTo(elemType))
+}
+
+// isFastPathNode returns whether node is decoded by the fast path.
+// The anchors and the aliases are decoded by the generic path to share the values with the other aliases.
+func isFastPathNode(node ast.Node) bool {
+	switch node.Type() {
+	case ast.AnchorType, ast.AliasType:
+		return false
+	}
+	return true
+}
+
+// decodeMapFast decodes mapNode into map[string]string or map[string]interface{} by the type switch.
+// It returns false if dst is not such a map or mapNode has the merge key.
+func (d *Decoder) decodeMapFast(ctx context.Context, dst reflect.Value, mapNode ast.MapNode) (bool, error) {
+	var elemType reflect.Type
+	switch dst.Type() {
+	case mapStringStringType:
+		elemType = stringType
+	case mapStringInterfaceType:
+		elemType = interfaceType
+	default:
+		return false, nil
+	}
+	if !d.canUseFastPath(stringType) || !d.canUseFastPath(elemType) {
+		return false, nil
+	}
+	for iter := mapNode.MapRange(); iter.Next(); {
+		if iter.Key().IsMergeKey() {
+			return false, nil
+		}
+	}
+	var (
+		strMap   map[string]string
+		ifaceMap map[string]interface{}
+	)
+	if elemType == stringType {
+		strMap = map[string]string{}
+	} else {
+		ifaceMap = map[string]interface{}{}
+	}
+	keyMap := map[string]struct{}{}
+	var foundErr error
+	for iter := mapNode.MapRange(); iter.Next(); {
+		keyNode := iter.Key()
+		k, isNull, err := d.fastMapKey(ctx, keyNode)
+		if err != nil {
+			return true, err
+		}
+		if !isNull {
+			if err := d.validateDuplicateKey(keyMap, k, keyNode); err != nil {
+				return true, err
+			}
+		}
+		value := iter.Value()
+		if strMap != nil {
+			v, err := d.fastString(ctx, value)
+			if err != nil {
+				foundErr = d.appendError(foundErr, err)
+				// the key of the value failed to decode isn't set like the generic path.
+				delete(strMap, k)
+				continue
+			}
+			strMap[k] = v
+			continue
+		}
+		v, err := d.fastInterface(ctx, value)
+		if err != nil {
+			foundErr = d.appendError(foundErr, err)
+			delete(ifaceMap, k)
+			continue
+		}
+		ifaceMap[k] = v
+	}
+	if strMap != nil {
+		dst.Set(reflect.ValueOf(strMap))
+	} else {
+		dst.Set(reflect.ValueOf(ifaceMap))
+	}
+	return true, foundErr
+}
+
+// decodeSliceFast decodes arrayNode into []string or []interface{} by the type switch.
+// It returns false if dst is not such a slice.
+func (d *Decoder) decodeSliceFast(ctx context.Context, dst reflect.Value, arrayNode ast.ArrayNode) (bool, error) {
+	var elemType reflect.Type
+	switch dst.Type() {
+	case stringSliceType:
+		elemType = stringType
+	case interfaceSliceType:
+		elemType = interfaceType
+	default:
+		return false, nil
+	}
+	if !d.canUseFastPath(elemType) {
+		return false, nil
+	}
+	iter := arrayNode.ArrayRange()
+	var (
+		strSlice   []string
+		ifaceSlice []interface{}
+	)
+	if elemType == stringType {
+		strSlice = make([]string, 0, iter.Len())
+	} else {
+		ifaceSlice = make([]interface{}, 0, iter.Len())
+	}
+	var foundErr error
+	for idx := 0; iter.Next(); idx++ {
+		value := iter.Value()
+		elemCtx := withSequenceElement(ctx, idx, value)
+		if strSlice != nil {
+			v, err := d.fastString(elemCtx, value)
+			if err != nil {
+				foundErr = d.appendError(foundErr, d.elementError(idx, value, err))
+				continue
+			}
+			strSlice = append(strSlice, v)
+			continue
+		}
+		v, err := d.fastInterface(elemCtx, value)
+		if err != nil {
+			foundErr = d.appendError(foundErr, d.elementError(idx, value, err))
+			continue
+		}
+		ifaceSlice = append(ifaceSlice, v)
+	}
+	if strSlice != nil {
+		dst.Set(reflect.ValueOf(strSlice))
+	} else {
+		dst.Set(reflect.ValueOf(ifaceSlice))
+	}
+	return true, foundErr
+}
+
+// fastMapKey returns the string key of the map and whether the key is null, which is set as the empty string.
+// The key which isn't a string scalar is converted by the generic path.
+func (d *Decoder) fastMapKey(ctx context.Context, node ast.Node) (string, bool, error) {
+	if keyNode, ok := node.(*ast.MappingKeyNode); ok {
+		node = keyNode.Value
+	}
+	if isFastPathNode(node) {
+		v, err := d.nodeToValue(node)
+		if err != nil {
+			return "", false, err
+		}
+		if s, ok := v.(string); ok {
+			return s, false, nil
+		}
+	}
+	k, err := d.createMapKey(ctx, stringType, node)
+	if err != nil {
+		return "", false, err
+	}
+	if !k.IsValid() {
+		return "", true, nil
+	}
+	return k.String(), false, nil
+}
+
+// fastString returns the string decoded from node in the same way as the generic path.
+func (d *Decoder) fastString(ctx context.Context, node ast.Node) (string, error) {
+	if !isFastPathNode(node) {
+		v, err := d.createDecodedNewValue(ctx, stringType, reflect.Value{}, node)
+		if err != nil {
+			return "", err
+		}
+		return v.String(), nil
+	}
+	if d.isNullNode(node) {
+		return "", nil
+	}
+	v, err := d.nodeToValue(node)
+	if err != nil {
+		return "", err
+	}
+	switch vv := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return vv, nil
+	case Number:
+		return string(vv), nil
+	case int:
+		return strconv.Itoa(vv), nil
+	case int64:
+		return strconv.FormatInt(vv, 10), nil
+	case uint64:
+		return strconv.FormatUint(vv, 10), nil
+	case float64:
+		return fmt.Sprint(vv), nil
+	case bool:
+		return strconv.FormatBool(vv), nil
+	}
+	converted, err := d.convertValue(reflect.ValueOf(v), stringType, node)
+	if err != nil {
+		return "", err
+	}
+	return converted.String(), nil
+}
+
+// fastInterface returns the value decoded from node into interface{} in the same way as the generic path.
+func (d *Decoder) fastInterface(ctx context.Context, node ast.Node) (interface{}, error) {
+	if !isFastPathNode(node) {
+		v, err := d.createDecodedNewValue(ctx, interfaceType, reflect.Value{}, node)
+		if err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+	if d.isNullNode(node) {
+		return nil, nil
+	}
+	return d.nodeToValue(node)
+}
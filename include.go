@@ -0,0 +1,247 @@
+package yaml
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// IncludeTag is the tag to include the other file by ResolveIncludes ( e.g. `!include other.yaml` ).
+const IncludeTag = "!include"
+
+// RefKey is the key of the JSON Reference style mapping resolved by ResolveIncludes ( e.g. `$ref: ./fragment.yaml#/path` ).
+const RefKey = "$ref"
+
+// IncludeLoader loads the files included by ResolveIncludes.
+// name is the reference without the fragment, joined with the directory of the including file if it's relative.
+type IncludeLoader interface {
+	Load(name string) ([]byte, error)
+}
+
+// IncludeLoaderFunc is the function implementing IncludeLoader.
+// It can be used to load the files from the other sources like HTTP.
+type IncludeLoaderFunc func(name string) ([]byte, error)
+
+// Load calls f(name).
+func (f IncludeLoaderFunc) Load(name string) ([]byte, error) {
+	return f(name)
+}
+
+// FSIncludeLoader returns the IncludeLoader reading the files from fsys, like os.DirFS or embed.FS.
+func FSIncludeLoader(fsys fs.FS) IncludeLoader {
+	return IncludeLoaderFunc(func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, strings.TrimPrefix(name, "/"))
+	})
+}
+
+// includeResolver replaces the references in the document with the nodes loaded by the loader.
+type includeResolver struct {
+	loader IncludeLoader
+	// stack is the references being resolved, to detect the cycle.
+	stack []string
+}
+
+// includeFile is the file having the references to be resolved.
+type includeFile struct {
+	name string
+	root ast.Node
+}
+
+func newIncludeResolver(loader IncludeLoader) *includeResolver {
+	return &includeResolver{loader: loader}
+}
+
+// resolve replaces the references in node of file, and returns the replaced node.
+func (r *includeResolver) resolve(file *includeFile, node ast.Node) (ast.Node, error) {
+	switch n := node.(type) {
+	case *ast.DocumentNode:
+		body, err := r.resolve(&includeFile{name: file.name, root: n.Body}, n.Body)
+		if err != nil {
+			return nil, err
+		}
+		n.Body = body
+	case *ast.MappingNode:
+		if len(n.Values) == 1 {
+			if ref, tk, ok := refValue(n.Values[0]); ok {
+				return r.resolveRef(file, ref, tk)
+			}
+		}
+		for _, value := range n.Values {
+			if _, err := r.resolve(file, value); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.MappingValueNode:
+		if ref, tk, ok := refValue(n); ok {
+			return r.resolveRef(file, ref, tk)
+		}
+		value, err := r.resolve(file, n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value = value
+	case *ast.SequenceNode:
+		for idx, value := range n.Values {
+			v, err := r.resolve(file, value)
+			if err != nil {
+				return nil, err
+			}
+			n.Values[idx] = v
+		}
+	case *ast.AnchorNode:
+		value, err := r.resolve(file, n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value = value
+	case *ast.TagNode:
+		if n.Start.Value == IncludeTag {
+			ref, ok := n.Value.(*ast.StringNode)
+			if !ok {
+				return nil, errors.ErrSyntax(fmt.Sprintf("the value of %s must be the file name", IncludeTag), n.GetToken())
+			}
+			return r.resolveRef(file, ref.Value, ref.GetToken())
+		}
+		value, err := r.resolve(file, n.Value)
+		if err != nil {
+			return nil, err
+		}
+		n.Value = value
+	}
+	return node, nil
+}
+
+// refValue returns the reference of the mapping value whose key is RefKey.
+func refValue(n *ast.MappingValueNode) (string, *token.Token, bool) {
+	if n.Key == nil || n.Key.GetToken() == nil || n.Key.GetToken().Value != RefKey {
+		return "", nil, false
+	}
+	value, ok := n.Value.(*ast.StringNode)
+	if !ok {
+		return "", nil, false
+	}
+	return value.Value, value.GetToken(), true
+}
+
+// resolveRef returns the node referenced by ref written at tk in file.
+// ref is the file name and the JSON Pointer as the fragment ( e.g. `other.yaml#/a/0` ).
+// If the file name is empty, the fragment refers to the same file.
+func (r *includeResolver) resolveRef(file *includeFile, ref string, tk *token.Token) (ast.Node, error) {
+	name, fragment, _ := strings.Cut(ref, "#")
+	target := file
+	if name != "" {
+		name = joinIncludeName(file.name, name)
+		root, err := r.load(name)
+		if err != nil {
+			return nil, errors.ErrInclude(ref, tk, err)
+		}
+		target = &includeFile{name: name, root: root}
+	}
+	key := target.name + "#" + fragment
+	for idx, resolving := range r.stack {
+		if resolving == key {
+			cycle := append(append([]string{}, r.stack[idx:]...), key)
+			return nil, errors.ErrInclude(ref, tk, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(cycle, " -> ")))
+		}
+	}
+	node, err := lookupJSONPointer(target.root, fragment)
+	if err != nil {
+		return nil, errors.ErrInclude(ref, tk, err)
+	}
+	r.stack = append(r.stack, key)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	resolved, err := r.resolve(target, node)
+	if err != nil {
+		if target != file {
+			return nil, errors.ErrInclude(ref, tk, err)
+		}
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// load loads and parses the file of name, and returns the body of the first document.
+func (r *includeResolver) load(name string) (ast.Node, error) {
+	src, err := r.loader.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range f.Docs {
+		if _, ok := doc.Body.(*ast.DirectiveNode); ok {
+			continue
+		}
+		if doc.Body != nil {
+			return doc.Body, nil
+		}
+	}
+	return ast.Null(token.New("null", "null", &token.Position{Line: 1, Column: 1})), nil
+}
+
+// joinIncludeName returns the name of the file referenced as name from the file of base.
+func joinIncludeName(base, name string) string {
+	if strings.Contains(name, "://") || path.IsAbs(name) {
+		return name
+	}
+	if strings.Contains(base, "://") {
+		if idx := strings.LastIndex(base, "/"); idx >= 0 {
+			return base[:idx+1] + name
+		}
+	}
+	return path.Join(path.Dir(base), name)
+}
+
+// lookupJSONPointer returns the node referenced by the JSON Pointer pointer ( e.g. `/a/0` ) from root.
+func lookupJSONPointer(root ast.Node, pointer string) (ast.Node, error) {
+	if pointer == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q: %w", pointer, ErrInvalidPathString)
+	}
+	node := root
+	for _, part := range strings.Split(pointer[1:], "/") {
+		key := strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		next, err := lookupJSONPointerToken(node, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %q of %s: %w", key, pointer, err)
+		}
+		node = next
+	}
+	return node, nil
+}
+
+func lookupJSONPointerToken(node ast.Node, key string) (ast.Node, error) {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		return lookupJSONPointerToken(n.Value, key)
+	case *ast.TagNode:
+		return lookupJSONPointerToken(n.Value, key)
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			if value.Key.GetToken() != nil && value.Key.GetToken().Value == key {
+				return value.Value, nil
+			}
+		}
+	case *ast.MappingValueNode:
+		if n.Key.GetToken() != nil && n.Key.GetToken().Value == key {
+			return n.Value, nil
+		}
+	case *ast.SequenceNode:
+		idx, err := strconv.Atoi(key)
+		if err == nil && idx >= 0 && idx < len(n.Values) {
+			return n.Values[idx], nil
+		}
+	}
+	return nil, ErrNotFoundNode
+}
@@ -0,0 +1,62 @@
+// Package astutil provides the helpers to edit the mappings and the sequences of the AST,
+// shared by the merge and patch packages.
+package astutil
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// KeyText returns the text of the map key to compare the keys.
+// The scalar keys are compared by the values, so the quoted and plain keys having the same text are the same.
+func KeyText(key ast.MapKeyNode) string {
+	switch k := key.(type) {
+	case *ast.MappingKeyNode:
+		if value, ok := k.Value.(ast.MapKeyNode); ok {
+			return KeyText(value)
+		}
+		return k.Value.String()
+	case ast.ScalarNode:
+		if _, ok := k.(*ast.LiteralNode); !ok {
+			return fmt.Sprint(k.GetValue())
+		}
+	}
+	return key.String()
+}
+
+// FindKey returns the index of the value of mapping having key, or -1 if it's not found.
+func FindKey(mapping *ast.MappingNode, key string) int {
+	for idx, value := range mapping.Values {
+		if KeyText(value.Key) == key {
+			return idx
+		}
+	}
+	return -1
+}
+
+// InsertEntry inserts entry with the head comment at idx of the sequence
+// keeping the head comments and the blank lines aligned with the entries.
+func InsertEntry(seq *ast.SequenceNode, idx int, entry ast.Node, comment *ast.CommentGroupNode) {
+	if len(seq.ValueHeadComments) == len(seq.Values) || comment != nil {
+		for len(seq.ValueHeadComments) < len(seq.Values) {
+			seq.ValueHeadComments = append(seq.ValueHeadComments, nil)
+		}
+		seq.ValueHeadComments = append(seq.ValueHeadComments[:idx], append([]*ast.CommentGroupNode{comment}, seq.ValueHeadComments[idx:]...)...)
+	}
+	if len(seq.ValueBlankLines) == len(seq.Values) {
+		seq.ValueBlankLines = append(seq.ValueBlankLines[:idx], append([]bool{false}, seq.ValueBlankLines[idx:]...)...)
+	}
+	seq.Values = append(seq.Values[:idx], append([]ast.Node{entry}, seq.Values[idx:]...)...)
+}
+
+// RemoveEntry removes the entry at idx from the sequence keeping the head comments and the blank lines aligned with the entries.
+func RemoveEntry(seq *ast.SequenceNode, idx int) {
+	if len(seq.ValueHeadComments) == len(seq.Values) {
+		seq.ValueHeadComments = append(seq.ValueHeadComments[:idx], seq.ValueHeadComments[idx+1:]...)
+	}
+	if len(seq.ValueBlankLines) == len(seq.Values) {
+		seq.ValueBlankLines = append(seq.ValueBlankLines[:idx], seq.ValueBlankLines[idx+1:]...)
+	}
+	seq.Values = append(seq.Values[:idx], seq.Values[idx+1:]...)
+}
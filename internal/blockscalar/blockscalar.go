@@ -0,0 +1,95 @@
+// Package blockscalar provides the helpers to write the strings as the block scalars,
+// shared by the encoder and the emitter.
+package blockscalar
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// IsIndentDetectable returns whether the parser can detect the indentation of the block scalar having s.
+// The block scalar having only the line breaks is decoded as the empty string,
+// and the first line starting with the tab is rejected by the parser.
+func IsIndentDetectable(s string) bool {
+	if s == "" {
+		return true
+	}
+	return strings.TrimLeft(s, "\n") != "" && s[0] != '\t'
+}
+
+// FoldedLines returns the lines of the folded block scalar representing lines exactly.
+// The line breaks between the lines not starting with the white space are folded into the space by the parser,
+// so the empty line is added to keep them.
+// If width is positive, the lines longer than width are folded at the spaces.
+func FoldedLines(lines []string, width int) []string {
+	folded := make([]string, 0, len(lines))
+	lastText := ""
+	for _, line := range lines {
+		if line != "" {
+			if lastText != "" && !isSpacedLine(lastText) && !isSpacedLine(line) {
+				folded = append(folded, "")
+			}
+			lastText = line
+		}
+		if width > 0 {
+			folded = append(folded, WrapLine(line, width)...)
+		} else {
+			folded = append(folded, line)
+		}
+	}
+	return folded
+}
+
+// WrapLine splits line at the spaces into the lines of at most width characters, if possible.
+// Each space at the split point is removed because it's restored by the folding of the parser.
+// The line starting with the white space is not split, because it's not folded by the parser.
+func WrapLine(line string, width int) []string {
+	remaining := utf8.RuneCountInString(line)
+	if isSpacedLine(line) || remaining <= width {
+		return []string{line}
+	}
+	var (
+		wrapped []string
+		start   int
+	)
+	for remaining > width {
+		split, splitLen := -1, 0
+		// n is the number of the characters of line[start:idx].
+		n := 0
+		for idx := start; idx < len(line)-1; idx++ {
+			if idx > start && line[idx] == ' ' && line[idx+1] != ' ' && line[idx+1] != '\t' {
+				if split >= 0 && n > width {
+					break
+				}
+				split, splitLen = idx, n
+			}
+			if utf8.RuneStart(line[idx]) {
+				n++
+			}
+		}
+		if split < 0 {
+			break
+		}
+		wrapped = append(wrapped, line[start:split])
+		start = split + 1
+		remaining -= splitLen + 1
+	}
+	return append(wrapped, line[start:])
+}
+
+func isSpacedLine(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// IsNeedIndentIndicator returns whether the indentation of the block scalar can't be detected from the first non-empty line.
+func IsNeedIndentIndicator(lines []string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, " ") {
+			return true
+		}
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return false
+}
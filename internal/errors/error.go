@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/printer"
@@ -25,6 +26,34 @@ type PrettyFormatError interface {
 	FormatError(bool, bool) string
 }
 
+// OptionsFormatError is the error formatted by the options of yaml.ErrorPrinter.
+type OptionsFormatError interface {
+	FormatErrorWithOptions(FormatOptions) string
+}
+
+// FormatOptions is the options to format the error with the source.
+type FormatOptions struct {
+	Colored       bool
+	IncludeSource bool
+	// LinesBefore and LinesAfter are the numbers of the lines of the source printed before and after the error lines.
+	LinesBefore int
+	LinesAfter  int
+	// FileName is printed in the position of the error if it's not empty.
+	FileName string
+	// omittedPosition is the position already written by the wrapping error, which is not written again.
+	omittedPosition *token.Position
+}
+
+// defaultFormatOptions returns the options of FormatError printing 3 lines before and after the error lines.
+func defaultFormatOptions(colored, inclSource bool) FormatOptions {
+	return FormatOptions{
+		Colored:       colored,
+		IncludeSource: inclSource,
+		LinesBefore:   3,
+		LinesAfter:    3,
+	}
+}
+
 type SyntaxError struct {
 	Message string
 	Token   *token.Token
@@ -43,6 +72,13 @@ type OverflowError struct {
 	Token   *token.Token
 }
 
+// PrecisionError is the error that the number cannot be represented exactly by the destination type.
+type PrecisionError struct {
+	DstType reflect.Type
+	SrcNum  string
+	Token   *token.Token
+}
+
 type DuplicateKeyError struct {
 	Message string
 	Token   *token.Token
@@ -53,6 +89,101 @@ type UnknownFieldError struct {
 	Token   *token.Token
 }
 
+// MergeKeyOverrideError is the error that the key merged by the merge key is also defined explicitly in the same mapping.
+type MergeKeyOverrideError struct {
+	Key string
+	// MergedToken is the key token in the merged mapping.
+	MergedToken *token.Token
+	// Token is the key token defined explicitly.
+	Token *token.Token
+}
+
+// AnchorRedefinitionError is the error that the anchor name is defined more than once in the same document.
+type AnchorRedefinitionError struct {
+	Name string
+	// PrevToken is the anchor token of the previous definition.
+	PrevToken *token.Token
+	// Token is the anchor token redefining the name.
+	Token *token.Token
+}
+
+// AnchorCollisionError is the error that the encoder defines the anchor name already used in the same document.
+type AnchorCollisionError struct {
+	Name string
+	// PrevPath is the YAMLPath of the value with the previous anchor.
+	PrevPath string
+	// Path is the YAMLPath of the value with the colliding anchor.
+	Path string
+}
+
+// PatchError is the error that occurred while applying the patch operation.
+type PatchError struct {
+	// Op is the name of the operation such as "add" or "remove".
+	Op string
+	// Path is the JSON Pointer of the operation.
+	Path    string
+	Message string
+	// Token is the token of the nearest node to the path. It may be nil.
+	Token *token.Token
+}
+
+// MergeConflictError is the error that the value is changed differently in both sides of the three-way merge.
+type MergeConflictError struct {
+	// Path is the YAMLPath of the conflicting value.
+	Path    string
+	Message string
+	// Token is the token of the value of ours. It may be nil.
+	Token *token.Token
+}
+
+// SequenceElementError is the error that occurred while decoding the element of the sequence.
+// The error of the struct field in the element is written as the message of Err,
+// and the other errors are written with the index and the position of the element.
+type SequenceElementError struct {
+	// Index is the index of the element in the sequence.
+	Index int
+	// Token is the start token of the element.
+	Token *token.Token
+	Err   error
+	// isField is true if Err is the error of the struct field in the element,
+	// which already points at the field, so the message of Err is kept as it is.
+	isField bool
+}
+
+// MergedValueError is the error that occurred while decoding the value merged by the alias of the merge key ( e.g. `<<: *base` ).
+// Err points at the value in the mapping of the anchor, and the positions of the alias and the anchor are also reported.
+type MergedValueError struct {
+	// Alias is the name of the alias.
+	Alias string
+	// AliasToken is the token of the alias used by the merge key.
+	AliasToken *token.Token
+	// AnchorToken is the token of the anchor defining the merged mapping. It may be nil.
+	AnchorToken *token.Token
+	Err         error
+}
+
+// ExpansionError is the error that occurred while expanding the scalar by the scalar expansion hook.
+type ExpansionError struct {
+	// Path is the YAMLPath of the scalar.
+	Path  string
+	Token *token.Token
+	Err   error
+}
+
+// IncludeError is the error that occurred while resolving the included file or the reference.
+type IncludeError struct {
+	// Ref is the reference written in the document like `other.yaml` or `./fragment.yaml#/path`.
+	Ref string
+	// Token is the token of the reference.
+	Token *token.Token
+	Err   error
+}
+
+// MultiError is the list of the errors found in a document, reported when the decoder collects all errors.
+type MultiError struct {
+	Errors []error
+}
+
 type UnexpectedNodeTypeError struct {
 	Actual   ast.NodeType
 	Expected ast.NodeType
@@ -76,6 +207,15 @@ func ErrOverflow(dstType reflect.Type, num string, tk *token.Token) *OverflowErr
 	}
 }
 
+// ErrPrecision creates a precision loss error instance with message and a token.
+func ErrPrecision(dstType reflect.Type, num string, tk *token.Token) *PrecisionError {
+	return &PrecisionError{
+		DstType: dstType,
+		SrcNum:  num,
+		Token:   tk,
+	}
+}
+
 // ErrTypeMismatch cerates an type mismatch error instance with token.
 func ErrTypeMismatch(dstType, srcType reflect.Type, token *token.Token) *TypeError {
 	return &TypeError{
@@ -101,6 +241,113 @@ func ErrUnknownField(msg string, tk *token.Token) *UnknownFieldError {
 	}
 }
 
+// ErrMergeKeyOverride creates a merge key override error instance with the merged key token and the explicit key token.
+func ErrMergeKeyOverride(key string, mergedTk, tk *token.Token) *MergeKeyOverrideError {
+	return &MergeKeyOverrideError{
+		Key:         key,
+		MergedToken: mergedTk,
+		Token:       tk,
+	}
+}
+
+// ErrAnchorRedefinition creates an anchor redefinition error instance with the previous anchor token and the redefining anchor token.
+func ErrAnchorRedefinition(name string, prevTk, tk *token.Token) *AnchorRedefinitionError {
+	return &AnchorRedefinitionError{
+		Name:      name,
+		PrevToken: prevTk,
+		Token:     tk,
+	}
+}
+
+// ErrAnchorCollision creates an anchor collision error instance with the paths of both anchors.
+func ErrAnchorCollision(name, prevPath, path string) *AnchorCollisionError {
+	return &AnchorCollisionError{
+		Name:     name,
+		PrevPath: prevPath,
+		Path:     path,
+	}
+}
+
+// ErrPatch creates a patch error instance with the operation, the path and the token of the nearest node.
+func ErrPatch(op, path, msg string, tk *token.Token) *PatchError {
+	return &PatchError{
+		Op:      op,
+		Path:    path,
+		Message: msg,
+		Token:   tk,
+	}
+}
+
+// ErrMergeConflict creates a merge conflict error instance.
+func ErrMergeConflict(path, msg string, tk *token.Token) *MergeConflictError {
+	return &MergeConflictError{
+		Path:    path,
+		Message: msg,
+		Token:   tk,
+	}
+}
+
+// ErrExpansion creates an expansion error instance wrapping err.
+func ErrExpansion(path string, tk *token.Token, err error) *ExpansionError {
+	return &ExpansionError{
+		Path:  path,
+		Token: tk,
+		Err:   err,
+	}
+}
+
+// ErrInclude creates an include error instance wrapping err.
+func ErrInclude(ref string, tk *token.Token, err error) *IncludeError {
+	return &IncludeError{
+		Ref:   ref,
+		Token: tk,
+		Err:   err,
+	}
+}
+
+// ErrSequenceElement creates a sequence element error instance wrapping err.
+func ErrSequenceElement(idx int, tk *token.Token, err error) *SequenceElementError {
+	return &SequenceElementError{
+		Index: idx,
+		Token: tk,
+		Err:   err,
+	}
+}
+
+// ErrSequenceElementField creates a sequence element error instance wrapping err of the struct field in the element.
+// The message of err is kept, and the index and the position of the element are reported by the fields.
+func ErrSequenceElementField(idx int, tk *token.Token, err error) *SequenceElementError {
+	return &SequenceElementError{
+		Index:   idx,
+		Token:   tk,
+		Err:     err,
+		isField: true,
+	}
+}
+
+// ErrMergedValue creates a merged value error instance wrapping err.
+func ErrMergedValue(alias string, aliasTk, anchorTk *token.Token, err error) *MergedValueError {
+	return &MergedValueError{
+		Alias:       alias,
+		AliasToken:  aliasTk,
+		AnchorToken: anchorTk,
+		Err:         err,
+	}
+}
+
+// ErrMulti returns the error having errs. The errors of MultiError in errs are flattened.
+func ErrMulti(errs ...error) *MultiError {
+	multi := &MultiError{}
+	for _, err := range errs {
+		if m, ok := err.(*MultiError); ok {
+			multi.Errors = append(multi.Errors, m.Errors...)
+			continue
+		}
+		multi.Errors = append(multi.Errors, err)
+	}
+	return multi
+}
+
 func ErrUnexpectedNodeType(actual, expected ast.NodeType, tk *token.Token) *UnexpectedNodeTypeError {
 	return &UnexpectedNodeTypeError{
 		Actual:   actual,
@@ -114,7 +361,11 @@ func (e *SyntaxError) Error() string {
 }
 
 func (e *SyntaxError) FormatError(colored, inclSource bool) string {
-	return formatError(e.Message, e.Token, colored, inclSource)
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *SyntaxError) FormatErrorWithOptions(opts FormatOptions) string {
+	return formatError(e.Message, e.Token, opts)
 }
 
 func (e *OverflowError) Error() string {
@@ -122,7 +373,23 @@ func (e *OverflowError) Error() string {
 }
 
 func (e *OverflowError) FormatError(colored, inclSource bool) string {
-	return formatError(fmt.Sprintf("cannot unmarshal %s into Go value of type %s ( overflow )", e.SrcNum, e.DstType), e.Token, colored, inclSource)
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *OverflowError) FormatErrorWithOptions(opts FormatOptions) string {
+	return formatError(fmt.Sprintf("cannot unmarshal %s into Go value of type %s ( overflow )", e.SrcNum, e.DstType), e.Token, opts)
+}
+
+func (e *PrecisionError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *PrecisionError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *PrecisionError) FormatErrorWithOptions(opts FormatOptions) string {
+	return formatError(fmt.Sprintf("cannot unmarshal %s into Go value of type %s ( precision loss )", e.SrcNum, e.DstType), e.Token, opts)
 }
 
 func (e *TypeError) msg() string {
@@ -137,7 +404,11 @@ func (e *TypeError) Error() string {
 }
 
 func (e *TypeError) FormatError(colored, inclSource bool) string {
-	return formatError(e.msg(), e.Token, colored, inclSource)
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *TypeError) FormatErrorWithOptions(opts FormatOptions) string {
+	return formatError(e.msg(), e.Token, opts)
 }
 
 func (e *DuplicateKeyError) Error() string {
@@ -145,7 +416,11 @@ func (e *DuplicateKeyError) Error() string {
 }
 
 func (e *DuplicateKeyError) FormatError(colored, inclSource bool) string {
-	return formatError(e.Message, e.Token, colored, inclSource)
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *DuplicateKeyError) FormatErrorWithOptions(opts FormatOptions) string {
+	return formatError(e.Message, e.Token, opts)
 }
 
 func (e *UnknownFieldError) Error() string {
@@ -153,7 +428,208 @@ func (e *UnknownFieldError) Error() string {
 }
 
 func (e *UnknownFieldError) FormatError(colored, inclSource bool) string {
-	return formatError(e.Message, e.Token, colored, inclSource)
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *UnknownFieldError) FormatErrorWithOptions(opts FormatOptions) string {
+	return formatError(e.Message, e.Token, opts)
+}
+
+func (e *MergeKeyOverrideError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *MergeKeyOverrideError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *MergeKeyOverrideError) FormatErrorWithOptions(opts FormatOptions) string {
+	msg := fmt.Sprintf("key %q overrides the merged key", e.Key)
+	if e.MergedToken != nil {
+		msg += fmt.Sprintf(" defined at [%d:%d]", e.MergedToken.Position.Line, e.MergedToken.Position.Column)
+	}
+	return formatError(msg, e.Token, opts)
+}
+
+func (e *AnchorRedefinitionError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *AnchorRedefinitionError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *AnchorRedefinitionError) FormatErrorWithOptions(opts FormatOptions) string {
+	msg := fmt.Sprintf("anchor %q is redefined", e.Name)
+	if e.PrevToken != nil {
+		msg += fmt.Sprintf(", previously defined at [%d:%d]", e.PrevToken.Position.Line, e.PrevToken.Position.Column)
+	}
+	return formatError(msg, e.Token, opts)
+}
+
+func (e *AnchorCollisionError) Error() string {
+	return fmt.Sprintf("anchor %q at %s collides with the anchor at %s", e.Name, e.Path, e.PrevPath)
+}
+
+func (e *PatchError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *PatchError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *PatchError) FormatErrorWithOptions(opts FormatOptions) string {
+	msg := fmt.Sprintf("%s %q: %s", e.Op, e.Path, e.Message)
+	if e.Token == nil {
+		return msg
+	}
+	return formatError(msg, e.Token, opts)
+}
+
+func (e *MergeConflictError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *MergeConflictError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *MergeConflictError) FormatErrorWithOptions(opts FormatOptions) string {
+	msg := fmt.Sprintf("conflict at %s: %s", e.Path, e.Message)
+	if e.Token == nil {
+		return msg
+	}
+	return formatError(msg, e.Token, opts)
+}
+
+func (e *SequenceElementError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *SequenceElementError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *SequenceElementError) FormatErrorWithOptions(opts FormatOptions) string {
+	if e.isField {
+		if formatted, ok := FormatWithOptions(e.Err, opts); ok {
+			return formatted
+		}
+		return e.Err.Error()
+	}
+	msg := fmt.Sprintf("sequence element [%d]", e.Index)
+	if e.Token != nil {
+		msg += fmt.Sprintf(" starting at [%d:%d]", e.Token.Position.Line, e.Token.Position.Column)
+		// the position of the error at the start of the element is written only once.
+		opts.omittedPosition = e.Token.Position
+	}
+	if formatted, ok := FormatWithOptions(e.Err, opts); ok {
+		return fmt.Sprintf("%s: %s", msg, formatted)
+	}
+	return fmt.Sprintf("%s: %s", msg, e.Err)
+}
+
+func (e *SequenceElementError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MergedValueError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *MergedValueError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *MergedValueError) FormatErrorWithOptions(opts FormatOptions) string {
+	msg := fmt.Sprintf("value merged by *%s", e.Alias)
+	if e.AliasToken != nil {
+		msg += fmt.Sprintf(" at [%d:%d]", e.AliasToken.Position.Line, e.AliasToken.Position.Column)
+	}
+	if e.AnchorToken != nil {
+		msg += fmt.Sprintf(" from &%s at [%d:%d]", e.Alias, e.AnchorToken.Position.Line, e.AnchorToken.Position.Column)
+	}
+	if formatted, ok := FormatWithOptions(e.Err, opts); ok {
+		return fmt.Sprintf("%s: %s", msg, formatted)
+	}
+	return fmt.Sprintf("%s: %s", msg, e.Err)
+}
+
+func (e *MergedValueError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExpansionError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *ExpansionError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *ExpansionError) FormatErrorWithOptions(opts FormatOptions) string {
+	msg := fmt.Sprintf("failed to expand %s: %s", e.Path, e.Err)
+	if e.Token == nil {
+		return msg
+	}
+	return formatError(msg, e.Token, opts)
+}
+
+func (e *ExpansionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *IncludeError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *IncludeError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *IncludeError) FormatErrorWithOptions(opts FormatOptions) string {
+	if formatted, ok := FormatWithOptions(e.Err, opts); ok {
+		// the error in the included file is reported with the source of the included file.
+		msg := fmt.Sprintf("failed to include %q", e.Ref)
+		if e.Token != nil {
+			msg += fmt.Sprintf(" at [%d:%d]", e.Token.Position.Line, e.Token.Position.Column)
+		}
+		return fmt.Sprintf("%s: %s", msg, formatted)
+	}
+	msg := fmt.Sprintf("failed to include %q: %s", e.Ref, e.Err)
+	if e.Token == nil {
+		return msg
+	}
+	return formatError(msg, e.Token, opts)
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MultiError) Error() string {
+	return e.FormatError(defaultFormatColor, defaultIncludeSource)
+}
+
+func (e *MultiError) FormatError(colored, inclSource bool) string {
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *MultiError) FormatErrorWithOptions(opts FormatOptions) string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		if formatted, ok := FormatWithOptions(err, opts); ok {
+			msgs = append(msgs, formatted)
+		} else {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
 }
 
 func (e *UnexpectedNodeTypeError) Error() string {
@@ -161,15 +637,36 @@ func (e *UnexpectedNodeTypeError) Error() string {
 }
 
 func (e *UnexpectedNodeTypeError) FormatError(colored, inclSource bool) string {
-	return formatError(fmt.Sprintf("%s was used where %s is expected", e.Actual.YAMLName(), e.Expected.YAMLName()), e.Token, colored, inclSource)
+	return e.FormatErrorWithOptions(defaultFormatOptions(colored, inclSource))
+}
+
+func (e *UnexpectedNodeTypeError) FormatErrorWithOptions(opts FormatOptions) string {
+	return formatError(fmt.Sprintf("%s was used where %s is expected", e.Actual.YAMLName(), e.Expected.YAMLName()), e.Token, opts)
 }
 
-func formatError(errMsg string, token *token.Token, colored, inclSource bool) string {
+func formatError(errMsg string, token *token.Token, opts FormatOptions) string {
 	var pp printer.Printer
 	pos := fmt.Sprintf("[%d:%d] ", token.Position.Line, token.Position.Column)
-	msg := pp.PrintErrorMessage(fmt.Sprintf("%s%s", pos, errMsg), colored)
-	if inclSource {
-		msg += "\n" + pp.PrintErrorToken(token, colored)
+	if opts.FileName != "" {
+		pos = fmt.Sprintf("[%s:%d:%d] ", opts.FileName, token.Position.Line, token.Position.Column)
+	} else if omitted := opts.omittedPosition; omitted != nil && omitted.Line == token.Position.Line && omitted.Column == token.Position.Column {
+		pos = ""
+	}
+	msg := pp.PrintErrorMessage(fmt.Sprintf("%s%s", pos, errMsg), opts.Colored)
+	if opts.IncludeSource {
+		msg += "\n" + pp.PrintErrorTokenLines(token, opts.Colored, opts.LinesBefore, opts.LinesAfter)
 	}
 	return msg
 }
+
+// FormatWithOptions formats err by opts if it's formatted with the source, and returns false otherwise.
+func FormatWithOptions(err error, opts FormatOptions) (string, bool) {
+	var pe PrettyFormatError
+	if !errors.As(err, &pe) {
+		return "", false
+	}
+	if oe, ok := pe.(OptionsFormatError); ok {
+		return oe.FormatErrorWithOptions(opts), true
+	}
+	return pe.FormatError(opts.Colored, opts.IncludeSource), true
+}
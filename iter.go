@@ -0,0 +1,44 @@
+//go:build go1.23
+
+package yaml
+
+import (
+	"io"
+	"iter"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// Documents returns the iterator over the documents read from r.
+// If the input can't be parsed, the iterator yields the error once and stops.
+//
+//	for doc, err := range yaml.Documents(r) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Documents(r io.Reader, opts ...DecodeOption) iter.Seq2[*ast.DocumentNode, error] {
+	return NewDecoder(r, opts...).Documents()
+}
+
+// Documents returns the iterator over the documents which have not been decoded yet.
+// The documents yielded by the iterator are skipped by the following Decode.
+func (d *Decoder) Documents() iter.Seq2[*ast.DocumentNode, error] {
+	return func(yield func(*ast.DocumentNode, error) bool) {
+		if !d.isInitialized() {
+			if err := d.decodeInit(); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+		for d.streamIndex < len(d.parsedFile.Docs) {
+			doc := d.parsedFile.Docs[d.streamIndex]
+			d.lastDocumentRange = d.documentRanges[d.streamIndex]
+			d.streamIndex++
+			if !yield(doc, nil) {
+				return
+			}
+		}
+	}
+}
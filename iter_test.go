@@ -0,0 +1,79 @@
+//go:build go1.23
+
+package yaml_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestDocuments(t *testing.T) {
+	t.Run("documents", func(t *testing.T) {
+		var bodies []string
+		for doc, err := range yaml.Documents(strings.NewReader("a: 1\n---\nb: 2\n---\n- c\n")) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			bodies = append(bodies, doc.Body.String())
+		}
+		expected := []string{"a: 1", "b: 2", "- c"}
+		if strings.Join(bodies, ",") != strings.Join(expected, ",") {
+			t.Fatalf("unexpected documents: %q", bodies)
+		}
+	})
+	t.Run("decode after break", func(t *testing.T) {
+		dec := yaml.NewDecoder(strings.NewReader("a: 1\n---\nb: 2\n"))
+		for _, err := range dec.Documents() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+		var v map[string]int
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if v["b"] != 2 {
+			t.Fatalf("unexpected value: %v", v)
+		}
+	})
+	t.Run("syntax error", func(t *testing.T) {
+		var count int
+		for doc, err := range yaml.Documents(strings.NewReader("a: [1\n")) {
+			count++
+			if err == nil || doc != nil {
+				t.Fatalf("expected syntax error but got %v", doc)
+			}
+		}
+		if count != 1 {
+			t.Fatalf("unexpected number of iterations: %d", count)
+		}
+	})
+}
+
+func TestNodeItems(t *testing.T) {
+	f, err := parser.ParseBytes([]byte("a: [x, y]\nb: 1\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys, values []string
+	for key, value := range f.Docs[0].Body.(*ast.MappingNode).Items() {
+		keys = append(keys, key.String())
+		if seq, ok := value.(*ast.SequenceNode); ok {
+			for idx, v := range seq.Items() {
+				values = append(values, fmt.Sprintf("%d:%s", idx, v))
+			}
+		}
+	}
+	if got := strings.Join(keys, ","); got != "a,b" {
+		t.Fatalf("unexpected keys: %s", got)
+	}
+	if got := strings.Join(values, ","); got != "0:x,1:y" {
+		t.Fatalf("unexpected values: %s", got)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !yaml_lean
+
+package yaml
+
+import "encoding/json"
+
+// jsonMarshal and jsonUnmarshal are used for the string option of the json tag by HonorJSONTagOptions.
+// They are replaced in the build with the yaml_lean tag to drop the dependency on encoding/json.
+func jsonMarshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func jsonUnmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,12 @@
+//go:build yaml_lean
+
+package yaml
+
+// jsonMarshal and jsonUnmarshal report errJSONNotSupported, because this build doesn't depend on encoding/json.
+func jsonMarshal(interface{}) ([]byte, error) {
+	return nil, errJSONNotSupported
+}
+
+func jsonUnmarshal([]byte, interface{}) error {
+	return errJSONNotSupported
+}
@@ -0,0 +1,26 @@
+//go:build yaml_lean
+
+package yaml_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestJSONTagStringOptionNotSupported(t *testing.T) {
+	type resource struct {
+		ID int `json:"id,string"`
+	}
+	if _, err := yaml.MarshalWithOptions(resource{ID: 1}, yaml.HonorJSONTagOptions()); err == nil || !strings.Contains(err.Error(), "yaml_lean") {
+		t.Fatalf("expected the error of the yaml_lean build but got %v", err)
+	}
+	var v resource
+	err := yaml.UnmarshalWithOptions([]byte("id: \"1\"\n"), &v, yaml.DecodeJSONTagOptions())
+	var typeErr *yaml.TypeError
+	if err == nil || errors.As(err, &typeErr) || !strings.Contains(err.Error(), "yaml_lean") {
+		t.Fatalf("expected the error of the yaml_lean build but got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+//go:build !yaml_lean
+
+package yaml_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+type JSONTagBase struct {
+	ID int64 `json:"id,string"`
+}
+
+type jsonTagResource struct {
+	JSONTagBase
+	Name     string            `json:"name"`
+	Kind     string            `json:",omitempty"`
+	Enabled  *bool             `json:"enabled,string,omitempty"`
+	Ratio    float64           `json:"ratio,string"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Spec     struct{ A int }   `json:"spec,omitempty"`
+	Dash     string            `json:"-,"`
+	Ignored  string            `json:"-"`
+	Override string            `json:"override" yaml:"over"`
+}
+
+func TestEncoder_HonorJSONTagOptions(t *testing.T) {
+	v := jsonTagResource{
+		JSONTagBase: JSONTagBase{ID: 42},
+		Name:        "app",
+		Ratio:       0.5,
+		Dash:        "dash",
+		Ignored:     "ignored",
+		Override:    "yaml",
+	}
+	b, err := yaml.MarshalWithOptions(v, yaml.HonorJSONTagOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+id: "42"
+name: app
+ratio: "0.5"
+spec:
+  A: 0
+-: dash
+over: yaml
+`
+	if got := "\n" + string(b); got != expected {
+		t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+	}
+
+	// the decoded value is the same as the one decoded from JSON.
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedValue jsonTagResource
+	if err := json.Unmarshal(jsonBytes, &expectedValue); err != nil {
+		t.Fatal(err)
+	}
+	expectedValue.Override = "yaml"
+	var got jsonTagResource
+	if err := yaml.UnmarshalWithOptions(b, &got, yaml.DecodeJSONTagOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expectedValue) {
+		t.Fatalf("unexpected decoded value:\nexpected:%+v\ngot:%+v", expectedValue, got)
+	}
+}
+
+func TestDecoder_DecodeJSONTagOptions(t *testing.T) {
+	type resource struct {
+		ID   int    `json:"id,string"`
+		Name string `json:"name"`
+	}
+	t.Run("json rules", func(t *testing.T) {
+		var v resource
+		if err := yaml.UnmarshalWithOptions([]byte("id: \"10\"\nNAME: app\n"), &v, yaml.DecodeJSONTagOptions()); err != nil {
+			t.Fatal(err)
+		}
+		if v.ID != 10 || v.Name != "app" {
+			t.Fatalf("unexpected value: %+v", v)
+		}
+	})
+	t.Run("unquoted value for string option", func(t *testing.T) {
+		var v resource
+		err := yaml.UnmarshalWithOptions([]byte("id: 10\n"), &v, yaml.DecodeJSONTagOptions())
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("expected type error but got %v", err)
+		}
+	})
+}
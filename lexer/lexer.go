@@ -2,15 +2,67 @@ package lexer
 
 import (
 	"io"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/goccy/go-yaml/scanner"
 	"github.com/goccy/go-yaml/token"
 )
 
-// Tokenize split to token instances from string
-func Tokenize(src string) token.Tokens {
+// DefaultTabWidth is the number of the columns of a tab used for the display columns by default.
+const DefaultTabWidth = 8
+
+// Option is the option of Tokenize and TokenizeForDecode.
+type Option func(*config)
+
+type config struct {
+	tabWidth int
+}
+
+// TabWidth sets the number of the columns of a tab used for the DisplayColumn of the token positions.
+// The tab moves the display column to the next multiple of n like the terminals.
+func TabWidth(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.tabWidth = n
+		}
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{tabWidth: DefaultTabWidth}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokenize split to token instances from string.
+// The Origins of the tokens keep all the text of src including the white spaces
+// and the line breaks, so tokens.Source() returns src as-is.
+func Tokenize(src string, opts ...Option) token.Tokens {
+	var s scanner.Scanner
+	s.Init(src)
+	var tokens token.Tokens
+	for {
+		subTokens, err := s.Scan()
+		if err == io.EOF {
+			break
+		}
+		tokens.Add(subTokens...)
+	}
+	fillOrigins(tokens, src)
+	fillPositions(tokens, src, newConfig(opts).tabWidth)
+	return tokens
+}
+
+// TokenizeForDecode splits src to the tokens like Tokenize, but drops the comments
+// and leaves the Origins of the indicator tokens empty, so tokens.Source() doesn't return src.
+// It's faster than Tokenize when the tokens are only parsed and decoded.
+func TokenizeForDecode(src string, opts ...Option) token.Tokens {
 	var s scanner.Scanner
 	s.Init(src)
+	s.DiscardOrigins()
 	var tokens token.Tokens
 	for {
 		subTokens, err := s.Scan()
@@ -19,5 +71,163 @@ func Tokenize(src string) token.Tokens {
 		}
 		tokens.Add(subTokens...)
 	}
+	fillPositions(tokens, src, newConfig(opts).tabWidth)
 	return tokens
 }
+
+// fillOrigins replaces the white spaces at the head of the Origins of the tokens with the text of src
+// between the tokens, because the scanner skips the spaces before some indicators and the line breaks
+// at the end of src, and puts the same line break into the Origins of both the adjacent tokens.
+func fillOrigins(tokens token.Tokens, src string) {
+	if len(tokens) == 0 {
+		return
+	}
+	var cursor int
+	for idx, tk := range tokens {
+		text := trimLeftWhiteSpace(tk.Origin)
+		pos := strings.Index(src[cursor:], text)
+		if pos < 0 || trimLeftWhiteSpace(src[cursor:cursor+pos]) != "" {
+			// the scanner normalizes the text of some tokens, like the trailing spaces of the lines in the multi-line scalar,
+			// so the text until the origin of the next token is used as the origin.
+			end := len(src)
+			if idx+1 < len(tokens) {
+				start := max(cursor, offset(src, tk.Position))
+				next := strings.Index(src[start:], trimLeftWhiteSpace(tokens[idx+1].Origin))
+				if next < 0 {
+					return
+				}
+				end = start + next
+			}
+			tk.Origin = src[cursor:end]
+			cursor = end
+			continue
+		}
+		tk.Origin = src[cursor : cursor+pos+len(text)]
+		cursor += len(tk.Origin)
+	}
+	if cursor < len(src) {
+		tokens[len(tokens)-1].Origin += src[cursor:]
+	}
+}
+
+// fillPositions sets the byte offsets and the display columns of the tokens, counted in src,
+// and the end positions of the tokens from the text of the Origins without the white spaces around it,
+// or from the Values if the Origins are discarded. The end offsets are counted in src rather than the Origins,
+// because the scanner normalizes the white spaces in the Origins of some multi-line tokens.
+func fillPositions(tokens token.Tokens, src string, tabWidth int) {
+	if len(tokens) == 0 {
+		return
+	}
+	cursor := &columnCursor{src: src, tabWidth: tabWidth}
+	lineStarts := []int{0}
+	lineByteStarts := []int{0}
+	if strings.HasPrefix(src, "\ufeff") {
+		// the scanner skips the byte order mark without counting it in the column.
+		lineStarts[0], lineByteStarts[0] = 1, len("\ufeff")
+	}
+	var runes int
+	for i, r := range src {
+		runes++
+		if r == '\n' {
+			lineStarts = append(lineStarts, runes)
+			lineByteStarts = append(lineByteStarts, i+1)
+		}
+	}
+	runeIndex := func(line, column int) int {
+		if line < 1 {
+			return 0
+		}
+		if line > len(lineStarts) {
+			return runes
+		}
+		return lineStarts[line-1] + column - 1
+	}
+	for _, tk := range tokens {
+		pos := tk.Position
+		if pos == nil {
+			continue
+		}
+		column := pos.Column
+		if column < 1 && pos.Line >= 1 && pos.Line <= len(lineByteStarts) {
+			// the column of the content of the block scalar may be unknown,
+			// so the text is assumed to start after the indent.
+			line := src[lineByteStarts[pos.Line-1]:]
+			column = len(line) - len(strings.TrimLeft(line, " \t")) + 1
+		}
+		if pos.Line >= 1 && pos.Line <= len(lineByteStarts) {
+			// the scanner may count the offsets of the tokens after the block scalars wrongly,
+			// so the offset is counted from the line and the column again.
+			pos.Offset = runeIndex(pos.Line, column) + 1
+			pos.ByteOffset, pos.DisplayColumn = cursor.move(pos.Line, lineByteStarts[pos.Line-1], column)
+		}
+		text := strings.TrimRight(trimLeftWhiteSpace(tk.Origin), " \t\r\n")
+		if text == "" {
+			text = tk.Value
+		}
+		if idx := strings.LastIndexByte(text, '\n'); idx >= 0 {
+			pos.EndLine = pos.Line + strings.Count(text, "\n")
+			pos.EndColumn = utf8.RuneCountInString(text[idx+1:]) + 1
+		} else {
+			pos.EndLine = pos.Line
+			pos.EndColumn = column + utf8.RuneCountInString(text)
+		}
+		pos.EndOffset = pos.Offset + runeIndex(pos.EndLine, pos.EndColumn) - runeIndex(pos.Line, column)
+	}
+}
+
+// columnCursor finds the byte offsets and the display columns of the columns in src.
+// The tokens are in the order of the source, so the cursor moves forward from the last column in the same line.
+type columnCursor struct {
+	src           string
+	tabWidth      int
+	line          int
+	column        int
+	byteOffset    int
+	displayColumn int
+}
+
+// move returns the byte offset and the display column of column in line starting at lineStart of src.
+func (c *columnCursor) move(line, lineStart, column int) (int, int) {
+	if line != c.line || column < c.column {
+		c.line, c.column, c.byteOffset, c.displayColumn = line, 1, lineStart, 1
+	}
+	for c.column < column && c.byteOffset < len(c.src) {
+		r, size := utf8.DecodeRuneInString(c.src[c.byteOffset:])
+		if r == '\n' {
+			break
+		}
+		if r == '\t' {
+			c.displayColumn += c.tabWidth - (c.displayColumn-1)%c.tabWidth
+		} else {
+			c.displayColumn++
+		}
+		c.byteOffset += size
+		c.column++
+	}
+	return c.byteOffset, c.displayColumn
+}
+
+// trimLeftWhiteSpace trims the white spaces and the byte order mark skipped by the scanner.
+func trimLeftWhiteSpace(s string) string {
+	return strings.TrimLeft(s, " \t\r\n\ufeff")
+}
+
+// offset returns the byte offset of the position in src.
+func offset(src string, pos *token.Position) int {
+	var offset int
+	for line := 1; line < pos.Line; line++ {
+		idx := strings.IndexByte(src[offset:], '\n')
+		if idx < 0 {
+			return len(src)
+		}
+		offset += idx + 1
+	}
+	for column := 1; column < pos.Column && offset < len(src); column++ {
+		if src[offset] == '\n' {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(src[offset:])
+		offset += size
+	}
+	return offset
+}
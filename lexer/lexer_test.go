@@ -1,7 +1,9 @@
 package lexer_test
 
 import (
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/goccy/go-yaml/lexer"
@@ -126,7 +128,7 @@ func TestTokenize(t *testing.T) {
 					CharacterType: token.CharacterTypeIndicator,
 					Indicator:     token.FlowCollectionIndicator,
 					Value:         "}",
-					Origin:        "}",
+					Origin:        "}\n  ",
 				},
 			},
 		},
@@ -604,7 +606,7 @@ a:
 					CharacterType: token.CharacterTypeIndicator,
 					Indicator:     token.QuotedScalarIndicator,
 					Value:         "bbb  ccc\nddd eee\n  fff ggg\nhhh iii\n jjj kkk ",
-					Origin:        "\n  \"bbb  \\\n      ccc\n\n      ddd eee\\n\\\n  \\ \\ fff ggg\\nhhh iii\\n\n  jjj kkk\n  \"",
+					Origin:        "\n  \"bbb  \\\n      ccc\n\n      ddd eee\\n\\\n  \\ \\ fff ggg\\nhhh iii\\n\n  jjj kkk\n  \"\n",
 				},
 			},
 		},
@@ -707,7 +709,7 @@ v:
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "B",
-					Origin:        " B",
+					Origin:        " B\n",
 				},
 			},
 		},
@@ -870,7 +872,7 @@ v:
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "3",
-					Origin:        " 3",
+					Origin:        " 3\n",
 				},
 			},
 		},
@@ -913,7 +915,7 @@ a:
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "c",
-					Origin:        " c",
+					Origin:        " c\n",
 				},
 			},
 		},
@@ -978,7 +980,7 @@ a:
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "world",
-					Origin:        " world",
+					Origin:        " world\n",
 				},
 			},
 		},
@@ -1162,7 +1164,7 @@ t4: 2098-01-09T10:40:47Z
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "2098-01-09T10:40:47Z",
-					Origin:        " 2098-01-09T10:40:47Z",
+					Origin:        " 2098-01-09T10:40:47Z\n",
 				},
 			},
 		},
@@ -1556,7 +1558,7 @@ sub:
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "5",
-					Origin:        " 5",
+					Origin:        " 5\n",
 				},
 			},
 		},
@@ -1945,7 +1947,7 @@ a:
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "b\nc",
-					Origin:        "\n b\n\n c",
+					Origin:        "\n b\n\n c\n",
 				},
 			},
 		},
@@ -1979,7 +1981,7 @@ e: f
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "b\nc d",
-					Origin:        "\n b\n\n\n c\n d\n",
+					Origin:        "   \n b   \n\n  \n c\n d \n",
 				},
 				{
 					Type:          token.StringType,
@@ -2000,7 +2002,7 @@ e: f
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "f",
-					Origin:        " f",
+					Origin:        " f\n",
 				},
 			},
 		},
@@ -2062,7 +2064,7 @@ e: f
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "f",
-					Origin:        " f",
+					Origin:        " f\n",
 				},
 			},
 		},
@@ -2124,7 +2126,7 @@ e: f
 					CharacterType: token.CharacterTypeMiscellaneous,
 					Indicator:     token.NotIndicator,
 					Value:         "f",
-					Origin:        " f",
+					Origin:        " f\n",
 				},
 			},
 		},
@@ -3291,4 +3293,200 @@ func TestTokenOffset(t *testing.T) {
 			t.Fatalf("unexpected offset. got %d", tokens[4].Position.Offset)
 		}
 	})
+	t.Run("after block scalar", func(t *testing.T) {
+		content := "a: |\n  b\n\nc: d\n"
+		tokens := lexer.Tokenize(content)
+		if len(tokens) != 7 {
+			t.Fatalf("invalid token num. got %d", len(tokens))
+		}
+		if tokens[4].Value != "c" {
+			t.Fatalf("unexpected value. got %q", tokens[4].Value)
+		}
+		if tokens[4].Position.Offset != 11 {
+			t.Fatalf("unexpected offset. got %d", tokens[4].Position.Offset)
+		}
+	})
+}
+
+func TestTokenizeSource(t *testing.T) {
+	tests := []string{
+		"",
+		"a: 1\n",
+		"a: 1\n\n\n",
+		"*a : *b  \n",
+		"- a\t\t\n-  b # comment\n",
+		"a:   \n b   \n\n  \n c\n d \ne: f\n",
+		"--- !!seq\n- !!str c\n--- !!str\nd\ne\n",
+		"a: |\n  text  \n\n# comment\n",
+		"a: 1\r\nb: 2\r\n",
+		"{ a: [1, 2], b : c }\n",
+		"? a\n: b\n",
+	}
+	for _, src := range tests {
+		if got := lexer.Tokenize(src).Source(); got != src {
+			t.Errorf("failed to reconstruct the source: expected %q but got %q", src, got)
+		}
+	}
+}
+
+func TestTokenizeForDecode(t *testing.T) {
+	tests := []string{
+		"a: 1 # comment\n",
+		"# head\n- a\t\t\n-  b # comment\n",
+		"--- !!seq\n- !!str c\n--- &x !!str\nd\ne\n",
+		"a: |\n  text  \n\n# comment\n",
+		"{ a: [1, 2], b : *c }\n",
+		"? a\n: b\n",
+	}
+	for _, src := range tests {
+		var expected token.Tokens
+		for _, tk := range lexer.Tokenize(src) {
+			if tk.Type != token.CommentType {
+				expected = append(expected, tk)
+			}
+		}
+		got := lexer.TokenizeForDecode(src)
+		if len(got) != len(expected) {
+			t.Fatalf("%q: expected %d tokens but got %d", src, len(expected), len(got))
+		}
+		for i, tk := range got {
+			if tk.Type != expected[i].Type || tk.Value != expected[i].Value || *tk.Position != *expected[i].Position {
+				t.Errorf("%q: expected %s %q at %s but got %s %q at %s",
+					src, expected[i].Type, expected[i].Value, expected[i].Position, tk.Type, tk.Value, tk.Position,
+				)
+			}
+			// Tokenize moves the white spaces between the tokens, so only the text of the scalar is compared.
+			if tk.Type == token.StringType && strings.TrimSpace(tk.Origin) != strings.TrimSpace(expected[i].Origin) {
+				t.Errorf("%q: the origin of the scalar %q should be kept but got %q", src, tk.Value, tk.Origin)
+			}
+		}
+	}
+}
+
+func TestTokenEndPosition(t *testing.T) {
+	src := "# head\na: b # line\nq: \"x\n  y\"\nl: |\n  foo\n  bar\n\nm: [1, 'あい']\n"
+	type endPosition struct {
+		value  string
+		line   int
+		column int
+		length int
+	}
+	expected := []endPosition{
+		{value: " head", line: 1, column: 7, length: 6},
+		{value: "a", line: 2, column: 2, length: 1},
+		{value: ":", line: 2, column: 3, length: 1},
+		{value: "b", line: 2, column: 5, length: 1},
+		{value: " line", line: 2, column: 12, length: 6},
+		{value: "q", line: 3, column: 2, length: 1},
+		{value: ":", line: 3, column: 3, length: 1},
+		{value: "x y", line: 4, column: 5, length: 7},
+		{value: "l", line: 5, column: 2, length: 1},
+		{value: ":", line: 5, column: 3, length: 1},
+		{value: "|", line: 5, column: 5, length: 1},
+		{value: "foo\nbar\n", line: 7, column: 6, length: 9},
+		{value: "m", line: 9, column: 2, length: 1},
+		{value: ":", line: 9, column: 3, length: 1},
+		{value: "[", line: 9, column: 5, length: 1},
+		{value: "1", line: 9, column: 6, length: 1},
+		{value: ",", line: 9, column: 7, length: 1},
+		{value: "あい", line: 9, column: 12, length: 4},
+		{value: "]", line: 9, column: 13, length: 1},
+	}
+	tokens := lexer.Tokenize(src)
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens but got %d", len(expected), len(tokens))
+	}
+	for i, tk := range tokens {
+		e := expected[i]
+		pos := tk.Position
+		if tk.Value != e.value || pos.EndLine != e.line || pos.EndColumn != e.column || pos.Length() != e.length {
+			t.Errorf("expected %q to end at %d:%d with length %d but got %q at %d:%d with length %d",
+				e.value, e.line, e.column, e.length, tk.Value, pos.EndLine, pos.EndColumn, pos.Length(),
+			)
+		}
+	}
+	for _, tk := range lexer.TokenizeForDecode(src) {
+		if tk.Position.EndLine == 0 {
+			t.Errorf("the end position of %q should be set", tk.Value)
+		}
+	}
+}
+
+func TestTokenByteOffsetAndDisplayColumn(t *testing.T) {
+	type position struct {
+		value         string
+		byteOffset    int
+		displayColumn int
+	}
+	tests := []struct {
+		name     string
+		src      string
+		opts     []lexer.Option
+		expected []position
+	}{
+		{
+			name: "multibyte",
+			src:  "あ: い\nb: [う, c]\n",
+			expected: []position{
+				{value: "あ", byteOffset: 0, displayColumn: 1},
+				{value: ":", byteOffset: 3, displayColumn: 2},
+				{value: "い", byteOffset: 5, displayColumn: 4},
+				{value: "b", byteOffset: 9, displayColumn: 1},
+				{value: ":", byteOffset: 10, displayColumn: 2},
+				{value: "[", byteOffset: 12, displayColumn: 4},
+				{value: "う", byteOffset: 13, displayColumn: 5},
+				{value: ",", byteOffset: 16, displayColumn: 6},
+				{value: "c", byteOffset: 18, displayColumn: 8},
+				{value: "]", byteOffset: 19, displayColumn: 9},
+			},
+		},
+		{
+			name: "tab",
+			src:  "a:\tb\nc: d\t\t# e\nあ\tい\n",
+			expected: []position{
+				{value: "a", byteOffset: 0, displayColumn: 1},
+				{value: ":", byteOffset: 1, displayColumn: 2},
+				{value: "b", byteOffset: 3, displayColumn: 9},
+				{value: "c", byteOffset: 5, displayColumn: 1},
+				{value: ":", byteOffset: 6, displayColumn: 2},
+				{value: "d", byteOffset: 8, displayColumn: 4},
+				{value: " e", byteOffset: 11, displayColumn: 17},
+				{value: "あ\tい", byteOffset: 15, displayColumn: 1},
+			},
+		},
+		{
+			name: "tab width",
+			src:  "a:\tb\nc: d\t\t# e\n",
+			opts: []lexer.Option{lexer.TabWidth(4)},
+			expected: []position{
+				{value: "a", byteOffset: 0, displayColumn: 1},
+				{value: ":", byteOffset: 1, displayColumn: 2},
+				{value: "b", byteOffset: 3, displayColumn: 5},
+				{value: "c", byteOffset: 5, displayColumn: 1},
+				{value: ":", byteOffset: 6, displayColumn: 2},
+				{value: "d", byteOffset: 8, displayColumn: 4},
+				{value: " e", byteOffset: 11, displayColumn: 13},
+			},
+		},
+		{
+			name: "byte order mark",
+			src:  "\ufeffa: b\n",
+			expected: []position{
+				{value: "a", byteOffset: 3, displayColumn: 1},
+				{value: ":", byteOffset: 4, displayColumn: 2},
+				{value: "b", byteOffset: 6, displayColumn: 4},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []position
+			for _, tk := range lexer.Tokenize(test.src, test.opts...) {
+				got = append(got, position{value: tk.Value, byteOffset: tk.Position.ByteOffset, displayColumn: tk.Position.DisplayColumn})
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %+v but got %+v", test.expected, got)
+			}
+		})
+	}
 }
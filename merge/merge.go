@@ -0,0 +1,313 @@
+package merge
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/astutil"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// ListStrategy is the strategy to merge the sequences.
+type ListStrategy int
+
+const (
+	// Replace replaces the sequence of the base with the sequence of the overlay.
+	Replace ListStrategy = iota
+	// Append appends the entries of the overlay to the sequence of the base.
+	Append
+	// MergeByKey merges the mapping entries having the same value of the merge key,
+	// and appends the other entries of the overlay.
+	MergeByKey
+)
+
+// Option is the option to change the behavior of Merge.
+type Option func(*merger)
+
+// WithListStrategy specifies the strategy to merge the sequences. The default is Replace.
+func WithListStrategy(strategy ListStrategy) Option {
+	return func(m *merger) {
+		m.listStrategy = strategy
+	}
+}
+
+// WithMergeKey merges the sequences by MergeByKey strategy with the key, like `name` of the containers.
+func WithMergeKey(key string) Option {
+	return func(m *merger) {
+		m.listStrategy = MergeByKey
+		m.mergeKey = key
+	}
+}
+
+// DeleteNull removes the key from the mapping of the base if the value of the overlay is null,
+// like JSON Merge Patch ( RFC 7396 ). The keys having null in the mappings added by the overlay are also removed.
+func DeleteNull() Option {
+	return func(m *merger) {
+		m.deleteNull = true
+	}
+}
+
+// Merge merges overlay into base and returns the merged file. base and overlay are not modified.
+// The documents at the same index are merged, and the rest of the documents of overlay are appended.
+//
+// The mappings are merged deeply by the key. The other values of overlay replace the values of base,
+// and the sequences are merged by the list strategy.
+// The nodes of base not touched by overlay are kept as they are, including the comments and the styles.
+// The line comment of the replaced value is also kept unless overlay has the comment for it.
+// The aliases of overlay refer to the anchors of overlay, so they are not resolved in the merged file
+// unless the anchors are also merged.
+// To merge the changes of two files made from the same file, use ThreeWay with the original file.
+func Merge(base, overlay *ast.File, opts ...Option) (*ast.File, error) {
+	m := &merger{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	dst, err := clone(base)
+	if err != nil {
+		return nil, err
+	}
+	src, err := clone(overlay)
+	if err != nil {
+		return nil, err
+	}
+	for idx, doc := range src.Docs {
+		if idx >= len(dst.Docs) {
+			dst.Docs = append(dst.Docs, doc)
+			continue
+		}
+		dstDoc := dst.Docs[idx]
+		if doc.Body == nil {
+			continue
+		}
+		if dstDoc.Body == nil {
+			dstDoc.Body = doc.Body
+			continue
+		}
+		dstDoc.Body = m.merge(dstDoc.Body, doc.Body, 0, false)
+	}
+	return dst, nil
+}
+
+// clone copies the file by parsing the text of it, so the nodes of the merged file don't share the nodes of the inputs.
+func clone(file *ast.File) (*ast.File, error) {
+	if file == nil {
+		return &ast.File{}, nil
+	}
+	cloned, err := parser.ParseBytes([]byte(file.String()), parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s: %w", file.Name, err)
+	}
+	cloned.Name = file.Name
+	return cloned, nil
+}
+
+type merger struct {
+	listStrategy ListStrategy
+	mergeKey     string
+	deleteNull   bool
+}
+
+// merge merges overlay into base and returns the node placed at base.
+// column is the difference of the columns between base and overlay, used to indent the nodes of overlay.
+func (m *merger) merge(base, overlay ast.Node, column int, inFlow bool) ast.Node {
+	baseValue := unwrapAnchor(base)
+	overlayValue := unwrapAnchor(overlay)
+	switch b := toMapping(baseValue).(type) {
+	case *ast.MappingNode:
+		if o, ok := toMapping(overlayValue).(*ast.MappingNode); ok {
+			m.mergeMapping(b, o)
+			return replaceAnchorValue(base, b)
+		}
+	case *ast.SequenceNode:
+		if o, ok := overlayValue.(*ast.SequenceNode); ok && m.listStrategy != Replace {
+			m.mergeSequence(b, o)
+			return base
+		}
+	}
+	return m.replace(base, overlay, column, inFlow)
+}
+
+// replace returns overlay to be placed at base instead of it.
+// column is the difference of the columns between base and overlay, used to indent overlay.
+func (m *merger) replace(base, overlay ast.Node, column int, inFlow bool) ast.Node {
+	overlay.AddColumn(column)
+	if m.deleteNull {
+		overlay = removeNull(overlay)
+	}
+	if inFlow {
+		overlay = flowStyle(overlay)
+	}
+	if overlay.GetComment() == nil && base.GetComment() != nil {
+		_ = overlay.SetComment(base.GetComment())
+	}
+	return overlay
+}
+
+func (m *merger) mergeMapping(base, overlay *ast.MappingNode) {
+	column := 0
+	if len(base.Values) != 0 && len(overlay.Values) != 0 {
+		column = keyColumn(base.Values[0]) - keyColumn(overlay.Values[0])
+	}
+	for _, value := range overlay.Values {
+		key := astutil.KeyText(value.Key)
+		idx := astutil.FindKey(base, key)
+		if m.deleteNull && value.Value.Type() == ast.NullType {
+			if idx >= 0 {
+				base.Values = append(base.Values[:idx], base.Values[idx+1:]...)
+			}
+			continue
+		}
+		if idx < 0 {
+			value.AddColumn(column)
+			if m.deleteNull {
+				value.Value = removeNull(value.Value)
+			}
+			if base.IsFlowStyle {
+				value.Value = flowStyle(value.Value)
+			}
+			base.Values = append(base.Values, value)
+			continue
+		}
+		baseValue := base.Values[idx]
+		baseValue.Value = m.merge(baseValue.Value, value.Value, keyColumn(baseValue)-keyColumn(value), base.IsFlowStyle)
+		if value.GetComment() != nil {
+			_ = baseValue.SetComment(value.GetComment())
+		}
+	}
+	if len(base.Values) == 0 {
+		// the empty mapping is written as `{}`, so it's treated as the flow style to be on the same line as the key.
+		base.IsFlowStyle = true
+	}
+}
+
+func (m *merger) mergeSequence(base, overlay *ast.SequenceNode) {
+	column := base.Start.Position.Column - overlay.Start.Position.Column
+	for idx, value := range overlay.Values {
+		if m.listStrategy == MergeByKey {
+			if baseIdx := m.findEntry(base, value); baseIdx >= 0 {
+				base.Values[baseIdx] = m.merge(base.Values[baseIdx], value, column, base.IsFlowStyle)
+				continue
+			}
+		}
+		value.AddColumn(column)
+		if base.IsFlowStyle {
+			value = flowStyle(value)
+		}
+		astutil.InsertEntry(base, len(base.Values), value, headComment(overlay, idx))
+	}
+}
+
+// findEntry returns the index of the mapping entry in base having the same value of the merge key as value.
+func (m *merger) findEntry(base *ast.SequenceNode, value ast.Node) int {
+	key, ok := m.entryKey(value)
+	if !ok {
+		return -1
+	}
+	for idx, entry := range base.Values {
+		if entryKey, ok := m.entryKey(entry); ok && entryKey == key {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (m *merger) entryKey(entry ast.Node) (string, bool) {
+	mapping, ok := toMapping(unwrapAnchor(entry)).(*ast.MappingNode)
+	if !ok {
+		return "", false
+	}
+	idx := astutil.FindKey(mapping, m.mergeKey)
+	if idx < 0 {
+		return "", false
+	}
+	scalar, ok := unwrapAnchor(mapping.Values[idx].Value).(ast.ScalarNode)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(scalar.GetValue()), true
+}
+
+func headComment(seq *ast.SequenceNode, idx int) *ast.CommentGroupNode {
+	if idx >= len(seq.ValueHeadComments) {
+		return nil
+	}
+	return seq.ValueHeadComments[idx]
+}
+
+func unwrapAnchor(node ast.Node) ast.Node {
+	if anchor, ok := node.(*ast.AnchorNode); ok {
+		return anchor.Value
+	}
+	return node
+}
+
+func replaceAnchorValue(node, value ast.Node) ast.Node {
+	if anchor, ok := node.(*ast.AnchorNode); ok {
+		anchor.Value = value
+		return anchor
+	}
+	return value
+}
+
+// toMapping converts the mapping value node to the mapping node having it as the single entry.
+func toMapping(node ast.Node) ast.Node {
+	if value, ok := node.(*ast.MappingValueNode); ok {
+		return ast.Mapping(value.GetToken(), false, value)
+	}
+	return node
+}
+
+// removeNull removes the keys having null from the mappings in node.
+// The sequences are kept as they are, because they are not merged by the key.
+func removeNull(node ast.Node) ast.Node {
+	switch n := toMapping(unwrapAnchor(node)).(type) {
+	case *ast.MappingNode:
+		values := make([]*ast.MappingValueNode, 0, len(n.Values))
+		for _, value := range n.Values {
+			if value.Value.Type() == ast.NullType {
+				continue
+			}
+			value.Value = removeNull(value.Value)
+			values = append(values, value)
+		}
+		if len(values) == 0 {
+			// the empty mapping is written as `{}`, so it's treated as the flow style to be on the same line as the key.
+			n.IsFlowStyle = true
+		}
+		n.Values = values
+		return replaceAnchorValue(node, n)
+	}
+	return node
+}
+
+// flowStyle converts node to the flow style to be placed in the flow style collection.
+func flowStyle(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		n.IsFlowStyle = true
+		for _, value := range n.Values {
+			value.Value = flowStyle(value.Value)
+		}
+	case *ast.MappingValueNode:
+		return flowStyle(toMapping(n))
+	case *ast.SequenceNode:
+		n.IsFlowStyle = true
+		for idx, value := range n.Values {
+			n.Values[idx] = flowStyle(value)
+		}
+	case *ast.AnchorNode:
+		n.Value = flowStyle(n.Value)
+	case *ast.TagNode:
+		n.Value = flowStyle(n.Value)
+	case *ast.LiteralNode:
+		value := strconv.Quote(n.Value.Value)
+		return ast.String(token.New(value, value, n.Start.Position))
+	}
+	return node
+}
+
+func keyColumn(value *ast.MappingValueNode) int {
+	return value.Key.GetToken().Position.Column
+}
@@ -0,0 +1,451 @@
+package merge_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/merge"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		overlay  string
+		options  []merge.Option
+		expected string
+	}{
+		{
+			name: "deep merge",
+			base: `
+# head comment
+a: 1 # keep the comment
+b:
+  c: 1 # untouched
+  d: [1, 2]
+e: x
+`,
+			overlay: `
+a: 2
+b:
+    d: [3]
+    f:
+        g: 1
+h:
+  - 1
+`,
+			expected: `
+# head comment
+a: 2 # keep the comment
+b:
+  c: 1 # untouched
+  d: [3]
+  f:
+      g: 1
+e: x
+h:
+  - 1
+`,
+		},
+		{
+			name:    "overlay comment",
+			base:    "a: 1 # base\n",
+			overlay: "a: 2 # overlay\n",
+			expected: `
+a: 2 # overlay
+`,
+		},
+		{
+			name:    "replace the type",
+			base:    "a: 1\nb:\n  c: 1\n",
+			overlay: "a:\n  x: 1\nb: [1]\n",
+			expected: `
+a:
+  x: 1
+b: [1]
+`,
+		},
+		{
+			name: "append",
+			base: `
+a:
+  - 1
+  # second
+  - 2
+b: [1]
+`,
+			overlay: `
+a:
+- 3
+- x: 1
+  y: 2
+b: [2, {c: 1}]
+`,
+			options: []merge.Option{merge.WithListStrategy(merge.Append)},
+			expected: `
+a:
+  - 1
+  # second
+  - 2
+  - 3
+  - x: 1
+    y: 2
+b: [1, 2, {c: 1}]
+`,
+		},
+		{
+			name: "merge by key",
+			base: `
+containers:
+  - name: app
+    image: app:1 # pinned
+  - name: sidecar
+    image: sidecar:1
+`,
+			overlay: `
+containers:
+- name: app
+  image: app:2
+- name: debug
+  image: debug:1
+`,
+			options: []merge.Option{merge.WithMergeKey("name")},
+			expected: `
+containers:
+  - name: app
+    image: app:2 # pinned
+  - name: sidecar
+    image: sidecar:1
+  - name: debug
+    image: debug:1
+`,
+		},
+		{
+			name:    "delete null",
+			base:    "a: 1\nb: 2\n",
+			overlay: "b: null\nc: 3\n",
+			options: []merge.Option{merge.DeleteNull()},
+			expected: `
+a: 1
+c: 3
+`,
+		},
+		{
+			name:    "keep null",
+			base:    "a: 1\nb: 2\n",
+			overlay: "b: null\n",
+			expected: `
+a: 1
+b: null
+`,
+		},
+		{
+			name:    "block into flow",
+			base:    "a: {x: 1}\n",
+			overlay: "a:\n  y:\n    z: [1]\n  l: |\n    text\n",
+			expected: `
+a: {x: 1, y: {z: [1]}, l: "text\n"}
+`,
+		},
+		{
+			name:    "anchor",
+			base:    "base: &b\n  x: 1\nc: *b\n",
+			overlay: "base:\n  y: 2\n",
+			expected: `
+base: &b
+  x: 1
+  y: 2
+c: *b
+`,
+		},
+		{
+			name:     "empty base",
+			base:     "",
+			overlay:  "a: 1\n",
+			expected: "a: 1\n",
+		},
+		{
+			name:     "empty overlay",
+			base:     "a: 1\n",
+			overlay:  "\n",
+			expected: "a: 1\n",
+		},
+		{
+			name:    "documents",
+			base:    "a: 1\n",
+			overlay: "a: 2\n---\nb: 1\n",
+			expected: `
+a: 2
+---
+b: 1
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base, err := parser.ParseBytes([]byte(test.base), parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			overlay, err := parser.ParseBytes([]byte(test.overlay), parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			baseText, overlayText := base.String(), overlay.String()
+			merged, err := merge.Merge(base, overlay, test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := merged.String()
+			if got != strings.TrimPrefix(test.expected, "\n") {
+				t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", strings.TrimPrefix(test.expected, "\n"), got)
+			}
+			if _, err := parser.ParseBytes([]byte(got), 0); err != nil {
+				t.Fatalf("failed to parse the merged file: %v", err)
+			}
+			if base.String() != baseText || overlay.String() != overlayText {
+				t.Fatal("the input files are modified")
+			}
+		})
+	}
+}
+
+func TestThreeWay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		ours     string
+		theirs   string
+		options  []merge.Option
+		expected string
+	}{
+		{
+			name: "changes of both sides",
+			base: `
+a: 1
+b: 1
+c: 1
+d:
+  x: 1
+  y: 1
+`,
+			ours: `
+# ours
+a: 2 # changed in ours
+b: 1
+c: 1 # removed in theirs
+d:
+  x: 2
+  y: 1
+`,
+			theirs: `
+a: 1
+b: 3
+d:
+    x: 1
+    y: 3
+e: 1
+`,
+			expected: `
+# ours
+a: 2 # changed in ours
+b: 3
+d:
+  x: 2
+  y: 3
+e: 1
+`,
+		},
+		{
+			name:     "same change",
+			base:     "a: 1\n",
+			ours:     "a: 2 # ours\n",
+			theirs:   "a: 2\n",
+			expected: "a: 2 # ours\n",
+		},
+		{
+			name:     "style of theirs",
+			base:     "a: [1, 2]\n",
+			ours:     "a: [1, 2]\nb: 1 # ours\n",
+			theirs:   "a:\n  - 1\n  - 2\n",
+			expected: "a: [1, 2]\nb: 1 # ours\n",
+		},
+		{
+			name:     "removed in ours",
+			base:     "a: 1\nb: 1\n",
+			ours:     "a: 1\n",
+			theirs:   "a: 2\nb: 1\n",
+			expected: "a: 2\n",
+		},
+		{
+			name: "merge by key",
+			base: `
+containers:
+  - name: app
+    image: app:1
+  - name: sidecar
+    image: sidecar:1
+  - name: old
+    image: old:1
+`,
+			ours: `
+containers:
+  - name: app
+    image: app:1
+    port: 80 # ours
+  - name: sidecar
+    image: sidecar:1
+  - name: old
+    image: old:1
+`,
+			theirs: `
+containers:
+- name: app
+  image: app:2
+- name: sidecar
+  image: sidecar:1
+- name: debug
+  image: debug:1
+`,
+			options: []merge.Option{merge.WithMergeKey("name")},
+			expected: `
+containers:
+  - name: app
+    image: app:2
+    port: 80 # ours
+  - name: sidecar
+    image: sidecar:1
+  - name: debug
+    image: debug:1
+`,
+		},
+		{
+			name:     "flow mapping",
+			base:     "a: {x: 1, y: 1}\n",
+			ours:     "a: {x: 2, y: 1}\n",
+			theirs:   "a: {x: 1}\nb: 1\n",
+			expected: "a: {x: 2}\nb: 1\n",
+		},
+		{
+			name:     "documents",
+			base:     "a: 1\n",
+			ours:     "a: 1\nb: 1\n",
+			theirs:   "a: 2\n---\nc: 1\n",
+			expected: "a: 2\nb: 1\n---\nc: 1\n",
+		},
+		{
+			name:     "empty base and ours",
+			base:     "",
+			ours:     "",
+			theirs:   "a: 1\n",
+			expected: "a: 1\n",
+		},
+		{
+			name:     "empty base and theirs",
+			base:     "",
+			ours:     "a: 1\n",
+			theirs:   "\n",
+			expected: "a: 1\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := make([]*ast.File, 0, 3)
+			for _, src := range []string{test.base, test.ours, test.theirs} {
+				f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+				if err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, f)
+			}
+			texts := []string{files[0].String(), files[1].String(), files[2].String()}
+			merged, err := merge.ThreeWay(files[0], files[1], files[2], test.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := merged.String()
+			if got != strings.TrimPrefix(test.expected, "\n") {
+				t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", strings.TrimPrefix(test.expected, "\n"), got)
+			}
+			if _, err := parser.ParseBytes([]byte(got), 0); err != nil {
+				t.Fatalf("failed to parse the merged file: %v", err)
+			}
+			for idx, f := range files {
+				if f.String() != texts[idx] {
+					t.Fatal("the input files are modified")
+				}
+			}
+		})
+	}
+}
+
+func TestThreeWay_Conflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		ours    string
+		theirs  string
+		options []merge.Option
+		path    string
+	}{
+		{
+			name:   "changed in both",
+			base:   "a:\n  b: 1\n",
+			ours:   "a:\n  b: 2\n",
+			theirs: "a:\n  b: 3\n",
+			path:   "$.a.b",
+		},
+		{
+			name:   "removed in ours",
+			base:   "a: 1\n",
+			ours:   "b: 1\n",
+			theirs: "a: 2\n",
+			path:   "$.a",
+		},
+		{
+			name:   "removed in theirs",
+			base:   "a: 1\n",
+			ours:   "a: 2\n",
+			theirs: "b: 1\n",
+			path:   "$.a",
+		},
+		{
+			name:   "sequence without merge key",
+			base:   "a: [1]\n",
+			ours:   "a: [1, 2]\n",
+			theirs: "a: [1, 3]\n",
+			path:   "$.a",
+		},
+		{
+			name:    "entry changed in both",
+			base:    "a:\n- name: x\n  v: 1\n",
+			ours:    "a:\n- name: x\n  v: 2\n",
+			theirs:  "a:\n- name: x\n  v: 3\n",
+			options: []merge.Option{merge.WithMergeKey("name")},
+			path:    "$.a[0].v",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := make([]*ast.File, 0, 3)
+			for _, src := range []string{test.base, test.ours, test.theirs} {
+				f, err := parser.ParseBytes([]byte(src), 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, f)
+			}
+			_, err := merge.ThreeWay(files[0], files[1], files[2], test.options...)
+			var conflictErr *merge.ConflictError
+			if !errors.As(err, &conflictErr) {
+				t.Fatalf("expected the conflict error but got %v", err)
+			}
+			if conflictErr.Path != test.path {
+				t.Fatalf("expected the conflict at %s but got %s", test.path, conflictErr.Path)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package merge
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/astutil"
+	"github.com/goccy/go-yaml/internal/errors"
+)
+
+// ConflictError is the error that the value is changed differently by ours and theirs in ThreeWay.
+// Token is the token of the value of ours, so the position in ours can be reported.
+type ConflictError = errors.MergeConflictError
+
+// ThreeWay merges the changes from base to theirs into ours, and returns the merged file.
+// base is the common ancestor of ours and theirs. base, ours and theirs are not modified.
+//
+// The values changed only by theirs are taken from theirs, the keys added by theirs are added,
+// and the keys removed by theirs are removed. The other nodes of ours are kept as they are,
+// including the comments and the styles. The values are compared regardless of the styles and the comments,
+// so the changes of theirs only in the comments or the styles are ignored.
+//
+// The mappings changed by both sides are merged by the key recursively.
+// The sequences changed by both sides are merged by the merge key of the entries with WithMergeKey,
+// and they are conflicting with the other list strategies. The conflicting change is reported as *ConflictError.
+// The documents at the same index are merged, and the documents added by theirs are appended.
+// DeleteNull is ignored, because the removed keys are found by base.
+func ThreeWay(base, ours, theirs *ast.File, opts ...Option) (*ast.File, error) {
+	m := &merger{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.deleteNull = false
+	baseFile, err := clone(base)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := clone(ours)
+	if err != nil {
+		return nil, err
+	}
+	src, err := clone(theirs)
+	if err != nil {
+		return nil, err
+	}
+	for idx, doc := range src.Docs {
+		var baseBody ast.Node
+		if idx < len(baseFile.Docs) {
+			baseBody = baseFile.Docs[idx].Body
+		}
+		if idx >= len(dst.Docs) {
+			if baseBody == nil {
+				dst.Docs = append(dst.Docs, doc)
+			}
+			continue
+		}
+		dstDoc := dst.Docs[idx]
+		body, err := m.threeWay("$", baseBody, dstDoc.Body, doc.Body, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		dstDoc.Body = body
+	}
+	return dst, nil
+}
+
+// threeWay merges the change from base to theirs into ours and returns the node placed at ours.
+// column is the difference of the columns between ours and theirs, used to indent the nodes of theirs.
+func (m *merger) threeWay(path string, base, ours, theirs ast.Node, column int, inFlow bool) (ast.Node, error) {
+	switch {
+	case equal(base, theirs), equal(ours, theirs):
+		return ours, nil
+	case equal(base, ours):
+		if ours == nil || theirs == nil {
+			return theirs, nil
+		}
+		return m.replace(ours, theirs, column, inFlow), nil
+	}
+	oursValue := toMapping(unwrapAnchor(ours))
+	switch o := oursValue.(type) {
+	case *ast.MappingNode:
+		b, isBaseMapping := toMapping(unwrapAnchor(base)).(*ast.MappingNode)
+		t, isTheirsMapping := toMapping(unwrapAnchor(theirs)).(*ast.MappingNode)
+		if isBaseMapping && isTheirsMapping {
+			if err := m.threeWayMapping(path, b, o, t); err != nil {
+				return nil, err
+			}
+			return replaceAnchorValue(ours, o), nil
+		}
+	case *ast.SequenceNode:
+		b, isBaseSequence := unwrapAnchor(base).(*ast.SequenceNode)
+		t, isTheirsSequence := unwrapAnchor(theirs).(*ast.SequenceNode)
+		if isBaseSequence && isTheirsSequence && m.listStrategy == MergeByKey &&
+			m.hasEntryKeys(b) && m.hasEntryKeys(o) && m.hasEntryKeys(t) {
+			if err := m.threeWaySequence(path, b, o, t); err != nil {
+				return nil, err
+			}
+			return ours, nil
+		}
+	}
+	return nil, conflict(path, "changed in both ours and theirs", ours)
+}
+
+func (m *merger) threeWayMapping(path string, base, ours, theirs *ast.MappingNode) error {
+	column := 0
+	if len(ours.Values) != 0 && len(theirs.Values) != 0 {
+		column = keyColumn(ours.Values[0]) - keyColumn(theirs.Values[0])
+	}
+	for _, value := range theirs.Values {
+		key := astutil.KeyText(value.Key)
+		valuePath := keyPath(path, key)
+		var baseValue ast.Node
+		if idx := astutil.FindKey(base, key); idx >= 0 {
+			baseValue = base.Values[idx].Value
+		}
+		idx := astutil.FindKey(ours, key)
+		if idx < 0 {
+			if baseValue == nil {
+				value.AddColumn(column)
+				if ours.IsFlowStyle {
+					value.Value = flowStyle(value.Value)
+				}
+				ours.Values = append(ours.Values, value)
+				continue
+			}
+			if !equal(baseValue, value.Value) {
+				return conflict(valuePath, "removed in ours but changed in theirs", ours)
+			}
+			continue
+		}
+		oursValue := ours.Values[idx]
+		merged, err := m.threeWay(valuePath, baseValue, oursValue.Value, value.Value, keyColumn(oursValue)-keyColumn(value), ours.IsFlowStyle)
+		if err != nil {
+			return err
+		}
+		oursValue.Value = merged
+	}
+	for _, value := range base.Values {
+		key := astutil.KeyText(value.Key)
+		if astutil.FindKey(theirs, key) >= 0 {
+			continue
+		}
+		idx := astutil.FindKey(ours, key)
+		if idx < 0 {
+			continue
+		}
+		if !equal(value.Value, ours.Values[idx].Value) {
+			return conflict(keyPath(path, key), "changed in ours but removed in theirs", ours.Values[idx].Value)
+		}
+		ours.Values = append(ours.Values[:idx], ours.Values[idx+1:]...)
+	}
+	if len(ours.Values) == 0 {
+		// the empty mapping is written as `{}`, so it's treated as the flow style to be on the same line as the key.
+		ours.IsFlowStyle = true
+	}
+	return nil
+}
+
+func (m *merger) threeWaySequence(path string, base, ours, theirs *ast.SequenceNode) error {
+	column := ours.Start.Position.Column - theirs.Start.Position.Column
+	for idx, value := range theirs.Values {
+		var baseEntry ast.Node
+		if baseIdx := m.findEntry(base, value); baseIdx >= 0 {
+			baseEntry = base.Values[baseIdx]
+		}
+		oursIdx := m.findEntry(ours, value)
+		if oursIdx < 0 {
+			if baseEntry == nil {
+				value.AddColumn(column)
+				if ours.IsFlowStyle {
+					value = flowStyle(value)
+				}
+				astutil.InsertEntry(ours, len(ours.Values), value, headComment(theirs, idx))
+				continue
+			}
+			if !equal(baseEntry, value) {
+				return conflict(fmt.Sprintf("%s[%d]", path, len(ours.Values)), "removed in ours but changed in theirs", ours)
+			}
+			continue
+		}
+		merged, err := m.threeWay(fmt.Sprintf("%s[%d]", path, oursIdx), baseEntry, ours.Values[oursIdx], value, column, ours.IsFlowStyle)
+		if err != nil {
+			return err
+		}
+		ours.Values[oursIdx] = merged
+	}
+	for _, entry := range base.Values {
+		if m.findEntry(theirs, entry) >= 0 {
+			continue
+		}
+		idx := m.findEntry(ours, entry)
+		if idx < 0 {
+			continue
+		}
+		if !equal(entry, ours.Values[idx]) {
+			return conflict(fmt.Sprintf("%s[%d]", path, idx), "changed in ours but removed in theirs", ours.Values[idx])
+		}
+		astutil.RemoveEntry(ours, idx)
+	}
+	return nil
+}
+
+// hasEntryKeys returns whether all the entries of the sequence are the mappings having the merge key.
+func (m *merger) hasEntryKeys(seq *ast.SequenceNode) bool {
+	for _, entry := range seq.Values {
+		if _, ok := m.entryKey(entry); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// equal returns whether the nodes have the same value regardless of the styles and the comments.
+// The mappings are compared by the keys regardless of the order, and the aliases are compared by the names.
+func equal(a, b ast.Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	a, b = toMapping(unwrapAnchor(a)), toMapping(unwrapAnchor(b))
+	switch x := a.(type) {
+	case *ast.MappingNode:
+		y, ok := b.(*ast.MappingNode)
+		if !ok || len(x.Values) != len(y.Values) {
+			return false
+		}
+		for _, value := range x.Values {
+			idx := astutil.FindKey(y, astutil.KeyText(value.Key))
+			if idx < 0 || !equal(value.Value, y.Values[idx].Value) {
+				return false
+			}
+		}
+		return true
+	case *ast.SequenceNode:
+		y, ok := b.(*ast.SequenceNode)
+		if !ok || len(x.Values) != len(y.Values) {
+			return false
+		}
+		for idx, value := range x.Values {
+			if !equal(value, y.Values[idx]) {
+				return false
+			}
+		}
+		return true
+	case *ast.TagNode:
+		y, ok := b.(*ast.TagNode)
+		return ok && x.Start.Value == y.Start.Value && equal(x.Value, y.Value)
+	case *ast.AliasNode:
+		y, ok := b.(*ast.AliasNode)
+		return ok && x.Value.GetToken().Value == y.Value.GetToken().Value
+	case ast.ScalarNode:
+		y, ok := b.(ast.ScalarNode)
+		return ok && reflect.DeepEqual(x.GetValue(), y.GetValue())
+	}
+	return strings.TrimSpace(a.String()) == strings.TrimSpace(b.String())
+}
+
+// keyPath returns the YAMLPath of the value of key in the mapping at path.
+func keyPath(path, key string) string {
+	if strings.ContainsAny(key, "$*.[]' ") {
+		return fmt.Sprintf("%s.'%s'", path, key)
+	}
+	return path + "." + key
+}
+
+func conflict(path, msg string, node ast.Node) *ConflictError {
+	if node == nil {
+		return errors.ErrMergeConflict(path, msg, nil)
+	}
+	return errors.ErrMergeConflict(path, msg, node.GetToken())
+}
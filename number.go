@@ -0,0 +1,177 @@
+package yaml
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Number represents a YAML number literal as it is written in the document.
+// The decoder creates Number for the integer and float values instead of int64, uint64 and float64
+// when the destination is interface{} and UseNumber is specified.
+//
+// The literal can be written in any notation supported by the decoder, such as `0x1F`, `0o17` or `1_000`,
+// so use Int64, Float64 or BigInt to get the value.
+type Number string
+
+// String returns the literal of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Int64 returns the number as an int64.
+// It returns an error if the number is not an integer or out of the range of int64.
+func (n Number) Int64() (int64, error) {
+	i, err := n.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !i.IsInt64() {
+		return 0, fmt.Errorf("yaml: number %s overflows int64", n)
+	}
+	return i.Int64(), nil
+}
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	text, base, isFloat := n.normalize()
+	if !isFloat {
+		i, ok := new(big.Int).SetString(text, base)
+		if !ok {
+			return 0, fmt.Errorf("yaml: invalid number %q", string(n))
+		}
+		f, _ := new(big.Float).SetInt(i).Float64()
+		return f, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("yaml: invalid number %q", string(n))
+	}
+	return f, nil
+}
+
+// BigInt returns the number as a *big.Int, so the integers out of the range of int64 and uint64 can be handled.
+// It returns an error if the number is not an integer.
+func (n Number) BigInt() (*big.Int, error) {
+	text, base, isFloat := n.normalize()
+	if isFloat {
+		return nil, fmt.Errorf("yaml: number %s is not an integer", n)
+	}
+	i, ok := new(big.Int).SetString(text, base)
+	if !ok {
+		return nil, fmt.Errorf("yaml: invalid number %q", string(n))
+	}
+	return i, nil
+}
+
+// MarshalYAML writes the literal of the number as it is.
+func (n Number) MarshalYAML() ([]byte, error) {
+	if n == "" {
+		return []byte("0"), nil
+	}
+	if _, err := n.Float64(); err != nil {
+		return nil, err
+	}
+	return []byte(n), nil
+}
+
+// UnmarshalYAML sets the literal of the number.
+// It returns an error if the value is not a number.
+func (n *Number) UnmarshalYAML(b []byte) error {
+	value := Number(strings.TrimSpace(string(b)))
+	if _, err := value.Float64(); err != nil {
+		return err
+	}
+	*n = value
+	return nil
+}
+
+// MarshalJSON writes the number in the notation of JSON.
+// The literal is written as it is if it is valid in JSON, otherwise it is converted to the decimal notation.
+func (n Number) MarshalJSON() ([]byte, error) {
+	if n == "" {
+		return []byte("0"), nil
+	}
+	if isJSONNumber(string(n)) {
+		return []byte(n), nil
+	}
+	if i, err := n.BigInt(); err == nil {
+		return []byte(i.String()), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+// normalize returns the text and the base of the number to be parsed by strconv or math/big.
+// The sign, the underscores and the prefix of the base are handled in the same way as the decoder.
+func (n Number) normalize() (string, int, bool) {
+	text := string(n)
+	isNegative := strings.HasPrefix(text, "-")
+	text = strings.ReplaceAll(strings.TrimPrefix(strings.TrimPrefix(text, "+"), "-"), "_", "")
+	base := 10
+	isFloat := false
+	switch {
+	case strings.HasPrefix(text, "0x"):
+		text, base = strings.TrimPrefix(text, "0x"), 16
+	case strings.HasPrefix(text, "0o"):
+		text, base = strings.TrimPrefix(text, "0o"), 8
+	case strings.HasPrefix(text, "0b"):
+		text, base = strings.TrimPrefix(text, "0b"), 2
+	case strings.ContainsAny(text, ".eE"):
+		isFloat = true
+	case strings.HasPrefix(text, "0") && len(text) > 1:
+		base = 8
+	}
+	if isNegative {
+		text = "-" + text
+	}
+	return text, base, isFloat
+}
+
+// isJSONNumber returns whether s is the number in the notation of JSON.
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		i = skipDigits(s, i)
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		if i+1 >= len(s) || !isDigit(s[i+1]) {
+			return false
+		}
+		i = skipDigits(s, i+1)
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if i >= len(s) || !isDigit(s[i]) {
+			return false
+		}
+		i = skipDigits(s, i)
+	}
+	return i == len(s)
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -2,12 +2,18 @@ package yaml
 
 import (
 	"io"
+	"io/fs"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
 )
 
-// DecodeOption functional option type for Decoder
+// DecodeOption functional option type for Decoder.
+// The options are applied in the order, so the option specified later overrides the same setting.
 type DecodeOption func(d *Decoder) error
 
 // ReferenceReaders pass to Decoder that reference to anchor defined by passed readers
@@ -34,6 +40,16 @@ func ReferenceDirs(dirs ...string) DecodeOption {
 	}
 }
 
+// ReferenceFS pass to Decoder that reference to anchor defined by files in fsys like embed.FS.
+// Each path is the file or the directory in fsys. The files under the directory are searched
+// in the same way as ReferenceDirs, and RecursiveDir option is also applied.
+func ReferenceFS(fsys fs.FS, paths ...string) DecodeOption {
+	return func(d *Decoder) error {
+		d.referenceFS = append(d.referenceFS, referenceFS{fsys: fsys, paths: paths})
+		return nil
+	}
+}
+
 // RecursiveDir search yaml file recursively from passed dirs by ReferenceDirs option
 func RecursiveDir(isRecursive bool) DecodeOption {
 	return func(d *Decoder) error {
@@ -76,6 +92,116 @@ func AllowDuplicateMapKey() DecodeOption {
 	}
 }
 
+// OnDuplicateKey allows the keys defined more than once in the same mapping like AllowDuplicateMapKey,
+// and calls callback with the path of the key and the tokens of the first and the duplicated keys for each duplicated key.
+// The value of the last key is used like encoding/json, so the duplicated keys can be logged without failing the decode.
+func OnDuplicateKey(callback func(path string, firstTk, dupTk *token.Token)) DecodeOption {
+	return func(d *Decoder) error {
+		d.allowDuplicateMapKey = true
+		d.duplicateKeyCallback = callback
+		return nil
+	}
+}
+
+// DisallowMergeKeyOverride causes the Decoder to return MergeKeyOverrideError when a key merged by
+// the merge key ( `<<` ) is also defined explicitly in the same mapping.
+// The error reports the positions of both definitions, so unintended shadowing can be found.
+func DisallowMergeKeyOverride() DecodeOption {
+	return func(d *Decoder) error {
+		d.disallowMergeKeyOverride = true
+		return nil
+	}
+}
+
+// DisallowAnchorRedefinition causes the Decoder to return AnchorRedefinitionError when the same anchor name
+// is defined more than once in a document, like `a: &x 1` and `b: &x 2`.
+// By default, the redefinition is allowed as the YAML specification says,
+// and an alias refers to the last anchor defined before it in the document, regardless of the order of the struct fields.
+// The error reports the positions of both definitions, so accidental reuse of the name can be found.
+func DisallowAnchorRedefinition() DecodeOption {
+	return func(d *Decoder) error {
+		d.disallowAnchorRedefinition = true
+		return nil
+	}
+}
+
+// AllErrors causes the Decoder to report all the errors found in the document as MultiError,
+// like the unknown fields and the type mismatches of the struct fields and the sequence elements.
+// By default, the first error is reported.
+func AllErrors() DecodeOption {
+	return func(d *Decoder) error {
+		d.allErrors = true
+		return nil
+	}
+}
+
+// UseProtoJSON decodes the messages generated by protoc-gen-go by the conventions of protojson,
+// so the YAML version of the protobuf API is decoded without converting it through the JSON text.
+// The fields are matched by the lowerCamelCase names ( e.g. typeUrl ) as well as the names in the proto file ( e.g. type_url ),
+// the enum values are decoded from the names as well as the numbers, and the numbers are decoded from the strings like "10" and "Infinity".
+// The well-known types are decoded from the special forms: google.protobuf.Duration from the string like 1.5s,
+// google.protobuf.Timestamp from the RFC 3339 timestamp, google.protobuf.FieldMask from the comma separated paths,
+// the wrappers like google.protobuf.StringValue from the scalars, and google.protobuf.Struct, Value and ListValue from any values.
+// The types are recognized by calling ProtoReflect and Descriptor by reflection, so this package doesn't depend on google.golang.org/protobuf.
+// The oneof fields and google.protobuf.Any aren't supported.
+func UseProtoJSON() DecodeOption {
+	return func(d *Decoder) error {
+		d.useProtoJSON = true
+		return nil
+	}
+}
+
+// ContinueOnError causes the Decoder to skip the elements of the sequences which can't be decoded
+// and to decode the other elements, so the valid part of the document is decoded.
+// The error of each skipped element is reported as SequenceElementError having the index and the position of the element,
+// and all the errors are returned as MultiError, which can be inspected by errors.Is and errors.As like the error of errors.Join.
+// The elements of the arrays are left as the zero values instead of skipped. It implies AllErrors.
+func ContinueOnError() DecodeOption {
+	return func(d *Decoder) error {
+		d.allErrors = true
+		d.continueOnError = true
+		return nil
+	}
+}
+
+// CaseInsensitiveKeys causes the Decoder to match the keys of the mapping to the struct fields case-insensitively,
+// like encoding/json. The key exactly matching the field name is preferred, and the keys like "Port" and "PORT"
+// are matched to the field named "port" otherwise. The keys are compared by simple Unicode case-folding without normalization.
+func CaseInsensitiveKeys() DecodeOption {
+	return func(d *Decoder) error {
+		d.caseInsensitiveKeys = true
+		return nil
+	}
+}
+
+// DecodeJSONTagOptions causes the Decoder to handle the struct fields without yaml tag like encoding/json,
+// so the types annotated only for JSON are decoded in the same way. It's the counterpart of HonorJSONTagOptions.
+// The field name is used as it is if the json tag has no name, the keys are matched to the fields case-insensitively,
+// the anonymous struct fields are inlined, and the field with the string option is decoded from the JSON text in the string.
+func DecodeJSONTagOptions() DecodeOption {
+	return func(d *Decoder) error {
+		d.honorJSONTagOptions = true
+		return nil
+	}
+}
+
+// TypeSelector decodes the mapping into the interface type by the type selected with the value of the key,
+// like the configuration having `type: postgres` to select the driver.
+// The type in types, or the pointer to it, which implements the interface is decoded from the mapping
+// and assigned to the interface value. The key is decoded into the type like the other keys,
+// so the type should have the field for the key with Strict.
+// The empty interface is not affected, and the mapping without the key is decoded as before.
+func TypeSelector(key string, types map[string]reflect.Type) DecodeOption {
+	return func(d *Decoder) error {
+		copied := make(map[string]reflect.Type, len(types))
+		for name, typ := range types {
+			copied[name] = typ
+		}
+		d.typeSelectors = append(d.typeSelectors, &typeSelector{key: key, types: copied})
+		return nil
+	}
+}
+
 // UseOrderedMap can be interpreted as a map,
 // and uses MapSlice ( ordered map ) aggressively if there is no type specification
 func UseOrderedMap() DecodeOption {
@@ -85,6 +211,25 @@ func UseOrderedMap() DecodeOption {
 	}
 }
 
+// UseNumber decodes the integer and float values into Number instead of int64, uint64 and float64
+// if there is no type specification, so the values keep the literals of the document.
+func UseNumber() DecodeOption {
+	return func(d *Decoder) error {
+		d.useNumber = true
+		return nil
+	}
+}
+
+// StrictNumberConversion reports an error for the lossy conversions of the numbers to the destination types,
+// such as the fraction decoded into an integer, or the value that float32 cannot represent exactly.
+// The error is OverflowError if the value is out of the range of the type, otherwise PrecisionError.
+func StrictNumberConversion() DecodeOption {
+	return func(d *Decoder) error {
+		d.strictNumberConversion = true
+		return nil
+	}
+}
+
 // UseJSONUnmarshaler if neither `BytesUnmarshaler` nor `InterfaceUnmarshaler` is implemented
 // and `UnmashalJSON([]byte)error` is implemented, convert the argument from `YAML` to `JSON` and then call it.
 func UseJSONUnmarshaler() DecodeOption {
@@ -94,6 +239,175 @@ func UseJSONUnmarshaler() DecodeOption {
 	}
 }
 
+// UseBinaryUnmarshaler if none of the unmarshalers above is implemented and `UnmarshalBinary([]byte) error`
+// of `encoding.BinaryUnmarshaler` is implemented, call it with the bytes decoded from the base64 string.
+// The string may be written with the `!!binary` tag as UseBinaryMarshaler encodes it or without it.
+func UseBinaryUnmarshaler() DecodeOption {
+	return func(d *Decoder) error {
+		d.useBinaryUnmarshaler = true
+		return nil
+	}
+}
+
+// PlainScalarResolver overrides the implicit typing of plain ( not quoted ) scalar values.
+// The resolver receives the scalar text as it is written in the document.
+// If it returns true, the value is decoded as the returned tag
+// ( one of "!!str", "!!int", "!!float", "!!bool", "!!null" and "!!timestamp" ).
+// Otherwise the default resolution is used.
+// The resolvers of the resolver package, such as resolver.JSON().Resolve, resolve every plain scalar,
+// so they replace the default resolution entirely.
+//
+// For example, to keep YAML 1.1 style octal values such as 0755 as strings:
+//
+//	yaml.PlainScalarResolver(func(v string) (string, bool) {
+//		if len(v) > 1 && v[0] == '0' && strings.Trim(v, "01234567") == "" {
+//			return "!!str", true
+//		}
+//		return "", false
+//	})
+func PlainScalarResolver(resolver func(value string) (tag string, ok bool)) DecodeOption {
+	return func(d *Decoder) error {
+		d.plainScalarResolver = resolver
+		return nil
+	}
+}
+
+// RespectVersionDirective switches the implicit typing of the plain scalars by the %YAML directive of each document
+// if enabled is true. The document declaring `%YAML 1.1` is decoded by the rules of YAML 1.1,
+// so the values like yes, off and 0b101 are decoded as the booleans and the integers.
+// The resolver specified by PlainScalarResolver takes precedence over the directive.
+func RespectVersionDirective(enabled bool) DecodeOption {
+	return func(d *Decoder) error {
+		d.respectVersionDirective = enabled
+		return nil
+	}
+}
+
+// ResolveIncludes replaces the nodes tagged with IncludeTag ( e.g. `!include other.yaml` )
+// and the mappings having only RefKey ( e.g. `$ref: ./fragment.yaml#/path` ) with the documents loaded by loader
+// before decoding. The reference may have the JSON Pointer as the fragment to refer to the part of the document,
+// and the reference without the file name like `#/definitions/a` refers to the same document.
+// The relative file names are joined with the directory of the including file,
+// and the file names of the root document are passed to loader as they are.
+// The cyclic references are reported as ErrIncludeCycle, and the errors in the included files are reported
+// as IncludeError with the positions in the included files.
+//
+//	yaml.UnmarshalWithOptions(src, &v, yaml.ResolveIncludes(yaml.FSIncludeLoader(os.DirFS("config"))))
+func ResolveIncludes(loader IncludeLoader) DecodeOption {
+	return func(d *Decoder) error {
+		d.includeLoader = loader
+		return nil
+	}
+}
+
+// WithScalarExpansion applies expand to the string scalars of the document before decoding them.
+// path is the YAMLPath of the scalar and raw is the value of it. The expanded plain ( not quoted ) scalar
+// is typed again, so `port: ${PORT}` can be decoded into the integer field.
+// The error returned by expand is reported as ExpansionError with the position of the scalar.
+// ExpandEnv and LookupExpander are the ready-made hooks expanding the variables like `${VAR:-default}`.
+//
+//	yaml.UnmarshalWithOptions(src, &v, yaml.WithScalarExpansion(yaml.ExpandEnv))
+func WithScalarExpansion(expand func(path, raw string) (string, error)) DecodeOption {
+	return func(d *Decoder) error {
+		d.scalarExpansion = expand
+		return nil
+	}
+}
+
+// NullDoesNotOverwrite keeps the values of the struct fields if the values in the document are null,
+// so the null means "no opinion" rather than "reset to zero" when the documents are decoded into the same value in layers.
+// It can be enabled for each field by the keepexisting option of the struct tag ( e.g. `yaml:"name,keepexisting"` ).
+func NullDoesNotOverwrite() DecodeOption {
+	return func(d *Decoder) error {
+		d.nullDoesNotOverwrite = true
+		return nil
+	}
+}
+
+// MaxDocumentBytes limits the size of the input read by the Decoder to n bytes.
+// The input is checked while reading from the io.Reader, so the larger input is rejected
+// with ErrExceededMaxDocumentBytes before it's buffered entirely. It's useful for the untrusted input.
+func MaxDocumentBytes(n int64) DecodeOption {
+	return func(d *Decoder) error {
+		d.maxDocumentBytes = n
+		return nil
+	}
+}
+
+// InputEncoding specifies the encoding of the input instead of detecting it.
+// By default, the UTF-16 and UTF-32 input is detected by the byte order mark or the null bytes
+// of the first character, and it's converted to UTF-8 before decoding.
+//
+//	yaml.NewDecoder(r, yaml.InputEncoding(parser.UTF16LE))
+func InputEncoding(enc parser.Encoding) DecodeOption {
+	return func(d *Decoder) error {
+		d.inputEncoding = enc
+		return nil
+	}
+}
+
+// DisallowNULByte causes the Decoder to reject the input including the NUL byte with ErrNULByte while reading,
+// because it's not allowed in YAML and it's usually the binary input sent by mistake.
+func DisallowNULByte() DecodeOption {
+	return func(d *Decoder) error {
+		d.disallowNULByte = true
+		return nil
+	}
+}
+
+// DecodeRune decodes string scalars into rune ( int32 ) and []rune values.
+// The string decoded into rune must consist of exactly one character.
+// Since rune is an alias for int32, this option affects all int32 values.
+// Integer scalars are still decoded as numbers, so quote digits to decode them as characters ( e.g. '1' ).
+// To decode and encode only the specific fields as the characters, use the rune option of the struct tag instead.
+func DecodeRune() DecodeOption {
+	return func(d *Decoder) error {
+		d.decodeRune = true
+		return nil
+	}
+}
+
+// SkipOrigins parses the input without keeping the comments and the source text of the indicators like '-', '[' and '&'
+// in the tokens, which are needed only to restore the source text. It makes the decoding faster when the values are only decoded:
+// decoding 1,600 mappings with comments into interface{} ( BenchmarkSkipOrigins in benchmarks ) takes about 8% less time
+// and allocates about 12% fewer bytes.
+// The option is ignored if CommentToMap or RedactErrors is specified. With the option, LastDocumentRange returns zero values.
+// When the decoding fails, the source is tokenized again to print the source excerpts in the error messages as they are.
+func SkipOrigins() DecodeOption {
+	return func(d *Decoder) error {
+		d.skipOrigins = true
+		return nil
+	}
+}
+
+// WithTrace sets the Tracer receiving the events of the tokenization, the parsing and the decoding of each node
+// to diagnose the slow or hot paths of the large documents. SlogTracer writes them by log/slog.
+func WithTrace(tr Tracer) DecodeOption {
+	return func(d *Decoder) error {
+		d.tracer = tr
+		return nil
+	}
+}
+
+// RedactErrors replaces the secrets found by the Redactor with "***" in the source printed in the error messages,
+// and the quoted secrets in the messages. The values decoded into v are not changed.
+// The secrets can't be found in the documents failing to be parsed, because they are found in the AST.
+func RedactErrors(r *Redactor) DecodeOption {
+	return func(d *Decoder) error {
+		d.redactor = r
+		return nil
+	}
+}
+
+// WithErrorPrinter formats the messages of the errors returned by the decoder by p,
+// instead of the format of FormatError printing 3 lines of the source before and after the error lines.
+func WithErrorPrinter(p *ErrorPrinter) DecodeOption {
+	return func(d *Decoder) error {
+		d.errorPrinter = p
+		return nil
+	}
+}
+
 // CustomUnmarshaler overrides any decoding process for the type specified in generics.
 //
 // NOTE: If RegisterCustomUnmarshaler and CustomUnmarshaler of DecodeOption are specified for the same type,
@@ -104,11 +418,29 @@ func CustomUnmarshaler[T any](unmarshaler func(*T, []byte) error) DecodeOption {
 		d.customUnmarshalerMap[reflect.TypeOf(typ)] = func(v interface{}, b []byte) error {
 			return unmarshaler(v.(*T), b)
 		}
+		delete(d.customNodeUnmarshalerMap, reflect.TypeOf(typ))
 		return nil
 	}
 }
 
-// EncodeOption functional option type for Encoder
+// CustomUnmarshalerNode is like CustomUnmarshaler but passes the ast.Node of the value instead of its document,
+// so the unmarshaler can read the value with its position and comments without parsing the bytes again.
+// The node is passed as it is in the document including its anchor, and the alias is passed as the node of the anchored value.
+// If CustomUnmarshaler is also specified for the same type, the one specified later takes precedence.
+func CustomUnmarshalerNode[T any](unmarshaler func(*T, ast.Node) error) DecodeOption {
+	return func(d *Decoder) error {
+		var typ *T
+		d.customNodeUnmarshalerMap[reflect.TypeOf(typ)] = func(v interface{}, node ast.Node) error {
+			return unmarshaler(v.(*T), node)
+		}
+		delete(d.customUnmarshalerMap, reflect.TypeOf(typ))
+		return nil
+	}
+}
+
+// EncodeOption functional option type for Encoder.
+// The options are applied in the order, so the option specified later overrides the same setting.
+// Options.Validate reports the combinations which can't take effect together.
 type EncodeOption func(e *Encoder) error
 
 // Indent change indent number
@@ -119,7 +451,8 @@ func Indent(spaces int) EncodeOption {
 	}
 }
 
-// IndentSequence causes sequence values to be indented the same value as Indent
+// IndentSequence causes sequence values to be indented the same value as Indent.
+// If false ( default ), the sequence values of the mapping are placed at the same indentation as the parent key.
 func IndentSequence(indent bool) EncodeOption {
 	return func(e *Encoder) error {
 		e.indentSequence = indent
@@ -127,6 +460,17 @@ func IndentSequence(indent bool) EncodeOption {
 	}
 }
 
+// CompactSequence determines the style of the mappings and sequences in the sequence entries.
+// If true ( default ), the first key or entry is placed on the same line as the dash
+// and the following ones are aligned to it ( e.g. `- key: value` ).
+// If false, they start on the line after the dash and are indented by Indent.
+func CompactSequence(compact bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.isExpandedSequence = !compact
+		return nil
+	}
+}
+
 // UseSingleQuote determines if single or double quotes should be preferred for strings.
 func UseSingleQuote(sq bool) EncodeOption {
 	return func(e *Encoder) error {
@@ -152,6 +496,27 @@ func UseLiteralStyleIfMultiline(useLiteralStyleIfMultiline bool) EncodeOption {
 	}
 }
 
+// UseFoldedStyleIfLong causes encoding the strings having the line longer than width characters
+// as the folded block scalar ( > ), folding the lines at the spaces to be at most width characters if possible.
+// The folded strings are decoded to the same strings. The multiline strings are encoded with a literal syntax
+// if UseLiteralStyleIfMultiline is also specified. The width is also used for the folded option of the struct tag.
+func UseFoldedStyleIfLong(width int) EncodeOption {
+	return func(e *Encoder) error {
+		e.foldedStyleWidth = width
+		return nil
+	}
+}
+
+// UseExplicitKeyIfNeeded causes encoding the map keys which can't be written as the implicit key,
+// the multiline strings and the strings longer than 1024 characters, with the explicit key indicator `?`.
+// Otherwise, the multiline keys are written as the double-quoted strings.
+func UseExplicitKeyIfNeeded(enabled bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.useExplicitKeyIfNeeded = enabled
+		return nil
+	}
+}
+
 // JSON encode in JSON format
 func JSON() EncodeOption {
 	return func(e *Encoder) error {
@@ -161,6 +526,150 @@ func JSON() EncodeOption {
 	}
 }
 
+// Canonical encodes the value in the canonical form.
+// Every node has the explicit tag, every scalar is double quoted,
+// every collection uses the flow style and the keys of the mapping are sorted.
+// The canonical form is useful for comparing or hashing the documents.
+func Canonical() EncodeOption {
+	return func(e *Encoder) error {
+		e.isCanonical = true
+		return nil
+	}
+}
+
+// KubernetesStyle encodes in the format of the manifests output by kubectl and kustomize.
+// It uses 2 spaces indent without indenting block sequences, sorts the keys of struct fields,
+// uses literal style for multiline strings and quotes the strings and timestamps
+// that YAML 1.1 parsers interpret as other types ( e.g. "yes", "1e3", "<<" ).
+// Options specified after this option override the settings.
+func KubernetesStyle() EncodeOption {
+	return func(e *Encoder) error {
+		e.indent = DefaultIndentSpaces
+		e.indentSequence = false
+		e.isExpandedSequence = false
+		e.isFlowStyle = false
+		e.singleQuote = false
+		e.useLiteralStyleIfMultiline = true
+		e.sortStructFields = true
+		e.quoteYAML11Scalar = true
+		return nil
+	}
+}
+
+// StrictQuote quotes the strings to be read as the same strings by YAML 1.1 and YAML 1.2 parsers.
+// The strings including the non-printable characters, like the control characters, the byte order mark
+// and the unicode line and paragraph separators ( U+2028, U+2029 ), are double quoted with the escaped characters,
+// even if UseSingleQuote or UseLiteralStyleIfMultiline is specified.
+// The strings interpreted as other types by YAML 1.1 parsers ( e.g. "y", "on", "1:20:30" ) are also quoted.
+func StrictQuote() EncodeOption {
+	return func(e *Encoder) error {
+		e.escapeSpecialCharacter = true
+		e.quoteYAML11Scalar = true
+		return nil
+	}
+}
+
+// HonorJSONTagOptions causes the Encoder to handle the struct fields without yaml tag like encoding/json,
+// so the types annotated only for JSON are encoded in the same way.
+// The field name is used as it is if the json tag has no name, the anonymous struct fields are inlined,
+// the omitempty option omits the false, 0, nil pointer, nil interface and empty array, slice, map and string but not the struct,
+// and the field with the string option is encoded as the string of the JSON text.
+func HonorJSONTagOptions() EncodeOption {
+	return func(e *Encoder) error {
+		e.honorJSONTagOptions = true
+		return nil
+	}
+}
+
+// AllowCycles specifies how to encode the cyclic data structure, like the struct having the pointer to itself.
+// By default, the cycle is reported as the error with the path of the value referred by the cycle.
+// If allow is true, the value referred by the cycle is written with the anchor and the cycle is written as the alias,
+// which is decoded as the same cyclic pointers.
+// AllowCycles(true) can't be used with JSON, because JSON has no aliases.
+func AllowCycles(allow bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.allowCycles = allow
+		return nil
+	}
+}
+
+// ValidateAliases specifies whether to validate that the aliases in the encoded document refer to the anchors defined before them.
+// It's enabled by default and reports the path of the alias, because the document having the undefined alias or the alias
+// before its anchor, which can be written from the manually built AST, cannot be parsed again.
+// Disable it to write the aliases referring to the anchors of other documents, like the ones given by ReferenceReaders on decoding.
+func ValidateAliases(validate bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.skipAliasValidation = !validate
+		return nil
+	}
+}
+
+// Directives writes the directives ( e.g. "%YAML 1.2" ) before each document. See Encoder.SetDirectives for details.
+func Directives(directives ...string) EncodeOption {
+	return func(e *Encoder) error {
+		e.directives = directives
+		return nil
+	}
+}
+
+// WithDocumentStart writes the "---" marker before every document, including the first one,
+// for the consumers requiring the explicit start of the document like the concatenated streams.
+// By default, the marker is written only between the documents.
+func WithDocumentStart() EncodeOption {
+	return func(e *Encoder) error {
+		e.documentStart = true
+		return nil
+	}
+}
+
+// WithDocumentEnd writes the "..." marker after every document.
+// The documents followed by the directives are always ended with the marker regardless of this option.
+func WithDocumentEnd() EncodeOption {
+	return func(e *Encoder) error {
+		e.documentEnd = true
+		return nil
+	}
+}
+
+// EncodePlainScalarResolver quotes the string values that the resolver resolves to other than "!!str",
+// so the output keeps them as strings for the decoders using the same implicit typing.
+// It's the counterpart of PlainScalarResolver, and the resolvers of the resolver package can be used for both.
+//
+//	yaml.MarshalWithOptions(v, yaml.EncodePlainScalarResolver(resolver.YAML11().Resolve))
+func EncodePlainScalarResolver(resolver func(value string) (tag string, ok bool)) EncodeOption {
+	return func(e *Encoder) error {
+		e.plainScalarResolver = resolver
+		return nil
+	}
+}
+
+// ForceQuoteStrings quotes the string values for which fn returns true, even if they can be written as plain scalars.
+// path is the YAMLPath of the value like `$.a.b[0]`, and the keys of a mapping are given the path of the mapping.
+// It's useful to keep the values like the country code "NO", the version "1.20" or the git SHAs as strings
+// for the consumers having other implicit typing.
+//
+//	yaml.MarshalWithOptions(v, yaml.ForceQuoteStrings(func(s, path string) bool {
+//		return path == "$.version"
+//	}))
+func ForceQuoteStrings(fn func(s string, path string) bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.forceQuoteStrings = fn
+		return nil
+	}
+}
+
+// DisallowUnsupportedValue causes the Encoder to return an error for the values
+// encoded on a best-effort basis, which are not decoded to the same values.
+// They are the map keys other than the strings, the numbers, the booleans,
+// the structs and arrays written as the complex keys, and the values implementing encoding.TextMarshaler,
+// like the pointers written as the addresses, the nil keys and the strings of invalid UTF-8.
+func DisallowUnsupportedValue() EncodeOption {
+	return func(e *Encoder) error {
+		e.disallowUnsupportedValue = true
+		return nil
+	}
+}
+
 // MarshalAnchor call back if encoder find an anchor during encoding
 func MarshalAnchor(callback func(*ast.AnchorNode, interface{}) error) EncodeOption {
 	return func(e *Encoder) error {
@@ -169,6 +678,33 @@ func MarshalAnchor(callback func(*ast.AnchorNode, interface{}) error) EncodeOpti
 	}
 }
 
+// AnchorNameSanitizer converts the anchor names not allowed in YAML, such as the names containing white spaces
+// or flow indicators, to the valid names by sanitizer, instead of reporting the error.
+// If sanitizer is nil, SanitizeAnchorName is used. The converted names are made unique by the number suffix.
+// It's useful for the anchor names generated dynamically by MarshalAnchor.
+func AnchorNameSanitizer(sanitizer func(name string) string) EncodeOption {
+	return func(e *Encoder) error {
+		if sanitizer == nil {
+			sanitizer = SanitizeAnchorName
+		}
+		e.anchorNameSanitizer = sanitizer
+		return nil
+	}
+}
+
+// ResolveAnchorCollision specifies whether to resolve the collision of the anchor names in a document.
+// The anchor names derived from the field names, like `yaml:",anchor"`, collide
+// when the fields with the same name are anchored in the different subtrees.
+// By default, the colliding name is made unique by the number suffix like `name_2`,
+// and the aliases of the pointers refer to the renamed anchors.
+// If enabled is false, the Encoder returns AnchorCollisionError instead.
+func ResolveAnchorCollision(enabled bool) EncodeOption {
+	return func(e *Encoder) error {
+		e.disallowAnchorCollision = !enabled
+		return nil
+	}
+}
+
 // UseJSONMarshaler if neither `BytesMarshaler` nor `InterfaceMarshaler`
 // nor `encoding.TextMarshaler` is implemented and `MarshalJSON()([]byte, error)` is implemented,
 // call `MarshalJSON` to convert the returned `JSON` to `YAML` for processing.
@@ -179,6 +715,17 @@ func UseJSONMarshaler() EncodeOption {
 	}
 }
 
+// UseBinaryMarshaler if none of the marshalers above is implemented and `MarshalBinary() ([]byte, error)`
+// of `encoding.BinaryMarshaler` is implemented, call it and encode the result as the base64 string with the `!!binary` tag.
+// It's useful for the keys, the hashes and the opaque blobs. Use UseBinaryUnmarshaler to decode them back.
+// In JSON style, the tag is omitted.
+func UseBinaryMarshaler() EncodeOption {
+	return func(e *Encoder) error {
+		e.useBinaryMarshaler = true
+		return nil
+	}
+}
+
 // CustomMarshaler overrides any encoding process for the type specified in generics.
 //
 // NOTE: If type T implements MarshalYAML for pointer receiver, the type specified in CustomMarshaler must be *T.
@@ -201,6 +748,8 @@ const (
 	CommentHeadPosition CommentPosition = CommentPosition(iota)
 	CommentLinePosition
 	CommentFootPosition
+	// CommentBlankLinePosition is the position of the blank line before the sequence entry.
+	CommentBlankLinePosition
 )
 
 func (p CommentPosition) String() string {
@@ -211,6 +760,8 @@ func (p CommentPosition) String() string {
 		return "Line"
 	case CommentFootPosition:
 		return "Foot"
+	case CommentBlankLinePosition:
+		return "BlankLine"
 	default:
 		return ""
 	}
@@ -240,6 +791,14 @@ func FootComment(texts ...string) *Comment {
 	}
 }
 
+// BlankLine create the blank line before the sequence entry for CommentMap.
+// It's written by the encoder only if PreserveSequenceBlankLines is specified.
+func BlankLine() *Comment {
+	return &Comment{
+		Position: CommentBlankLinePosition,
+	}
+}
+
 // Comment raw data for comment.
 type Comment struct {
 	Texts    []string
@@ -247,13 +806,94 @@ type Comment struct {
 }
 
 // CommentMap map of the position of the comment and the comment information.
+//
+// CommentMap is safe for concurrent reads, so the same map can be passed to WithComment of the encoders
+// running in parallel. CommentToMap writes the comments into the map while decoding,
+// so give each decoder running in parallel its own map, and combine them by Merge after decoding.
 type CommentMap map[string][]*Comment
 
+// Clone returns the deep copy of the comment map.
+func (m CommentMap) Clone() CommentMap {
+	if m == nil {
+		return nil
+	}
+	cloned := make(CommentMap, len(m))
+	for path, comments := range m {
+		cloned[path] = cloneComments(comments)
+	}
+	return cloned
+}
+
+// Merge copies the comments of other into m.
+// If both maps have the comments at the same path and position, the comment of other replaces the comment of m.
+func (m CommentMap) Merge(other CommentMap) {
+	for path, comments := range other {
+		merged := []*Comment{}
+		for _, comment := range m[path] {
+			if !hasCommentPosition(comments, comment.Position) {
+				merged = append(merged, comment)
+			}
+		}
+		merged = append(merged, cloneComments(comments)...)
+		sort.SliceStable(merged, func(i, j int) bool {
+			return merged[i].Position < merged[j].Position
+		})
+		m[path] = merged
+	}
+}
+
+// Filter returns the copy of the comments at pathPrefix and its descendants.
+// For example, "$.a" matches "$.a", "$.a.b" and "$.a[0]", but doesn't match "$.ab".
+func (m CommentMap) Filter(pathPrefix string) CommentMap {
+	filtered := CommentMap{}
+	for path, comments := range m {
+		if isPathOrDescendant(path, pathPrefix) {
+			filtered[path] = cloneComments(comments)
+		}
+	}
+	return filtered
+}
+
+func cloneComments(comments []*Comment) []*Comment {
+	cloned := make([]*Comment, 0, len(comments))
+	for _, comment := range comments {
+		cloned = append(cloned, &Comment{
+			Texts:    append([]string{}, comment.Texts...),
+			Position: comment.Position,
+		})
+	}
+	return cloned
+}
+
+func hasCommentPosition(comments []*Comment, pos CommentPosition) bool {
+	for _, comment := range comments {
+		if comment.Position == pos {
+			return true
+		}
+	}
+	return false
+}
+
+func isPathOrDescendant(path, prefix string) bool {
+	if path == prefix {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	switch path[len(prefix)] {
+	case '.', '[':
+		return true
+	}
+	return false
+}
+
 // WithComment add a comment using the location and text information given in the CommentMap.
 func WithComment(cm CommentMap) EncodeOption {
 	return func(e *Encoder) error {
 		commentMap := map[*Path][]*Comment{}
-		for k, v := range cm {
+		// copy the comments so that the later changes of cm don't affect the encoder.
+		for k, v := range cm.Clone() {
 			path, err := PathString(k)
 			if err != nil {
 				return err
@@ -265,6 +905,51 @@ func WithComment(cm CommentMap) EncodeOption {
 	}
 }
 
+// CommentIndent indents the comments written on their own lines, like the head and foot comments,
+// by spaces relative to the key or the sequence entry. The comments following the block scalars are not indented,
+// because they would be read as the content of the scalars.
+func CommentIndent(spaces int) EncodeOption {
+	return func(e *Encoder) error {
+		e.commentIndent = spaces
+		return nil
+	}
+}
+
+// LineCommentSpacing specifies the number of the spaces between the value and the line comment. The default is 1.
+func LineCommentSpacing(spaces int) EncodeOption {
+	return func(e *Encoder) error {
+		e.lineCommentSpacing = spaces
+		return nil
+	}
+}
+
+// AlignLineComments aligns the line comments of the consecutive lines to the same column like a table.
+func AlignLineComments() EncodeOption {
+	return func(e *Encoder) error {
+		e.alignLineComments = true
+		return nil
+	}
+}
+
+// PreserveCommentBlankLines writes the empty texts of the comments between the other texts as the blank lines.
+// By default, they are written as the empty comments ( "#" ).
+func PreserveCommentBlankLines() EncodeOption {
+	return func(e *Encoder) error {
+		e.preserveCommentBlankLines = true
+		return nil
+	}
+}
+
+// PreserveSequenceBlankLines writes the blank lines before the sequence entries recorded by CommentToMap,
+// which separate the groups of the entries like the hand-maintained lists.
+// By default, the blank lines are not written.
+func PreserveSequenceBlankLines() EncodeOption {
+	return func(e *Encoder) error {
+		e.preserveSequenceBlankLines = true
+		return nil
+	}
+}
+
 // CommentToMap apply the position and content of comments in a YAML document to a CommentMap.
 func CommentToMap(cm CommentMap) DecodeOption {
 	return func(d *Decoder) error {
@@ -275,3 +960,32 @@ func CommentToMap(cm CommentMap) DecodeOption {
 		return nil
 	}
 }
+
+// SourceMap map of the path of the decoded value and the position in the document.
+// The position of the mapping value is the position of the key, and the position of the sequence element is the start of the element.
+type SourceMap map[string]token.Position
+
+// Position returns the position of the value specified by path.
+func (m SourceMap) Position(path *Path) (token.Position, bool) {
+	if path == nil {
+		return token.Position{}, false
+	}
+	pos, exists := m[path.String()]
+	return pos, exists
+}
+
+// CaptureSourcePositions records the positions of the mapping values and the sequence elements
+// of the decoded document to the SourceMap, so the errors found after decoding can be reported with the positions.
+// The SourceMap is reset by each decoding, so it has the positions of the last decoded document.
+func CaptureSourcePositions(sm *SourceMap) DecodeOption {
+	return func(d *Decoder) error {
+		if sm == nil {
+			return ErrInvalidSourceMapValue
+		}
+		if *sm == nil {
+			*sm = SourceMap{}
+		}
+		d.toSourceMap = *sm
+		return nil
+	}
+}
@@ -11,6 +11,7 @@ import (
 type context struct {
 	tokenRef *tokenRef
 	path     string
+	depth    int
 	isFlow   bool
 }
 
@@ -85,12 +86,14 @@ func (c *context) withGroup(g *TokenGroup) *context {
 func (c *context) withChild(path string) *context {
 	ctx := *c
 	ctx.path = c.path + "." + normalizePath(path)
+	ctx.depth++
 	return &ctx
 }
 
 func (c *context) withIndex(idx uint) *context {
 	ctx := *c
 	ctx.path = c.path + "[" + fmt.Sprint(idx) + "]"
+	ctx.depth++
 	return &ctx
 }
 
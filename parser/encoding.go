@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of the YAML stream.
+type Encoding int
+
+const (
+	// EncodingAuto detects the encoding by the byte order mark or the null bytes of the first character
+	// as defined by the YAML specification. The stream is treated as UTF-8 if it's not detected.
+	EncodingAuto Encoding = iota
+	UTF8
+	UTF16LE
+	UTF16BE
+	UTF32LE
+	UTF32BE
+)
+
+// String returns the name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingAuto:
+		return "auto"
+	case UTF8:
+		return "UTF-8"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF16BE:
+		return "UTF-16BE"
+	case UTF32LE:
+		return "UTF-32LE"
+	case UTF32BE:
+		return "UTF-32BE"
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// DetectEncoding detects the encoding of src by the byte order mark or, if src doesn't have it,
+// by the null bytes of the first character, which must be an ASCII character in YAML.
+// Without the byte order mark, src is detected as UTF-16 or UTF-32 only if the first character
+// has exactly the null bytes of the pattern in the YAML specification, e.g. `x 00 00 00` for UTF-32LE.
+func DetectEncoding(src []byte) Encoding {
+	switch {
+	case hasPrefix(src, 0x00, 0x00, 0xFE, 0xFF):
+		return UTF32BE
+	case hasPrefix(src, 0xFF, 0xFE, 0x00, 0x00):
+		return UTF32LE
+	case hasPrefix(src, 0xFE, 0xFF):
+		return UTF16BE
+	case hasPrefix(src, 0xFF, 0xFE):
+		return UTF16LE
+	case hasPrefix(src, 0xEF, 0xBB, 0xBF):
+		return UTF8
+	case len(src) >= 4 && src[0] == 0 && src[1] == 0 && src[2] == 0 && isASCII(src[3]):
+		return UTF32BE
+	case len(src) >= 4 && isASCII(src[0]) && src[1] == 0 && src[2] == 0 && src[3] == 0:
+		return UTF32LE
+	case len(src) >= 2 && src[0] == 0 && isASCII(src[1]):
+		return UTF16BE
+	case len(src) >= 2 && isASCII(src[0]) && src[1] == 0:
+		return UTF16LE
+	}
+	return UTF8
+}
+
+// isASCII returns whether b is the ASCII character other than NUL.
+func isASCII(b byte) bool {
+	return b != 0 && b < utf8.RuneSelf
+}
+
+// ToUTF8 converts src encoded by enc to UTF-8, which is the encoding handled by the lexer.
+// If enc is EncodingAuto, the encoding is detected by DetectEncoding.
+// The byte order mark at the beginning of src is kept as U+FEFF, which is skipped by the lexer,
+// so the offsets of the tokens count the characters of src including it.
+func ToUTF8(src []byte, enc Encoding) ([]byte, error) {
+	if enc == EncodingAuto {
+		enc = DetectEncoding(src)
+	}
+	switch enc {
+	case UTF8:
+		return src, nil
+	case UTF16LE:
+		return utf16ToUTF8(src, binary.LittleEndian, enc)
+	case UTF16BE:
+		return utf16ToUTF8(src, binary.BigEndian, enc)
+	case UTF32LE:
+		return utf32ToUTF8(src, binary.LittleEndian, enc)
+	case UTF32BE:
+		return utf32ToUTF8(src, binary.BigEndian, enc)
+	}
+	return nil, fmt.Errorf("unsupported encoding %s", enc)
+}
+
+func hasPrefix(src []byte, prefix ...byte) bool {
+	if len(src) < len(prefix) {
+		return false
+	}
+	for i, b := range prefix {
+		if src[i] != b {
+			return false
+		}
+	}
+	return true
+}
+
+func utf16ToUTF8(src []byte, order binary.ByteOrder, enc Encoding) ([]byte, error) {
+	if len(src)%2 != 0 {
+		return nil, fmt.Errorf("invalid %s stream: the length %d is not a multiple of 2", enc, len(src))
+	}
+	units := make([]uint16, 0, len(src)/2)
+	for i := 0; i < len(src); i += 2 {
+		units = append(units, order.Uint16(src[i:]))
+	}
+	dst := make([]byte, 0, len(units))
+	for _, r := range utf16.Decode(units) {
+		dst = utf8.AppendRune(dst, r)
+	}
+	return dst, nil
+}
+
+func utf32ToUTF8(src []byte, order binary.ByteOrder, enc Encoding) ([]byte, error) {
+	if len(src)%4 != 0 {
+		return nil, fmt.Errorf("invalid %s stream: the length %d is not a multiple of 4", enc, len(src))
+	}
+	dst := make([]byte, 0, len(src)/4)
+	for i := 0; i < len(src); i += 4 {
+		r := rune(order.Uint32(src[i:]))
+		if !utf8.ValidRune(r) {
+			return nil, fmt.Errorf("invalid %s stream: invalid character %#x at offset %d", enc, uint32(r), i)
+		}
+		dst = utf8.AppendRune(dst, r)
+	}
+	return dst, nil
+}
@@ -0,0 +1,116 @@
+package parser_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+func TestToUTF8(t *testing.T) {
+	src := "a: héllo 😀\nb: [1, 2]\n"
+	encode := func(order binary.ByteOrder, is32, withBOM bool) []byte {
+		runes := []rune(src)
+		if withBOM {
+			runes = append([]rune{0xFEFF}, runes...)
+		}
+		var buf bytes.Buffer
+		if is32 {
+			_ = binary.Write(&buf, order, runes)
+		} else {
+			_ = binary.Write(&buf, order, utf16.Encode(runes))
+		}
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name     string
+		src      []byte
+		expected parser.Encoding
+	}{
+		{name: "utf-8", src: []byte(src), expected: parser.UTF8},
+		{name: "utf-8 with bom", src: []byte("\ufeff" + src), expected: parser.UTF8},
+		{name: "utf-16le with bom", src: encode(binary.LittleEndian, false, true), expected: parser.UTF16LE},
+		{name: "utf-16be with bom", src: encode(binary.BigEndian, false, true), expected: parser.UTF16BE},
+		{name: "utf-16le", src: encode(binary.LittleEndian, false, false), expected: parser.UTF16LE},
+		{name: "utf-16be", src: encode(binary.BigEndian, false, false), expected: parser.UTF16BE},
+		{name: "utf-32le with bom", src: encode(binary.LittleEndian, true, true), expected: parser.UTF32LE},
+		{name: "utf-32be with bom", src: encode(binary.BigEndian, true, true), expected: parser.UTF32BE},
+		{name: "utf-32le", src: encode(binary.LittleEndian, true, false), expected: parser.UTF32LE},
+		{name: "utf-32be", src: encode(binary.BigEndian, true, false), expected: parser.UTF32BE},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if enc := parser.DetectEncoding(test.src); enc != test.expected {
+				t.Fatalf("expected %s but got %s", test.expected, enc)
+			}
+			got, err := parser.ToUTF8(test.src, parser.EncodingAuto)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the byte order mark is kept to keep the offsets.
+			if strings.TrimPrefix(string(got), "\ufeff") != src {
+				t.Fatalf("unexpected result %q", got)
+			}
+			f, err := parser.ParseBytes(test.src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.String() != src {
+				t.Fatalf("unexpected parsed result %q", f.String())
+			}
+		})
+	}
+	t.Run("input encoding", func(t *testing.T) {
+		b := encode(binary.LittleEndian, false, false)
+		f, err := parser.ParseBytes(b, 0, parser.InputEncoding(parser.UTF16LE))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.String() != src {
+			t.Fatalf("unexpected parsed result %q", f.String())
+		}
+		if _, err := parser.ParseBytes(b, 0, parser.InputEncoding(parser.UTF32LE)); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("null bytes out of the pattern", func(t *testing.T) {
+		for _, src := range [][]byte{{0x00, 0x00}, {0xC3, 0x00}, {0x00, 0x00, 0x00, 0x00}, {0x00, 0xC3, 'a'}} {
+			if enc := parser.DetectEncoding(src); enc != parser.UTF8 {
+				t.Fatalf("expected UTF-8 for %q but got %s", src, enc)
+			}
+		}
+	})
+	t.Run("offsets with bom", func(t *testing.T) {
+		for _, b := range [][]byte{[]byte("\ufeff" + src), encode(binary.LittleEndian, false, true)} {
+			tokens := lexer.Tokenize("\ufeff" + src)
+			f, err := parser.ParseBytes(b, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value := f.Docs[0].Body.(*ast.MappingNode).Values[1].Key.GetToken()
+			var expected *token.Token
+			for _, tk := range tokens {
+				if tk.Value == "b" {
+					expected = tk
+				}
+			}
+			if *value.Position != *expected.Position || value.Position.Offset != 13 || value.Position.Column != 1 {
+				t.Fatalf("expected %+v but got %+v", expected.Position, value.Position)
+			}
+			if tokens[0].Position.Column != 1 || tokens.Source() != "\ufeff"+src {
+				t.Fatalf("unexpected first token %+v", tokens[0])
+			}
+		}
+	})
+	t.Run("invalid length", func(t *testing.T) {
+		if _, err := parser.ToUTF8([]byte{0xFF, 0xFE, 'a'}, parser.EncodingAuto); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -10,3 +10,19 @@ func AllowDuplicateMapKey() Option {
 		p.allowDuplicateMapKey = true
 	}
 }
+
+// MaxDepth sets the maximum nesting depth of the collections.
+// Documents nested deeper than depth cause a syntax error instead of exhausting the stack and the memory.
+// The default is DefaultMaxDepth.
+func MaxDepth(depth int) Option {
+	return func(p *parser) {
+		p.maxDepth = depth
+	}
+}
+
+// InputEncoding specifies the encoding of the source of ParseBytes and ParseFile instead of detecting it.
+func InputEncoding(enc Encoding) Option {
+	return func(p *parser) {
+		p.encoding = enc
+	}
+}
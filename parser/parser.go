@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
 
@@ -15,18 +16,44 @@ type Mode uint
 
 const (
 	ParseComments Mode = 1 << iota // parse comments and add them to AST
+	SkipOrigins                    // drop comments and origins of indicators for decoding only. ignored with ParseComments
 )
 
 // ParseBytes parse from byte slice, and returns ast.File
+// The UTF-16 and UTF-32 streams are converted to UTF-8 by the encoding detected by DetectEncoding
+// or specified by InputEncoding.
 func ParseBytes(bytes []byte, mode Mode, opts ...Option) (*ast.File, error) {
-	tokens := lexer.Tokenize(string(bytes))
+	var p parser
+	for _, opt := range opts {
+		opt(&p)
+	}
+	src, err := ToUTF8(bytes, p.encoding)
+	if err != nil {
+		return nil, err
+	}
+	skipOrigins := mode&SkipOrigins != 0 && mode&ParseComments == 0
+	tokens := tokenize(string(src), skipOrigins)
 	f, err := Parse(tokens, mode, opts...)
 	if err != nil {
+		if skipOrigins {
+			// parse again with the origins to print the source in the error message.
+			_, err = Parse(lexer.Tokenize(string(src)), mode&^SkipOrigins, opts...)
+		}
 		return nil, err
 	}
 	return f, nil
 }
 
+// tokenize splits src to the tokens.
+// If skipOrigins is true, the tokens don't keep the comments and the origins of the indicators,
+// which makes the tokenizing faster but the source text can't be restored from the AST.
+func tokenize(src string, skipOrigins bool) token.Tokens {
+	if skipOrigins {
+		return lexer.TokenizeForDecode(src)
+	}
+	return lexer.Tokenize(src)
+}
+
 // Parse parse from token instances, and returns ast.File
 func Parse(tokens token.Tokens, mode Mode, opts ...Option) (*ast.File, error) {
 	if tk := tokens.InvalidToken(); tk != nil {
@@ -57,6 +84,20 @@ func ParseFile(filename string, mode Mode, opts ...Option) (*ast.File, error) {
 	return f, nil
 }
 
+// ParseFS parse from the file of name in fsys like embed.FS.
+func ParseFS(fsys fs.FS, name string, mode Mode, opts ...Option) (*ast.File, error) {
+	file, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseBytes(file, mode, opts...)
+	if err != nil {
+		return nil, err
+	}
+	f.Name = name
+	return f, nil
+}
+
 type YAMLVersion string
 
 const (
@@ -73,11 +114,16 @@ var yamlVersionMap = map[string]YAMLVersion{
 	"1.3": YAML13,
 }
 
+// DefaultMaxDepth is the default maximum nesting depth of the collections.
+const DefaultMaxDepth = 10000
+
 type parser struct {
 	tokens                []*Token
 	pathMap               map[string]ast.Node
 	yamlVersion           YAMLVersion
 	allowDuplicateMapKey  bool
+	maxDepth              int
+	encoding              Encoding
 	secondaryTagDirective *ast.DirectiveNode
 }
 
@@ -100,8 +146,9 @@ func newParser(tokens token.Tokens, mode Mode, opts []Option) (*parser, error) {
 		return nil, err
 	}
 	p := &parser{
-		tokens:  tks,
-		pathMap: make(map[string]ast.Node),
+		tokens:   tks,
+		pathMap:  make(map[string]ast.Node),
+		maxDepth: DefaultMaxDepth,
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -111,16 +158,86 @@ func newParser(tokens token.Tokens, mode Mode, opts []Option) (*parser, error) {
 
 func (p *parser) parse(ctx *context) (*ast.File, error) {
 	file := &ast.File{Docs: []*ast.DocumentNode{}}
+	var (
+		directives []*ast.DirectiveNode
+		commentDoc *ast.DocumentNode
+	)
 	for _, token := range p.tokens {
-		doc, err := p.parseDocument(ctx, token.Group)
-		if err != nil {
-			return nil, err
+		for _, group := range splitDirectiveGroup(token.Group) {
+			doc, err := p.parseDocument(ctx, group)
+			if err != nil {
+				return nil, err
+			}
+			if isCommentOnlyDocument(doc) {
+				if commentDoc != nil {
+					file.Docs = append(file.Docs, commentDoc)
+				}
+				commentDoc = doc
+				continue
+			}
+			// the comments before the directives or the document header are the head comment of the document.
+			if commentDoc != nil {
+				doc.HeadComment = commentDoc.Body.(*ast.CommentGroupNode)
+				commentDoc = nil
+			}
+			// the directives are parsed as the documents, and they are also set to the document following them.
+			if directive, ok := doc.Body.(*ast.DirectiveNode); ok {
+				directives = append(directives, directive)
+			} else {
+				doc.Directives = directives
+				directives = nil
+			}
+			file.Docs = append(file.Docs, doc)
+		}
+	}
+	if commentDoc != nil {
+		// the comments after the document end marker ( `...` ) are the foot comment of the document.
+		if len(file.Docs) != 0 && file.Docs[len(file.Docs)-1].End != nil {
+			file.Docs[len(file.Docs)-1].FootComment = commentDoc.Body.(*ast.CommentGroupNode)
+		} else {
+			file.Docs = append(file.Docs, commentDoc)
 		}
-		file.Docs = append(file.Docs, doc)
 	}
 	return file, nil
 }
 
+// isCommentOnlyDocument returns whether doc has only the comments without the document header and the document end marker.
+func isCommentOnlyDocument(doc *ast.DocumentNode) bool {
+	if doc.Start != nil || doc.End != nil {
+		return false
+	}
+	_, ok := doc.Body.(*ast.CommentGroupNode)
+	return ok
+}
+
+// splitDirectiveGroup splits the group of the directives before the document header ( e.g. %YAML and %TAG )
+// into the groups of each directive, because the directive is parsed as the document.
+// The comments between the directives are split into the groups of the consecutive comments.
+func splitDirectiveGroup(g *TokenGroup) []*TokenGroup {
+	if len(g.Tokens) < 2 {
+		return []*TokenGroup{g}
+	}
+	for _, tk := range g.Tokens {
+		switch tk.GroupType() {
+		case TokenGroupDirective, TokenGroupDirectiveName:
+		default:
+			if tk.Type() != token.CommentType {
+				return []*TokenGroup{g}
+			}
+		}
+	}
+	groups := make([]*TokenGroup, 0, len(g.Tokens))
+	for idx, tk := range g.Tokens {
+		if tk.Type() == token.CommentType && idx > 0 && g.Tokens[idx-1].Type() == token.CommentType {
+			last := groups[len(groups)-1]
+			last.Tokens = append(last.Tokens, tk)
+			continue
+		}
+		groups = append(groups, &TokenGroup{Type: g.Type, Tokens: []*Token{tk}})
+	}
+	return groups
+}
+
 func (p *parser) parseDocument(ctx *context, docGroup *TokenGroup) (*ast.DocumentNode, error) {
 	if len(docGroup.Tokens) == 0 {
 		return ast.Document(docGroup.RawToken(), nil), nil
@@ -174,6 +291,10 @@ func (p *parser) parseDocumentBody(ctx *context) (ast.Node, error) {
 }
 
 func (p *parser) parseToken(ctx *context, tk *Token) (ast.Node, error) {
+	if ctx.depth > p.maxDepth {
+		// the path of the node grows with the depth, so deeply nested documents are rejected before parsing them.
+		return nil, errors.ErrSyntax(fmt.Sprintf("exceeded max depth %d", p.maxDepth), tk.RawToken())
+	}
 	switch tk.GroupType() {
 	case TokenGroupMapKey, TokenGroupMapKeyValue:
 		return p.parseMap(ctx)
@@ -586,6 +707,21 @@ func (p *parser) parseMapKey(ctx *context, g *TokenGroup) (ast.MapKeyNode, error
 			return nil, errors.ErrSyntax("could not find value for mapping key", mapKeyTk.RawToken())
 		}
 
+		if isFlowCollectionStartType(ctx.currentToken()) {
+			collection, err := p.parseFlowCollectionKey(ctx, ctx.tokenRef.tokens[ctx.tokenRef.idx:])
+			if err != nil {
+				return nil, err
+			}
+			key.Value = collection
+			keyPath := collection.GetPath()
+			key.SetPath(keyPath)
+			if err := p.validateMapKey(ctx, key, keyPath, g.Last()); err != nil {
+				return nil, err
+			}
+			p.pathMap[keyPath] = key
+			return key, nil
+		}
+
 		scalar, err := p.parseScalarValue(ctx, ctx.currentToken())
 		if err != nil {
 			return nil, err
@@ -594,7 +730,7 @@ func (p *parser) parseMapKey(ctx *context, g *TokenGroup) (ast.MapKeyNode, error
 		keyText := p.mapKeyText(scalar)
 		keyPath := ctx.withChild(keyText).path
 		key.SetPath(keyPath)
-		if err := p.validateMapKey(ctx, key.GetToken(), keyPath, g.Last()); err != nil {
+		if err := p.validateMapKey(ctx, key, keyPath, g.Last()); err != nil {
 			return nil, err
 		}
 		p.pathMap[keyPath] = key
@@ -615,16 +751,42 @@ func (p *parser) parseMapKey(ctx *context, g *TokenGroup) (ast.MapKeyNode, error
 	keyText := p.mapKeyText(key)
 	keyPath := ctx.withChild(keyText).path
 	key.SetPath(keyPath)
-	if err := p.validateMapKey(ctx, key.GetToken(), keyPath, g.Last()); err != nil {
+	if err := p.validateMapKey(ctx, key, keyPath, g.Last()); err != nil {
 		return nil, err
 	}
 	p.pathMap[keyPath] = key
 	return key, nil
 }
 
-func (p *parser) validateMapKey(ctx *context, tk *token.Token, keyPath string, colonTk *Token) error {
+// parseFlowCollectionKey parses the flow collection used as the map key.
+// The nodes in the collection have the paths under the text of the key,
+// so that they don't conflict with the paths of the other keys in the same map.
+func (p *parser) parseFlowCollectionKey(ctx *context, tokens []*Token) (ast.Node, error) {
+	var origins []string
+	for _, tk := range GroupedTokens(tokens).RawTokens() {
+		origins = append(origins, tk.Origin)
+	}
+	keyText := strings.Join(strings.Fields(strings.Join(origins, "")), " ")
+	keyCtx := ctx.withChild(keyText).withGroup(&TokenGroup{Tokens: tokens})
+	return p.parseToken(keyCtx, keyCtx.currentToken())
+}
+
+// mapKeyType returns the type of the key value to distinguish the keys of the same text,
+// like `1` and `"1"` which are decoded to the different values.
+func mapKeyType(key ast.Node) ast.NodeType {
+	if k, ok := key.(*ast.MappingKeyNode); ok && k.Value != nil {
+		key = k.Value
+	}
+	if key.Type() == ast.LiteralType {
+		return ast.StringType
+	}
+	return key.Type()
+}
+
+func (p *parser) validateMapKey(ctx *context, key ast.Node, keyPath string, colonTk *Token) error {
+	tk := key.GetToken()
 	if !p.allowDuplicateMapKey {
-		if n, exists := p.pathMap[keyPath]; exists {
+		if n, exists := p.pathMap[keyPath]; exists && mapKeyType(n) == mapKeyType(key) {
 			pos := n.GetToken().Position
 			return errors.ErrSyntax(
 				fmt.Sprintf("mapping key %q already defined at [%d:%d]", tk.Value, pos.Line, pos.Column),
@@ -652,9 +814,9 @@ func (p *parser) validateMapKey(ctx *context, tk *token.Token, keyPath string, c
 }
 
 func (p *parser) removeLeftWhiteSpace(src string) string {
-	// CR or LF or CRLF
+	// CR or LF or CRLF, and the tabs at the end of the previous line
 	return strings.TrimLeftFunc(src, func(r rune) bool {
-		return r == ' ' || r == '\r' || r == '\n'
+		return r == ' ' || r == '\t' || r == '\r' || r == '\n'
 	})
 }
 
@@ -991,7 +1153,7 @@ func (p *parser) parseTagValue(ctx *context, tagRawTk *token.Token, tk *Token) (
 		}
 		ctx.goNext()
 		return scalar, nil
-	case token.SequenceTag, token.OrderedMapTag:
+	case token.SequenceTag, token.OrderedMapTag, token.PairsTag:
 		if tk.Type() == token.SequenceStartType {
 			return p.parseFlowSequence(ctx.withFlow(true))
 		}
@@ -1066,7 +1228,12 @@ func (p *parser) parseSequence(ctx *context) (*ast.SequenceNode, error) {
 		if err != nil {
 			return nil, err
 		}
+		var blankLine bool
+		if len(seqNode.Values) != 0 {
+			blankLine = hasBlankLineBefore(seqTk.RawToken())
+		}
 		seqNode.ValueHeadComments = append(seqNode.ValueHeadComments, comment)
+		seqNode.ValueBlankLines = append(seqNode.ValueBlankLines, blankLine)
 		seqNode.Values = append(seqNode.Values, value)
 
 		if ctx.isComment() {
@@ -1088,6 +1255,31 @@ func (p *parser) parseSequence(ctx *context) (*ast.SequenceNode, error) {
 	return seqNode, nil
 }
 
+// hasBlankLineBefore returns whether the blank line is written between the previous token and tk
+// or the comments before tk. The comments are found by the tokens before tk,
+// because they are removed from the tokens of the parser without ParseComments.
+func hasBlankLineBefore(tk *token.Token) bool {
+	line := tk.Position.Line
+	prev := tk.Prev
+	for ; prev != nil && prev.Type == token.CommentType; prev = prev.Prev {
+		line = prev.Position.Line
+	}
+	if prev == nil {
+		return false
+	}
+	return line-tokenEndLine(prev) > 1
+}
+
+// tokenEndLine returns the line where the text of tk ends, for the multi-line scalars.
+func tokenEndLine(tk *token.Token) int {
+	text := strings.TrimRight(tk.Origin, " \t\r\n")
+	if tk.Position.Column > 0 {
+		// the origin may contain the preceding line breaks of the token.
+		text = strings.TrimLeft(text, " \t\r\n")
+	}
+	return tk.Position.Line + strings.Count(text, "\n")
+}
+
 func (p *parser) parseSequenceValue(ctx *context, seqTk *Token) (ast.Node, error) {
 	tk := ctx.currentToken()
 	if tk == nil {
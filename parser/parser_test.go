@@ -1,11 +1,15 @@
 package parser_test
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"unicode/utf8"
 
 	"github.com/goccy/go-yaml"
 	"github.com/goccy/go-yaml/ast"
@@ -68,6 +72,8 @@ func TestParser(t *testing.T) {
 		"---\na: b\n",
 		"a: b\n...\n",
 		"%YAML 1.2\n---\n",
+		"%YAML 1.2\n%TAG !e! tag:example.com,2000:\n---\na: !e!foo 1\n",
+		"a: 1\n...\n%YAML 1.2\n---\nb: 2\n",
 		"a: !!binary gIGC\n",
 		"a: !!binary |\n  " + strings.Repeat("kJCQ", 17) + "kJ\n  CQ\n",
 		"v: !!foo 1",
@@ -394,15 +400,19 @@ aliased: *anchor
 - &LEFT {x: 0, y: 2}
 - &BIG {r: 10}
 - &SMALL {r: 1}
+
 - x: 1
   y: 2
   r: 10
   label: center/big
+
 - <<: *CENTER
   r: 10
   label: center/big
+
 - <<: [*CENTER, *BIG]
   label: center/big
+
 - <<: [*BIG, *LEFT, *SMALL]
   x: 1
   label: center/big
@@ -682,6 +692,39 @@ a:
  b: &anchor null
  c: &anchor2 null
 d: e
+`,
+		},
+		{
+			`
+? [a, b]
+: c
+? {d: 1, e: 2}
+: f
+g: h
+`,
+			`
+? [a, b]
+: c
+? {d: 1, e: 2}
+: f
+g: h
+`,
+		},
+		{
+			`
+a: !!omap
+  - b: 1
+  - c: 2
+d:
+  - !!pairs
+    - e: 3
+`, `
+a: !!omap
+  - b: 1
+  - c: 2
+d:
+  - !!pairs
+    - e: 3
 `,
 		},
 	}
@@ -717,6 +760,30 @@ func TestParseWhitespace(t *testing.T) {
 	}{
 		{
 			`
+a: |+
+  value
+
+
+b:
+  c: |+
+    value
+
+  d: e
+`,
+			`
+a: |+
+  value
+
+
+b:
+  c: |+
+    value
+
+  d: e
+`,
+		},
+		{
+			`
 a: b
 
 c: d
@@ -1004,6 +1071,26 @@ baz: 1
 	}
 }
 
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.yaml": {Data: []byte("a: 1\nb: [c, d]\n")},
+	}
+	f, err := parser.ParseFS(fsys, "config.yaml", 0)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if f.Name != "config.yaml" {
+		t.Fatalf("unexpected file name: %s", f.Name)
+	}
+	expect := "a: 1\nb: [c, d]\n"
+	if actual := f.String(); actual != expect {
+		t.Fatalf("unexpected result\nexpected:\n%s\ngot:\n%s", expect, actual)
+	}
+	if _, err := parser.ParseFS(fsys, "missing.yaml", 0); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestNewLineChar(t *testing.T) {
 	for _, f := range []string{
 		"lf.yml",
@@ -1364,6 +1451,22 @@ foo:
    6 |     foo: 3
 >  7 |     foo: 4
            ^
+`,
+		},
+		{
+			`
+1: a
+"1": b
+"foo": c
+foo: d
+`,
+			`
+[5:1] mapping key "foo" already defined at [4:1]
+   2 | 1: a
+   3 | "1": b
+   4 | "foo": c
+>  5 | foo: d
+       ^
 `,
 		},
 	}
@@ -1377,6 +1480,9 @@ foo:
 			if test.expect != actual {
 				t.Fatalf("expected: [%s] but got [%s]", test.expect, actual)
 			}
+			if _, err := parser.ParseBytes([]byte(test.source), parser.SkipOrigins); err == nil || "\n"+err.Error() != test.expect {
+				t.Fatalf("unexpected error with SkipOrigins: %v", err)
+			}
 		})
 	}
 }
@@ -1464,6 +1570,20 @@ foo: | # comment
 			yaml: `
 foo: > # comment
   x: 42
+`,
+		},
+		{
+			name: "sequence with blank lines",
+			yaml: `
+allow:
+  # office
+  - 10.0.0.1
+  - 10.0.0.2
+
+  # vpn
+  - 10.1.0.1
+
+  - 10.2.0.1
 `,
 		},
 		{
@@ -1674,6 +1794,141 @@ multiple:
 	}
 }
 
+func TestDocumentComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		yaml        string
+		docs        int
+		headComment string
+		footComment string
+	}{
+		{
+			name: "head comment",
+			yaml: `
+# license
+---
+a: b
+`,
+			docs:        1,
+			headComment: "# license",
+		},
+		{
+			name: "foot comment",
+			yaml: `
+a: b
+...
+# foot comment
+`,
+			docs:        1,
+			footComment: "# foot comment",
+		},
+		{
+			name: "comments around directives",
+			yaml: `
+# license
+# license2
+%YAML 1.2
+# head comment
+---
+a: b
+...
+# foot comment
+`,
+			docs:        2,
+			headComment: "# head comment",
+			footComment: "# foot comment",
+		},
+		{
+			name: "comment between documents",
+			yaml: `
+a: b
+...
+# head comment
+---
+c: d
+`,
+			docs:        2,
+			headComment: "# head comment",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := parser.ParseBytes([]byte(test.yaml), parser.ParseComments)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if len(f.Docs) != test.docs {
+				t.Fatalf("unexpected number of documents: %d", len(f.Docs))
+			}
+			doc := f.Docs[len(f.Docs)-1]
+			var headComment, footComment string
+			if doc.HeadComment != nil {
+				headComment = doc.HeadComment.String()
+			}
+			if doc.FootComment != nil {
+				footComment = doc.FootComment.String()
+			}
+			if headComment != test.headComment {
+				t.Fatalf("unexpected head comment: %q", headComment)
+			}
+			if footComment != test.footComment {
+				t.Fatalf("unexpected foot comment: %q", footComment)
+			}
+			if got := "\n" + f.String(); got != test.yaml {
+				t.Fatalf("expected:%s\ngot:%s", test.yaml, got)
+			}
+		})
+	}
+	t.Run("directive head comment", func(t *testing.T) {
+		f, err := parser.ParseBytes([]byte("# license\n%YAML 1.2\n---\na: b\n"), parser.ParseComments)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, ok := f.Docs[0].Body.(*ast.DirectiveNode); !ok {
+			t.Fatalf("unexpected body: %T", f.Docs[0].Body)
+		}
+		if f.Docs[0].HeadComment == nil || f.Docs[0].HeadComment.String() != "# license" {
+			t.Fatalf("unexpected head comment: %v", f.Docs[0].HeadComment)
+		}
+	})
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	yml := `
+a: 1
+"a": 2
+1: x
+"1": y
+b:
+  c: 1
+  c: 2
+a: 3
+`
+	f, err := parser.ParseBytes([]byte(yml), 0, parser.AllowDuplicateMapKey())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	root, ok := f.Docs[0].Body.(*ast.MappingNode)
+	if !ok {
+		t.Fatalf("unexpected body: %T", f.Docs[0].Body)
+	}
+	var got []string
+	for _, value := range root.DuplicateKeys() {
+		pos := value.Key.GetToken().Position
+		got = append(got, fmt.Sprintf("%s:%d:%d", value.Key.GetToken().Value, pos.Line, pos.Column))
+	}
+	if expected := []string{"a:3:1", "a:9:1"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected duplicate keys: %v", got)
+	}
+	nested, ok := root.Values[4].Value.(*ast.MappingNode)
+	if !ok {
+		t.Fatalf("unexpected value: %T", root.Values[4].Value)
+	}
+	if duplicates := nested.DuplicateKeys(); len(duplicates) != 1 || duplicates[0].Value.String() != "2" {
+		t.Fatalf("unexpected duplicate keys: %v", duplicates)
+	}
+}
+
 func TestNodePath(t *testing.T) {
 	yml := `
 a: # commentA
@@ -1753,3 +2008,184 @@ func (v *Visitor) Visit(node ast.Node) ast.Visitor {
 	tk.Next = nil
 	return v
 }
+
+func TestGroupedTokens(t *testing.T) {
+	src := `
+a: &x 1
+b:
+  c: *x
+  d: |
+    text
+`
+	tks, err := parser.CreateGroupedTokens(lexer.Tokenize(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tks) != 1 || tks[0].GroupType() != parser.TokenGroupDocument {
+		t.Fatalf("failed to get document group: %v", tks)
+	}
+	t.Run("Find", func(t *testing.T) {
+		anchors := tks.Find(parser.TokenGroupAnchor)
+		if len(anchors) != 1 {
+			t.Fatalf("failed to find anchor group. got %d groups", len(anchors))
+		}
+		names := anchors[0].Find(parser.TokenGroupAnchorName)
+		if len(names) != 1 || names[0].Last().RawToken().Value != "x" {
+			t.Fatalf("failed to find anchor name group: %v", names)
+		}
+		if aliases := tks.Find(parser.TokenGroupAlias); len(aliases) != 1 {
+			t.Fatalf("failed to find alias group. got %d groups", len(aliases))
+		}
+		if literals := tks.Find(parser.TokenGroupLiteral); len(literals) != 1 {
+			t.Fatalf("failed to find literal group. got %d groups", len(literals))
+		}
+	})
+	t.Run("ByLine", func(t *testing.T) {
+		var values []string
+		for _, tk := range tks.ByLine(4) {
+			values = append(values, tk.RawToken().Value)
+		}
+		if expected := []string{"c", ":", "*", "x"}; !reflect.DeepEqual(expected, values) {
+			t.Fatalf("expected %v but got %v", expected, values)
+		}
+	})
+	t.Run("RawTokens", func(t *testing.T) {
+		if got := len(tks.RawTokens()); got != len(lexer.Tokenize(src)) {
+			t.Fatalf("unexpected number of raw tokens: %d", got)
+		}
+	})
+}
+
+func TestNodeRange(t *testing.T) {
+	src := `
+a: 'x y' # comment
+b:
+  - |
+    text
+    z
+
+  - "é"
+c: {d: 1}
+e: &x [1, 2]
+f: *x
+`
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		start string
+		end   string
+		text  string
+	}{
+		{path: "$", start: "2:1", end: "11:6", text: src[1 : len(src)-1]},
+		{path: "$.a", start: "2:4", end: "2:9", text: "'x y'"},
+		{path: "$.b", start: "4:3", end: "8:8", text: "- |\n    text\n    z\n\n  - \"é\""},
+		{path: "$.b[0]", start: "4:5", end: "6:6", text: "|\n    text\n    z"},
+		{path: "$.b[1]", start: "8:5", end: "8:8", text: "\"é\""},
+		{path: "$.c", start: "9:4", end: "9:10", text: "{d: 1}"},
+		{path: "$.e", start: "10:4", end: "10:13", text: "&x [1, 2]"},
+		{path: "$.f", start: "11:4", end: "11:6", text: "*x"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			path, err := yaml.PathString(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			node, err := path.FilterFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start, end := ast.NodeRange(node)
+			if got := fmt.Sprintf("%d:%d", start.Line, start.Column); got != test.start {
+				t.Fatalf("unexpected start position. expected %s but got %s", test.start, got)
+			}
+			if got := fmt.Sprintf("%d:%d", end.Line, end.Column); got != test.end {
+				t.Fatalf("unexpected end position. expected %s but got %s", test.end, got)
+			}
+			if got := src[start.ByteOffset:end.ByteOffset]; got != test.text {
+				t.Fatalf("unexpected text. expected %q but got %q", test.text, got)
+			}
+			if got := end.Offset - start.Offset; got != utf8.RuneCountInString(test.text) {
+				t.Fatalf("unexpected offsets. expected %d characters but got %d", utf8.RuneCountInString(test.text), got)
+			}
+		})
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	nestedSequence := func(depth int) string {
+		return strings.Repeat("[", depth) + "1" + strings.Repeat("]", depth)
+	}
+	nestedMap := func(depth int) string {
+		return strings.Repeat("{a: ", depth) + "1" + strings.Repeat("}", depth)
+	}
+	nestedBlockMap := func(depth int) string {
+		var b strings.Builder
+		for i := 0; i < depth; i++ {
+			b.WriteString(strings.Repeat(" ", i) + "a:\n")
+		}
+		b.WriteString(strings.Repeat(" ", depth) + "a: 1\n")
+		return b.String()
+	}
+	t.Run("default", func(t *testing.T) {
+		if _, err := parser.ParseBytes([]byte(nestedSequence(parser.DefaultMaxDepth)), 0); err != nil {
+			t.Fatal(err)
+		}
+		for _, src := range []string{
+			nestedSequence(parser.DefaultMaxDepth + 1),
+			nestedMap(parser.DefaultMaxDepth + 1),
+			nestedSequence(100000),
+		} {
+			_, err := parser.ParseBytes([]byte(src), 0)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), "exceeded max depth") {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+	t.Run("option", func(t *testing.T) {
+		for _, src := range []string{nestedSequence(3), nestedMap(3), nestedBlockMap(2)} {
+			if _, err := parser.ParseBytes([]byte(src), 0, parser.MaxDepth(3)); err != nil {
+				t.Fatalf("failed to parse %q: %v", src, err)
+			}
+		}
+		for _, src := range []string{nestedSequence(4), nestedMap(4), nestedBlockMap(3)} {
+			if _, err := parser.ParseBytes([]byte(src), 0, parser.MaxDepth(3)); err == nil {
+				t.Fatalf("expected error for %q", src)
+			}
+		}
+	})
+}
+
+func TestDocumentVersion(t *testing.T) {
+	src := `%YAML 1.1
+%TAG !e! tag:example.com,2000:
+---
+a: yes
+...
+---
+b: no
+`
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, doc := range f.Docs {
+		if _, ok := doc.Body.(*ast.DirectiveNode); ok {
+			continue
+		}
+		versions = append(versions, doc.Version())
+	}
+	if !reflect.DeepEqual(versions, []string{"1.1", ""}) {
+		t.Fatalf("unexpected versions %q", versions)
+	}
+	if got := f.String(); got != src {
+		t.Fatalf("failed to round trip. got:\n%s", got)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"github.com/goccy/go-yaml/token"
 )
 
+// TokenGroupType type of the token group created by CreateGroupedTokens.
+// The group types and the way they nest are part of the supported API,
+// so formatters and highlighters can rely on them.
 type TokenGroupType int
 
 const (
@@ -59,6 +62,9 @@ func (t TokenGroupType) String() string {
 	return "none"
 }
 
+// Token represents either a raw token or a group of tokens.
+// If Token is nil, Group is not nil.
+// LineComment is the comment placed on the same line as the token.
 type Token struct {
 	Token       *token.Token
 	Group       *TokenGroup
@@ -144,6 +150,7 @@ type groupTokenRenderContext struct {
 	num int
 }
 
+// TokenGroup group of tokens that make up a syntactic unit ( e.g. map key and value, anchor name and value ).
 type TokenGroup struct {
 	Type   TokenGroupType
 	Tokens []*Token
@@ -201,7 +208,69 @@ func (g *TokenGroup) TokenType() token.Type {
 	return g.Tokens[0].Type()
 }
 
-func CreateGroupedTokens(tokens token.Tokens) ([]*Token, error) {
+// Find returns the groups of typ nested in the group in depth-first order.
+// The group itself is not included.
+func (g *TokenGroup) Find(typ TokenGroupType) []*TokenGroup {
+	return GroupedTokens(g.Tokens).Find(typ)
+}
+
+// GroupedTokens list of the tokens created by CreateGroupedTokens.
+// The top level tokens are always TokenGroupDocument groups or groups without type ( tokens before the first document header ).
+type GroupedTokens []*Token
+
+// Walk traverses the tokens in depth-first order and calls fn for each token including groups.
+// If fn returns false, the children of the token are skipped.
+func (t GroupedTokens) Walk(fn func(*Token) bool) {
+	for _, tk := range t {
+		if !fn(tk) {
+			continue
+		}
+		if tk.Group != nil {
+			GroupedTokens(tk.Group.Tokens).Walk(fn)
+		}
+	}
+}
+
+// Find returns the groups of typ in depth-first order.
+func (t GroupedTokens) Find(typ TokenGroupType) []*TokenGroup {
+	var ret []*TokenGroup
+	t.Walk(func(tk *Token) bool {
+		if tk.Group != nil && tk.Group.Type == typ {
+			ret = append(ret, tk.Group)
+		}
+		return true
+	})
+	return ret
+}
+
+// ByLine returns the raw tokens placed on the specified line.
+// Grouped tokens are flattened.
+func (t GroupedTokens) ByLine(line int) []*Token {
+	var ret []*Token
+	t.Walk(func(tk *Token) bool {
+		if tk.Token != nil && tk.Token.Position.Line == line {
+			ret = append(ret, tk)
+		}
+		return true
+	})
+	return ret
+}
+
+// RawTokens returns the flattened raw tokens.
+// Line comments are not included.
+func (t GroupedTokens) RawTokens() token.Tokens {
+	var ret token.Tokens
+	t.Walk(func(tk *Token) bool {
+		if tk.Token != nil {
+			ret = append(ret, tk.Token)
+		}
+		return true
+	})
+	return ret
+}
+
+// CreateGroupedTokens groups the tokens by syntactic unit.
+func CreateGroupedTokens(tokens token.Tokens) (GroupedTokens, error) {
 	var err error
 	tks := newTokens(tokens)
 	tks = createLineCommentTokenGroups(tks)
@@ -483,13 +552,27 @@ func createMapKeyByMappingKey(tokens []*Token) ([]*Token, error) {
 			if i+1 >= len(tokens) {
 				return nil, errors.ErrSyntax("undefined map key", tk.RawToken())
 			}
+			keyTks := []*Token{tokens[i+1]}
+			end := i + 1
+			if isFlowCollectionStartType(tokens[end]) {
+				// the flow collection is used as the map key. e.g.) ? [a, b]
+				end = findFlowCollectionEnd(tokens, end)
+				if end < 0 {
+					return nil, errors.ErrSyntax("could not find the end of the flow collection for map key", tokens[i+1].RawToken())
+				}
+				tks, err := createMapKeyTokenGroups(tokens[i+1 : end+1])
+				if err != nil {
+					return nil, err
+				}
+				keyTks = createMapKeyValueTokenGroups(tks)
+			}
 			ret = append(ret, &Token{
 				Group: &TokenGroup{
 					Type:   TokenGroupMapKey,
-					Tokens: []*Token{tk, tokens[i+1]},
+					Tokens: append([]*Token{tk}, keyTks...),
 				},
 			})
-			i++
+			i = end
 		default:
 			ret = append(ret, tk)
 		}
@@ -523,6 +606,32 @@ func createMapKeyByMappingValue(tokens []*Token) ([]*Token, error) {
 	return ret, nil
 }
 
+func isFlowCollectionStartType(tk *Token) bool {
+	return tk.Type() == token.SequenceStartType || tk.Type() == token.MappingStartType
+}
+
+func isFlowCollectionEndType(tk *Token) bool {
+	return tk.Type() == token.SequenceEndType || tk.Type() == token.MappingEndType
+}
+
+// findFlowCollectionEnd returns the index of the token closing the flow collection started at tokens[start].
+// It returns -1 if it is not found.
+func findFlowCollectionEnd(tokens []*Token, start int) int {
+	var depth int
+	for i := start; i < len(tokens); i++ {
+		switch {
+		case isFlowCollectionStartType(tokens[i]):
+			depth++
+		case isFlowCollectionEndType(tokens[i]):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func createMapKeyValueTokenGroups(tokens []*Token) []*Token {
 	ret := make([]*Token, 0, len(tokens))
 	for i := 0; i < len(tokens); i++ {
@@ -590,7 +699,13 @@ func createDirectiveTokenGroups(tokens []*Token) ([]*Token, error) {
 				valueTks = append(valueTks, tokens[j])
 				i++
 			}
-			if i+1 >= len(tokens) || tokens[i+1].Type() != token.DocumentHeaderType {
+			// the directives are followed by the other directives or the document header.
+			// the comments between them are the head comment of the following directive or document.
+			next := i + 1
+			for next < len(tokens) && tokens[next].Type() == token.CommentType {
+				next++
+			}
+			if next >= len(tokens) || (tokens[next].Type() != token.DocumentHeaderType && tokens[next].Type() != token.DirectiveType) {
 				return nil, errors.ErrSyntax("unexpected directive value. document not started", tk.RawToken())
 			}
 			if len(valueTks) != 0 {
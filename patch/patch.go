@@ -0,0 +1,578 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/astutil"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/merge"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Error is the error that occurred while applying the operation.
+// Token is the token of the nearest node to the path, so the position in the YAML document can be reported.
+type Error = errors.PatchError
+
+// Operation is the operation of JSON Patch ( RFC 6902 ).
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies JSON Patch ( RFC 6902 ) to the first document of file, and returns the patched file.
+// file is not modified, and the patched file is not returned if any of the operations fails.
+func ApplyJSONPatch(file *ast.File, patch []byte) (*ast.File, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+	}
+	return ApplyOperations(file, ops)
+}
+
+// ApplyOperations applies the operations of JSON Patch to the first document of file, and returns the patched file.
+// The nodes not referred by the operations are kept as they are, including the comments and the styles.
+// The added values are written in the block style, or in the flow style if the parent collection is in the flow style.
+// The values moved or copied are written in the same way, so the comments of them are not kept.
+func ApplyOperations(file *ast.File, ops []Operation) (*ast.File, error) {
+	dst := clone(file)
+	if len(dst.Docs) == 0 {
+		dst.Docs = append(dst.Docs, ast.Document(nil, nil))
+	}
+	a := &applier{doc: dst.Docs[0]}
+	for _, op := range ops {
+		if err := a.apply(op); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// ApplyMergePatch applies JSON Merge Patch ( RFC 7396 ) to file, and returns the patched file.
+// The mappings are merged deeply, the keys having null in the patch are removed, and the other values are replaced.
+func ApplyMergePatch(file *ast.File, patch []byte) (*ast.File, error) {
+	src, err := yaml.JSONToYAML(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Merge Patch: %w", err)
+	}
+	overlay, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return nil, err
+	}
+	return merge.Merge(file, overlay, merge.DeleteNull())
+}
+
+// clone copies the nodes of file to apply the operations without modifying file.
+// The tokens are shared with file, so the errors report the positions in file.
+func clone(file *ast.File) *ast.File {
+	if file == nil {
+		return &ast.File{}
+	}
+	return copyValue(reflect.ValueOf(file)).Interface().(*ast.File)
+}
+
+var tokenType = reflect.TypeOf((*token.Token)(nil))
+
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Type() == tokenType {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(v.Elem())
+		if copied.Elem().Kind() == reflect.Struct {
+			copyFields(copied.Elem())
+		}
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(copyValue(v.Elem()))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return copied
+	}
+	return v
+}
+
+func copyFields(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		field.Set(copyValue(field))
+	}
+}
+
+type applier struct {
+	doc *ast.DocumentNode
+}
+
+// slot is the place of the node in the document.
+type slot struct {
+	get func() ast.Node
+	set func(ast.Node)
+	// create creates the node of the value to be set to the slot.
+	create func(v interface{}) (ast.Node, error)
+	// tk is the token to report the error, such as the key of the mapping value.
+	tk *token.Token
+}
+
+func (a *applier) apply(op Operation) error {
+	switch op.Op {
+	case "add":
+		v, err := a.value(op)
+		if err != nil {
+			return err
+		}
+		return a.add(op, op.Path, v)
+	case "remove":
+		return a.remove(op, op.Path)
+	case "replace":
+		v, err := a.value(op)
+		if err != nil {
+			return err
+		}
+		return a.replace(op, v)
+	case "move":
+		if op.From == op.Path {
+			return nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return errors.ErrPatch(op.Op, op.Path, fmt.Sprintf("cannot move %q into its child", op.From), nil)
+		}
+		v, err := a.valueAt(op, op.From)
+		if err != nil {
+			return err
+		}
+		if err := a.remove(op, op.From); err != nil {
+			return err
+		}
+		return a.add(op, op.Path, v)
+	case "copy":
+		v, err := a.valueAt(op, op.From)
+		if err != nil {
+			return err
+		}
+		return a.add(op, op.Path, v)
+	case "test":
+		return a.test(op)
+	}
+	return errors.ErrPatch(op.Op, op.Path, "unknown operation", nil)
+}
+
+func (a *applier) value(op Operation) (interface{}, error) {
+	if len(op.Value) == 0 {
+		return nil, errors.ErrPatch(op.Op, op.Path, "value is required", nil)
+	}
+	var v interface{}
+	if err := yaml.UnmarshalWithOptions(op.Value, &v, yaml.UseOrderedMap()); err != nil {
+		return nil, errors.ErrPatch(op.Op, op.Path, fmt.Sprintf("invalid value: %v", err), nil)
+	}
+	return v, nil
+}
+
+// valueAt returns the value of the node referred by path.
+func (a *applier) valueAt(op Operation, path string) (interface{}, error) {
+	s, err := a.resolve(op, path)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := yaml.NodeToValue(s.get(), &v, yaml.UseOrderedMap()); err != nil {
+		return nil, errors.ErrPatch(op.Op, path, err.Error(), s.tk)
+	}
+	return v, nil
+}
+
+func (a *applier) add(op Operation, path string, v interface{}) error {
+	if path == "" {
+		return a.setRoot(v)
+	}
+	parent, key, err := a.resolveParent(op, path)
+	if err != nil {
+		return err
+	}
+	switch container := parent.(type) {
+	case *ast.MappingNode:
+		if idx := astutil.FindKey(container, key); idx >= 0 {
+			return a.setSlot(mappingValueSlot(container, idx), v)
+		}
+		value, err := mappingEntry(container, key, v)
+		if err != nil {
+			return err
+		}
+		container.Values = append(container.Values, value)
+	case *ast.SequenceNode:
+		idx := len(container.Values)
+		if key != "-" {
+			idx, err = parseIndex(key, len(container.Values))
+			if err != nil {
+				return errors.ErrPatch(op.Op, path, err.Error(), container.GetToken())
+			}
+		}
+		value, err := sequenceEntry(container, v)
+		if err != nil {
+			return err
+		}
+		astutil.InsertEntry(container, idx, value, nil)
+	}
+	return nil
+}
+
+func (a *applier) remove(op Operation, path string) error {
+	if path == "" {
+		return errors.ErrPatch(op.Op, path, "cannot remove the root", nil)
+	}
+	parent, key, err := a.resolveParent(op, path)
+	if err != nil {
+		return err
+	}
+	switch container := parent.(type) {
+	case *ast.MappingNode:
+		idx := astutil.FindKey(container, key)
+		if idx < 0 {
+			return errors.ErrPatch(op.Op, path, fmt.Sprintf("key %q is not found", key), container.GetToken())
+		}
+		container.Values = append(container.Values[:idx], container.Values[idx+1:]...)
+		// the empty mapping is written as `{}`.
+		container.IsFlowStyle = container.IsFlowStyle || len(container.Values) == 0
+	case *ast.SequenceNode:
+		idx, err := parseIndex(key, len(container.Values)-1)
+		if err != nil {
+			return errors.ErrPatch(op.Op, path, err.Error(), container.GetToken())
+		}
+		astutil.RemoveEntry(container, idx)
+		// the empty sequence is written as `[]`.
+		container.IsFlowStyle = container.IsFlowStyle || len(container.Values) == 0
+	}
+	return nil
+}
+
+func (a *applier) replace(op Operation, v interface{}) error {
+	if op.Path == "" {
+		if a.doc.Body == nil {
+			return errors.ErrPatch(op.Op, op.Path, "the document is empty", nil)
+		}
+		return a.setRoot(v)
+	}
+	s, err := a.resolve(op, op.Path)
+	if err != nil {
+		return err
+	}
+	return a.setSlot(s, v)
+}
+
+func (a *applier) test(op Operation) error {
+	expected, err := a.value(op)
+	if err != nil {
+		return err
+	}
+	var (
+		actual interface{}
+		tk     *token.Token
+	)
+	if op.Path == "" {
+		if a.doc.Body != nil {
+			if err := yaml.NodeToValue(a.doc.Body, &actual); err != nil {
+				return err
+			}
+			tk = a.doc.Body.GetToken()
+		}
+	} else {
+		s, err := a.resolve(op, op.Path)
+		if err != nil {
+			return err
+		}
+		if err := yaml.NodeToValue(s.get(), &actual); err != nil {
+			return errors.ErrPatch(op.Op, op.Path, err.Error(), s.tk)
+		}
+		tk = s.tk
+	}
+	equal, err := equalJSON(actual, expected)
+	if err != nil {
+		return errors.ErrPatch(op.Op, op.Path, err.Error(), tk)
+	}
+	if !equal {
+		return errors.ErrPatch(op.Op, op.Path, fmt.Sprintf("the value is not equal to %s", op.Value), tk)
+	}
+	return nil
+}
+
+// equalJSON compares the values as JSON, so the types of the numbers and the order of the keys are not compared.
+func equalJSON(actual, expected interface{}) (bool, error) {
+	normalize := func(v interface{}) (interface{}, error) {
+		b, err := yaml.MarshalWithOptions(v, yaml.JSON())
+		if err != nil {
+			return nil, err
+		}
+		var normalized interface{}
+		if err := json.Unmarshal(b, &normalized); err != nil {
+			return nil, err
+		}
+		return normalized, nil
+	}
+	a, err := normalize(actual)
+	if err != nil {
+		return false, err
+	}
+	e, err := normalize(expected)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(a, e), nil
+}
+
+func (a *applier) setRoot(v interface{}) error {
+	node, err := newNode(v, false)
+	if err != nil {
+		return err
+	}
+	a.doc.Body = node
+	return nil
+}
+
+// setSlot replaces the node of the slot with the value, keeping the comment of the node.
+func (a *applier) setSlot(s slot, v interface{}) error {
+	node, err := s.create(v)
+	if err != nil {
+		return err
+	}
+	if old := s.get(); node.GetComment() == nil && old.GetComment() != nil {
+		_ = node.SetComment(old.GetComment())
+	}
+	s.set(node)
+	return nil
+}
+
+// resolve returns the slot of the node referred by path.
+func (a *applier) resolve(op Operation, path string) (slot, error) {
+	if path == "" {
+		return slot{}, errors.ErrPatch(op.Op, path, "the root is not supported", nil)
+	}
+	parent, key, err := a.resolveParent(op, path)
+	if err != nil {
+		return slot{}, err
+	}
+	switch container := parent.(type) {
+	case *ast.MappingNode:
+		idx := astutil.FindKey(container, key)
+		if idx < 0 {
+			return slot{}, errors.ErrPatch(op.Op, path, fmt.Sprintf("key %q is not found", key), container.GetToken())
+		}
+		return mappingValueSlot(container, idx), nil
+	case *ast.SequenceNode:
+		idx, err := parseIndex(key, len(container.Values)-1)
+		if err != nil {
+			return slot{}, errors.ErrPatch(op.Op, path, err.Error(), container.GetToken())
+		}
+		return sequenceEntrySlot(container, idx), nil
+	}
+	return slot{}, nil
+}
+
+// resolveParent returns the mapping or the sequence containing the node referred by path, and the last reference token.
+func (a *applier) resolveParent(op Operation, path string) (ast.Node, string, error) {
+	refs, err := parsePointer(path)
+	if err != nil {
+		return nil, "", errors.ErrPatch(op.Op, path, err.Error(), nil)
+	}
+	current := slot{
+		get: func() ast.Node { return a.doc.Body },
+		set: func(node ast.Node) { a.doc.Body = node },
+	}
+	for idx, ref := range refs {
+		container, err := a.container(op, path, current)
+		if err != nil {
+			return nil, "", err
+		}
+		if idx == len(refs)-1 {
+			return container, ref, nil
+		}
+		switch c := container.(type) {
+		case *ast.MappingNode:
+			valueIdx := astutil.FindKey(c, ref)
+			if valueIdx < 0 {
+				return nil, "", errors.ErrPatch(op.Op, path, fmt.Sprintf("key %q is not found", ref), tokenOf(current, c))
+			}
+			current = mappingValueSlot(c, valueIdx)
+		case *ast.SequenceNode:
+			entryIdx, err := parseIndex(ref, len(c.Values)-1)
+			if err != nil {
+				return nil, "", errors.ErrPatch(op.Op, path, err.Error(), tokenOf(current, c))
+			}
+			current = sequenceEntrySlot(c, entryIdx)
+		}
+	}
+	return nil, "", nil
+}
+
+// container returns the mapping or the sequence of the slot.
+// The mapping value node is converted to the mapping node to add the keys to it.
+func (a *applier) container(op Operation, path string, s slot) (ast.Node, error) {
+	node := s.get()
+	if node == nil {
+		return nil, errors.ErrPatch(op.Op, path, "the document is empty", nil)
+	}
+	setValue := s.set
+	if anchor, ok := node.(*ast.AnchorNode); ok {
+		node = anchor.Value
+		setValue = func(value ast.Node) { anchor.Value = value }
+	}
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		mapping := ast.Mapping(n.GetToken(), false, n)
+		setValue(mapping)
+		return mapping, nil
+	case *ast.MappingNode, *ast.SequenceNode:
+		return n, nil
+	case *ast.AliasNode:
+		return nil, errors.ErrPatch(op.Op, path, "cannot patch the value referred by the alias", tokenOf(s, n))
+	}
+	return nil, errors.ErrPatch(op.Op, path, fmt.Sprintf("%s is not a container", node.Type()), tokenOf(s, node))
+}
+
+func tokenOf(s slot, node ast.Node) *token.Token {
+	if s.tk != nil {
+		return s.tk
+	}
+	return node.GetToken()
+}
+
+func mappingValueSlot(mapping *ast.MappingNode, idx int) slot {
+	value := mapping.Values[idx]
+	return slot{
+		get: func() ast.Node { return value.Value },
+		set: func(node ast.Node) { value.Value = node },
+		create: func(v interface{}) (ast.Node, error) {
+			entry, err := mappingEntry(mapping, astutil.KeyText(value.Key), v)
+			if err != nil {
+				return nil, err
+			}
+			// mappingEntry aligns the key to the first key of the mapping, so align it to the key of the value.
+			entry.AddColumn(value.Key.GetToken().Position.Column - entry.Key.GetToken().Position.Column)
+			return entry.Value, nil
+		},
+		tk: value.Key.GetToken(),
+	}
+}
+
+func sequenceEntrySlot(seq *ast.SequenceNode, idx int) slot {
+	return slot{
+		get: func() ast.Node { return seq.Values[idx] },
+		set: func(node ast.Node) { seq.Values[idx] = node },
+		create: func(v interface{}) (ast.Node, error) {
+			return sequenceEntry(seq, v)
+		},
+		tk: seq.Values[idx].GetToken(),
+	}
+}
+
+// newNode creates the node of the value by encoding it.
+func newNode(v interface{}, isFlowStyle bool) (ast.Node, error) {
+	var opts []yaml.EncodeOption
+	if isFlowStyle {
+		opts = append(opts, yaml.Flow(true))
+	}
+	b, err := yaml.MarshalWithOptions(v, opts...)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseBytes(b, 0)
+	if err != nil {
+		return nil, err
+	}
+	return f.Docs[0].Body, nil
+}
+
+// mappingEntry creates the mapping value node aligned with the keys of the mapping.
+func mappingEntry(mapping *ast.MappingNode, key string, v interface{}) (*ast.MappingValueNode, error) {
+	if len(mapping.Values) == 0 {
+		// the empty mapping is written as `{}`, so the entries are added in flow style.
+		mapping.IsFlowStyle = true
+	}
+	node, err := newNode(yaml.MapSlice{{Key: key, Value: v}}, mapping.IsFlowStyle)
+	if err != nil {
+		return nil, err
+	}
+	var entry *ast.MappingValueNode
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		entry = n
+	case *ast.MappingNode:
+		entry = n.Values[0]
+	default:
+		return nil, fmt.Errorf("unexpected node type %s", node.Type())
+	}
+	if !mapping.IsFlowStyle {
+		entry.AddColumn(mapping.Values[0].Key.GetToken().Position.Column - entry.Key.GetToken().Position.Column)
+	}
+	return entry, nil
+}
+
+// sequenceEntry creates the node of the sequence entry aligned with the entries of the sequence.
+func sequenceEntry(seq *ast.SequenceNode, v interface{}) (ast.Node, error) {
+	if len(seq.Values) == 0 {
+		// the empty sequence is written as `[]`, so the entries are added in flow style.
+		seq.IsFlowStyle = true
+	}
+	node, err := newNode([]interface{}{v}, seq.IsFlowStyle)
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := node.(*ast.SequenceNode)
+	if !ok {
+		return nil, fmt.Errorf("unexpected node type %s", node.Type())
+	}
+	entry := entries.Values[0]
+	if !seq.IsFlowStyle {
+		entry.AddColumn(seq.Start.Position.Column - entries.Start.Position.Column)
+	}
+	return entry, nil
+}
+
+// parsePointer parses JSON Pointer ( RFC 6901 ) and returns the reference tokens.
+func parsePointer(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("JSON Pointer must start with '/'")
+	}
+	refs := strings.Split(path[1:], "/")
+	for idx, ref := range refs {
+		refs[idx] = strings.ReplaceAll(strings.ReplaceAll(ref, "~1", "/"), "~0", "~")
+	}
+	return refs, nil
+}
+
+// parseIndex parses the reference token as the index of the sequence not greater than max.
+func parseIndex(ref string, max int) (int, error) {
+	if ref == "" || (len(ref) > 1 && ref[0] == '0') {
+		return 0, fmt.Errorf("invalid index %q", ref)
+	}
+	idx, err := strconv.Atoi(ref)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("invalid index %q", ref)
+	}
+	if idx > max {
+		return 0, fmt.Errorf("index %d is out of range", idx)
+	}
+	return idx, nil
+}
@@ -0,0 +1,276 @@
+package patch_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/patch"
+)
+
+const source = `
+# config
+metadata:
+  name: app # the name
+  labels:
+    tier: web
+spec:
+  replicas: 1 # scaled by hpa
+  containers:
+    - name: app
+      image: app:1
+    # sidecar
+    - name: proxy
+      image: proxy:1
+  ports: [80]
+`
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{
+			name:  "replace",
+			patch: `[{"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+			expected: `
+# config
+metadata:
+  name: app # the name
+  labels:
+    tier: web
+spec:
+  replicas: 3 # scaled by hpa
+  containers:
+    - name: app
+      image: app:1
+    # sidecar
+    - name: proxy
+      image: proxy:1
+  ports: [80]
+`,
+		},
+		{
+			name: "add",
+			patch: `[
+  {"op": "add", "path": "/metadata/labels/app.kubernetes.io~1name", "value": "app"},
+  {"op": "add", "path": "/spec/containers/1", "value": {"name": "init", "args": ["a"]}},
+  {"op": "add", "path": "/spec/ports/-", "value": 443}
+]`,
+			expected: `
+# config
+metadata:
+  name: app # the name
+  labels:
+    tier: web
+    app.kubernetes.io/name: app
+spec:
+  replicas: 1 # scaled by hpa
+  containers:
+    - name: app
+      image: app:1
+    - name: init
+      args:
+      - a
+    # sidecar
+    - name: proxy
+      image: proxy:1
+  ports: [80, 443]
+`,
+		},
+		{
+			name: "remove",
+			patch: `[
+  {"op": "remove", "path": "/spec/containers/0"},
+  {"op": "remove", "path": "/metadata/labels/tier"}
+]`,
+			expected: `
+# config
+metadata:
+  name: app # the name
+  labels: {}
+spec:
+  replicas: 1 # scaled by hpa
+  containers:
+    # sidecar
+    - name: proxy
+      image: proxy:1
+  ports: [80]
+`,
+		},
+		{
+			name: "move and copy",
+			patch: `[
+  {"op": "move", "from": "/metadata/labels", "path": "/spec/labels"},
+  {"op": "copy", "from": "/spec/ports", "path": "/spec/targetPorts"}
+]`,
+			expected: `
+# config
+metadata:
+  name: app # the name
+spec:
+  replicas: 1 # scaled by hpa
+  containers:
+    - name: app
+      image: app:1
+    # sidecar
+    - name: proxy
+      image: proxy:1
+  ports: [80]
+  labels:
+    tier: web
+  targetPorts:
+  - 80
+`,
+		},
+		{
+			name: "test",
+			patch: `[
+  {"op": "test", "path": "/spec/replicas", "value": 1},
+  {"op": "test", "path": "/metadata/labels", "value": {"tier": "web"}}
+]`,
+			expected: source,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(source), parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patched, err := patch.ApplyJSONPatch(file, []byte(test.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := strings.TrimPrefix(test.expected, "\n")
+			if got := patched.String(); got != expected {
+				t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", expected, got)
+			}
+			if file.String() != strings.TrimPrefix(source, "\n") {
+				t.Fatal("the input file is modified")
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchError(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "key not found",
+			patch:    `[{"op": "replace", "path": "/spec/missing/x", "value": 2}]`,
+			expected: `[7:1] replace "/spec/missing/x": key "missing" is not found`,
+		},
+		{
+			name:     "index out of range",
+			patch:    `[{"op": "add", "path": "/spec/containers/5", "value": 1}]`,
+			expected: `[10:5] add "/spec/containers/5": index 5 is out of range`,
+		},
+		{
+			name:     "test failed",
+			patch:    `[{"op": "test", "path": "/spec/replicas", "value": 2}]`,
+			expected: `[8:3] test "/spec/replicas": the value is not equal to 2`,
+		},
+		{
+			name:     "scalar is not container",
+			patch:    `[{"op": "add", "path": "/spec/replicas/x", "value": 2}]`,
+			expected: `[8:3] add "/spec/replicas/x": Integer is not a container`,
+		},
+		{
+			name:     "invalid pointer",
+			patch:    `[{"op": "remove", "path": "spec"}]`,
+			expected: `remove "spec": JSON Pointer must start with '/'`,
+		},
+		{
+			name:     "unknown operation",
+			patch:    `[{"op": "delete", "path": "/spec"}]`,
+			expected: `delete "/spec": unknown operation`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := parser.ParseBytes([]byte(source), parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = patch.ApplyJSONPatch(file, []byte(test.patch))
+			var patchErr *patch.Error
+			if !errors.As(err, &patchErr) {
+				t.Fatalf("expected patch error but got %v", err)
+			}
+			if got := yaml.FormatError(err, false, false); got != test.expected {
+				t.Fatalf("unexpected error message:\nexpected: %s\ngot:      %s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	file, err := parser.ParseBytes([]byte(source), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched, err := patch.ApplyMergePatch(file, []byte(`{
+  "metadata": {"labels": {"tier": null}},
+  "spec": {"replicas": 2, "ports": null, "selector": {"app": "app", "unused": null}}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# config
+metadata:
+  name: app # the name
+  labels: {}
+spec:
+  replicas: 2 # scaled by hpa
+  containers:
+    - name: app
+      image: app:1
+    # sidecar
+    - name: proxy
+      image: proxy:1
+  selector:
+    app: app
+`
+	if got := patched.String(); got != strings.TrimPrefix(expected, "\n") {
+		t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", strings.TrimPrefix(expected, "\n"), got)
+	}
+}
+
+func TestApplyMergePatch_Empty(t *testing.T) {
+	for _, src := range []string{"", "\n"} {
+		file, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		patched, err := patch.ApplyMergePatch(file, []byte(`{"a": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := patched.String(); got != "a: 1\n" {
+			t.Fatalf("unexpected output for %q: %q", src, got)
+		}
+	}
+}
+
+func TestApplyMergePatch_ReplaceAllKeys(t *testing.T) {
+	file, err := parser.ParseBytes([]byte("b:\n  c: 2\n"), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched, err := patch.ApplyMergePatch(file, []byte(`{"b": {"c": null, "d": [1]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "b:\n  d:\n  - 1\n"
+	if got := patched.String(); got != expected {
+		t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,96 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Diagnostic is a problem of the document reported by the validator of Pipeline.
+type Diagnostic struct {
+	Message string
+	// Token is the token where the problem is found. It may be nil.
+	Token *token.Token
+}
+
+// String returns the message of the diagnostic with the position of the token.
+func (d Diagnostic) String() string {
+	if d.Token == nil || d.Token.Position == nil {
+		return d.Message
+	}
+	return fmt.Sprintf("[%d:%d] %s", d.Token.Position.Line, d.Token.Position.Column, d.Message)
+}
+
+// Transform modifies the parsed file in place.
+type Transform func(*ast.File) error
+
+// FileValidator reports the problems of the parsed file as diagnostics.
+type FileValidator func(*ast.File) []Diagnostic
+
+// Pipeline processes YAML documents by parsing, transforming, validating and writing them in order.
+// The zero value writes the input as it is.
+type Pipeline struct {
+	// ParseMode is the mode passed to the parser. Specify parser.ParseComments to keep the comments in the output.
+	ParseMode parser.Mode
+	// ParseOptions are the options passed to the parser.
+	ParseOptions []parser.Option
+	// Transforms are applied to the parsed file in order.
+	Transforms []Transform
+	// Validators are applied to the transformed file, and all of the diagnostics are collected.
+	Validators []FileValidator
+	// Output are the options to encode the documents.
+	// If it is empty, the transformed file is written as it is.
+	// Otherwise, each document is decoded and encoded again with the options,
+	// so the comments and the styles of the input are not kept.
+	Output []EncodeOption
+}
+
+// Run processes src and returns the output and the diagnostics reported by the validators.
+// The diagnostics don't stop the pipeline, so the output is returned with them.
+// An error is returned if parsing, transforming or encoding fails.
+func (p *Pipeline) Run(src []byte) ([]byte, []Diagnostic, error) {
+	file, err := parser.ParseBytes(src, p.ParseMode, p.ParseOptions...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, transform := range p.Transforms {
+		if err := transform(file); err != nil {
+			return nil, nil, err
+		}
+	}
+	var diagnostics []Diagnostic
+	for _, validate := range p.Validators {
+		diagnostics = append(diagnostics, validate(file)...)
+	}
+	out, err := p.output(file)
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	return out, diagnostics, nil
+}
+
+func (p *Pipeline) output(file *ast.File) ([]byte, error) {
+	if len(p.Output) == 0 {
+		return []byte(file.String()), nil
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, p.Output...)
+	for _, doc := range file.Docs {
+		if doc.Body == nil {
+			continue
+		}
+		var v interface{}
+		dec := NewDecoder(strings.NewReader(""), UseOrderedMap())
+		if err := dec.DecodeFromNode(doc.Body, &v); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
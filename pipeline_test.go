@@ -0,0 +1,109 @@
+package yaml_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestPipeline(t *testing.T) {
+	src := `
+# server config
+server:
+  host: localhost
+  password: secret # must be removed
+`
+	replaceHost := func(f *ast.File) error {
+		path, err := yaml.PathString("$.server.host")
+		if err != nil {
+			return err
+		}
+		return path.ReplaceWithReader(f, strings.NewReader("example.com"))
+	}
+	disallowPassword := func(f *ast.File) []yaml.Diagnostic {
+		var diagnostics []yaml.Diagnostic
+		for _, doc := range f.Docs {
+			for _, node := range ast.Filter(ast.MappingValueType, doc) {
+				key := node.(*ast.MappingValueNode).Key
+				if key.GetToken().Value == "password" {
+					diagnostics = append(diagnostics, yaml.Diagnostic{
+						Message: "password must not be written in the file",
+						Token:   key.GetToken(),
+					})
+				}
+			}
+		}
+		return diagnostics
+	}
+
+	t.Run("keep the source", func(t *testing.T) {
+		p := &yaml.Pipeline{
+			ParseMode:  parser.ParseComments,
+			Transforms: []yaml.Transform{replaceHost},
+			Validators: []yaml.FileValidator{disallowPassword},
+		}
+		out, diagnostics, err := p.Run([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `
+# server config
+server:
+  host: example.com
+  password: secret # must be removed
+`
+		if got := "\n" + string(out); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+		if len(diagnostics) != 1 {
+			t.Fatalf("unexpected diagnostics: %v", diagnostics)
+		}
+		if got := diagnostics[0].String(); got != "[5:3] password must not be written in the file" {
+			t.Fatalf("unexpected diagnostic: %s", got)
+		}
+	})
+	t.Run("encode with options", func(t *testing.T) {
+		p := &yaml.Pipeline{
+			Transforms: []yaml.Transform{replaceHost},
+			Output:     []yaml.EncodeOption{yaml.Indent(4)},
+		}
+		out, diagnostics, err := p.Run([]byte(src + "---\na: [1, 2]\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diagnostics) != 0 {
+			t.Fatalf("unexpected diagnostics: %v", diagnostics)
+		}
+		expected := `
+server:
+    host: example.com
+    password: secret
+---
+a:
+- 1
+- 2
+`
+		if got := "\n" + string(out); got != expected {
+			t.Fatalf("unexpected output:\nexpected:%s\ngot:%s", expected, got)
+		}
+	})
+	t.Run("transform error", func(t *testing.T) {
+		errTransform := errors.New("transform error")
+		p := &yaml.Pipeline{
+			Transforms: []yaml.Transform{func(*ast.File) error { return errTransform }},
+		}
+		if _, _, err := p.Run([]byte(src)); !errors.Is(err, errTransform) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("parse error", func(t *testing.T) {
+		var p yaml.Pipeline
+		if _, _, err := p.Run([]byte("a: [")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
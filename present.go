@@ -0,0 +1,62 @@
+package yaml
+
+import (
+	"reflect"
+)
+
+// Present wraps the value of T to report whether the key of the mapping is present in the document
+// and whether the value is null. Both the absent key and the null value are decoded as the zero value of T,
+// so Present is used to tell them apart, like the configuration to be merged into another.
+//
+// For example:
+//
+//	type Config struct {
+//		Timeout yaml.Present[int] `yaml:"timeout"`
+//	}
+//
+// `timeout: 10` is decoded as {Value: 10, Set: true}, `timeout: null` as {Set: true, Null: true},
+// and the absent key leaves the field as {}.
+//
+// Present is encoded as the value of T, or null if Null is true.
+// With the omitempty flag, the field is omitted if Set is false.
+type Present[T any] struct {
+	Value T
+	// Set is true if the key is present in the document, including the null value.
+	Set bool
+	// Null is true if the value is null.
+	Null bool
+}
+
+// IsZero reports whether the key was absent. It's used to omit the field with the omitempty flag.
+func (p Present[T]) IsZero() bool {
+	return !p.Set
+}
+
+// MarshalYAML encodes the value, or null if Null is true.
+func (p Present[T]) MarshalYAML() (interface{}, error) {
+	if p.Null {
+		return nil, nil
+	}
+	return p.Value, nil
+}
+
+func (p *Present[T]) setPresent(null bool) reflect.Value {
+	p.Set = true
+	p.Null = null
+	return reflect.ValueOf(&p.Value).Elem()
+}
+
+// presentSetter is implemented by Present to be set by the decoder.
+// setPresent marks the value as present and returns the value to decode into.
+type presentSetter interface {
+	setPresent(null bool) reflect.Value
+}
+
+// presentValue returns the presentSetter if v is the addressable Present value.
+func presentValue(v reflect.Value) (presentSetter, bool) {
+	if v.Kind() != reflect.Struct || !v.CanAddr() || !v.CanInterface() {
+		return nil, false
+	}
+	setter, ok := v.Addr().Interface().(presentSetter)
+	return setter, ok
+}
@@ -29,6 +29,11 @@ type Printer struct {
 	String           PrintFunc
 	Number           PrintFunc
 	Comment          PrintFunc
+	// TabWidth is the number of columns of a tab.
+	// If it's greater than zero, the tabs are printed as the white spaces up to the next tab stop.
+	// Otherwise, the tabs are printed as they are, and the error marker is aligned by the same tabs,
+	// so it points at the right character regardless of the tab width of the terminal.
+	TabWidth int
 }
 
 func defaultLineNumberFormat(num int) string {
@@ -102,10 +107,20 @@ func (p *Printer) PrintTokens(tokens token.Tokens) string {
 			p.LineNumberFormat = defaultLineNumberFormat
 		}
 	}
-	texts := []string{}
+	// the tokens on the same line are appended by strings.Builder to avoid quadratic time for the long line.
+	texts := []*strings.Builder{}
 	lineNumber := tokens[0].Position.Line
+	column := 0
 	for _, tk := range tokens {
 		lines := strings.Split(tk.Origin, "\n")
+		if p.TabWidth > 0 {
+			for idx := range lines {
+				if idx > 0 {
+					column = 0
+				}
+				lines[idx], column = expandTabs(lines[idx], column, p.TabWidth)
+			}
+		}
 		prop := p.property(tk)
 		header := ""
 		if p.LineNumber {
@@ -114,11 +129,10 @@ func (p *Printer) PrintTokens(tokens token.Tokens) string {
 		if len(lines) == 1 {
 			line := prop.Prefix + lines[0] + prop.Suffix
 			if len(texts) == 0 {
-				texts = append(texts, header+line)
+				texts = append(texts, newLineBuilder(header+line))
 				lineNumber++
 			} else {
-				text := texts[len(texts)-1]
-				texts[len(texts)-1] = text + line
+				texts[len(texts)-1].WriteString(line)
 			}
 		} else {
 			for idx, src := range lines {
@@ -128,20 +142,49 @@ func (p *Printer) PrintTokens(tokens token.Tokens) string {
 				line := prop.Prefix + src + prop.Suffix
 				if idx == 0 {
 					if len(texts) == 0 {
-						texts = append(texts, header+line)
+						texts = append(texts, newLineBuilder(header+line))
 						lineNumber++
 					} else {
-						text := texts[len(texts)-1]
-						texts[len(texts)-1] = text + line
+						texts[len(texts)-1].WriteString(line)
 					}
 				} else {
-					texts = append(texts, fmt.Sprintf("%s%s", header, line))
+					texts = append(texts, newLineBuilder(fmt.Sprintf("%s%s", header, line)))
 					lineNumber++
 				}
 			}
 		}
 	}
-	return strings.Join(texts, "\n")
+	lines := make([]string, 0, len(texts))
+	for _, text := range texts {
+		lines = append(lines, text.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// expandTabs replaces the tabs of s starting at column with the white spaces up to the next tab stop.
+// It returns the replaced text and the column after it.
+func expandTabs(s string, column, tabWidth int) (string, int) {
+	if !strings.Contains(s, "\t") {
+		return s, column + len([]rune(s))
+	}
+	var b strings.Builder
+	for _, c := range s {
+		if c == '\t' {
+			n := tabWidth - column%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			column += n
+			continue
+		}
+		b.WriteRune(c)
+		column++
+	}
+	return b.String(), column
+}
+
+func newLineBuilder(text string) *strings.Builder {
+	var b strings.Builder
+	b.WriteString(text)
+	return &b
 }
 
 // PrintNode create text from ast.Node
@@ -309,6 +352,10 @@ func (p *Printer) printAfterTokens(tk *token.Token, maxLine int) token.Tokens {
 		tokens.Add(clonedTk)
 		tk = clonedTk.Next
 	}
+	if lastTk := tokens[len(tokens)-1]; lastTk.Next == nil {
+		// the white spaces at the end of the source are not printed as the empty lines.
+		lastTk.Origin = p.removeRightSideWhiteSpaceChar(lastTk.Origin)
+	}
 	return tokens
 }
 
@@ -332,6 +379,12 @@ func (p *Printer) setupErrorTokenFormat(annotateLine int, isColored bool) {
 }
 
 func (p *Printer) PrintErrorToken(tk *token.Token, isColored bool) string {
+	return p.PrintErrorTokenLines(tk, isColored, 3, 3)
+}
+
+// PrintErrorTokenLines prints the lines of the error token with the before lines before them and the after lines after them.
+func (p *Printer) PrintErrorTokenLines(tk *token.Token, isColored bool, before, after int) string {
+	before, after = max(before, 0), max(after, 0)
 	errToken := tk
 	curLine := tk.Position.Line
 	curExtLine := curLine + p.newLineCount(p.removeLeftSideNewLineChar(tk.Origin))
@@ -340,17 +393,57 @@ func (p *Printer) PrintErrorToken(tk *token.Token, isColored bool) string {
 		curExtLine--
 	}
 
-	minLine := int(math.Max(float64(curLine-3), 1))
-	maxLine := curExtLine + 3
+	minLine := int(math.Max(float64(curLine-before), 1))
+	maxLine := curExtLine + after
 	p.setupErrorTokenFormat(curLine, isColored)
 
 	beforeTokens := p.printBeforeTokens(tk, minLine, curExtLine)
 	lastTk := beforeTokens[len(beforeTokens)-1]
 	afterTokens := p.printAfterTokens(lastTk.Next, maxLine)
 
-	beforeSource := p.PrintTokens(beforeTokens)
+	beforeSource := p.removeCarriageReturn(p.PrintTokens(beforeTokens))
 	prefixSpaceNum := len(fmt.Sprintf("  %2d | ", curLine))
-	annotateLine := strings.Repeat(" ", prefixSpaceNum+errToken.Position.Column-1) + "^"
-	afterSource := p.PrintTokens(afterTokens)
+	annotateLine := strings.Repeat(" ", prefixSpaceNum) + p.markerIndent(beforeTokens, curLine, errToken.Position.Column) + "^"
+	afterSource := p.removeCarriageReturn(p.PrintTokens(afterTokens))
 	return fmt.Sprintf("%s\n%s\n%s", beforeSource, annotateLine, afterSource)
 }
+
+// removeCarriageReturn removes the carriage returns of CRLF line breaks,
+// which move the cursor of the terminal to the beginning of the line.
+func (p *Printer) removeCarriageReturn(src string) string {
+	return strings.TrimSuffix(strings.ReplaceAll(src, "\r\n", "\n"), "\r")
+}
+
+// markerIndent returns the indent of the error marker pointing at column of line.
+// The column counts the characters including the tabs as one, so the tabs before the column
+// are kept or expanded in the same way as the source.
+func (p *Printer) markerIndent(tokens token.Tokens, line, column int) string {
+	var plain Printer
+	lines := strings.Split(plain.PrintTokens(tokens), "\n")
+	idx := line - tokens[0].Position.Line
+	if idx < 0 || idx >= len(lines) {
+		return strings.Repeat(" ", column-1)
+	}
+	var b strings.Builder
+	width := 0
+	for i, c := range []rune(lines[idx]) {
+		if i >= column-1 {
+			break
+		}
+		switch {
+		case c != '\t':
+			b.WriteByte(' ')
+			width++
+		case p.TabWidth > 0:
+			n := p.TabWidth - width%p.TabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			width += n
+		default:
+			b.WriteByte('\t')
+		}
+	}
+	if n := column - 1 - len([]rune(lines[idx])); n > 0 {
+		b.WriteString(strings.Repeat(" ", n))
+	}
+	return b.String()
+}
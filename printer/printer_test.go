@@ -44,6 +44,36 @@ alias: *x
 			t.Fatalf("unexpected output: expect:[%s]\n actual:[%s]", expect, actual)
 		}
 	})
+	t.Run("print starting from tokens[3] with 1 line before and after", func(t *testing.T) {
+		tokens := lexer.Tokenize(yml)
+		var p printer.Printer
+		actual := "\n" + p.PrintErrorTokenLines(tokens[3], false, 1, 1)
+		expect := `
+   1 | ---
+>  2 | text: aaaa
+             ^
+   3 | text2: aaaa
+   4 |  bbbb
+   5 |  cccc
+   6 |  dddd
+   7 |  eeee
+   8 | `
+		if actual != expect {
+			t.Fatalf("unexpected output: expect:[%s]\n actual:[%s]", expect, actual)
+		}
+	})
+	t.Run("print starting from tokens[3] without lines before and after", func(t *testing.T) {
+		tokens := lexer.Tokenize(yml)
+		var p printer.Printer
+		actual := "\n" + p.PrintErrorTokenLines(tokens[3], false, 0, 0)
+		expect := `
+>  2 | text: aaaa
+             ^
+`
+		if actual != expect {
+			t.Fatalf("unexpected output: expect:[%s]\n actual:[%s]", expect, actual)
+		}
+	})
 	t.Run("print starting from tokens[4]", func(t *testing.T) {
 		tokens := lexer.Tokenize(yml)
 		var p printer.Printer
@@ -221,3 +251,56 @@ text3: hello
 		})
 	}
 }
+
+func Test_Printer_TabAndCRLF(t *testing.T) {
+	tc := []struct {
+		name     string
+		yml      string
+		token    int
+		tabWidth int
+		want     string
+	}{
+		{
+			name:  "tab",
+			yml:   "a: 1\nb:\t@x\n",
+			token: 5,
+			want: `
+   1 | a: 1
+>  2 | b:	@x
+         	^
+`,
+		},
+		{
+			name:     "tab width",
+			yml:      "a: 1\nb:\t@x\n",
+			token:    5,
+			tabWidth: 4,
+			want: `
+   1 | a: 1
+>  2 | b:  @x
+           ^
+`,
+		},
+		{
+			name:  "crlf",
+			yml:   "a: 1\r\nb: [1, 2\r\nc: 3\r\n",
+			token: 9,
+			want: `
+   1 | a: 1
+   2 | b: [1, 2
+>  3 | c: 3
+       ^
+`,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := lexer.Tokenize(tt.yml)
+			p := printer.Printer{TabWidth: tt.tabWidth}
+			got := "\n" + p.PrintErrorToken(tokens[tt.token], false)
+			if got != tt.want {
+				t.Fatalf("PrintErrorToken() got: %q\n want:%q\n", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package yaml
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+)
+
+// The messages and the enums generated by protoc-gen-go are decoded by UseProtoJSON without importing
+// google.golang.org/protobuf. The names of the messages and the enum values are looked up by calling
+// the methods of the protoreflect API like ProtoReflect and Descriptor by reflection.
+
+// protoWrapperNames is the well-known types wrapping a scalar, which is written as the scalar in protojson.
+var protoWrapperNames = map[string]struct{}{
+	"google.protobuf.DoubleValue": {},
+	"google.protobuf.FloatValue":  {},
+	"google.protobuf.Int64Value":  {},
+	"google.protobuf.UInt64Value": {},
+	"google.protobuf.Int32Value":  {},
+	"google.protobuf.UInt32Value": {},
+	"google.protobuf.BoolValue":   {},
+	"google.protobuf.StringValue": {},
+	"google.protobuf.BytesValue":  {},
+}
+
+// protoMessageNameCache caches the full name of the message for each type, which is empty if the type isn't a message.
+var protoMessageNameCache sync.Map
+
+// callProtoMethod calls the method having no arguments except args and returning one value.
+func callProtoMethod(v reflect.Value, name string, args ...interface{}) (reflect.Value, bool) {
+	if !v.IsValid() || (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && v.IsNil() {
+		return reflect.Value{}, false
+	}
+	method := v.MethodByName(name)
+	if !method.IsValid() {
+		return reflect.Value{}, false
+	}
+	typ := method.Type()
+	if typ.NumIn() != len(args) || typ.NumOut() != 1 {
+		return reflect.Value{}, false
+	}
+	in := make([]reflect.Value, 0, len(args))
+	for i, arg := range args {
+		argValue := reflect.ValueOf(arg)
+		if !argValue.Type().ConvertibleTo(typ.In(i)) {
+			return reflect.Value{}, false
+		}
+		in = append(in, argValue.Convert(typ.In(i)))
+	}
+	return method.Call(in)[0], true
+}
+
+// protoMessageName returns the full name of the message of typ generated by protoc-gen-go, like google.protobuf.Duration.
+func protoMessageName(typ reflect.Type) string {
+	if cached, exists := protoMessageNameCache.Load(typ); exists {
+		return cached.(string)
+	}
+	var name string
+	if typ.Kind() == reflect.Struct {
+		msg, _ := callProtoMethod(reflect.New(typ), "ProtoReflect")
+		desc, _ := callProtoMethod(msg, "Descriptor")
+		if fullName, ok := callProtoMethod(desc, "FullName"); ok && fullName.Kind() == reflect.String {
+			name = fullName.String()
+		}
+	}
+	protoMessageNameCache.Store(typ, name)
+	return name
+}
+
+// isProtoEnumType returns whether typ is the enum generated by protoc-gen-go.
+func isProtoEnumType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Int32 {
+		return false
+	}
+	for _, name := range []string{"Descriptor", "Number", "Type"} {
+		if _, exists := typ.MethodByName(name); !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// protoJSONFieldName returns the name of the field in protojson specified by the protobuf tag generated by protoc-gen-go.
+// It's empty if the name is the same as the name in the proto file.
+func protoJSONFieldName(field reflect.StructField) string {
+	for _, opt := range strings.Split(field.Tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(opt, "json=") {
+			return strings.TrimPrefix(opt, "json=")
+		}
+	}
+	return ""
+}
+
+// decodeProtoJSON decodes src into dst by the conventions of protojson if dst is the enum or the well-known type
+// which is written in the special form. It returns false if dst is decoded as usual.
+func (d *Decoder) decodeProtoJSON(ctx context.Context, dst reflect.Value, src ast.Node) (bool, error) {
+	if d.isNullNode(src) {
+		return false, nil
+	}
+	typ := dst.Type()
+	if isProtoEnumType(typ) {
+		return d.decodeProtoEnum(dst, src)
+	}
+	if str, ok := src.(*ast.StringNode); ok {
+		if ok, err := decodeProtoQuotedNumber(dst, str); ok {
+			return true, err
+		}
+	}
+	name := protoMessageName(typ)
+	if name == "" {
+		return false, nil
+	}
+	if _, ok := protoWrapperNames[name]; ok {
+		if src.Type() == ast.MappingType {
+			return false, nil
+		}
+		return true, d.decodeValue(ctx, dst.FieldByName("Value"), src)
+	}
+	switch name {
+	case "google.protobuf.Duration":
+		return d.decodeProtoDuration(dst, src)
+	case "google.protobuf.Timestamp":
+		return d.decodeProtoTimestamp(dst, src)
+	case "google.protobuf.FieldMask":
+		return d.decodeProtoFieldMask(dst, src)
+	case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.ListValue":
+		if unmarshaler, ok := dst.Addr().Interface().(jsonUnmarshaler); ok {
+			return true, d.decodeByJSONUnmarshaler(unmarshaler, src)
+		}
+	}
+	return false, nil
+}
+
+// decodeProtoQuotedNumber decodes the number written as the string like "10" and "Infinity" into dst,
+// because protojson writes the 64-bit integers and the special floats as the strings.
+func decodeProtoQuotedNumber(dst reflect.Value, src *ast.StringNode) (bool, error) {
+	s := src.Value
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return true, errors.ErrTypeMismatch(dst.Type(), reflect.TypeOf(s), src.GetToken())
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return true, errors.ErrTypeMismatch(dst.Type(), reflect.TypeOf(s), src.GetToken())
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return true, errors.ErrTypeMismatch(dst.Type(), reflect.TypeOf(s), src.GetToken())
+		}
+		dst.SetFloat(f)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// decodeProtoEnum decodes the name of the enum value into dst.
+// The number is decoded as usual.
+func (d *Decoder) decodeProtoEnum(dst reflect.Value, src ast.Node) (bool, error) {
+	v, err := d.nodeToValue(src)
+	if err != nil {
+		return true, err
+	}
+	name, ok := v.(string)
+	if !ok {
+		return false, nil
+	}
+	desc, _ := callProtoMethod(dst, "Descriptor")
+	values, _ := callProtoMethod(desc, "Values")
+	value, _ := callProtoMethod(values, "ByName", name)
+	number, ok := callProtoMethod(value, "Number")
+	if !ok {
+		return true, errors.ErrSyntax(fmt.Sprintf("unknown value %q of enum %s", name, dst.Type()), src.GetToken())
+	}
+	dst.SetInt(number.Int())
+	return true, nil
+}
+
+// protoScalarString returns the string of the scalar node written for the well-known type.
+// The other nodes like the mapping are decoded as the message, so src must be the scalar.
+func (d *Decoder) protoScalarString(dst reflect.Value, src ast.Node) (string, error) {
+	v, err := d.nodeToValue(src)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.ErrTypeMismatch(dst.Type(), reflect.TypeOf(v), src.GetToken())
+	}
+	return s, nil
+}
+
+func isScalarNode(node ast.Node) bool {
+	_, ok := node.(ast.ScalarNode)
+	return ok
+}
+
+func (d *Decoder) decodeProtoDuration(dst reflect.Value, src ast.Node) (bool, error) {
+	if !isScalarNode(src) {
+		return false, nil
+	}
+	s, err := d.protoScalarString(dst, src)
+	if err != nil {
+		return true, err
+	}
+	seconds, nanos, err := parseProtoDuration(s)
+	if err != nil {
+		return true, errors.ErrSyntax(fmt.Sprintf("invalid duration %q: %s", s, err), src.GetToken())
+	}
+	dst.FieldByName("Seconds").SetInt(seconds)
+	dst.FieldByName("Nanos").SetInt(int64(nanos))
+	return true, nil
+}
+
+// parseProtoDuration parses the duration of protojson like 1.5s.
+// The duration of time.ParseDuration like 1m30s is also accepted.
+func parseProtoDuration(s string) (int64, int32, error) {
+	text, ok := strings.CutSuffix(s, "s")
+	if !ok || strings.ContainsAny(text, "hmsuµn") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, 0, err
+		}
+		return int64(d / time.Second), int32(d % time.Second), nil
+	}
+	negative := strings.HasPrefix(text, "-")
+	intPart, fracPart, _ := strings.Cut(strings.TrimPrefix(text, "-"), ".")
+	if intPart == "" || len(fracPart) > 9 || strings.ContainsAny(intPart+fracPart, "+-") {
+		return 0, 0, fmt.Errorf("invalid format")
+	}
+	seconds, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	var nanos int64
+	if fracPart != "" {
+		n, err := strconv.ParseInt(fracPart+strings.Repeat("0", 9-len(fracPart)), 10, 32)
+		if err != nil {
+			return 0, 0, err
+		}
+		nanos = n
+	}
+	if negative {
+		seconds, nanos = -seconds, -nanos
+	}
+	return seconds, int32(nanos), nil
+}
+
+func (d *Decoder) decodeProtoTimestamp(dst reflect.Value, src ast.Node) (bool, error) {
+	if !isScalarNode(src) {
+		return false, nil
+	}
+	v, err := d.nodeToValue(src)
+	if err != nil {
+		return true, err
+	}
+	var t time.Time
+	switch vv := v.(type) {
+	case time.Time:
+		t = vv
+	case string:
+		t, err = time.Parse(time.RFC3339Nano, vv)
+		if err != nil {
+			return true, errors.ErrSyntax(fmt.Sprintf("invalid timestamp %q: %s", vv, err), src.GetToken())
+		}
+	default:
+		return true, errors.ErrTypeMismatch(dst.Type(), reflect.TypeOf(v), src.GetToken())
+	}
+	dst.FieldByName("Seconds").SetInt(t.Unix())
+	dst.FieldByName("Nanos").SetInt(int64(t.Nanosecond()))
+	return true, nil
+}
+
+// decodeProtoFieldMask decodes the comma separated paths in lowerCamelCase like `a.fooBar,c` into the FieldMask.
+func (d *Decoder) decodeProtoFieldMask(dst reflect.Value, src ast.Node) (bool, error) {
+	if !isScalarNode(src) {
+		return false, nil
+	}
+	s, err := d.protoScalarString(dst, src)
+	if err != nil {
+		return true, err
+	}
+	paths := []string{}
+	if s != "" {
+		for _, path := range strings.Split(s, ",") {
+			paths = append(paths, camelToSnake(path))
+		}
+	}
+	dst.FieldByName("Paths").Set(reflect.ValueOf(paths))
+	return true, nil
+}
+
+func camelToSnake(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
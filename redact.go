@@ -0,0 +1,227 @@
+package yaml
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/token"
+)
+
+// RedactedValue is the value replacing the secrets redacted by Redactor.
+const RedactedValue = "***"
+
+// DefaultRedactKeys matches the keys of the common secrets like password, token and secret.
+// NewRedactor uses it if neither the paths nor the keys are specified.
+var DefaultRedactKeys = regexp.MustCompile(`(?i)passw(or)?d|secret|token|api_?key|private_?key|credential`)
+
+// Redactor finds the secrets in the AST by the YAMLPaths and the patterns of the keys.
+// The value at the matched path or of the matched key is a secret, and all the scalars in it are secrets if it's a collection.
+type Redactor struct {
+	paths []*regexp.Regexp
+	keys  []*regexp.Regexp
+}
+
+// NewRedactor creates the Redactor for the values at paths and the values of the keys matching keys.
+// The path is the YAMLPath like `$.database.password`, `$.users[*].token` matching any index
+// or `$..password` matching the key at any depth. If neither paths nor keys are specified,
+// the keys matching DefaultRedactKeys are used.
+func NewRedactor(paths []string, keys ...*regexp.Regexp) (*Redactor, error) {
+	r := &Redactor{keys: keys}
+	for _, path := range paths {
+		if _, err := PathString(path); err != nil {
+			return nil, err
+		}
+		r.paths = append(r.paths, redactPathPattern(path))
+	}
+	if len(r.paths) == 0 && len(r.keys) == 0 {
+		r.keys = []*regexp.Regexp{DefaultRedactKeys}
+	}
+	return r, nil
+}
+
+// redactPathPattern converts the YAMLPath to the pattern matching the paths of the nodes.
+func redactPathPattern(path string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(path)
+	pattern = strings.ReplaceAll(pattern, `\[\*\]`, `\[[0-9]+\]`)
+	pattern = strings.ReplaceAll(pattern, `\.\.`, `(?:\..*)?\.`)
+	return regexp.MustCompile("^" + pattern + "$")
+}
+
+// Redact replaces the secrets in node with "***" keeping the structure and the comments,
+// so the redacted node can be printed or logged safely.
+// The origins of the tokens of the secrets are also replaced for the printer and the error messages.
+func (r *Redactor) Redact(node ast.Node) {
+	r.walk(node, false, func(node ast.Node) ast.Node {
+		maskOrigins(node)
+		tk := node.GetToken()
+		redacted := ast.String(token.DoubleQuote(RedactedValue, strconv.Quote(RedactedValue), tk.Position))
+		redacted.SetPath(node.GetPath())
+		if comment := node.GetComment(); comment != nil {
+			_ = redacted.SetComment(comment)
+		}
+		return redacted
+	})
+}
+
+// RedactFile replaces the secrets in all the documents of f like Redact.
+func (r *Redactor) RedactFile(f *ast.File) {
+	for _, doc := range f.Docs {
+		r.Redact(doc)
+	}
+}
+
+// walk calls fn for each secret scalar in node and replaces it with the result.
+func (r *Redactor) walk(node ast.Node, secret bool, fn func(ast.Node) ast.Node) ast.Node {
+	if node == nil {
+		return nil
+	}
+	secret = secret || r.matchPath(node.GetPath())
+	switch n := node.(type) {
+	case *ast.DocumentNode:
+		n.Body = r.walk(n.Body, secret, fn)
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			r.walk(value, secret, fn)
+		}
+	case *ast.MappingValueNode:
+		n.Value = r.walk(n.Value, secret || r.matchKey(n.Key), fn)
+	case *ast.SequenceNode:
+		for i, value := range n.Values {
+			n.Values[i] = r.walk(value, secret, fn)
+		}
+	case *ast.AnchorNode:
+		n.Value = r.walk(n.Value, secret, fn)
+	case *ast.TagNode:
+		n.Value = r.walk(n.Value, secret, fn)
+	case *ast.AliasNode, *ast.NullNode:
+		// the alias and the null don't have the secret value.
+	case ast.ScalarNode:
+		if secret {
+			return fn(n)
+		}
+	}
+	return node
+}
+
+func (r *Redactor) matchPath(path string) bool {
+	for _, pattern := range r.paths {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) matchKey(key ast.MapKeyNode) bool {
+	var node ast.Node = key
+	if explicitKey, ok := key.(*ast.MappingKeyNode); ok {
+		node = explicitKey.Value
+	}
+	scalar, ok := node.(ast.ScalarNode)
+	if !ok {
+		return false
+	}
+	text, ok := scalar.GetValue().(string)
+	if !ok {
+		text = scalar.GetToken().Value
+	}
+	for _, pattern := range r.keys {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretTokens returns the tokens having the text of the secret scalar node.
+func secretTokens(node ast.Node) []*token.Token {
+	if literal, ok := node.(*ast.LiteralNode); ok {
+		return []*token.Token{literal.Value.GetToken()}
+	}
+	return []*token.Token{node.GetToken()}
+}
+
+// maskOrigins replaces the text of the secret in the origins of the tokens with "***".
+// The text in the multiple lines is replaced line by line keeping the indentation and the line breaks,
+// so that the other lines are printed at the same positions.
+func maskOrigins(node ast.Node) {
+	for _, tk := range secretTokens(node) {
+		trimmed := strings.TrimLeft(tk.Origin, " \t\r\n")
+		text := strings.TrimRight(trimmed, " \t\r\n")
+		if text == "" {
+			continue
+		}
+		lead := tk.Origin[:len(tk.Origin)-len(trimmed)]
+		trail := trimmed[len(text):]
+		if !strings.Contains(text, "\n") {
+			tk.Origin = lead + strconv.Quote(RedactedValue) + trail
+			continue
+		}
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			content := strings.TrimSpace(line)
+			if content == "" {
+				continue
+			}
+			lines[i] = line[:strings.Index(line, content)] + RedactedValue
+		}
+		tk.Origin = lead + strings.Join(lines, "\n") + trail
+	}
+}
+
+// redactOrigins masks the origins of the secrets in f for the error messages and returns the values of the secrets.
+func (r *Redactor) redactOrigins(f *ast.File) []string {
+	var values []string
+	for _, doc := range f.Docs {
+		r.walk(doc, false, func(node ast.Node) ast.Node {
+			for _, tk := range secretTokens(node) {
+				values = append(values, tk.Value)
+			}
+			maskOrigins(node)
+			return node
+		})
+	}
+	return values
+}
+
+// redactedError is the error replacing the quoted values of the secrets in the message with "***".
+type redactedError struct {
+	err    error
+	values []string
+}
+
+func (e *redactedError) redact(msg string) string {
+	for _, value := range e.values {
+		if value == "" {
+			continue
+		}
+		msg = strings.ReplaceAll(msg, strconv.Quote(value), strconv.Quote(RedactedValue))
+	}
+	return msg
+}
+
+func (e *redactedError) Error() string {
+	return e.redact(e.err.Error())
+}
+
+func (e *redactedError) FormatError(colored, inclSource bool) string {
+	var pe errors.PrettyFormatError
+	if errors.As(e.err, &pe) {
+		return e.redact(pe.FormatError(colored, inclSource))
+	}
+	return e.Error()
+}
+
+func (e *redactedError) FormatErrorWithOptions(opts errors.FormatOptions) string {
+	if formatted, ok := errors.FormatWithOptions(e.err, opts); ok {
+		return e.redact(formatted)
+	}
+	return e.Error()
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,160 @@
+package yaml_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+)
+
+func TestRedactor(t *testing.T) {
+	src := `
+db:
+  user: admin
+  password: hunter2 # rotated monthly
+api_token: |
+  line1
+  line2
+users:
+  - name: a
+    key: &k k1
+  - name: b
+    key: *k
+nested:
+  deep:
+    cert: [x, y]
+empty_secret:
+`
+	r, err := yaml.NewRedactor([]string{"$.users[*].key", "$..cert"}, yaml.DefaultRedactKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.RedactFile(f)
+	expected := `
+db:
+  user: admin
+  password: "***" # rotated monthly
+api_token: "***"
+users:
+  - name: a
+    key: &k "***"
+  - name: b
+    key: *k
+nested:
+  deep:
+    cert: ["***", "***"]
+empty_secret: null
+`
+	if got := f.String(); "\n"+got != expected {
+		t.Fatalf("unexpected redacted document:\n%s", got)
+	}
+
+	t.Run("default keys", func(t *testing.T) {
+		r, err := yaml.NewRedactor(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parser.ParseBytes([]byte("user: a\nPassword: b\napiKey: c\n"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.RedactFile(f)
+		if got := f.String(); got != "user: a\nPassword: \"***\"\napiKey: \"***\"\n" {
+			t.Fatalf("unexpected redacted document:\n%s", got)
+		}
+	})
+	t.Run("custom keys", func(t *testing.T) {
+		r, err := yaml.NewRedactor(nil, regexp.MustCompile(`^pin$`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parser.ParseBytes([]byte("pin: 1234\npassword: b\n"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.RedactFile(f)
+		if got := f.String(); got != "pin: \"***\"\npassword: b\n" {
+			t.Fatalf("unexpected redacted document:\n%s", got)
+		}
+	})
+	t.Run("invalid path", func(t *testing.T) {
+		if _, err := yaml.NewRedactor([]string{"$.a[x]"}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDecoder_RedactErrors(t *testing.T) {
+	r, err := yaml.NewRedactor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("source", func(t *testing.T) {
+		src := `
+db:
+  user: admin
+  password: hunter2
+  token: |
+    abc
+    def
+`
+		var v struct {
+			DB struct {
+				User     string `yaml:"user"`
+				Password int    `yaml:"password"`
+			} `yaml:"db"`
+		}
+		err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.RedactErrors(r))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		expected := `
+[4:13] cannot unmarshal string into Go struct field .DB of type int
+   2 | db:
+   3 |   user: admin
+>  4 |   password: "***"
+                   ^
+   5 |   token: |
+   6 |     ***
+   7 |     ***`
+		if got := strings.TrimRight(yaml.FormatError(err, false, true), "\n"); "\n"+got != expected {
+			t.Fatalf("unexpected error:\n%s", got)
+		}
+		if strings.Contains(err.Error(), "hunter2") || strings.Contains(err.Error(), "abc") {
+			t.Fatalf("the secret is not redacted:\n%s", err)
+		}
+	})
+	t.Run("message", func(t *testing.T) {
+		var v struct {
+			Token time.Time `yaml:"token,layout=DateOnly"`
+		}
+		err := yaml.UnmarshalWithOptions([]byte("token: s3cr3t\n"), &v, yaml.RedactErrors(r))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if strings.Contains(err.Error(), "s3cr3t") {
+			t.Fatalf("the secret is not redacted:\n%s", err)
+		}
+		if !strings.Contains(err.Error(), `cannot parse "***" as time`) {
+			t.Fatalf("unexpected error:\n%s", err)
+		}
+	})
+	t.Run("decoded value", func(t *testing.T) {
+		var v struct {
+			Password string `yaml:"password"`
+		}
+		if err := yaml.UnmarshalWithOptions([]byte("password: hunter2\n"), &v, yaml.RedactErrors(r)); err != nil {
+			t.Fatal(err)
+		}
+		if v.Password != "hunter2" {
+			t.Fatalf("unexpected password %q", v.Password)
+		}
+	})
+}
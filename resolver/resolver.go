@@ -0,0 +1,122 @@
+// Package resolver provides the table-driven resolution of plain ( not quoted ) scalars to tags,
+// known as the implicit typing of YAML.
+//
+// A Resolver is a list of rules tried in order, and the scalar matching no rule is resolved to !!str.
+// The predefined resolvers follow the schemas of YAML 1.2 ( JSON and Core ) and YAML 1.1,
+// and they can be customized by adding rules.
+//
+//	r := resolver.Core().With(resolver.Rule{Tag: resolver.StrTag, Pattern: regexp.MustCompile(`^0[0-7]+$`)})
+//	yaml.UnmarshalWithOptions(src, &v, yaml.PlainScalarResolver(r.Resolve))
+package resolver
+
+import (
+	"regexp"
+)
+
+const (
+	NullTag      = "!!null"
+	BoolTag      = "!!bool"
+	IntTag       = "!!int"
+	FloatTag     = "!!float"
+	StrTag       = "!!str"
+	TimestampTag = "!!timestamp"
+)
+
+// Rule resolves the scalar to Tag if the scalar is one of Values or matches Pattern.
+type Rule struct {
+	Tag     string
+	Values  []string
+	Pattern *regexp.Regexp
+}
+
+// Match reports whether the rule resolves value.
+func (r Rule) Match(value string) bool {
+	for _, v := range r.Values {
+		if v == value {
+			return true
+		}
+	}
+	return r.Pattern != nil && r.Pattern.MatchString(value)
+}
+
+// Resolver resolves plain scalars to tags by the rules.
+type Resolver struct {
+	rules []Rule
+}
+
+// New creates a Resolver from the rules. The rules are tried in the order.
+func New(rules ...Rule) *Resolver {
+	return &Resolver{rules: append([]Rule{}, rules...)}
+}
+
+// With returns a new Resolver that tries the rules before the rules of r.
+func (r *Resolver) With(rules ...Rule) *Resolver {
+	return New(append(append([]Rule{}, rules...), r.rules...)...)
+}
+
+// Rules returns the copy of the rules of r.
+func (r *Resolver) Rules() []Rule {
+	return append([]Rule{}, r.rules...)
+}
+
+// Resolve returns the tag of the plain scalar value.
+// It returns StrTag if no rule matches, so the second return value is always true.
+// The signature is the same as the resolver of yaml.PlainScalarResolver.
+func (r *Resolver) Resolve(value string) (string, bool) {
+	for _, rule := range r.rules {
+		if rule.Match(value) {
+			return rule.Tag, true
+		}
+	}
+	return StrTag, true
+}
+
+// IsString reports whether the plain scalar value is resolved to StrTag.
+// If not, the string value must be quoted to keep it as a string.
+func (r *Resolver) IsString(value string) bool {
+	tag, _ := r.Resolve(value)
+	return tag == StrTag
+}
+
+// JSON returns the resolver of the JSON schema of YAML 1.2.
+// Only null, true, false and the numbers in the JSON notation are resolved to other than !!str.
+func JSON() *Resolver {
+	return New(
+		Rule{Tag: NullTag, Values: []string{"null"}},
+		Rule{Tag: BoolTag, Values: []string{"true", "false"}},
+		Rule{Tag: IntTag, Pattern: regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)},
+		Rule{Tag: FloatTag, Pattern: regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)},
+	)
+}
+
+// Core returns the resolver of the Core schema of YAML 1.2.
+func Core() *Resolver {
+	return New(
+		Rule{Tag: NullTag, Values: []string{"", "~", "null", "Null", "NULL"}},
+		Rule{Tag: BoolTag, Values: []string{"true", "True", "TRUE", "false", "False", "FALSE"}},
+		Rule{Tag: IntTag, Pattern: regexp.MustCompile(`^([-+]?[0-9]+|0o[0-7]+|0x[0-9a-fA-F]+)$`)},
+		Rule{Tag: FloatTag, Pattern: regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)},
+		Rule{Tag: FloatTag, Pattern: regexp.MustCompile(`^([-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)},
+	)
+}
+
+// YAML11 returns the resolver of the types of YAML 1.1, including the booleans like yes and no,
+// the binary and octal integers like 0b1010 and 0755, the underscores in the numbers and the timestamps.
+func YAML11() *Resolver {
+	return New(
+		Rule{Tag: NullTag, Values: []string{"", "~", "null", "Null", "NULL"}},
+		Rule{Tag: BoolTag, Values: []string{
+			"y", "Y", "yes", "Yes", "YES", "n", "N", "no", "No", "NO",
+			"true", "True", "TRUE", "false", "False", "FALSE",
+			"on", "On", "ON", "off", "Off", "OFF",
+		}},
+		Rule{Tag: IntTag, Pattern: regexp.MustCompile(`^[-+]?(0b[0-1_]+|0[0-7_]+|(0|[1-9][0-9_]*)|0x[0-9a-fA-F_]+)$`)},
+		Rule{Tag: FloatTag, Pattern: regexp.MustCompile(`^[-+]?([0-9][0-9_]*\.[0-9_]*|\.[0-9][0-9_]*)([eE][-+][0-9]+)?$`)},
+		Rule{Tag: FloatTag, Pattern: regexp.MustCompile(`^([-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)},
+		Rule{Tag: TimestampTag, Pattern: regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)},
+		Rule{Tag: TimestampTag, Pattern: regexp.MustCompile(
+			`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt][0-9]{1,2}:[0-9]{1,2}:[0-9]{1,2}(\.[0-9]+)?(Z|[-+][0-9]{2}:[0-9]{2})|` +
+				` [0-9]{1,2}:[0-9]{1,2}:[0-9]{1,2}(\.[0-9]+)?)$`,
+		)},
+	)
+}
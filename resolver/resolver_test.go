@@ -0,0 +1,84 @@
+package resolver_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/goccy/go-yaml/resolver"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		value  string
+		json   string
+		core   string
+		yaml11 string
+	}{
+		{value: "", json: resolver.StrTag, core: resolver.NullTag, yaml11: resolver.NullTag},
+		{value: "~", json: resolver.StrTag, core: resolver.NullTag, yaml11: resolver.NullTag},
+		{value: "null", json: resolver.NullTag, core: resolver.NullTag, yaml11: resolver.NullTag},
+		{value: "true", json: resolver.BoolTag, core: resolver.BoolTag, yaml11: resolver.BoolTag},
+		{value: "True", json: resolver.StrTag, core: resolver.BoolTag, yaml11: resolver.BoolTag},
+		{value: "yes", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.BoolTag},
+		{value: "off", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.BoolTag},
+		{value: "10", json: resolver.IntTag, core: resolver.IntTag, yaml11: resolver.IntTag},
+		{value: "-10", json: resolver.IntTag, core: resolver.IntTag, yaml11: resolver.IntTag},
+		{value: "+10", json: resolver.StrTag, core: resolver.IntTag, yaml11: resolver.IntTag},
+		{value: "0o17", json: resolver.StrTag, core: resolver.IntTag, yaml11: resolver.StrTag},
+		{value: "0755", json: resolver.StrTag, core: resolver.IntTag, yaml11: resolver.IntTag},
+		{value: "0x1F", json: resolver.StrTag, core: resolver.IntTag, yaml11: resolver.IntTag},
+		{value: "0b101", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.IntTag},
+		{value: "1_000", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.IntTag},
+		{value: "1.5", json: resolver.FloatTag, core: resolver.FloatTag, yaml11: resolver.FloatTag},
+		{value: "1e3", json: resolver.FloatTag, core: resolver.FloatTag, yaml11: resolver.StrTag},
+		{value: ".5", json: resolver.StrTag, core: resolver.FloatTag, yaml11: resolver.FloatTag},
+		{value: ".", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.StrTag},
+		{value: "-.inf", json: resolver.StrTag, core: resolver.FloatTag, yaml11: resolver.FloatTag},
+		{value: ".NaN", json: resolver.StrTag, core: resolver.FloatTag, yaml11: resolver.FloatTag},
+		{value: "2001-12-14", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.TimestampTag},
+		{value: "2001-12-14t21:59:43.10Z", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.TimestampTag},
+		{value: "hello", json: resolver.StrTag, core: resolver.StrTag, yaml11: resolver.StrTag},
+	}
+	json, core, yaml11 := resolver.JSON(), resolver.Core(), resolver.YAML11()
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			for _, r := range []struct {
+				name     string
+				resolver *resolver.Resolver
+				expected string
+			}{
+				{name: "json", resolver: json, expected: test.json},
+				{name: "core", resolver: core, expected: test.core},
+				{name: "yaml11", resolver: yaml11, expected: test.yaml11},
+			} {
+				tag, ok := r.resolver.Resolve(test.value)
+				if !ok {
+					t.Fatalf("%s: expected to be resolved", r.name)
+				}
+				if tag != r.expected {
+					t.Fatalf("%s: expected %s but got %s", r.name, r.expected, tag)
+				}
+			}
+		})
+	}
+}
+
+func TestResolver_With(t *testing.T) {
+	core := resolver.Core()
+	r := core.With(resolver.Rule{Tag: resolver.StrTag, Pattern: regexp.MustCompile(`^0[0-7]+$`)})
+	if tag, _ := r.Resolve("0755"); tag != resolver.StrTag {
+		t.Fatalf("expected %s but got %s", resolver.StrTag, tag)
+	}
+	if tag, _ := r.Resolve("755"); tag != resolver.IntTag {
+		t.Fatalf("expected %s but got %s", resolver.IntTag, tag)
+	}
+	if tag, _ := core.Resolve("0755"); tag != resolver.IntTag {
+		t.Fatalf("expected the original resolver not to be modified but got %s", tag)
+	}
+	if len(r.Rules()) != len(core.Rules())+1 {
+		t.Fatalf("unexpected number of rules: %d", len(r.Rules()))
+	}
+	if r.IsString("null") || !r.IsString("hello") {
+		t.Fatal("unexpected result of IsString")
+	}
+}
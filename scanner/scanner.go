@@ -52,6 +52,7 @@ type Scanner struct {
 	startedFlowMapNum      int
 	indentState            IndentState
 	savedPos               *token.Position
+	discardOrigins         bool
 }
 
 func (s *Scanner) pos() *token.Position {
@@ -742,7 +743,7 @@ func (s *Scanner) scanComment(ctx *Context) bool {
 		}
 		value := ctx.source(ctx.idx, ctx.idx+idx)
 		progress := len([]rune(value))
-		ctx.addToken(token.Comment(value, string(ctx.obuf), s.pos()))
+		s.addCommentToken(ctx, value)
 		s.progressColumn(ctx, progress)
 		s.progressLine(ctx)
 		ctx.clear()
@@ -750,7 +751,7 @@ func (s *Scanner) scanComment(ctx *Context) bool {
 	}
 	// document ends with comment.
 	value := string(ctx.src[ctx.idx:])
-	ctx.addToken(token.Comment(value, string(ctx.obuf), s.pos()))
+	s.addCommentToken(ctx, value)
 	progress := len([]rune(value))
 	s.progressColumn(ctx, progress)
 	s.progressLine(ctx)
@@ -888,7 +889,7 @@ func (s *Scanner) scanFlowMapStart(ctx *Context) bool {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf('{')
-	ctx.addToken(token.MappingStart(string(ctx.obuf), s.pos()))
+	ctx.addToken(token.MappingStart(s.indicatorOrigin(ctx), s.pos()))
 	s.startedFlowMapNum++
 	s.progressColumn(ctx, 1)
 	ctx.clear()
@@ -902,7 +903,7 @@ func (s *Scanner) scanFlowMapEnd(ctx *Context) bool {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf('}')
-	ctx.addToken(token.MappingEnd(string(ctx.obuf), s.pos()))
+	ctx.addToken(token.MappingEnd(s.indicatorOrigin(ctx), s.pos()))
 	s.startedFlowMapNum--
 	s.progressColumn(ctx, 1)
 	ctx.clear()
@@ -916,7 +917,7 @@ func (s *Scanner) scanFlowArrayStart(ctx *Context) bool {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf('[')
-	ctx.addToken(token.SequenceStart(string(ctx.obuf), s.pos()))
+	ctx.addToken(token.SequenceStart(s.indicatorOrigin(ctx), s.pos()))
 	s.startedFlowSequenceNum++
 	s.progressColumn(ctx, 1)
 	ctx.clear()
@@ -930,7 +931,7 @@ func (s *Scanner) scanFlowArrayEnd(ctx *Context) bool {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf(']')
-	ctx.addToken(token.SequenceEnd(string(ctx.obuf), s.pos()))
+	ctx.addToken(token.SequenceEnd(s.indicatorOrigin(ctx), s.pos()))
 	s.startedFlowSequenceNum--
 	s.progressColumn(ctx, 1)
 	ctx.clear()
@@ -944,7 +945,7 @@ func (s *Scanner) scanFlowEntry(ctx *Context, c rune) bool {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf(c)
-	ctx.addToken(token.CollectEntry(string(ctx.obuf), s.pos()))
+	ctx.addToken(token.CollectEntry(s.indicatorOrigin(ctx), s.pos()))
 	s.progressColumn(ctx, 1)
 	ctx.clear()
 	return true
@@ -963,7 +964,7 @@ func (s *Scanner) scanMapDelim(ctx *Context) (bool, error) {
 		return false, nil
 	}
 
-	if strings.HasPrefix(strings.TrimPrefix(string(ctx.obuf), " "), "\t") && !strings.HasPrefix(string(ctx.buf), "\t") {
+	if !s.isFlowMode() && strings.HasPrefix(strings.TrimPrefix(string(ctx.obuf), " "), "\t") && !strings.HasPrefix(string(ctx.buf), "\t") {
 		invalidTk := token.Invalid("tab character cannot use as a map key directly", string(ctx.obuf), s.pos())
 		s.progressColumn(ctx, 1)
 		return false, ErrInvalidToken(invalidTk)
@@ -1075,7 +1076,7 @@ func (s *Scanner) scanSequence(ctx *Context) (bool, error) {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf('-')
-	tk := token.SequenceEntry(string(ctx.obuf), s.pos())
+	tk := token.SequenceEntry(s.indicatorOrigin(ctx), s.pos())
 	s.lastDelimColumn = tk.Position.Column
 	ctx.addToken(tk)
 	s.progressColumn(ctx, 1)
@@ -1220,7 +1221,7 @@ func (s *Scanner) scanAnchor(ctx *Context) bool {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf('&')
-	ctx.addToken(token.Anchor(string(ctx.obuf), s.pos()))
+	ctx.addToken(token.Anchor(s.indicatorOrigin(ctx), s.pos()))
 	s.progressColumn(ctx, 1)
 	s.isAnchor = true
 	ctx.clear()
@@ -1234,7 +1235,7 @@ func (s *Scanner) scanAlias(ctx *Context) bool {
 
 	s.addBufferedTokenIfExists(ctx)
 	ctx.addOriginBuf('*')
-	ctx.addToken(token.Alias(string(ctx.obuf), s.pos()))
+	ctx.addToken(token.Alias(s.indicatorOrigin(ctx), s.pos()))
 	s.progressColumn(ctx, 1)
 	s.isAlias = true
 	ctx.clear()
@@ -1429,13 +1430,13 @@ func (s *Scanner) scan(ctx *Context) error {
 			if ctx.existsBuffer() && s.lastDelimColumn == 0 {
 				// tab indent for plain text (yaml-test-suite's spec-example-7-12-plain-lines).
 				s.indentNum++
-				ctx.addOriginBuf(c)
+				s.addTabToBuf(ctx, c)
 				s.progressColumn(ctx, 1)
 				continue
 			}
 			if s.lastDelimColumn < s.column {
 				s.indentNum++
-				ctx.addOriginBuf(c)
+				s.addTabToBuf(ctx, c)
 				s.progressColumn(ctx, 1)
 				continue
 			}
@@ -1451,6 +1452,15 @@ func (s *Scanner) scan(ctx *Context) error {
 	return nil
 }
 
+// addTabToBuf adds the tab to the origin, and to the text of the plain scalar unless it's the indent,
+// so the tab is kept between the words and the column of the scalar is counted with the tab after it.
+func (s *Scanner) addTabToBuf(ctx *Context, c rune) {
+	if !s.isFirstCharAtLine {
+		ctx.addBuf(c)
+	}
+	ctx.addOriginBuf(c)
+}
+
 // Init prepares the scanner s to tokenize the text src by setting the scanner at the beginning of src.
 func (s *Scanner) Init(text string) {
 	src := []rune(text)
@@ -1460,10 +1470,37 @@ func (s *Scanner) Init(text string) {
 	s.line = 1
 	s.column = 1
 	s.offset = 1
+	if s.sourceSize != 0 && src[0] == '\ufeff' {
+		// the byte order mark is skipped, but it's counted by the offset to keep the offsets of the source.
+		s.sourcePos++
+		s.offset++
+	}
 	s.isFirstCharAtLine = true
 	s.clearState()
 }
 
+// DiscardOrigins causes the scanner to drop the comments and to leave the Origins of the indicator tokens empty,
+// like '-', '[' and '&'. The tokens can be parsed and decoded, but they can't restore the source text.
+// It reduces the allocations when the source is only decoded.
+func (s *Scanner) DiscardOrigins() {
+	s.discardOrigins = true
+}
+
+// indicatorOrigin returns the origin of the indicator token, which is empty if DiscardOrigins is specified.
+func (s *Scanner) indicatorOrigin(ctx *Context) string {
+	if s.discardOrigins {
+		return ""
+	}
+	return string(ctx.obuf)
+}
+
+func (s *Scanner) addCommentToken(ctx *Context, value string) {
+	if s.discardOrigins {
+		return
+	}
+	ctx.addToken(token.Comment(value, string(ctx.obuf), s.pos()))
+}
+
 func (s *Scanner) clearState() {
 	s.prevLineIndentNum = 0
 	s.lastDelimColumn = 0
@@ -3,7 +3,10 @@ package yaml
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+
+	"github.com/goccy/go-yaml/token"
 )
 
 const (
@@ -17,11 +20,60 @@ type StructField struct {
 	RenderName   string
 	AnchorName   string
 	AliasName    string
+	Comment      string
 	IsAutoAnchor bool
 	IsAutoAlias  bool
 	IsOmitEmpty  bool
-	IsFlow       bool
-	IsInline     bool
+	// IsKeepExisting is true if the field has the keepexisting option,
+	// so the null value in the document doesn't overwrite the value of the field.
+	IsKeepExisting bool
+	IsFlow         bool
+	IsInline       bool
+	// IsLiteral and IsFolded are true if the field has the literal or folded option,
+	// so the string value is written as the literal or folded block scalar.
+	IsLiteral bool
+	IsFolded  bool
+	// IsKeep is true if the field has the keep option, so the block scalar uses the keep chomping indicator `+`
+	// if the string ends with the line break.
+	IsKeep bool
+	// BlockIndent is the indentation indicator of the block scalar specified by the indent option ( e.g. `indent=4` ).
+	// The indicator is written only if it's needed when BlockIndent is 0.
+	BlockIndent int
+	// DefaultValue is the YAML text decoded into the field when the key is absent.
+	// It's used only if HasDefault is true, because the empty text is also the default value.
+	DefaultValue string
+	HasDefault   bool
+	// IsJSON is true if the field follows the rules of encoding/json by HonorJSONTagOptions,
+	// because the field has no yaml tag.
+	IsJSON bool
+	// IsString is true if the json tag has the string option to encode the value as the JSON string.
+	// It's used only if IsJSON is true.
+	IsString bool
+	// Order is the position of the field in the encoded mapping specified by the order option ( e.g. `order=1` ).
+	// The fields having the order option are written before the other fields in ascending order.
+	// It's used only if HasOrder is true.
+	Order    int
+	HasOrder bool
+	// TimeLayout is the layout of the time specified by the layout option ( e.g. `layout=2006-01-02 15:04` ),
+	// which is used instead of the default timestamp formats to decode and encode the field.
+	TimeLayout string
+	// IsRune is true if the field has the rune option, so the rune ( int32 ) value is encoded
+	// as the single-character string and decoded from it, and so is []rune.
+	IsRune bool
+	// CollectionTag is the tag of the collection specified by the set, omap or pairs option ( e.g. `!!set` ),
+	// which is written with the map or the slice encoded in the form of the tag.
+	CollectionTag string
+
+	// invalidOption is the option which can't be parsed, reported by structFieldMap.
+	invalidOption string
+	// protoJSONName is the lowerCamelCase name of the field generated by protoc-gen-go, used by UseProtoJSON.
+	protoJSONName string
+}
+
+// isBlockScalar returns whether the string value of the field is written as the block scalar.
+// The keep and indent options imply the literal style unless the folded option is specified.
+func (f *StructField) isBlockScalar() bool {
+	return f.IsLiteral || f.IsFolded || f.IsKeep || f.BlockIndent > 0
 }
 
 func getTag(field reflect.StructField) string {
@@ -35,9 +87,14 @@ func getTag(field reflect.StructField) string {
 	return tag
 }
 
-func structField(field reflect.StructField) *StructField {
+func structField(field reflect.StructField, honorJSONTag bool) *StructField {
 	tag := getTag(field)
+	isJSON := honorJSONTag && field.Tag.Get(StructTagName) == ""
 	fieldName := strings.ToLower(field.Name)
+	if isJSON {
+		// encoding/json uses the field name as it is.
+		fieldName = field.Name
+	}
 	options := strings.Split(tag, ",")
 	if len(options) > 0 {
 		if options[0] != "" {
@@ -45,18 +102,79 @@ func structField(field reflect.StructField) *StructField {
 		}
 	}
 	structField := &StructField{
-		FieldName:  field.Name,
-		RenderName: fieldName,
+		FieldName:     field.Name,
+		RenderName:    fieldName,
+		IsJSON:        isJSON,
+		protoJSONName: protoJSONFieldName(field),
 	}
 	if len(options) > 1 {
-		for _, opt := range options[1:] {
+		for idx := 1; idx < len(options); idx++ {
+			opt := options[idx]
+			if strings.HasPrefix(opt, "comment=") {
+				// comment text may contain commas, so it takes the rest of the tag.
+				structField.Comment = strings.TrimPrefix(strings.Join(options[idx:], ","), "comment=")
+				break
+			}
+			if strings.HasPrefix(opt, "default=") {
+				// the flow style default value like [1, 2] may contain commas, so it takes the options until the brackets are closed.
+				end := defaultValueEnd(options, idx)
+				structField.DefaultValue = strings.TrimPrefix(strings.Join(options[idx:end+1], ","), "default=")
+				structField.HasDefault = true
+				idx = end
+				continue
+			}
 			switch {
 			case opt == "omitempty":
 				structField.IsOmitEmpty = true
+			case opt == "keepexisting":
+				structField.IsKeepExisting = true
 			case opt == "flow":
 				structField.IsFlow = true
 			case opt == "inline":
 				structField.IsInline = true
+			case opt == "string":
+				structField.IsString = true
+			case opt == "literal":
+				structField.IsLiteral = true
+			case opt == "folded":
+				structField.IsFolded = true
+			case opt == "keep":
+				structField.IsKeep = true
+			case strings.HasPrefix(opt, "indent="):
+				indent, err := strconv.Atoi(strings.TrimPrefix(opt, "indent="))
+				if err != nil || indent < 1 || indent > 9 {
+					structField.invalidOption = opt
+					continue
+				}
+				structField.BlockIndent = indent
+			case strings.HasPrefix(opt, "order="):
+				order, err := strconv.Atoi(strings.TrimPrefix(opt, "order="))
+				if err != nil {
+					structField.invalidOption = opt
+					continue
+				}
+				structField.Order = order
+				structField.HasOrder = true
+			case strings.HasPrefix(opt, "layout="):
+				layout := strings.TrimPrefix(opt, "layout=")
+				if layout == "" || !isTimeType(field.Type) {
+					structField.invalidOption = opt
+					continue
+				}
+				structField.TimeLayout = timeLayout(layout)
+			case opt == "rune":
+				if !isRuneType(field.Type) {
+					structField.invalidOption = opt
+					continue
+				}
+				structField.IsRune = true
+			case opt == "set" || opt == "omap" || opt == "pairs":
+				tag := "!!" + opt
+				if !isCollectionTagType(token.ReservedTagKeyword(tag), field.Type) {
+					structField.invalidOption = opt
+					continue
+				}
+				structField.CollectionTag = tag
 			case strings.HasPrefix(opt, "anchor"):
 				anchor := strings.Split(opt, "=")
 				if len(anchor) > 1 {
@@ -75,9 +193,50 @@ func structField(field reflect.StructField) *StructField {
 			}
 		}
 	}
+	if isJSON && field.Anonymous && (len(options) == 0 || options[0] == "") {
+		// encoding/json embeds the fields of the anonymous struct field without the name.
+		typ := field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() == reflect.Struct {
+			structField.IsInline = true
+		}
+	}
 	return structField
 }
 
+// isRuneType returns whether typ is rune, []rune or the pointer to them.
+// Since rune is an alias for int32, the int32 types are also accepted.
+func isRuneType(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Int32
+}
+
+// defaultValueEnd returns the index of the last option of the default value starting at options[start].
+func defaultValueEnd(options []string, start int) int {
+	depth := 0
+	for idx := start; idx < len(options); idx++ {
+		for _, c := range options[idx] {
+			switch c {
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+			}
+		}
+		if depth <= 0 {
+			return idx
+		}
+	}
+	return len(options) - 1
+}
+
 func isIgnoredStructField(field reflect.StructField) bool {
 	if field.PkgPath != "" && !field.Anonymous {
 		// private field
@@ -86,6 +245,21 @@ func isIgnoredStructField(field reflect.StructField) bool {
 	return getTag(field) == "-"
 }
 
+// isJSONStringOptionType reports whether the string option of the json tag is applied to the type like encoding/json.
+func isJSONStringOptionType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
 type StructFieldMap map[string]*StructField
 
 func (m StructFieldMap) isIncludedRenderName(name string) bool {
@@ -106,7 +280,9 @@ func (m StructFieldMap) hasMergeProperty() bool {
 	return false
 }
 
-func structFieldMap(structType reflect.Type) (StructFieldMap, error) {
+// structFieldMap creates the StructFieldMap of structType.
+// If honorJSONTag is true, the fields without yaml tag follow the rules of encoding/json.
+func structFieldMap(structType reflect.Type, honorJSONTag bool) (StructFieldMap, error) {
 	structFieldMap := StructFieldMap{}
 	renderNameMap := map[string]struct{}{}
 	for i := 0; i < structType.NumField(); i++ {
@@ -114,7 +290,10 @@ func structFieldMap(structType reflect.Type) (StructFieldMap, error) {
 		if isIgnoredStructField(field) {
 			continue
 		}
-		structField := structField(field)
+		structField := structField(field, honorJSONTag)
+		if structField.invalidOption != "" {
+			return nil, fmt.Errorf("invalid option %q of struct field %s", structField.invalidOption, field.Name)
+		}
 		if _, exists := renderNameMap[structField.RenderName]; exists {
 			return nil, fmt.Errorf("duplicated struct field name %s", structField.RenderName)
 		}
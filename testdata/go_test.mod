@@ -5,6 +5,7 @@ go 1.21.0
 require (
 	github.com/go-playground/validator/v10 v10.22.1
 	github.com/google/go-cmp v0.6.0
+	google.golang.org/protobuf v1.36.5
 )
 
 require (
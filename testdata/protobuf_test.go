@@ -0,0 +1,155 @@
+package yaml_test
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestUseProtoJSON(t *testing.T) {
+	src := `
+name: Config
+fields:
+  - name: timeout
+    kind: TYPE_MESSAGE
+    cardinality: CARDINALITY_OPTIONAL
+    number: 1
+    typeUrl: type.googleapis.com/google.protobuf.Duration
+    jsonName: timeout
+  - name: retry_count
+    kind: 5
+    number: "2"
+    oneof_index: 1
+    defaultValue: "3"
+syntax: SYNTAX_PROTO3
+sourceContext:
+  fileName: config.proto
+`
+	var got typepb.Type
+	if err := yaml.UnmarshalWithOptions([]byte(src), &got, yaml.UseProtoJSON()); err != nil {
+		t.Fatal(err)
+	}
+	jsonBytes, err := yaml.YAMLToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected typepb.Type
+	if err := protojson.Unmarshal(jsonBytes, &expected); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&got, &expected) {
+		t.Fatalf("expected %v but got %v", &expected, &got)
+	}
+	if got.Fields[1].Kind != typepb.Field_TYPE_INT32 {
+		t.Fatalf("unexpected kind %v", got.Fields[1].Kind)
+	}
+}
+
+func TestUseProtoJSON_WellKnownTypes(t *testing.T) {
+	var v struct {
+		Timeout  *durationpb.Duration    `yaml:"timeout"`
+		Interval *durationpb.Duration    `yaml:"interval"`
+		Created  *timestamppb.Timestamp  `yaml:"created"`
+		Name     *wrapperspb.StringValue `yaml:"name"`
+		Count    *wrapperspb.Int64Value  `yaml:"count"`
+		Mask     *fieldmaskpb.FieldMask  `yaml:"mask"`
+		Meta     *structpb.Struct        `yaml:"meta"`
+		Values   *structpb.ListValue     `yaml:"values"`
+		Null     *durationpb.Duration    `yaml:"none"`
+	}
+	src := `
+timeout: -1.5s
+interval: 1m30s
+created: 2024-03-05T14:30:00.5Z
+name: go-yaml
+count: "9007199254740993"
+mask: fields.typeUrl,name
+meta:
+  a: [1, true, null]
+  b: {c: d}
+values: [1, x]
+none: null
+`
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.UseProtoJSON()); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := structpb.NewStruct(map[string]interface{}{
+		"a": []interface{}{1, true, nil},
+		"b": map[string]interface{}{"c": "d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := structpb.NewList([]interface{}{1, "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		got      proto.Message
+		expected proto.Message
+	}{
+		{"timeout", v.Timeout, &durationpb.Duration{Seconds: -1, Nanos: -500000000}},
+		{"interval", v.Interval, &durationpb.Duration{Seconds: 90}},
+		{"created", v.Created, &timestamppb.Timestamp{Seconds: 1709649000, Nanos: 500000000}},
+		{"name", v.Name, wrapperspb.String("go-yaml")},
+		{"count", v.Count, wrapperspb.Int64(9007199254740993)},
+		{"mask", v.Mask, &fieldmaskpb.FieldMask{Paths: []string{"fields.type_url", "name"}}},
+		{"meta", v.Meta, meta},
+		{"values", v.Values, values},
+	}
+	for _, test := range tests {
+		if !proto.Equal(test.got, test.expected) {
+			t.Errorf("%s: expected %v but got %v", test.name, test.expected, test.got)
+		}
+	}
+	if v.Null != nil {
+		t.Errorf("unexpected value %v", v.Null)
+	}
+}
+
+func TestUseProtoJSON_Error(t *testing.T) {
+	tests := []struct {
+		src      string
+		v        interface{}
+		expected string
+	}{
+		{
+			src:      "kind: TYPE_NOTHING\n",
+			v:        &typepb.Field{},
+			expected: `unknown value "TYPE_NOTHING" of enum typepb.Field_Kind`,
+		},
+		{
+			src: "timeout: 1.5\n",
+			v: &struct {
+				Timeout *durationpb.Duration `yaml:"timeout"`
+			}{},
+			expected: "cannot unmarshal float64 into Go struct field .Timeout of type durationpb.Duration",
+		},
+		{
+			src: "timeout: 1x\n",
+			v: &struct {
+				Timeout *durationpb.Duration `yaml:"timeout"`
+			}{},
+			expected: `invalid duration "1x"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			err := yaml.UnmarshalWithOptions([]byte(test.src), test.v, yaml.UseProtoJSON())
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("expected error containing %q but got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package yaml_test
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -61,6 +63,33 @@ func TestStructValidator(t *testing.T) {
 				Age  int    `yaml:"age" validate:"gte=0,lt=120"`
 			}{},
 		},
+		{
+			TestName: "Test Missing Required Field In Multiline Element",
+			YAMLContent: `---
+users:
+  - name: john
+    age: 20
+  - age: 10
+    email: ken@example.com
+  - name: tom
+    age: 30`,
+			ExpectedErr: `[5:3] Key: 'Name' Error:Field validation for 'Name' failed on the 'required' tag
+   2 | users:
+   3 |   - name: john
+   4 |     age: 20
+>  5 |   - age: 10
+         ^
+   6 |     email: ken@example.com
+   7 |   - name: tom
+   8 |     age: 30`,
+			Instance: &struct {
+				Users []struct {
+					Name  string `yaml:"name" validate:"required"`
+					Age   int    `yaml:"age"`
+					Email string `yaml:"email"`
+				} `yaml:"users"`
+			}{},
+		},
 		{
 			TestName: "Test Nested Validation Missing Internal Required",
 			YAMLContent: `---
@@ -197,3 +226,57 @@ lt10: 20
 		})
 	}
 }
+
+func TestStructValidator_SequenceElement(t *testing.T) {
+	type User struct {
+		Name string `yaml:"name" validate:"required"`
+		Age  int    `yaml:"age"`
+	}
+	tests := []struct {
+		name     string
+		src      string
+		index    int
+		position string
+		message  string
+	}{
+		{
+			name: "block",
+			src: `users:
+  - name: john
+  - age: 10
+    name: ""
+`,
+			index:    1,
+			position: "[3:5]",
+			message:  "[4:11] Key: 'User.Name'",
+		},
+		{
+			name: "flow",
+			src: `users:
+  - name: john
+  - {age: 10}
+`,
+			index:    1,
+			position: "[3:5]",
+			message:  "[3:3] Key: 'User.Name'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v struct {
+				Users []User `yaml:"users"`
+			}
+			err := yaml.UnmarshalWithOptions([]byte(test.src), &v, yaml.Validator(validator.New()))
+			var se *yaml.SequenceElementError
+			if !errors.As(err, &se) {
+				t.Fatalf("expected SequenceElementError but got %v", err)
+			}
+			if got := fmt.Sprintf("[%d:%d]", se.Token.Position.Line, se.Token.Position.Column); se.Index != test.index || got != test.position {
+				t.Fatalf("expected the element %d at %s but got %d at %s", test.index, test.position, se.Index, got)
+			}
+			if !strings.HasPrefix(err.Error(), test.message) {
+				t.Fatalf("unexpected message: %v", err)
+			}
+		})
+	}
+}
@@ -951,6 +951,45 @@ hoge:
 				{"$.hoge.moga", []*yaml.Comment{yaml.LineComment(" moga line comment"), yaml.FootComment(" moga foot comment")}},
 			},
 		},
+		{
+			name: "sequence blank lines",
+			yml: `
+foo:
+  - a
+
+  # b head comment
+  - b
+  - c
+
+  - d
+`,
+			expected: []struct {
+				path     string
+				comments []*yaml.Comment
+			}{
+				{"$.foo[1]", []*yaml.Comment{yaml.HeadComment(" b head comment"), yaml.BlankLine()}},
+				{"$.foo[3]", []*yaml.Comment{yaml.BlankLine()}},
+			},
+		},
+		{
+			name: "document comment",
+			yml: `
+# license
+%YAML 1.2
+# document head comment
+---
+foo: aaa # foo comment
+...
+# document foot comment
+`,
+			expected: []struct {
+				path     string
+				comments []*yaml.Comment
+			}{
+				{"$", []*yaml.Comment{yaml.HeadComment(" license", " document head comment"), yaml.FootComment(" document foot comment")}},
+				{"$.foo", []*yaml.Comment{yaml.LineComment(" foo comment")}},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -1042,6 +1081,94 @@ a: 1 # line
 			source: `"a": b # b comment`,
 			expect: `a: b # b comment`,
 		},
+		{
+			name: "sequence blank lines",
+			source: `
+allow:
+# office
+- 10.0.0.1
+- 10.0.0.2
+
+# vpn
+- 10.1.0.1
+
+- 10.2.0.1
+`,
+			encodeOptions: []yaml.EncodeOption{yaml.PreserveSequenceBlankLines()},
+		},
+		{
+			name: "sequence blank lines after multi-line values",
+			source: `
+- a: 1
+  b: 2
+- |
+  c
+- "d
+  e"
+
+- - f
+  - g
+
+- h
+`,
+			expect: `
+- a: 1
+  b: 2
+- |
+  c
+- d e
+
+- - f
+  - g
+
+- h
+`,
+			encodeOptions: []yaml.EncodeOption{yaml.PreserveSequenceBlankLines()},
+		},
+		{
+			name: "sequence blank lines are not written by default",
+			source: `
+- a
+
+- b
+`,
+			expect: `
+- a
+- b
+`,
+		},
+		{
+			name: "document head comment",
+			source: `
+# license
+---
+a: 1 # line
+`,
+		},
+		{
+			name: "document foot comment",
+			source: `
+a: 1
+...
+# foot
+`,
+		},
+		{
+			name: "directive comments",
+			source: `
+# license
+%YAML 1.2
+# head
+---
+a: 1
+`,
+			expect: `
+# license
+# head
+---
+a: 1
+`,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -1068,6 +1195,60 @@ a: 1 # line
 	}
 }
 
+func TestCommentMapUtilities(t *testing.T) {
+	sources := []string{
+		"# head a\na: 1 # line a\nab: 2\n",
+		"a:\n  # head b\n  b: 1\n  c: 2 # line c\n",
+	}
+	maps := make([]yaml.CommentMap, len(sources))
+	errs := make(chan error, len(sources))
+	for i, src := range sources {
+		maps[i] = yaml.CommentMap{}
+		go func(i int, src string) {
+			var v any
+			errs <- yaml.UnmarshalWithOptions([]byte(src), &v, yaml.CommentToMap(maps[i]))
+		}(i, src)
+	}
+	for range sources {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merged := maps[0].Clone()
+	merged.Merge(maps[1])
+	expected := yaml.CommentMap{
+		"$.a":   []*yaml.Comment{yaml.HeadComment(" head a"), yaml.LineComment(" line a")},
+		"$.a.b": []*yaml.Comment{yaml.HeadComment(" head b")},
+		"$.a.c": []*yaml.Comment{yaml.LineComment(" line c")},
+	}
+	if diff := cmp.Diff(expected, merged); diff != "" {
+		t.Fatalf("unexpected merged map (-want +got):\n%s", diff)
+	}
+	if len(maps[0]) != 1 {
+		t.Fatalf("expected the original map not to be modified: %v", maps[0])
+	}
+
+	override := yaml.CommentMap{"$.a": []*yaml.Comment{yaml.LineComment(" new line a")}}
+	merged.Merge(override)
+	override["$.a"][0].Texts[0] = " modified"
+	expectedA := []*yaml.Comment{yaml.HeadComment(" head a"), yaml.LineComment(" new line a")}
+	if diff := cmp.Diff(expectedA, merged["$.a"]); diff != "" {
+		t.Fatalf("unexpected comments (-want +got):\n%s", diff)
+	}
+
+	filtered := merged.Filter("$.a.b")
+	if diff := cmp.Diff(yaml.CommentMap{"$.a.b": expected["$.a.b"]}, filtered); diff != "" {
+		t.Fatalf("unexpected filtered map (-want +got):\n%s", diff)
+	}
+	if got := merged.Filter("$.a"); len(got) != 3 {
+		t.Fatalf("unexpected filtered map: %v", got)
+	}
+	if got := merged.Filter("$.ab"); len(got) != 0 {
+		t.Fatalf("unexpected filtered map: %v", got)
+	}
+}
+
 func TestRegisterCustomMarshaler(t *testing.T) {
 	type T struct {
 		Foo []byte `yaml:"foo"`
@@ -0,0 +1,96 @@
+package yaml
+
+import (
+	"encoding"
+	"reflect"
+	"time"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// namedTimeLayouts is the layouts of the time package which can be specified by the name in the layout option,
+// because some of them include commas which separate the options.
+var namedTimeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// timeLayout returns the layout specified by the layout option, which is the layout string or the name of the layout of the time package.
+func timeLayout(layout string) string {
+	if named, exists := namedTimeLayouts[layout]; exists {
+		return named
+	}
+	return layout
+}
+
+// isTimeStructType returns whether typ is time.Time, the type defined by time.Time like `type Date time.Time`
+// or the struct having only the embedded time.Time like `type Timestamp struct{ time.Time }`.
+// The type defined by time.Time having its own MarshalText or UnmarshalText is encoded and decoded by them instead.
+func isTimeStructType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	if typ == timeType {
+		return true
+	}
+	if typ.ConvertibleTo(timeType) {
+		ptrType := reflect.PointerTo(typ)
+		return !ptrType.Implements(textMarshalerType) && !ptrType.Implements(textUnmarshalerType)
+	}
+	return typ.NumField() == 1 && typ.Field(0).Anonymous && typ.Field(0).Type == timeType
+}
+
+// isTimeType returns whether the layout option can be specified for the field of typ.
+func isTimeType(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return isTimeStructType(typ)
+}
+
+// timeValue returns v or the embedded time.Time field of v, which is convertible to time.Time.
+func timeValue(v reflect.Value) (reflect.Value, bool) {
+	typ := v.Type()
+	if !isTimeStructType(typ) {
+		return reflect.Value{}, false
+	}
+	if typ.ConvertibleTo(timeType) {
+		return v, true
+	}
+	return v.Field(0), true
+}
+
+// toTime returns the time.Time value of v dereferencing the pointers.
+func toTime(v reflect.Value) (time.Time, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return time.Time{}, false
+		}
+		v = v.Elem()
+	}
+	tv, ok := timeValue(v)
+	if !ok {
+		return time.Time{}, false
+	}
+	return tv.Convert(timeType).Interface().(time.Time), true
+}
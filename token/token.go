@@ -399,6 +399,8 @@ const (
 	BinaryTag ReservedTagKeyword = "!!binary"
 	// OrderedMapTag `!!omap` tag
 	OrderedMapTag ReservedTagKeyword = "!!omap"
+	// PairsTag `!!pairs` tag
+	PairsTag ReservedTagKeyword = "!!pairs"
 	// SetTag `!!set` tag
 	SetTag ReservedTagKeyword = "!!set"
 	// TimestampTag `!!timestamp` tag
@@ -492,6 +494,16 @@ var (
 				Position:      pos,
 			}
 		},
+		PairsTag: func(value, org string, pos *Position) *Token {
+			return &Token{
+				Type:          TagType,
+				CharacterType: CharacterTypeIndicator,
+				Indicator:     NodePropertyIndicator,
+				Value:         value,
+				Origin:        org,
+				Position:      pos,
+			}
+		},
 		SetTag: func(value, org string, pos *Position) *Token {
 			return &Token{
 				Type:          TagType,
@@ -758,6 +770,26 @@ type Position struct {
 	Offset      int
 	IndentNum   int
 	IndentLevel int
+	// EndLine, EndColumn and EndOffset are the position just after the last character of the token,
+	// excluding the white spaces and the line breaks around it, so EndOffset - Offset is the length of the token.
+	// The multi-line tokens, like the block scalars and the quoted strings, end at the line of the last character.
+	// They are set by the lexer, and zero for the tokens created by the other ways.
+	EndLine   int
+	EndColumn int
+	EndOffset int
+	// ByteOffset is the 0-based byte offset of the token in the source, unlike Offset counting the characters from 1.
+	// DisplayColumn is the column in the terminal, which counts a tab as the columns up to the next tab stop
+	// by the tab width of the lexer. They are set by the lexer, and zero for the tokens created by the other ways.
+	ByteOffset    int
+	DisplayColumn int
+}
+
+// Length returns the number of the characters of the token in the source.
+func (p *Position) Length() int {
+	if p.EndOffset == 0 {
+		return 0
+	}
+	return p.EndOffset - p.Offset
 }
 
 // String position to text
@@ -864,6 +896,38 @@ func (t *Tokens) Add(tks ...*Token) {
 	}
 }
 
+// Source returns the source text by concatenating the Origins of the tokens.
+// The Origins of the tokens created by lexer.Tokenize keep all the text of the source,
+// so Source returns the tokenized source as-is.
+func (t Tokens) Source() string {
+	var b strings.Builder
+	for _, tk := range t {
+		b.WriteString(tk.Origin)
+	}
+	return b.String()
+}
+
+// Splice returns the tokens replacing t[start:end] with tks, like editing the source text by the tokens.
+// The tokens are cloned and linked by Prev and Next in the returned order, so t is not modified.
+// The positions are not updated. To get them, tokenize the Source of the returned tokens again.
+func (t Tokens) Splice(start, end int, tks ...*Token) Tokens {
+	spliced := make(Tokens, 0, len(t)-(end-start)+len(tks))
+	for _, tk := range t[:start] {
+		spliced.add(tk.Clone())
+	}
+	for _, tk := range tks {
+		spliced.add(tk.Clone())
+	}
+	for _, tk := range t[end:] {
+		spliced.add(tk.Clone())
+	}
+	if len(spliced) != 0 {
+		spliced[0].Prev = nil
+		spliced[len(spliced)-1].Next = nil
+	}
+	return spliced
+}
+
 // Dump dump all token structures for debugging
 func (t Tokens) Dump() {
 	for _, tk := range t {
@@ -151,3 +151,33 @@ func TestIsNeedQuoted(t *testing.T) {
 		}
 	}
 }
+
+func TestTokensSplice(t *testing.T) {
+	pos := &token.Position{}
+	var tokens token.Tokens
+	tokens.Add(
+		token.New("name", "name", pos),
+		token.MappingValue(pos),
+		token.New("v1", " v1 ", pos),
+		token.Comment(" version", "# version\n", pos),
+	)
+	if got := tokens.Source(); got != "name: v1 # version\n" {
+		t.Fatalf("unexpected source: %q", got)
+	}
+	spliced := tokens.Splice(2, 3, token.New("v2", " v2 ", pos))
+	if got := spliced.Source(); got != "name: v2 # version\n" {
+		t.Fatalf("unexpected source: %q", got)
+	}
+	if got := tokens.Source(); got != "name: v1 # version\n" {
+		t.Fatalf("the original tokens are modified: %q", got)
+	}
+	if spliced[1].Next != spliced[2] || spliced[3].Prev != spliced[2] {
+		t.Fatal("the spliced tokens are not linked")
+	}
+	if spliced[0].Prev != nil || spliced[3].Next != nil {
+		t.Fatal("the spliced tokens are linked to the original tokens")
+	}
+	if got := tokens.Splice(0, 2).Source(); got != " v1 # version\n" {
+		t.Fatalf("unexpected source: %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+package yaml
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// Tracer receives the events of the decode pipeline set by WithTrace to find the slow or hot paths of the large documents.
+// TokenizeStart and TokenizeEnd are called around the tokenization of the source of size bytes, and ParseStart and ParseEnd
+// around the parsing of the tokens. NodeDecoded is called after the node at path is decoded into the value of typ,
+// so it's called for the descendants before the ancestors and the duration includes the descendants.
+// The node decoded into interface{}, or into []string, []interface{}, map[string]string and map[string]interface{}
+// which are decoded without reflection for each element, is reported as a whole.
+// The methods are called from the goroutine decoding the document.
+// SlogTracer writes the events with log/slog, except in the build with the yaml_lean tag.
+type Tracer interface {
+	TokenizeStart(size int)
+	TokenizeEnd(tokens int, duration time.Duration)
+	ParseStart()
+	ParseEnd(duration time.Duration, err error)
+	NodeDecoded(path string, typ reflect.Type, duration time.Duration)
+}
+
+// traceNodeDecoded reports the node decoded from start to the tracer.
+func (d *Decoder) traceNodeDecoded(src ast.Node, typ reflect.Type, start time.Time) {
+	var path string
+	if src != nil {
+		path = src.GetPath()
+	}
+	d.tracer.NodeDecoded(path, typ, time.Since(start))
+}
@@ -0,0 +1,56 @@
+//go:build !yaml_lean
+
+package yaml
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// SlogTracer is the Tracer writing the events to Logger, which is slog.Default() if it's nil.
+// The end events are written with the durations at Level, and the start events are not written.
+// NodeDecoded is written only if the duration is at least MinNodeDuration to keep the logs of the large documents small.
+type SlogTracer struct {
+	Logger          *slog.Logger
+	Level           slog.Level
+	MinNodeDuration time.Duration
+}
+
+func (t *SlogTracer) logger() *slog.Logger {
+	if t.Logger == nil {
+		return slog.Default()
+	}
+	return t.Logger
+}
+
+func (t *SlogTracer) TokenizeStart(size int) {}
+
+func (t *SlogTracer) TokenizeEnd(tokens int, duration time.Duration) {
+	t.logger().LogAttrs(context.Background(), t.Level, "yaml: tokenized",
+		slog.Int("tokens", tokens),
+		slog.Duration("duration", duration),
+	)
+}
+
+func (t *SlogTracer) ParseStart() {}
+
+func (t *SlogTracer) ParseEnd(duration time.Duration, err error) {
+	attrs := []slog.Attr{slog.Duration("duration", duration)}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	t.logger().LogAttrs(context.Background(), t.Level, "yaml: parsed", attrs...)
+}
+
+func (t *SlogTracer) NodeDecoded(path string, typ reflect.Type, duration time.Duration) {
+	if duration < t.MinNodeDuration {
+		return
+	}
+	t.logger().LogAttrs(context.Background(), t.Level, "yaml: decoded",
+		slog.String("path", path),
+		slog.String("type", typ.String()),
+		slog.Duration("duration", duration),
+	)
+}
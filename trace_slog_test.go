@@ -0,0 +1,61 @@
+//go:build !yaml_lean
+
+package yaml_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestSlogTracer(t *testing.T) {
+	type T struct {
+		A int               `yaml:"a"`
+		B []string          `yaml:"b"`
+		C map[string]string `yaml:"c"`
+		D interface{}       `yaml:"d"`
+	}
+	src := `
+a: 1
+b: [x]
+c: {k: v}
+d: {e: [1]}
+`
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	var v T
+	tr := &yaml.SlogTracer{Logger: logger, Level: slog.LevelInfo}
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.WithTrace(tr)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("unexpected logs:\n%s", buf.String())
+	}
+	if lines[0] != `level=INFO msg="yaml: tokenized" tokens=24` {
+		t.Fatalf("unexpected log: %s", lines[0])
+	}
+	if lines[2] != `level=INFO msg="yaml: decoded" path=$.a type=int` {
+		t.Fatalf("unexpected log: %s", lines[2])
+	}
+
+	buf.Reset()
+	tr.MinNodeDuration = time.Hour
+	if err := yaml.UnmarshalWithOptions([]byte(src), &v, yaml.WithTrace(tr)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "yaml: decoded") {
+		t.Fatalf("unexpected logs:\n%s", buf.String())
+	}
+}
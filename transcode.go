@@ -0,0 +1,210 @@
+//go:build !yaml_lean
+
+package yaml
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSONTranscoder converts JSON documents read from an input stream into YAML documents
+// without decoding the whole input into memory.
+// The order of the object keys and the text of the numbers are preserved as they are.
+type JSONTranscoder struct {
+	decoder *json.Decoder
+	writer  *bufio.Writer
+	encoder *Encoder
+	written bool
+}
+
+// NewJSONTranscoder returns a new transcoder that reads JSON from r and writes YAML to w.
+// The Indent, IndentSequence and UseSingleQuote options of EncodeOption are respected.
+func NewJSONTranscoder(r io.Reader, w io.Writer, opts ...EncodeOption) *JSONTranscoder {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return &JSONTranscoder{
+		decoder: decoder,
+		writer:  bufio.NewWriter(w),
+		encoder: NewEncoder(nil, opts...),
+	}
+}
+
+// Transcode converts all JSON values in the input stream to YAML.
+// If the input stream contains multiple JSON values,
+// the second and subsequent documents will be preceded with a "---" document separator.
+func (t *JSONTranscoder) Transcode() error {
+	for _, opt := range t.encoder.opts {
+		if err := opt(t.encoder); err != nil {
+			return err
+		}
+	}
+	for {
+		tk, err := t.decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if t.written {
+			if _, err := t.writer.WriteString("---\n"); err != nil {
+				return err
+			}
+		}
+		t.written = true
+		if err := t.transcodeValue(tk, 0); err != nil {
+			return err
+		}
+	}
+	return t.writer.Flush()
+}
+
+// transcodeValue writes the value started by tk.
+// The cursor is expected to be already positioned at the column.
+func (t *JSONTranscoder) transcodeValue(tk json.Token, column int) error {
+	switch v := tk.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			if !t.decoder.More() {
+				return t.writeEmpty("{}")
+			}
+			return t.transcodeObject(column, true)
+		case '[':
+			if !t.decoder.More() {
+				return t.writeEmpty("[]")
+			}
+			return t.transcodeArray(column, true)
+		}
+		return fmt.Errorf("unexpected delimiter %s", v)
+	default:
+		if _, err := t.writer.WriteString(t.scalarText(tk)); err != nil {
+			return err
+		}
+		return t.writer.WriteByte('\n')
+	}
+}
+
+// writeEmpty consumes the end delimiter of the empty object or array and writes text.
+func (t *JSONTranscoder) writeEmpty(text string) error {
+	if _, err := t.decoder.Token(); err != nil {
+		return err
+	}
+	_, err := t.writer.WriteString(text + "\n")
+	return err
+}
+
+func (t *JSONTranscoder) transcodeObject(column int, continued bool) error {
+	space := strings.Repeat(" ", column)
+	for t.decoder.More() {
+		keyToken, err := t.decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := keyToken.(string)
+		if !ok {
+			return fmt.Errorf("unexpected object key %v", keyToken)
+		}
+		if !continued {
+			if _, err := t.writer.WriteString(space); err != nil {
+				return err
+			}
+		}
+		continued = false
+		if _, err := t.writer.WriteString(t.quoteString(key) + ":"); err != nil {
+			return err
+		}
+		valueToken, err := t.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if err := t.transcodeMapValue(valueToken, column); err != nil {
+			return err
+		}
+	}
+	// consume '}'
+	_, err := t.decoder.Token()
+	return err
+}
+
+func (t *JSONTranscoder) transcodeMapValue(tk json.Token, column int) error {
+	delim, ok := tk.(json.Delim)
+	if !ok {
+		if err := t.writer.WriteByte(' '); err != nil {
+			return err
+		}
+		return t.transcodeValue(tk, column)
+	}
+	if !t.decoder.More() {
+		if err := t.writer.WriteByte(' '); err != nil {
+			return err
+		}
+		return t.transcodeValue(tk, column)
+	}
+	if err := t.writer.WriteByte('\n'); err != nil {
+		return err
+	}
+	switch delim {
+	case '{':
+		return t.transcodeObject(column+t.encoder.indent, false)
+	case '[':
+		if t.encoder.indentSequence {
+			column += t.encoder.indent
+		}
+		return t.transcodeArray(column, false)
+	}
+	return fmt.Errorf("unexpected delimiter %s", delim)
+}
+
+func (t *JSONTranscoder) transcodeArray(column int, continued bool) error {
+	space := strings.Repeat(" ", column)
+	for t.decoder.More() {
+		if !continued {
+			if _, err := t.writer.WriteString(space); err != nil {
+				return err
+			}
+		}
+		continued = false
+		if _, err := t.writer.WriteString("- "); err != nil {
+			return err
+		}
+		tk, err := t.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if err := t.transcodeValue(tk, column+2); err != nil {
+			return err
+		}
+	}
+	// consume ']'
+	_, err := t.decoder.Token()
+	return err
+}
+
+func (t *JSONTranscoder) scalarText(tk json.Token) string {
+	switch v := tk.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return t.quoteString(v)
+	}
+	return fmt.Sprint(tk)
+}
+
+func (t *JSONTranscoder) quoteString(v string) string {
+	if !t.encoder.isNeedQuoted(v) && !strings.ContainsAny(v, "\n\r") {
+		return v
+	}
+	if t.encoder.singleQuote && !strings.ContainsAny(v, "\n\r") {
+		return quoteWith(v, '\'')
+	}
+	return strconv.Quote(v)
+}
@@ -0,0 +1,106 @@
+//go:build !yaml_lean
+
+package yaml_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestJSONTranscoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected string
+		options  []yaml.EncodeOption
+	}{
+		{
+			name:     "scalar",
+			json:     `"hello"`,
+			expected: "hello\n",
+		},
+		{
+			name: "keep key order",
+			json: `{"z": 1, "a": "b", "m": null, "t": true}`,
+			expected: `
+z: 1
+a: b
+m: null
+t: true
+`,
+		},
+		{
+			name: "keep number text",
+			json: `{"big": 12345678901234567890, "float": 1.0000000000000001, "exp": 1e+100}`,
+			expected: `
+big: 12345678901234567890
+float: 1.0000000000000001
+exp: 1e+100
+`,
+		},
+		{
+			name: "nested",
+			json: `{"a": {"b": [1, {"c": "d", "e": []}, [2, 3]], "f": {}}, "g": "true"}`,
+			expected: `
+a:
+  b:
+  - 1
+  - c: d
+    e: []
+  - - 2
+    - 3
+  f: {}
+g: "true"
+`,
+		},
+		{
+			name: "indent sequence",
+			json: `{"a": [{"b": 1}]}`,
+			expected: `
+a:
+    - b: 1
+`,
+			options: []yaml.EncodeOption{yaml.Indent(4), yaml.IndentSequence(true)},
+		},
+		{
+			name: "multiple documents",
+			json: `{"a": 1} [1, 2]`,
+			expected: `
+a: 1
+---
+- 1
+- 2
+`,
+		},
+		{
+			name: "quoted string",
+			json: `{"": "a: b", "multi": "a\nb"}`,
+			expected: `
+"": "a: b"
+multi: "a\nb"
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := yaml.NewJSONTranscoder(strings.NewReader(test.json), &buf, test.options...).Transcode(); err != nil {
+				t.Fatal(err)
+			}
+			expected := strings.TrimPrefix(test.expected, "\n")
+			if expected != buf.String() {
+				t.Fatalf("expected:\n%s\nbut got:\n%s", expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestJSONTranscoder_InvalidJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := yaml.NewJSONTranscoder(strings.NewReader(`{"a": }`), &buf).Transcode(); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -3,12 +3,17 @@ package yaml
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/goccy/go-yaml/ast"
 	"github.com/goccy/go-yaml/internal/errors"
+	"github.com/goccy/go-yaml/merge"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
 )
 
 // BytesMarshaler interface may be implemented by types to customize their
@@ -36,6 +41,57 @@ type InterfaceMarshalerContext interface {
 	MarshalYAML(context.Context) (interface{}, error)
 }
 
+// Marshaler is the name of InterfaceMarshaler in gopkg.in/yaml.v2 and gopkg.in/yaml.v3,
+// so the code referring to yaml.Marshaler can be migrated by changing the import path.
+type Marshaler = InterfaceMarshaler
+
+// NodeMarshaler interface may be implemented by types to customize their
+// behavior when being marshaled into a YAML document.
+// The returned ast.Node is written in place of the original value as it is,
+// so the styles, anchors, tags and comments of the node are kept.
+// The node is copied to be written, so it isn't modified by the encoder.
+type NodeMarshaler interface {
+	MarshalYAML() (ast.Node, error)
+}
+
+// NodeMarshalerContext interface use NodeMarshaler with context.Context.
+type NodeMarshalerContext interface {
+	MarshalYAML(context.Context) (ast.Node, error)
+}
+
+// MarshalerTo interface may be implemented by types to encode their value into the encoder,
+// like MarshalJSONTo of encoding/json/v2, without materializing the YAML document of the value like BytesMarshaler.
+// The encoder passed to MarshalYAMLTo is positioned at the value and has the options of the encoder calling it.
+// MarshalYAMLTo must call Encode or EncodeContext of it exactly once, and the encoded value is written in place of the original value.
+type MarshalerTo interface {
+	MarshalYAMLTo(*Encoder) error
+}
+
+// OrderedIterator interface may be implemented by the ordered map types to be encoded as the mapping
+// in the order of Iterate like MapSlice, without converting them to MapSlice.
+// Iterate must call yield for each key and value in order, and stop if yield returns false.
+// The marshaler interfaces take precedence over it.
+type OrderedIterator interface {
+	Iterate(yield func(key, value any) bool)
+}
+
+// FieldCommenter interface may be implemented by struct types to attach
+// line comments to their fields when being marshaled.
+// The keys of the returned map are the rendered field names, and the values
+// take precedence over comments declared by the `comment` struct tag option.
+type FieldCommenter interface {
+	YAMLComments() map[string]string
+}
+
+// FieldOrderer interface may be implemented by struct types to control
+// the order of their fields when being marshaled.
+// The fields of the returned rendered names, including the fields of the inline structs,
+// are written first in that order, followed by the other fields.
+// It takes precedence over the order declared by the `order` struct tag option.
+type FieldOrderer interface {
+	YAMLFieldOrder() []string
+}
+
 // BytesUnmarshaler interface may be implemented by types to customize their
 // behavior when being unmarshaled from a YAML document.
 type BytesUnmarshaler interface {
@@ -57,6 +113,49 @@ type InterfaceUnmarshalerContext interface {
 	UnmarshalYAML(context.Context, func(interface{}) error) error
 }
 
+// Unmarshaler is the name of InterfaceUnmarshaler in gopkg.in/yaml.v2,
+// so the code referring to yaml.Unmarshaler can be migrated by changing the import path.
+// UnmarshalYAML(*yaml.Node) error of gopkg.in/yaml.v3 isn't supported because this package
+// doesn't have the compatible Node type. Implement NodeUnmarshaler receiving ast.Node instead.
+type Unmarshaler = InterfaceUnmarshaler
+
+// NodeUnmarshaler interface may be implemented by types to customize their
+// behavior when being unmarshaled from a YAML document.
+// The ast.Node of the value is passed as it is.
+type NodeUnmarshaler interface {
+	UnmarshalYAML(ast.Node) error
+}
+
+// NodeUnmarshalerContext interface use NodeUnmarshaler with context.Context.
+type NodeUnmarshalerContext interface {
+	UnmarshalYAML(context.Context, ast.Node) error
+}
+
+// UnmarshalerFrom interface may be implemented by types to decode their value from the decoder,
+// like UnmarshalJSONFrom of encoding/json/v2, without materializing the YAML document of the value like BytesUnmarshaler.
+// The decoder passed to UnmarshalYAMLFrom is positioned at the node of the value and has the options of the decoder calling it,
+// so Decode of it decodes the node, and returns io.EOF after that.
+type UnmarshalerFrom interface {
+	UnmarshalYAMLFrom(*Decoder) error
+}
+
+// AnchorAwareUnmarshaler interface may be implemented by types to know which anchor the value came from,
+// e.g. to record the provenance of `*env_default`-style values.
+// name is the name of the anchor referred by the alias, or defined by the anchor with the value.
+// It's empty if the value is neither anchored nor aliased. b is the document of the anchored value like BytesUnmarshaler.
+type AnchorAwareUnmarshaler interface {
+	UnmarshalYAMLWithAnchor(name string, b []byte) error
+}
+
+// DefaultsSetter interface may be implemented by struct types to set the default values
+// before being unmarshaled. SetYAMLDefaults is called for each value of the type decoded
+// from a mapping, including the elements of slices and maps allocated by the decoder,
+// and for the struct fields whose keys are absent.
+// The values decoded from the document overwrite the default values.
+type DefaultsSetter interface {
+	SetYAMLDefaults()
+}
+
 // MapItem is an item in a MapSlice.
 type MapItem struct {
 	Key, Value interface{}
@@ -106,16 +205,82 @@ func (s MapSlice) ToMap() map[interface{}]interface{} {
 //	             causing all of its fields or keys to be processed as if
 //	             they were part of the outer struct. For maps, keys must
 //	             not conflict with the yaml keys of other struct fields.
+//	             When decoding, the inline map receives the keys matching no other field.
+//	             With several inline maps, each key goes to the first map which can decode the value.
 //
 //	anchor       Marshal with anchor. If want to define anchor name explicitly, use anchor=name style.
-//	             Otherwise, if used 'anchor' name only, used the field name lowercased as the anchor name
+//	             Otherwise, if used 'anchor' name only, used the field name lowercased as the anchor name.
+//	             The name already used in the document gets the number suffix like name_2 (see ResolveAnchorCollision).
 //
 //	alias        Marshal with alias. If want to define alias name explicitly, use alias=name style.
 //	             Otherwise, If omitted alias name and the field type is pointer type,
 //	             assigned anchor name automatically from same pointer address.
 //
+//	comment      Marshal with a line comment. Use comment=text style.
+//	             The comment text may contain commas, so this option must be the last one.
+//	             Comments are not written in flow or JSON style.
+//
+//	default      Unmarshal the value into the field if the key is absent.
+//	             Use default=value style, like default=3 or default=[a, b].
+//	             The field set before Unmarshal or by SetYAMLDefaults
+//	             (see the DefaultsSetter interface type) is kept.
+//
+//	order        Marshal the field before the fields without this option.
+//	             Use order=n style. The fields are written in ascending order of n
+//	             (see the FieldOrderer interface type to order them by the names).
+//
+//	literal      Marshal the string as the literal block scalar ( | ).
+//
+//	folded       Marshal the string as the folded block scalar ( > ).
+//	             The long lines are folded by the width of UseFoldedStyleIfLong.
+//
+//	keep         Marshal the string ending with the line break with the keep
+//	             chomping indicator ( |+ ). It implies literal unless folded is specified.
+//
+//	indent       Marshal the block scalar with the indentation indicator.
+//	             Use indent=n style, 1 <= n <= 9. It implies literal unless folded is specified.
+//	             The chomping indicator is always chosen to keep the string exactly,
+//	             and the strings which can't be written as the block scalar are marshaled as usual.
+//
+//	layout       Marshal and unmarshal the time by the layout of time.Format instead of RFC3339.
+//	             Use layout=value style, like layout=2006-01-02 15:04, or the name of
+//	             the layout constant of the time package, like layout=RFC1123, for the layouts with commas.
+//	             The field must be time.Time, the type defined by time.Time or
+//	             the struct embedding only time.Time, or the pointer to them.
+//
+//	rune         Marshal the rune ( int32 ) as the quoted single-character string and []rune as the string,
+//	             and unmarshal them from the strings like DecodeRune.
+//	             The field must be rune, []rune or the pointer to them.
+//
+//	set          Marshal the keys of the map or the values of the slice as the !!set
+//	             mapping having only the keys, like `? a`. The !!set mapping is unmarshaled
+//	             into the map, whose bool values are set to true, or into the slice of the keys.
+//	             See also the Set type.
+//
+//	omap, pairs  Marshal the map, MapSlice or []MapItem as the !!omap or !!pairs sequence
+//	             of the single pair mappings. The keys of the map are sorted.
+//	             Both are unmarshaled into the map, MapSlice or []MapItem, and !!omap
+//	             unmarshaled into the map or MapSlice must not have the duplicate keys.
+//
 // In addition, if the key is "-", the field is ignored.
 //
+// The types defined by time.Time ( e.g. `type Date time.Time` ) and the structs
+// embedding only time.Time ( e.g. `type Timestamp struct{ time.Time }` ) are
+// marshaled and unmarshaled as time.Time unless they have their own MarshalText or UnmarshalText.
+//
+// Map keys implementing encoding.TextMarshaler are encoded by MarshalText,
+// and decoded by UnmarshalText when they implement encoding.TextUnmarshaler.
+// Other struct or array keys are encoded in flow style with the explicit key indicator,
+// like `? {a: 1, b: 2}`.
+//
+// The value implementing the marshaler interfaces is encoded by the first of the following:
+// the marshaler specified by CustomMarshaler, MarshalerTo, BytesMarshaler, InterfaceMarshaler
+// ( Marshaler of gopkg.in/yaml.v2 and gopkg.in/yaml.v3 ), NodeMarshaler, encoding.TextMarshaler,
+// and json.Marshaler and encoding.BinaryMarshaler if UseJSONMarshaler and UseBinaryMarshaler are specified.
+// The interface with context.Context takes precedence over the one without it.
+// As in gopkg.in/yaml.v3, the nil pointer is encoded as null without calling the marshaler,
+// and the marshaler implemented by the pointer receiver is called only for the pointer.
+//
 // For example:
 //
 //	type T struct {
@@ -142,6 +307,111 @@ func MarshalContext(ctx context.Context, v interface{}, opts ...EncodeOption) ([
 	return buf.Bytes(), nil
 }
 
+// MarshalIndent serializes the value provided into a YAML document with the indent spaces.
+// It is a shorthand of MarshalWithOptions with the Indent option,
+// and indent takes precedence over the Indent specified in opts.
+func MarshalIndent(v interface{}, indent int, opts ...EncodeOption) ([]byte, error) {
+	return MarshalWithOptions(v, append(append([]EncodeOption{}, opts...), Indent(indent))...)
+}
+
+// MarshalStrict serializes the value provided into a YAML document with the DisallowUnsupportedValue option,
+// so it returns an error for the values that can't be decoded as they are instead of the best-effort output.
+// The options specified in opts are applied after it.
+func MarshalStrict(v interface{}, opts ...EncodeOption) ([]byte, error) {
+	return MarshalWithOptions(v, append([]EncodeOption{DisallowUnsupportedValue()}, opts...)...)
+}
+
+// ValidateIndent detects the number of the indent spaces used in the src,
+// so that the edited document can be encoded by MarshalIndent with the same style.
+// The indent is the difference between the columns of the key and its nested block mapping or indented block sequence.
+// It returns an error if the src cannot be parsed or the indent is not consistent in the src.
+// If the src has no nested collection, DefaultIndentSpaces is returned.
+func ValidateIndent(src []byte) (int, error) {
+	f, err := parser.ParseBytes(src, 0)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		indent    int
+		indentTk  *token.Token
+		indentErr error
+	)
+	for _, doc := range f.Docs {
+		ast.Walk(indentVisitor(func(key, child *token.Token) bool {
+			if indentErr != nil {
+				return false
+			}
+			n := child.Position.Column - key.Position.Column
+			if n <= 0 {
+				// the sequence at the same column as the key.
+				return true
+			}
+			if indentTk == nil {
+				indent, indentTk = n, child
+				return true
+			}
+			if n != indent {
+				indentErr = errors.ErrSyntax(
+					fmt.Sprintf(
+						"found %d spaces indent, but %d spaces indent is used at [%d:%d]",
+						n, indent, indentTk.Position.Line, indentTk.Position.Column,
+					),
+					child,
+				)
+				return false
+			}
+			return true
+		}), doc)
+	}
+	if indentErr != nil {
+		return 0, indentErr
+	}
+	if indentTk == nil {
+		return DefaultIndentSpaces, nil
+	}
+	return indent, nil
+}
+
+// indentVisitor calls the function with the key token and the first token of its nested block collection.
+type indentVisitor func(key, child *token.Token) bool
+
+func (f indentVisitor) Visit(node ast.Node) ast.Visitor {
+	mv, ok := node.(*ast.MappingValueNode)
+	if !ok {
+		return f
+	}
+	if child := blockCollectionToken(mv.Value); child != nil {
+		if !f(mv.Key.GetToken(), child) {
+			return nil
+		}
+	}
+	return f
+}
+
+// blockCollectionToken returns the first token of the block collection.
+// It returns nil if the node is not a block collection.
+func blockCollectionToken(node ast.Node) *token.Token {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		return blockCollectionToken(n.Value)
+	case *ast.TagNode:
+		return blockCollectionToken(n.Value)
+	case *ast.MappingNode:
+		if n.IsFlowStyle || len(n.Values) == 0 {
+			return nil
+		}
+		return n.Values[0].Key.GetToken()
+	case *ast.MappingValueNode:
+		return n.Key.GetToken()
+	case *ast.SequenceNode:
+		if n.IsFlowStyle {
+			return nil
+		}
+		return n.Start
+	}
+	return nil
+}
+
 // ValueToNode convert from value to ast.Node.
 func ValueToNode(v interface{}, opts ...EncodeOption) (ast.Node, error) {
 	var buf bytes.Buffer
@@ -174,6 +444,20 @@ func ValueToNode(v interface{}, opts ...EncodeOption) (ast.Node, error) {
 //
 // See the documentation of Marshal for the format of tags and a list of
 // supported tag options.
+//
+// Map keys are decoded by the unmarshaler of the key type in the same way as values.
+// For a pointer key type, a new key is allocated for each entry,
+// so the keys of such a map are compared by address, not by content.
+// For an interface key type, the decoded key must implement the interface
+// unless the unmarshaler is registered by CustomUnmarshaler.
+// In either case, the decoded key must be comparable.
+// A struct or array key type is decoded from the complex key such as `? {a: 1, b: 2}` or `? [1, 2]`.
+//
+// The value implementing the unmarshaler interfaces by the pointer is decoded by the first of the following:
+// the unmarshaler specified by CustomUnmarshaler, UnmarshalerFrom, AnchorAwareUnmarshaler, BytesUnmarshaler, InterfaceUnmarshaler
+// ( Unmarshaler of gopkg.in/yaml.v2 ), NodeUnmarshaler, encoding.TextUnmarshaler,
+// and json.Unmarshaler and encoding.BinaryUnmarshaler if UseJSONUnmarshaler and UseBinaryUnmarshaler are specified.
+// The interface with context.Context takes precedence over the one without it.
 func Unmarshal(data []byte, v interface{}) error {
 	return UnmarshalWithOptions(data, v)
 }
@@ -196,10 +480,70 @@ func UnmarshalContext(ctx context.Context, data []byte, v interface{}, opts ...D
 	return nil
 }
 
+// UnmarshalStrict decodes the first document with the Strict and AllErrors options.
+// It reports all the unknown fields and the type mismatches found in the document as MultiError.
+// The duplicate keys are disallowed by default, and reported by the parser before decoding.
+// The options specified in opts are applied after them.
+func UnmarshalStrict(data []byte, v interface{}, opts ...DecodeOption) error {
+	return UnmarshalWithOptions(data, v, append([]DecodeOption{Strict(), AllErrors()}, opts...)...)
+}
+
+// UnmarshalTo decodes the first document found within data into the new value of T and returns it,
+// so the variable doesn't have to be declared before decoding like
+//
+//	cfg, err := yaml.UnmarshalTo[Config](data)
+//
+// If T is a pointer type, the value pointed to by it is allocated and the document is decoded into it.
+func UnmarshalTo[T any](data []byte, opts ...DecodeOption) (T, error) {
+	v := newValue[T]()
+	if err := UnmarshalWithOptions(data, valuePtr(&v), opts...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// MustUnmarshalTo is like UnmarshalTo but panics if data can't be decoded.
+// It's intended for the documents embedded in the program such as the default configurations.
+func MustUnmarshalTo[T any](data []byte, opts ...DecodeOption) T {
+	v, err := UnmarshalTo[T](data, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// newValue returns the zero value of T, or the pointer to the zero value of the element type if T is a pointer,
+// because the decoder doesn't allocate the value for the nil pointer pointed to by the destination.
+func newValue[T any]() T {
+	var v T
+	typ := reflect.TypeOf(&v).Elem()
+	if typ.Kind() == reflect.Ptr {
+		reflect.ValueOf(&v).Elem().Set(reflect.New(typ.Elem()))
+	}
+	return v
+}
+
+// valuePtr returns the destination to decode into v made by newValue.
+// The allocated pointer is passed as is so that the decoder fills the value pointed to by it.
+func valuePtr[T any](v *T) any {
+	if rv := reflect.ValueOf(v).Elem(); rv.Kind() == reflect.Ptr {
+		return rv.Interface()
+	}
+	return v
+}
+
 // NodeToValue converts node to the value pointed to by v.
 func NodeToValue(node ast.Node, v interface{}, opts ...DecodeOption) error {
+	return NodeToValueContext(context.Background(), node, v, opts...)
+}
+
+// NodeToValueContext converts node to the value pointed to by v with context.Context.
+// All the decode options are honored as UnmarshalWithOptions does, so the AST-first workflows can decode
+// the nodes with the custom unmarshalers, the strict mode and the comment map like the source text.
+func NodeToValueContext(ctx context.Context, node ast.Node, v interface{}, opts ...DecodeOption) error {
 	var buf bytes.Buffer
-	if err := NewDecoder(&buf, opts...).DecodeFromNode(node, v); err != nil {
+	if err := NewDecoder(&buf, opts...).DecodeFromNodeContext(ctx, node, v); err != nil {
 		return err
 	}
 	return nil
@@ -220,6 +564,69 @@ func FormatError(e error, colored, inclSource bool) string {
 	return e.Error()
 }
 
+// ErrorPrinter formats the errors having the positions in the source like FormatError with the configurable format.
+// The zero value prints only the messages with the positions. Use NewErrorPrinter for the format of FormatError.
+type ErrorPrinter struct {
+	// Colored colorizes the message and the source.
+	Colored bool
+	// IncludeSource prints the lines of the source around the error.
+	IncludeSource bool
+	// LinesBefore and LinesAfter are the numbers of the lines of the source printed before and after the error lines.
+	LinesBefore int
+	LinesAfter  int
+	// FileName is printed in the position like [config.yaml:4:13] if it's not empty.
+	// Set it to the name of the file passed to parser.ParseFile or parser.ParseFS to print it with the errors of the file.
+	FileName string
+}
+
+// NewErrorPrinter creates the ErrorPrinter printing 3 lines of the source before and after the error lines without colors,
+// which is the format of FormatError(err, false, true).
+func NewErrorPrinter() *ErrorPrinter {
+	return &ErrorPrinter{
+		IncludeSource: true,
+		LinesBefore:   3,
+		LinesAfter:    3,
+	}
+}
+
+// Format returns the message of e formatted by p.
+// The error not having the position in the source is formatted by its Error method.
+func (p *ErrorPrinter) Format(e error) string {
+	formatted, ok := errors.FormatWithOptions(e, errors.FormatOptions{
+		Colored:       p.Colored,
+		IncludeSource: p.IncludeSource,
+		LinesBefore:   p.LinesBefore,
+		LinesAfter:    p.LinesAfter,
+		FileName:      p.FileName,
+	})
+	if !ok {
+		return e.Error()
+	}
+	return formatted
+}
+
+// printedError is the error formatted by the ErrorPrinter specified by WithErrorPrinter.
+type printedError struct {
+	err     error
+	printer *ErrorPrinter
+}
+
+func (e *printedError) Error() string {
+	return e.printer.Format(e.err)
+}
+
+// FormatError formats the error by the printer with colored and inclSource instead of its settings.
+func (e *printedError) FormatError(colored, inclSource bool) string {
+	p := *e.printer
+	p.Colored = colored
+	p.IncludeSource = inclSource
+	return p.Format(e.err)
+}
+
+func (e *printedError) Unwrap() error {
+	return e.err
+}
+
 // YAMLToJSON convert YAML bytes to JSON.
 func YAMLToJSON(bytes []byte) ([]byte, error) {
 	var v interface{}
@@ -246,6 +653,129 @@ func JSONToYAML(bytes []byte) ([]byte, error) {
 	return out, nil
 }
 
+// SplitFrontMatter splits data into the YAML front matter and the remaining content.
+// The front matter must start with a "---" line at the beginning of data
+// and end with a "---" or "..." line. The delimiter lines are not included in the results.
+// The lines can end with CRLF, and the UTF-8 BOM before the front matter is skipped.
+// If data does not start with front matter, nil and data are returned.
+func SplitFrontMatter(data []byte) ([]byte, []byte) {
+	line, rest, found := bytes.Cut(bytes.TrimPrefix(data, utf8BOM), []byte("\n"))
+	if !found || !isFrontMatterDelimiter(line, false) {
+		return nil, data
+	}
+	start := len(data) - len(rest)
+	for offset := start; offset < len(data); {
+		line, _, _ := bytes.Cut(data[offset:], []byte("\n"))
+		next := offset + len(line) + 1
+		if isFrontMatterDelimiter(line, true) {
+			if next > len(data) {
+				next = len(data)
+			}
+			return data[start:offset], data[next:]
+		}
+		offset = next
+	}
+	return nil, data
+}
+
+// SplitFrontMatterReader is like SplitFrontMatter but reads the content like the Markdown document or the template from r.
+func SplitFrontMatterReader(r io.Reader) ([]byte, []byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	frontMatter, content := SplitFrontMatter(data)
+	return frontMatter, content, nil
+}
+
+// UnmarshalFrontMatter decodes the YAML front matter of data into the value pointed to by v, and returns the remaining content.
+// See SplitFrontMatter for the format of the front matter. If data does not start with front matter, v is left unchanged
+// and data is returned as is.
+func UnmarshalFrontMatter(data []byte, v interface{}, opts ...DecodeOption) ([]byte, error) {
+	frontMatter, content := SplitFrontMatter(data)
+	if frontMatter == nil {
+		return content, nil
+	}
+	if err := UnmarshalWithOptions(frontMatter, v, opts...); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+func isFrontMatterDelimiter(line []byte, isEnd bool) bool {
+	line = bytes.TrimRight(line, " \t\r")
+	if string(line) == "---" {
+		return true
+	}
+	return isEnd && string(line) == "..."
+}
+
+// FillTemplate renders v into template, the annotated YAML like config.sample.yaml, and returns the result.
+// The values of template are replaced by the values of v, and the mappings are merged deeply by the key,
+// so the comments, the styles and the keys not specified by v are kept as they are in template.
+// The null values of v, such as nil pointers and nil maps, are treated as unspecified,
+// so use the omitempty option for the fields that should keep the values of template if they are zero.
+func FillTemplate(template []byte, v interface{}, opts ...EncodeOption) ([]byte, error) {
+	base, err := parser.ParseBytes(template, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	node, err := ValueToNode(v, opts...)
+	if err != nil {
+		return nil, err
+	}
+	node = removeNullValue(node)
+	if node == nil {
+		return template, nil
+	}
+	src, err := parser.ParseBytes([]byte(node.String()), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := merge.Merge(base, src)
+	if err != nil {
+		return nil, err
+	}
+	out := merged.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return []byte(out), nil
+}
+
+// removeNullValue removes the mapping values having null from node recursively.
+// If node itself is null, it returns nil.
+func removeNullValue(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.NullNode:
+		return nil
+	case *ast.MappingNode:
+		values := make([]*ast.MappingValueNode, 0, len(n.Values))
+		for _, value := range n.Values {
+			if v := removeNullValue(value.Value); v != nil {
+				value.Value = v
+				values = append(values, value)
+			}
+		}
+		n.Values = values
+	case *ast.MappingValueNode:
+		v := removeNullValue(n.Value)
+		if v == nil {
+			return nil
+		}
+		n.Value = v
+	case *ast.SequenceNode:
+		for _, value := range n.Values {
+			removeNullValue(value)
+		}
+	case *ast.AnchorNode:
+		removeNullValue(n.Value)
+	}
+	return node
+}
+
 var (
 	globalCustomMarshalerMu    sync.Mutex
 	globalCustomUnmarshalerMu  sync.Mutex